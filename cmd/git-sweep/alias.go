@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+)
+
+var installAliasCmd = &cobra.Command{
+	Use:   "install-alias",
+	Short: "Configure 'git sweep' and optionally a post-merge status hook",
+	Long: `The install-alias command configures 'git sweep' as a git alias running this
+git-sweep binary, in the global git configuration or, with --local, in the
+current repository's.
+
+With --hook, it also installs a post-merge hook in the current repository that
+prints the quick-status summary after every 'git pull', as a regular reminder
+to clean up. An existing post-merge hook that git-sweep did not install is
+never overwritten.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		local, _ := cmd.Flags().GetBool("local")
+		hook, _ := cmd.Flags().GetBool("hook")
+		if local || hook {
+			requireRepoRoot(ctx)
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not determine the git-sweep executable: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := gitcmd.SetAlias(ctx, "sweep", gitcmd.ShellLine([]string{executable}), !local); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		scope := "global"
+		if local {
+			scope = "repository"
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Configured 'git sweep' to run %s (%s git config).\n", executable, scope)
+
+		if !hook {
+			return
+		}
+		status := []string{executable, "--quick-status"}
+		if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+			if abs, err := filepath.Abs(configPath); err == nil {
+				configPath = abs
+			}
+			status = append(status, "--config", configPath)
+		}
+		path, err := gitcmd.InstallHook(ctx, "post-merge", gitcmd.ShellLine(status))
+		if errors.Is(err, gitcmd.ErrHookExists) {
+			fmt.Fprintf(os.Stderr, "Error: %v\nAdd this line to it to print the status after every pull:\n  %s\n",
+				err, gitcmd.ShellLine(status))
+			os.Exit(exitError)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Installed %s; it prints a quick status after every pull.\n", path)
+	},
+}
+
+func init() {
+	installAliasCmd.Flags().Bool("local", false, "Set the alias in the current repository instead of globally.")
+	installAliasCmd.Flags().Bool("hook", false,
+		"Also install a post-merge hook printing the quick status after every pull.")
+	rootCmd.AddCommand(installAliasCmd)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/filter"
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/tui"
+	"github.com/bral/git-sweep-go/internal/types"
+	"github.com/bral/git-sweep-go/pkg/sweep"
+)
+
+// checkRepoState refuses to delete branches while a merge, rebase, cherry-pick, revert or bisect
+// is in progress: HEAD is then detached or temporary, so the branch being worked on is not
+// protected as the current branch. A dry run only warns. If the state cannot be determined,
+// e.g. outside a repository, the analysis reports the problem instead.
+func checkRepoState(ctx context.Context, dryRun bool) error {
+	repoState, err := gitcmd.GetRepoState(ctx)
+	if err != nil {
+		slog.Debug("Could not determine repository state", "error", err)
+		return nil
+	}
+	if repoState == gitcmd.RepoStateClean {
+		return nil
+	}
+	if !dryRun {
+		return fmt.Errorf("a %s is in progress; finish or abort it before deleting branches (--dry-run still works)",
+			repoState)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: A %s is in progress; the branch it operates on may be suggested for deletion.\n",
+		repoState)
+	return nil
+}
+
+// nothingToSweepYet explains why a repository without commits is left alone.
+const nothingToSweepYet = "Nothing to sweep yet: the repository has no commits."
+
+// isEmptyRepository reports whether the repository has no commits yet, e.g. right after
+// 'git init', so there are no branches to analyze. Outside a repository it is false, leaving
+// the problem to the analysis to report.
+func isEmptyRepository(ctx context.Context) bool {
+	if inGitRepo, err := gitcmd.IsInGitRepo(ctx); err != nil || !inGitRepo {
+		return false
+	}
+	hasCommits, err := gitcmd.HasCommits(ctx)
+	if err != nil {
+		slog.Debug("Could not check for commits", "error", err)
+	}
+	return err == nil && !hasCommits
+}
+
+// detachedHeadNotice returns the note that HEAD is detached and at which commit, since no branch
+// is protected as the checked-out one then. It is empty while a branch is checked out.
+func detachedHeadNotice(ctx context.Context) string {
+	if inGitRepo, err := gitcmd.IsInGitRepo(ctx); err != nil || !inGitRepo {
+		return ""
+	}
+	if current, err := gitcmd.GetCurrentBranchName(ctx); err != nil || current != "" {
+		return ""
+	}
+	commits, err := gitcmd.GetRecentCommits(ctx, "HEAD", 1)
+	if err != nil || len(commits) == 0 {
+		return ""
+	}
+	hash, subject, _ := strings.Cut(commits[0], " ")
+	return fmt.Sprintf("HEAD is detached at %s (%s); no branch is checked out, so every unprotected branch "+
+		"may be suggested.", hash, subject)
+}
+
+// offlineNotice returns the note labeling results as possibly stale in offline mode, with the
+// age of the last fetch. It is empty when offline mode is off.
+func offlineNotice(ctx context.Context) string {
+	if !appConfig.Offline {
+		return ""
+	}
+	last, err := gitcmd.LastFetchTime(ctx)
+	if err != nil {
+		slog.Debug("Could not determine the last fetch time", "error", err)
+	}
+	if err != nil || last.IsZero() {
+		return "Offline: nothing is fetched, so remote branches and merge status may be stale."
+	}
+	return fmt.Sprintf("Offline: remote branches and merge status are as of the last fetch %s and may be stale.",
+		timeAgo(time.Since(last)))
+}
+
+// timeAgo describes how long ago something happened, in whole days or hours.
+func timeAgo(d time.Duration) string {
+	switch days, hours := int(d.Hours())/24, int(d.Hours()); {
+	case days > 1:
+		return fmt.Sprintf("%d days ago", days)
+	case days == 1:
+		return "1 day ago"
+	case hours > 1:
+		return fmt.Sprintf("%d hours ago", hours)
+	case hours == 1:
+		return "1 hour ago"
+	}
+	return "less than an hour ago"
+}
+
+// analyzeRepository runs the shared discovery pipeline: it checks the environment, optionally
+// fetches remoteName, gathers and annotates local branches, and analyzes them against the
+// configured primary main branch. It returns an empty slice if the repository has no branches.
+func analyzeRepository(ctx context.Context, remoteName string, fetch bool) ([]types.AnalyzedBranch, error) {
+	if notice := offlineNotice(ctx); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	if notice := detachedHeadNotice(ctx); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	repo, err := sweep.Gather(ctx, "", sweepOptions(remoteName, fetch))
+	if err != nil {
+		return nil, err
+	}
+	if len(repo.Branches) == 0 {
+		return []types.AnalyzedBranch{}, nil
+	}
+
+	analyzedBranches, err := repo.Analyze(ctx, repo.Branches)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("Branch analysis complete", "branches", len(analyzedBranches))
+	repo.RecordMergeChecks(ctx, analyzedBranches)
+	profile.write(os.Stderr)
+	return analyzedBranches, nil
+}
+
+// sweepOptions returns the options the library runs the analysis with: the loaded
+// configuration, the branches selected by 'git-sweep check', warnings printed to stderr and
+// phases timed for --profile.
+func sweepOptions(remoteName string, fetch bool) sweep.Options {
+	return sweep.Options{
+		Config:   appConfig,
+		Remote:   remoteName,
+		Fetch:    fetch,
+		Branches: selectedBranches,
+		Warn: func(message string) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+		},
+		Track: profile.track,
+	}
+}
+
+const (
+	// analysisBatchSize is the number of branches analyzed between two updates of the TUI.
+	analysisBatchSize = 20
+	// slowFetchNotice is how long the TUI waits for the fetch before pointing out the timeout.
+	slowFetchNotice = 3 * time.Second
+)
+
+// streamAnalysis runs the pipeline of analyzeRepository in the background for the TUI. It
+// reports each step and sends the branches worth displaying, those neither protected nor
+// excluded by branchFilter, in batches as they are analyzed. A slow or failed fetch is
+// reported as a warning, and the analysis continues with the local state. The channel is
+// closed when the analysis has finished or ctx is cancelled.
+func streamAnalysis(
+	ctx context.Context, remoteName string, fetch bool, branchFilter filter.Filter,
+) <-chan tui.LoadEvent {
+	events := make(chan tui.LoadEvent)
+	send := func(event tui.LoadEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(events)
+		if fetch {
+			stopFetch := profile.track("fetch")
+			if appConfig.Unshallow {
+				if !send(tui.LoadEvent{Status: "Fetching the full history of the shallow clone..."}) {
+					return
+				}
+				if err := sweep.Unshallow(ctx, remoteName); err != nil {
+					warning := fmt.Sprintf("Could not fetch the full history from '%s': %s", remoteName,
+						sweep.ErrorSummary(err))
+					if !send(tui.LoadEvent{Warning: warning}) {
+						return
+					}
+				}
+			}
+			if !streamFetches(ctx, sweep.RemotesToFetch(ctx, appConfig, remoteName), send) {
+				return
+			}
+			if appConfig.FastForwardMain {
+				status := fmt.Sprintf("Fast-forwarding %s...", appConfig.PrimaryMainBranch)
+				if !send(tui.LoadEvent{Status: status}) {
+					return
+				}
+				if err := sweep.FastForwardMain(ctx, appConfig); err != nil {
+					warning := fmt.Sprintf("Could not fast-forward '%s': %s", appConfig.PrimaryMainBranch,
+						sweep.ErrorSummary(err))
+					if !send(tui.LoadEvent{Warning: warning}) {
+						return
+					}
+				}
+			}
+			stopFetch()
+		}
+		if !send(tui.LoadEvent{Status: "Reading branches..."}) {
+			return
+		}
+		// Warnings such as the one about a shallow clone would corrupt the UI on stderr
+		opts := sweepOptions(remoteName, false)
+		opts.Warn = func(message string) { send(tui.LoadEvent{Warning: message}) }
+		repo, err := sweep.Gather(ctx, "", opts)
+		if err != nil {
+			send(tui.LoadEvent{Err: err})
+			return
+		}
+
+		now := time.Now()
+		var checked []types.AnalyzedBranch
+		for start := 0; start < len(repo.Branches); start += analysisBatchSize {
+			batch := repo.Branches[start:min(start+analysisBatchSize, len(repo.Branches))]
+			status := fmt.Sprintf("Analyzing branches (%d of %d)...", start, len(repo.Branches))
+			if !send(tui.LoadEvent{Status: status}) {
+				return
+			}
+			analyzed, err := repo.Analyze(ctx, batch)
+			if err != nil {
+				send(tui.LoadEvent{Err: err})
+				return
+			}
+			checked = append(checked, analyzed...)
+
+			displayable := make([]types.AnalyzedBranch, 0, len(analyzed))
+			for _, branch := range analyzed {
+				if branch.Category != types.CategoryProtected && branchFilter.Matches(branch, now) {
+					displayable = append(displayable, branch)
+				}
+			}
+			if !send(tui.LoadEvent{Branches: displayable}) {
+				return
+			}
+		}
+		slog.Debug("Branch analysis complete", "branches", len(repo.Branches))
+		repo.RecordMergeChecks(ctx, checked)
+	}()
+	return events
+}
+
+// streamFetches fetches remotes for streamAnalysis, reporting progress with send. The remotes
+// that could not be fetched are reported in a single warning. It returns false if the TUI
+// stopped listening.
+func streamFetches(ctx context.Context, remotes []string, send func(tui.LoadEvent) bool) bool {
+	var failed, reasons []string
+	for _, remote := range remotes {
+		ok, err := streamFetch(ctx, remote, send)
+		if !ok {
+			return false
+		}
+		if err != nil {
+			slog.Debug("Fetch failed", "remote", remote, "error", err)
+			failed = append(failed, remote)
+			reasons = append(reasons, sweep.ErrorSummary(err))
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return true
+	case 1:
+		return send(tui.LoadEvent{Warning: fmt.Sprintf("Could not fetch %s, showing the local state: %v",
+			failed[0], reasons[0])})
+	default:
+		return send(tui.LoadEvent{Warning: fmt.Sprintf("Could not fetch %s, showing their local state: %v",
+			strings.Join(failed, ", "), reasons[0])})
+	}
+}
+
+// streamFetch fetches remoteName, reporting when it is slow. It returns false if the TUI
+// stopped listening, and the error of the fetch.
+func streamFetch(ctx context.Context, remoteName string, send func(tui.LoadEvent) bool) (bool, error) {
+	if !send(tui.LoadEvent{Status: fmt.Sprintf("Fetching %s...", remoteName)}) {
+		return false, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- sweep.FetchRemote(ctx, appConfig, remoteName) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(slowFetchNotice):
+		status := fmt.Sprintf("Still fetching %s (giving up after %ds; --no-fetch skips it)...",
+			remoteName, appConfig.FetchTimeoutSeconds)
+		if !send(tui.LoadEvent{Status: status}) {
+			return false, nil
+		}
+		err = <-done
+	}
+	return true, err
+}
+
+// setupHints gathers repository facts offered as defaults during first-run setup.
+func setupHints(ctx context.Context, remoteName string) config.SetupHints {
+	var hints config.SetupHints
+	if inGitRepo, err := gitcmd.IsInGitRepo(ctx); err != nil || !inGitRepo {
+		return hints
+	}
+	hints.DefaultBranch, _ = gitcmd.DetectDefaultBranch(ctx, remoteName)
+	hints.Branches, _ = gitcmd.ListBranchNames(ctx, remoteName)
+	return hints
+}
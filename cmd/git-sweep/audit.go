@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// auditDeletions appends every executed deletion, including failed ones, to the audit log
+// configured by audit_log. Failures to write the log are reported as warnings.
+func auditDeletions(ctx context.Context, results []types.DeleteResult) {
+	if len(results) == 0 {
+		return
+	}
+	path, err := state.AuditPath(appConfig.AuditLog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record deletions in audit log: %v\n", err)
+		return
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record deletions in audit log: %v\n", err)
+		return
+	}
+	user, err := gitcmd.GetUserEmail(ctx)
+	if err != nil {
+		slog.Debug("Could not determine user for audit log", "error", err)
+	}
+
+	now := time.Now().UTC()
+	entries := make([]state.AuditEntry, 0, len(results))
+	for _, res := range results {
+		entries = append(entries, state.AuditEntry{
+			Time:       now,
+			Repo:       repoRoot,
+			Branch:     res.BranchName,
+			Hash:       res.DeletedHash,
+			IsRemote:   res.IsRemote,
+			Remote:     res.RemoteName,
+			Success:    res.Success,
+			Message:    res.Message,
+			Cmd:        res.Cmd,
+			ArchivedAs: res.ArchivedAs,
+			Bundle:     res.BundlePath,
+			Tag:        res.Tag,
+			User:       user,
+		})
+	}
+
+	if err := state.AppendAudit(path, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record deletions in audit log: %v\n", err)
+		return
+	}
+	slog.Debug("Recorded deletions in audit log", "path", path, "count", len(entries))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bral/git-sweep-go/internal/types"
+	"github.com/bral/git-sweep-go/pkg/sweep"
+)
+
+// isProvablySafe reports whether --auto deletes the branch: a suggested branch whose tip is
+// reachable from the compare target, so 'git branch -d' accepts it and every commit of the
+// branch is kept there. Branches merged only into another protected branch such as develop,
+// and merges detected through pull requests, 'git cherry' or an empty diff are left for review.
+func isProvablySafe(branch types.AnalyzedBranch) bool {
+	return isDeletionCandidate(branch) && !branch.IsProtected && branch.IsMerged &&
+		branch.MergedBy == types.MergedByAncestry && branch.MergedInto == ""
+}
+
+// runAuto deletes the provably safe local branches among branches without asking, prints each
+// result and a summary, and returns the exit code. Remote branches are kept.
+func runAuto(
+	ctx context.Context, out io.Writer, branches []types.AnalyzedBranch, dryRun bool, maxDelete int, bundleDir string,
+) int {
+	var safe []types.AnalyzedBranch
+	leftForReview := 0
+	for _, branch := range branches {
+		if isProvablySafe(branch) {
+			safe = append(safe, branch)
+		} else if isDeletionCandidate(branch) {
+			leftForReview++
+		}
+	}
+	reviewHint := func() {
+		if leftForReview > 0 {
+			_, _ = fmt.Fprintf(out, "%d other suggested branch(es) need a closer look; run git-sweep to review them.\n",
+				leftForReview)
+		}
+	}
+	if len(safe) == 0 {
+		_, _ = fmt.Fprintln(out, "No branches are provably safe to delete.")
+		reviewHint()
+		return exitOK
+	}
+	if err := checkDeleteLimit(len(safe), maxDelete); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	plan := sweep.NewPlan(safe, sweep.PlanOptions{
+		Archive: sweep.ArchiveMode(appConfig), BundleDir: bundleDir, TagPrefix: appConfig.TagPrefix,
+	})
+	results := sweep.Delete(ctx, "", plan, sweep.DeleteOptions{
+		DryRun: dryRun,
+		OnResult: func(res types.DeleteResult) {
+			if ctx.Err() == nil {
+				_, _ = fmt.Fprintln(out, describeResult(res))
+			}
+		},
+	})
+	if !dryRun {
+		// Record what was deleted before an interruption too; the journal must not miss a branch
+		journalDeletions(context.WithoutCancel(ctx), results)
+		auditDeletions(context.WithoutCancel(ctx), results)
+		recordLastRun(context.WithoutCancel(ctx), results)
+		sendNotification(context.WithoutCancel(ctx), results)
+	}
+	if ctx.Err() != nil {
+		printInterruptedSummary(out, results)
+		return exitInterrupted
+	}
+
+	deleted := 0
+	for _, res := range results {
+		if res.Success {
+			deleted++
+		}
+	}
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	_, _ = fmt.Fprintf(out, "%s %d of %d branch(es) fully merged into %s; remote branches were kept.\n",
+		verb, deleted, len(safe), appConfig.CompareTarget())
+	reviewHint()
+	return deletionsExitCode(results)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+)
+
+// bundleDir returns the directory local branches are bundled to before they are deleted, or ""
+// if bundles are off. With expire set, bundles older than bundle_expiry_days are deleted from
+// it first; dry runs leave them alone.
+func bundleDir(ctx context.Context, expire bool) (string, error) {
+	if !appConfig.Bundle {
+		return "", nil
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the repository for bundles: %w", err)
+	}
+	dir, err := state.BundleDir(appConfig.BundleDir, repoRoot)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the bundle directory: %w", err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", fmt.Errorf("could not determine the bundle directory: %w", err)
+	}
+
+	if expire && appConfig.BundleExpiryDays > 0 {
+		maxAge := time.Duration(appConfig.BundleExpiryDays) * 24 * time.Hour
+		deleted, err := state.ExpireBundles(dir, maxAge, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		slog.Debug("Expired old bundles", "dir", dir, "deleted", deleted)
+	}
+	return dir, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// selectedBranches limits the analysis to these branches when set by 'git-sweep check'.
+var selectedBranches []string
+
+// readBranchNames reads branch names, one per line, in the format of 'git branch' (and
+// 'git branch -v'): the markers of the current and worktree branches are removed, a detached
+// HEAD is skipped, and anything after the name is ignored.
+func readBranchNames(r io.Reader) ([]string, error) {
+	var names []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSpace(strings.TrimLeft(line, "*+"))
+		if line == "" || strings.HasPrefix(line, "(") {
+			continue
+		}
+		names = append(names, strings.Fields(line)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read branch names: %w", err)
+	}
+	return names, nil
+}
+
+// branchesToCheck returns the branches named in args and, with --stdin, on standard input,
+// sorted and without repetitions.
+func branchesToCheck(cmd *cobra.Command, args []string) ([]string, error) {
+	names := slices.Clone(args)
+	if fromStdin, _ := cmd.Flags().GetBool("stdin"); fromStdin {
+		read, err := readBranchNames(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, read...)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no branches given; name them as arguments or pipe them in with --stdin")
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [branch...]",
+	Short: "Analyze and clean up only the given branches",
+	Long: `The check command runs git-sweep on an explicit set of local branches instead of
+discovering candidates among all of them: the interactive view, --dry-run,
+--no-tui, --quick-status and the other flags of git-sweep work as usual, but
+only the named branches are analyzed and offered for deletion. They are still
+categorized as usual, so protected, checked-out and recent branches are not
+suggested.
+
+Name the branches as arguments, or with --stdin one per line in the format of
+'git branch', for example:
+  git branch --merged | git-sweep check --stdin --dry-run
+
+After reading standard input, the interactive view reads the keyboard from the
+terminal.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		names, err := branchesToCheck(cmd, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		selectedBranches = names
+		slog.Debug("Checking selected branches", "branches", names)
+
+		if fromStdin, _ := cmd.Flags().GetBool("stdin"); fromStdin {
+			// Standard input is used up, so prompts and the interactive view read from the terminal
+			if tty, err := os.Open("/dev/tty"); err == nil {
+				os.Stdin = tty
+			}
+		}
+		rootCmd.Run(cmd, nil)
+	},
+}
+
+func init() {
+	checkCmd.Flags().Bool("stdin", false, "Read branch names from standard input, one per line.")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/config"
+)
+
+// checkStrictConfig refuses to continue if strict_config is set and the configuration file at
+// customPath has problems, which would otherwise be ignored or replaced by defaults.
+func checkStrictConfig(customPath string) error {
+	if !appConfig.StrictConfig {
+		return nil
+	}
+	problems, err := config.Validate(customPath)
+	if err != nil || len(problems) == 0 {
+		return err
+	}
+	return fmt.Errorf("strict_config is set and the configuration has problems:\n  - %s",
+		strings.Join(problems, "\n  - "))
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Check, create, locate, export and import the configuration file",
+	Long: `The config command works with the git-sweep configuration file, the one
+given with --config or the default one.`,
+	// Skip the root pre-run: an invalid config must be reported, not fixed interactively
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := changeDirectory(cmd); err != nil {
+			return err
+		}
+		return setupLogging(cmd)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Report unknown keys, invalid values and conflicting rules in the configuration",
+	Long: `The validate command checks the configuration file more strictly than
+git-sweep does when it starts: besides the errors that stop git-sweep, it
+reports unknown keys, which are ignored, invalid values, which are replaced
+by their defaults, and settings that conflict with each other or have no
+effect. It exits with a non-zero status if it finds any problem. Set
+strict_config = true to make git-sweep refuse to run with such problems.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		configPath, err := config.ResolvePath(customConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		problems, err := config.Validate(customConfigPath)
+		if errors.Is(err, config.ErrConfigNotFound) {
+			_, _ = fmt.Fprintf(os.Stdout, "No configuration file at %s; the defaults are used.\n", configPath)
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if len(problems) == 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "%s is valid.\n", configPath)
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s has %d problem(s):\n", configPath, len(problems))
+		for _, problem := range problems {
+			_, _ = fmt.Fprintf(os.Stdout, "  - %s\n", problem)
+		}
+		os.Exit(exitError)
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print where the configuration file is and which file is loaded",
+	Long: `The path command prints the location of the configuration file, given
+with --config or the default one for this system, and the file git-sweep
+actually loads from there, which differs if the location is a symlink.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		configPath, err := config.ResolvePath(customConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		source := "default location"
+		if customConfigPath != "" {
+			source = "from --config"
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Config file: %s (%s)\n", configPath, source)
+
+		loaded, err := config.LoadedPath(customConfigPath)
+		if errors.Is(err, config.ErrConfigNotFound) {
+			_, _ = fmt.Fprintln(os.Stdout, "Loaded:      none; the file does not exist, so the defaults are used")
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Loaded:      %s\n", loaded)
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a configuration file without prompting",
+	Long: `The init command writes a configuration file with the defaults, changed
+by the override flags given, such as --age, --protected or --primary-main,
+without the interactive first-run setup. Use it to create configuration
+files from scripts, e.g. in a dotfiles repository:
+
+  git-sweep config init --age 120 --protected develop,staging
+
+It refuses to replace an existing file unless --force is given.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		configPath, err := config.ResolvePath(customConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force {
+			if _, err := os.Lstat(configPath); err == nil {
+				fmt.Fprintf(os.Stderr, "Error: %s already exists; use --force to replace it\n", configPath)
+				os.Exit(exitError)
+			}
+		}
+
+		cfg := config.DefaultConfig()
+		applyFlagOverrides(cmd, &cfg)
+		savedPath, err := config.SaveConfig(cfg, customConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to save configuration to %q: %v\n", savedPath, err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Configuration saved to %q\n", savedPath)
+	},
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the configuration for copying it to another machine",
+	Long: `The export command prints the settings of the configuration file as TOML,
+for sharing a vetted team configuration or copying yours to another machine:
+
+  git-sweep config export > team.toml
+  git-sweep config import < team.toml
+
+API tokens (provider_token and github_token) are left out.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		cfg, err := config.LoadConfig(customConfigPath)
+		if errors.Is(err, config.ErrConfigNotFound) {
+			configPath, _ := config.ResolvePath(customConfigPath)
+			fmt.Fprintf(os.Stderr, "Error: no configuration file at %s to export\n", configPath)
+			os.Exit(exitError)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		data, err := config.Export(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "# git-sweep configuration exported by git-sweep %s\n", version)
+		_, _ = os.Stdout.Write(data)
+	},
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Replace the configuration with one exported by config export",
+	Long: `The import command reads a configuration printed by config export from
+standard input and saves it in place of the current configuration file, or
+creates the file. The API tokens of the current file are kept, and so are
+its comments where the settings did not change. Nothing is saved if the
+input has unknown keys or invalid values.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if isTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Error: pipe the exported configuration in, e.g. git-sweep config import < team.toml")
+			os.Exit(exitError)
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read standard input: %v\n", err)
+			os.Exit(exitError)
+		}
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		savedPath, err := config.Import(data, "standard input", customConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Configuration imported into %q\n", savedPath)
+	},
+}
+
+func init() {
+	configInitCmd.Flags().Bool("force", false, "Replace an existing configuration file.")
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configExportCmd)
+	configCmd.AddCommand(configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// applyConfigProfile applies the profile named by --config-profile or $GIT_SWEEP_PROFILE to
+// appConfig. Its remote replaces the default of --remote; flags given explicitly still win.
+func applyConfigProfile(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("config-profile")
+	if name == "" {
+		name = os.Getenv(config.ProfileEnv)
+	}
+	if name == "" {
+		return nil
+	}
+	cfg, err := appConfig.WithProfile(name)
+	if err != nil {
+		return err
+	}
+	appConfig = cfg
+	slog.Debug("Applied configuration profile", "profile", name)
+	if remote := cfg.Profiles[name].Remote; remote != "" && !cmd.Flags().Changed("remote") {
+		if err := cmd.Flags().Set("remote", remote); err != nil {
+			return fmt.Errorf("could not use the remote of profile %q: %w", name, err)
+		}
+	}
+	return nil
+}
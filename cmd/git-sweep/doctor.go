@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/state"
+)
+
+// remoteCheckTimeout bounds how long doctor waits for the remote to answer.
+const remoteCheckTimeout = 15 * time.Second
+
+// Outcomes of a single doctor check.
+const (
+	doctorPass = "PASS"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// doctorCheck is the outcome of one diagnostic performed by 'git-sweep doctor'.
+type doctorCheck struct {
+	Name   string
+	Status string // doctorPass, doctorWarn or doctorFail
+	Detail string
+}
+
+// doctorChecks collects check results in the order they were performed.
+type doctorChecks []doctorCheck
+
+func (c *doctorChecks) add(name, status, format string, a ...any) {
+	*c = append(*c, doctorCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, a...)})
+}
+
+// failed returns the number of failed checks.
+func (c doctorChecks) failed() int {
+	count := 0
+	for _, check := range c {
+		if check.Status == doctorFail {
+			count++
+		}
+	}
+	return count
+}
+
+// runDoctor diagnoses the environment git-sweep runs in. Checks that depend on being inside
+// a repository are skipped when it is not, and the remote is not contacted in offline mode.
+func runDoctor(ctx context.Context, customConfigPath, remoteName, mainOverride string, offline bool) doctorChecks {
+	var checks doctorChecks
+
+	// Git installation
+	gitVersion, err := gitcmd.GetGitVersion(ctx)
+	if err != nil {
+		checks.add("Git", doctorFail, "git could not be run: %v", err)
+		return checks
+	}
+	checks.add("Git", doctorPass, "git version %s", gitVersion)
+
+	// Configuration file syntax
+	cfg, err := config.LoadConfig(customConfigPath)
+	configPath, pathErr := config.ResolvePath(customConfigPath)
+	if pathErr != nil {
+		configPath = "(unknown)"
+	}
+	switch {
+	case errors.Is(err, config.ErrConfigNotFound):
+		checks.add("Config", doctorWarn, "no config file at %s; defaults are used until git-sweep creates one",
+			configPath)
+	case err != nil:
+		checks.add("Config", doctorFail, "%v", err)
+	default:
+		checks.add("Config", doctorPass, "loaded from %s", configPath)
+		if problems, _ := config.Validate(customConfigPath); len(problems) > 0 {
+			checks.add("Config validation", doctorWarn, "%d problem(s); run 'git-sweep config validate' to list them",
+				len(problems))
+		}
+	}
+	if mainOverride != "" {
+		cfg.PrimaryMainBranch = mainOverride
+	}
+	if cfg.CABundle != "" {
+		if _, err := httpclient.New(cfg, 0); err != nil {
+			checks.add("CA bundle", doctorFail, "%v", err)
+		} else {
+			checks.add("CA bundle", doctorPass, "certificates loaded from %s", cfg.CABundle)
+		}
+	}
+
+	// Write permissions outside the repository
+	checks.addWritable("Config directory", filepath.Dir(configPath))
+	if stateDir, err := state.Dir(); err != nil {
+		checks.add("State directory", doctorFail, "%v", err)
+	} else {
+		checks.addWritable("State directory", stateDir)
+	}
+
+	// Repository validity
+	inGitRepo, err := gitcmd.IsInGitRepo(ctx)
+	if err != nil || !inGitRepo {
+		checks.add("Repository", doctorFail, "not inside a Git working tree; repository checks skipped")
+		return checks
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		checks.add("Repository", doctorFail, "%v", err)
+		return checks
+	}
+	checks.add("Repository", doctorPass, "%s", repoRoot)
+	if gitDir, err := gitcmd.GetGitCommonDir(ctx); err != nil {
+		checks.add("Git directory", doctorFail, "%v", err)
+	} else {
+		checks.addWritable("Git directory", gitDir)
+	}
+
+	// Primary main branch
+	mainBranch, source := cfg.PrimaryMainBranch, ""
+	var detectErr error
+	if mainBranch == config.PrimaryMainAuto {
+		mainBranch, detectErr = gitcmd.DetectDefaultBranch(ctx, remoteName)
+		source = " (detected)"
+	}
+	if detectErr != nil {
+		checks.add("Primary main branch", doctorFail, "%v; set primary_main_branch in the config", detectErr)
+	} else if mainHash, err := gitcmd.GetMainBranchHash(ctx, mainBranch); err != nil {
+		checks.add("Primary main branch", doctorFail,
+			"'%s' does not exist; set primary_main_branch in the config or pass --primary-main", mainBranch)
+	} else {
+		checks.add("Primary main branch", doctorPass, "'%s'%s at %.7s", mainBranch, source, mainHash)
+	}
+
+	if cfg.CompareRef != "" {
+		if hash, err := gitcmd.GetMainBranchHash(ctx, cfg.CompareRef); err != nil {
+			checks.add("Compare ref", doctorFail, "'%s' does not exist; fix compare_ref in the config", cfg.CompareRef)
+		} else {
+			checks.add("Compare ref", doctorPass, "'%s' at %.7s", cfg.CompareRef, hash)
+		}
+	}
+
+	// Remote reachability
+	remoteURL, err := gitcmd.GetRemoteURL(ctx, remoteName)
+	if err != nil {
+		checks.add("Remote", doctorWarn, "remote '%s' is not configured; only local branches can be cleaned up",
+			remoteName)
+		return checks
+	}
+	if offline || cfg.Offline {
+		checks.add("Remote", doctorPass, "'%s' (%s) is configured; not contacted in offline mode", remoteName, remoteURL)
+		return checks
+	}
+	remoteCtx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+	if err := gitcmd.CheckRemote(remoteCtx, remoteName); err != nil {
+		checks.add("Remote", doctorFail, "'%s' (%s) is not reachable: %v", remoteName, remoteURL, err)
+	} else {
+		checks.add("Remote", doctorPass, "'%s' (%s) is reachable", remoteName, remoteURL)
+	}
+
+	return checks
+}
+
+// addWritable records whether files can be created in dir. A directory that does not exist yet
+// passes if it can be created, i.e. its nearest existing parent is writable.
+func (c *doctorChecks) addWritable(name, dir string) {
+	existing := dir
+	for {
+		if _, err := os.Stat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			c.add(name, doctorFail, "%s: no existing parent directory", dir)
+			return
+		}
+		existing = parent
+	}
+
+	probe, err := os.CreateTemp(existing, ".git-sweep-doctor-*")
+	if err != nil {
+		c.add(name, doctorFail, "%s is not writable: %v", dir, err)
+		return
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	c.add(name, doctorPass, "%s is writable", dir)
+}
+
+// printDoctorReport writes one line per check followed by a summary.
+func printDoctorReport(w io.Writer, checks doctorChecks) {
+	for _, check := range checks {
+		_, _ = fmt.Fprintf(w, "[%s] %-20s %s\n", check.Status, check.Name, check.Detail)
+	}
+	if failed := checks.failed(); failed > 0 {
+		_, _ = fmt.Fprintf(w, "\n%d check(s) failed.\n", failed)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "\nAll checks passed.")
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the git setup, repository and configuration",
+	Long: `The doctor command checks the git installation, the configuration file,
+write permissions, the current repository, the primary main branch and the
+remote, and prints a pass/fail report. It exits with a non-zero status if
+any check fails.`,
+	Args: cobra.NoArgs,
+	// Skip the root pre-run: a broken or missing config must be reported, not fixed interactively
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := changeDirectory(cmd); err != nil {
+			return err
+		}
+		return setupLogging(cmd)
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		customConfigPath, _ := cmd.Flags().GetString("config")
+		remoteName, _ := cmd.Flags().GetString("remote")
+		mainOverride, _ := cmd.Flags().GetString("primary-main")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		checks := runDoctor(cmd.Context(), customConfigPath, remoteName, mainOverride, offline)
+		printDoctorReport(os.Stdout, checks)
+		if checks.failed() > 0 {
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
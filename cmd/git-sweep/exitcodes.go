@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Exit codes reported by git-sweep. They are part of the documented interface (see README),
+// so existing values must never change meaning.
+const (
+	exitOK              = 0   // Success; with --exit-code, also "nothing to clean up"
+	exitError           = 1   // Invalid usage, not a repository, or analysis failed
+	exitCandidatesFound = 3   // With --exit-code: branches were found that could be cleaned up
+	exitDeletionsFailed = 4   // At least one requested deletion failed
+	exitInterrupted     = 130 // Stopped by Ctrl+C (SIGINT) or SIGTERM
+)
+
+// isDeletionCandidate reports whether a branch is suggested for deletion.
+func isDeletionCandidate(branch types.AnalyzedBranch) bool {
+	return branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld
+}
+
+// countCandidates returns the number of branches suggested for deletion.
+func countCandidates(branches []types.AnalyzedBranch) int {
+	count := 0
+	for _, branch := range branches {
+		if isDeletionCandidate(branch) {
+			count++
+		}
+	}
+	return count
+}
+
+// deleteLimit returns the most branches that may be deleted in one run, or 0 for no limit.
+// --force lifts the configured limit.
+func deleteLimit(cmd *cobra.Command) int {
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		return 0
+	}
+	return appConfig.MaxDelete
+}
+
+// checkDeleteLimit returns an error if deleting count branches would exceed limit.
+func checkDeleteLimit(count, limit int) error {
+	if limit > 0 && count > limit {
+		return fmt.Errorf("refusing to delete %d branches, more than max_delete (%d); narrow the selection or pass --force",
+			count, limit)
+	}
+	return nil
+}
+
+// candidatesExitCode returns the exit code for a read-only run that found the given number of
+// candidates. Without --exit-code, finding candidates is not reported through the exit status.
+func candidatesExitCode(cmd *cobra.Command, candidates int) int {
+	if exitCode, _ := cmd.Flags().GetBool("exit-code"); exitCode && candidates > 0 {
+		return exitCandidatesFound
+	}
+	return exitOK
+}
+
+// deletionsExitCode returns exitDeletionsFailed if any deletion was unsuccessful.
+func deletionsExitCode(results []types.DeleteResult) int {
+	for _, res := range results {
+		if !res.Success {
+			return exitDeletionsFailed
+		}
+	}
+	return exitOK
+}
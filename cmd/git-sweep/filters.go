@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/filter"
+)
+
+// candidateFilter builds the branch filter from the --older-than, --pattern and --exclude flags.
+func candidateFilter(cmd *cobra.Command) (filter.Filter, error) {
+	var f filter.Filter
+	if olderThan, _ := cmd.Flags().GetString("older-than"); olderThan != "" {
+		days, err := filter.ParseAge(olderThan)
+		if err != nil {
+			return f, fmt.Errorf("--older-than: %w", err)
+		}
+		f.OlderThanDays = days
+	}
+	f.Patterns, _ = cmd.Flags().GetStringArray("pattern")
+	f.Excludes, _ = cmd.Flags().GetStringArray("exclude")
+	if err := f.Validate(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// candidateSelection builds the preselection for the interactive UI from the --select and
+// --select-all flags.
+func candidateSelection(cmd *cobra.Command) (filter.Selection, error) {
+	values, _ := cmd.Flags().GetStringArray("select")
+	all, _ := cmd.Flags().GetBool("select-all")
+	selection, err := filter.ParseSelection(values, all)
+	if err != nil {
+		return selection, fmt.Errorf("--select: %w", err)
+	}
+	return selection, nil
+}
+
+func init() {
+	rootCmd.Flags().String("older-than", "",
+		"Only consider branches whose last commit is older than this (e.g. 180d, 6m, 1y).")
+	rootCmd.Flags().StringArray("pattern", nil,
+		"Only consider branches matching this glob (e.g. 'feature/*'). Repeatable.")
+	rootCmd.Flags().StringArray("exclude", nil,
+		"Never consider branches matching this glob (e.g. 'feature/keep-*'). Repeatable.")
+	rootCmd.Flags().StringArray("select", nil,
+		"Preselect candidates in the interactive UI: merged, unmerged or a glob (e.g. 'feature/*'). Repeatable.")
+	rootCmd.Flags().Bool("select-all", false, "Preselect all candidates in the interactive UI.")
+}
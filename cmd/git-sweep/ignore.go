@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+)
+
+// parseSnoozeDuration parses durations such as "30d", "2w" or "12h".
+// Days and weeks are accepted in addition to the units understood by time.ParseDuration.
+func parseSnoozeDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30d, 2w or 12h)", value)
+	}
+	return d, nil
+}
+
+// snoozeUntilLabel describes when a snooze expires.
+func snoozeUntilLabel(until time.Time) string {
+	if until.IsZero() {
+		return "indefinitely"
+	}
+	return "until " + until.Local().Format("2006-01-02 15:04")
+}
+
+// requireRepoRoot returns the root of the current repository or exits with an error.
+func requireRepoRoot(ctx context.Context) string {
+	inGitRepo, err := gitcmd.IsInGitRepo(ctx)
+	if err != nil || !inGitRepo {
+		fmt.Fprintln(os.Stderr, "Error: Not inside a Git repository.")
+		os.Exit(exitError)
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	return repoRoot
+}
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage branches snoozed from deletion suggestions",
+	Long: `The ignore command manages the per-repository list of snoozed branches.
+Snoozed branches are never suggested for deletion until the snooze expires.
+Branches can also be snoozed from the interactive view with 'z'.`,
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <branch>",
+	Short: "Snooze a branch so it is not suggested for deletion",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		repoRoot := requireRepoRoot(ctx)
+
+		var until time.Time
+		if forValue, _ := cmd.Flags().GetString("for"); forValue != "" {
+			d, err := parseSnoozeDuration(forValue)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			until = time.Now().Add(d).UTC()
+		}
+
+		if err := state.SnoozeBranch(repoRoot, args[0], until); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Snoozed '%s' %s.\n", args[0], snoozeUntilLabel(until))
+	},
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:   "remove <branch>",
+	Short: "Remove a branch from the snooze list",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repoRoot := requireRepoRoot(cmd.Context())
+		removed, err := state.UnsnoozeBranch(repoRoot, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !removed {
+			_, _ = fmt.Fprintf(os.Stdout, "'%s' was not snoozed.\n", args[0])
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "'%s' will be suggested again.\n", args[0])
+	},
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snoozed branches for the current repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		repoRoot := requireRepoRoot(cmd.Context())
+		snoozes, err := state.ListSnoozes(repoRoot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if len(snoozes) == 0 {
+			_, _ = fmt.Fprintln(os.Stdout, "No branches are snoozed for this repository.")
+			return
+		}
+		for _, s := range snoozes {
+			_, _ = fmt.Fprintf(os.Stdout, "%-40s %s\n", s.Branch, snoozeUntilLabel(s.Until))
+		}
+	},
+}
+
+func init() {
+	ignoreAddCmd.Flags().String("for", "", "Snooze duration, e.g. 30d, 2w or 12h (default: indefinitely).")
+	ignoreCmd.AddCommand(ignoreAddCmd, ignoreRemoveCmd, ignoreListCmd)
+	rootCmd.AddCommand(ignoreCmd)
+}
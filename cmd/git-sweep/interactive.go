@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bral/git-sweep-go/internal/filter"
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/logging"
+	"github.com/bral/git-sweep-go/internal/tui"
+)
+
+// runInteractive launches the TUI, analyzes the repository in the background while it is
+// shown, and records whatever the user deleted. It exits the program when the TUI is done.
+func runInteractive(
+	ctx context.Context, remoteName string, fetch bool, branchFilter filter.Filter, selection filter.Selection,
+	maxDelete int, bundleDir string,
+) {
+	slog.Debug("Launching TUI")
+	// NO_COLOR (https://no-color.org) disables colors whenever it is set to a non-empty value
+	tui.SetTheme(tui.ResolveTheme(appConfig, os.Getenv("NO_COLOR") != ""))
+
+	// The analysis stops once the UI exits; deletions run on ctx and are not affected
+	analysisCtx, stopAnalysis := context.WithCancel(ctx)
+	defer stopAnalysis()
+
+	initialModel := tui.InitialModel(ctx, nil, false)
+	initialModel.LoadFrom(streamAnalysis(analysisCtx, remoteName, fetch, branchFilter))
+	initialModel.DeleteOptions = gitcmd.DeleteOptions{
+		RemoteWorkers:   appConfig.RemoteDeleteWorkers,
+		RemoteRateLimit: appConfig.RemoteRateLimit,
+	}
+	initialModel.Archive = appConfig.Archive
+	initialModel.MaxDelete = maxDelete
+	initialModel.ConfirmEach = appConfig.InteractiveConfirm
+	initialModel.ArchiveMode = appConfig.ArchiveMode
+	initialModel.BundleDir = bundleDir
+	initialModel.TagPrefix = appConfig.TagPrefix
+	initialModel.SnoozeFor = time.Duration(appConfig.SnoozeDays) * 24 * time.Hour
+	initialModel.UpdateNotice = updateNotice
+	initialModel.Offline = appConfig.Offline
+	initialModel.OfflineNotice = offlineNotice(ctx)
+	initialModel.HeadNotice = detachedHeadNotice(ctx)
+	if !selection.IsZero() {
+		initialModel.Preselect(selection.Matches)
+	}
+	// Log records would corrupt the UI, so collect them and print them once it exits
+	logRecords := make(logging.ChannelWriter, logBufferSize)
+	logging.Redirect(logRecords)
+	initialModel.LogRecords = logRecords
+	p := tea.NewProgram(initialModel)
+
+	stopTUI := profile.track("tui")
+	finalModel, err := p.Run()
+	stopTUI()
+	logging.Redirect(os.Stderr)
+	stopAnalysis()
+	// On SIGINT Bubble Tea restores the terminal and returns the last model, whose deletions
+	// the cancelled context is already stopping
+	if err != nil && !errors.Is(err, tea.ErrInterrupted) {
+		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	// Deletions are executed and their results displayed within the TUI
+	m, ok := finalModel.(tui.Model)
+	if !ok {
+		os.Exit(exitOK)
+	}
+	m.WaitForDeletions()
+	printCollectedLogs(m.Logs, logRecords)
+	profile.write(os.Stderr)
+	if m.LoadErr != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", m.LoadErr)
+		os.Exit(exitError)
+	}
+	if m.SwitchedTo != "" {
+		_, _ = fmt.Fprintf(os.Stdout, "Switched to branch '%s'.\n", m.SwitchedTo)
+	}
+	// The results screen already shows the outcome if the UI got that far
+	if m.Interrupted && m.ViewState != tui.StateResults {
+		printInterruptedSummary(os.Stdout, m.Results)
+	}
+
+	// Record deletions in the undo journal, the audit log and the last run, and notify the webhook
+	if !m.DryRun {
+		journalDeletions(context.WithoutCancel(ctx), m.Results)
+		auditDeletions(context.WithoutCancel(ctx), m.Results)
+		recordLastRun(context.WithoutCancel(ctx), m.Results)
+		sendNotification(context.WithoutCancel(ctx), m.Results)
+	}
+
+	slog.Debug("Exiting git-sweep")
+	if ctx.Err() != nil || m.Interrupted {
+		os.Exit(exitInterrupted)
+	}
+	os.Exit(deletionsExitCode(m.Results))
+}
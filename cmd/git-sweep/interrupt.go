@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// signalContext returns a context that is cancelled by the first SIGINT or SIGTERM, stopping
+// in-flight git commands. After that, the default handling is restored so a second Ctrl+C
+// exits immediately.
+func signalContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx
+}
+
+// interruptibleReader makes blocking reads, such as prompts on stdin, return ctx.Err() as soon
+// as ctx is cancelled. A read abandoned this way is left running; callers exit soon after.
+type interruptibleReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (ir interruptibleReader) Read(p []byte) (int, error) {
+	if err := ir.ctx.Err(); err != nil {
+		return 0, err
+	}
+	type result struct {
+		n   int
+		err error
+	}
+	buf := make([]byte, len(p))
+	done := make(chan result, 1)
+	go func() {
+		n, err := ir.r.Read(buf)
+		done <- result{n, err}
+	}()
+	select {
+	case res := <-done:
+		return copy(p, buf[:res.n]), res.err
+	case <-ir.ctx.Done():
+		return 0, ir.ctx.Err()
+	}
+}
+
+// describeResult summarizes the outcome of one deletion on a single line.
+func describeResult(res types.DeleteResult) string {
+	label := "Local " + res.BranchName
+	if res.IsRemote {
+		label = fmt.Sprintf("Remote %s/%s", res.RemoteName, res.BranchName)
+	}
+	status := "ok"
+	if !res.Success {
+		status = "FAILED"
+	}
+	message := res.Message
+	if gitcmd.IsAuthFailure(res) {
+		message = gitcmd.AuthFailureMessage
+	}
+	return fmt.Sprintf("[%s] %s - %s", status, label, message)
+}
+
+// printInterruptedSummary reports what an interrupted deletion run did before it stopped.
+func printInterruptedSummary(w io.Writer, results []types.DeleteResult) {
+	succeeded := 0
+	for _, res := range results {
+		if res.Success {
+			succeeded++
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Interrupted: %d of %d deletions completed.\n", succeeded, len(results))
+	for _, res := range results {
+		_, _ = fmt.Fprintln(w, describeResult(res))
+	}
+	printAuthHint(w, results)
+}
+
+// printAuthHint explains once how to fix the credentials if remote deletions failed because of them.
+func printAuthHint(w io.Writer, results []types.DeleteResult) {
+	if hint := gitcmd.AuthHint(results); hint != "" {
+		_, _ = fmt.Fprintf(w, "\n%s\n", hint)
+	}
+}
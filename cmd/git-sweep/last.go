@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// recordLastRun remembers the results of a deletion run for 'git-sweep last', replacing the
+// previous run of the repository. Failures are reported as warnings.
+func recordLastRun(ctx context.Context, results []types.DeleteResult) {
+	if len(results) == 0 {
+		return
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record the results of this run: %v\n", err)
+		return
+	}
+
+	run := state.LastRun{Time: time.Now(), Results: make([]state.RunResult, 0, len(results))}
+	for _, res := range results {
+		run.Results = append(run.Results, state.RunResult{
+			Branch:     res.BranchName,
+			Hash:       res.DeletedHash,
+			IsRemote:   res.IsRemote,
+			Remote:     res.RemoteName,
+			Success:    res.Success,
+			Message:    res.Message,
+			ArchivedAs: res.ArchivedAs,
+			Bundle:     res.BundlePath,
+			Tag:        res.Tag,
+		})
+	}
+	if err := state.RecordLastRun(repoRoot, run); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record the results of this run: %v\n", err)
+		return
+	}
+	slog.Debug("Recorded last run", "count", len(run.Results))
+}
+
+// recoveryCommand returns the git command that recreates a deleted branch at its old tip.
+func recoveryCommand(res state.RunResult) string {
+	if res.IsRemote {
+		return gitcmd.ShellLine([]string{"git", "push", res.Remote, res.Hash + ":refs/heads/" + res.Branch})
+	}
+	return gitcmd.ShellLine([]string{"git", "branch", res.Branch, res.Hash})
+}
+
+// printLastRun lists the results of a run with the commands that recover the deleted branches.
+func printLastRun(w io.Writer, run state.LastRun) {
+	succeeded := 0
+	for _, res := range run.Results {
+		if res.Success {
+			succeeded++
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Last run: %s (%s), %d of %d deletions succeeded.\n",
+		run.Time.Local().Format("2006-01-02 15:04"), timeAgo(time.Since(run.Time)), succeeded, len(run.Results))
+	for _, res := range run.Results {
+		_, _ = fmt.Fprintln(w, describeResult(types.DeleteResult{
+			BranchName: res.Branch, IsRemote: res.IsRemote, RemoteName: res.Remote,
+			Success: res.Success, Message: res.Message,
+		}))
+		if res.Success && res.Hash != "" {
+			_, _ = fmt.Fprintf(w, "    recover: %s\n", recoveryCommand(res))
+		}
+	}
+}
+
+var lastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Show what the last run deleted in this repository",
+	Long: `The last command shows the results of the most recent run that deleted
+branches in the current repository: when it ran, which branches were deleted
+or failed to be, and the commands that recover each deleted branch at the
+commit it pointed to. Unlike the undo journal ('git-sweep restore --list') and
+the audit log, it only keeps the last run, for a quick "what did I just do?".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported --output %q (supported: text, json)\n", output)
+			os.Exit(exitError)
+		}
+		repoRoot := requireRepoRoot(cmd.Context())
+
+		run, ok, err := state.LastRunOf(repoRoot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !ok {
+			_, _ = fmt.Fprintln(os.Stdout, "No run has deleted branches in this repository yet.")
+			return
+		}
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(run); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			return
+		}
+		printLastRun(os.Stdout, run)
+	},
+}
+
+func init() {
+	lastCmd.Flags().StringP("output", "o", "text", "Output format: text or json.")
+	rootCmd.AddCommand(lastCmd)
+}
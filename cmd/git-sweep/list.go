@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/stats"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// defaultListFormat is the template used by 'git-sweep list' when --format is not given.
+const defaultListFormat = "{{.Name}}\t{{.Category}}\t{{.AgeDays}}{{with .DescriptionLine}}\t{{.}}{{end}}"
+
+// listItem is the data passed to the list template: every AnalyzedBranch field plus derived values.
+type listItem struct {
+	types.AnalyzedBranch
+	AgeDays     int  // Whole days since the last commit
+	IsCandidate bool // Suggested for deletion (MergedOld or UnmergedOld)
+
+	DescriptionLine string // First line of the branch description
+}
+
+// newListItem derives the template data for a branch.
+func newListItem(branch types.AnalyzedBranch, now time.Time) listItem {
+	return listItem{
+		AnalyzedBranch: branch,
+		AgeDays:        int(now.Sub(branch.LastCommitDate).Hours() / 24),
+		IsCandidate:    isDeletionCandidate(branch),
+
+		DescriptionLine: strings.SplitN(branch.Description, "\n", 2)[0],
+	}
+}
+
+// printBranchList renders each branch through the given text/template format, one per line.
+// With byAge, the branches are grouped into age buckets, each under a heading with its subtotal.
+func printBranchList(w io.Writer, branches []types.AnalyzedBranch, format string, candidatesOnly, byAge bool) error {
+	// Allow escaped tabs and newlines, which are awkward to pass through a shell
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	tmpl, err := template.New("list").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid --format template: %w", err)
+	}
+
+	now := time.Now()
+	if candidatesOnly {
+		branches = slices.DeleteFunc(slices.Clone(branches), func(b types.AnalyzedBranch) bool {
+			return !isDeletionCandidate(b)
+		})
+	}
+	groups := []stats.AgeGroup{{Branches: branches}}
+	if byAge {
+		groups = stats.GroupByAge(branches, now)
+	}
+	for i, group := range groups {
+		if byAge {
+			if i > 0 {
+				_, _ = fmt.Fprintln(w)
+			}
+			_, _ = fmt.Fprintf(w, "%s (%d branch(es)):\n", group.Label, len(group.Branches))
+		}
+		for _, branch := range group.Branches {
+			if err := tmpl.Execute(w, newListItem(branch, now)); err != nil {
+				return fmt.Errorf("failed to render branch %q: %w", branch.Name, err)
+			}
+			_, _ = fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print analyzed branches without the interactive UI",
+	Long: `The list command analyzes the repository like the interactive mode and prints
+one line per branch using a Go text/template, for use in scripts.
+
+The template is executed for each branch with the fields of AnalyzedBranch
+(.Name, .Category, .IsMerged, .IsOldByAge, .IsProtected, .IsCurrent, .Remote,
+.Upstream, .CommitHash, .AuthorEmail, .Description, .LastCommitDate, ...) plus
+.AgeDays, .IsCandidate and .DescriptionLine, the first line of the description.
+The escapes \t and \n are expanded. The default format appends .DescriptionLine
+for branches that have a description. With --by-age, the branches are grouped
+into age buckets, each under a heading with the number of branches in it.
+
+Example:
+  git-sweep list --candidates --format '{{.Name}} {{.Category}} {{.AgeDays}}'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+		format, _ := cmd.Flags().GetString("format")
+		candidatesOnly, _ := cmd.Flags().GetBool("candidates")
+		byAge, _ := cmd.Flags().GetBool("by-age")
+		porcelain := porcelainFlags(cmd)
+		if porcelain.enabled && (cmd.Flags().Changed("format") || byAge) {
+			fmt.Fprintln(os.Stderr, "Error: --porcelain and -z cannot be combined with --format or --by-age.")
+			os.Exit(exitError)
+		}
+
+		branches, err := analyzeRepository(ctx, remoteName, fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if porcelain.enabled {
+			printPorcelainList(os.Stdout, porcelain, branches, candidatesOnly)
+			return
+		}
+		if err := printBranchList(os.Stdout, branches, format, candidatesOnly, byAge); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	listCmd.Flags().String("format", defaultListFormat, "Go text/template applied to each branch.")
+	listCmd.Flags().Bool("candidates", false, "Only list branches suggested for deletion.")
+	listCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	addPorcelainFlags(listCmd, "Print a stable format for scripts instead of the --format template.")
+	rootCmd.AddCommand(listCmd)
+}
@@ -2,22 +2,27 @@
 package main
 
 import (
-	"bufio"   // Added for setup input
+	"bufio" // Added for setup input
+	"cmp"
 	"context" // Added for git commands
 	"errors"  // Added for error checking
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath" // Added for config path handling
 	"runtime/debug" // Added for build info
-	"time"          // Added for branch age calculation
+	"strconv"
+	"strings"
+	"time" // Added for branch age calculation
 
 	"github.com/bral/git-sweep-go/internal/analyze"
 	"github.com/bral/git-sweep-go/internal/config" // Added config import
 	"github.com/bral/git-sweep-go/internal/gitcmd" // Added gitcmd import
-	"github.com/bral/git-sweep-go/internal/tui"    // Added tui import
+	"github.com/bral/git-sweep-go/internal/logging"
+	"github.com/bral/git-sweep-go/internal/stats"
 	"github.com/bral/git-sweep-go/internal/types"
-	versionpkg "github.com/bral/git-sweep-go/internal/version" // Added version import with alias
-	tea "github.com/charmbracelet/bubbletea"                   // Added bubbletea import
+	"github.com/bral/git-sweep-go/pkg/sweep"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 )
 
@@ -25,139 +30,196 @@ import (
 var version = "dev"
 
 // Global config variable to be used by the command logic
-var (
-	appConfig config.Config
-	isDebug   bool // Global variable to store debug flag state
-)
+var appConfig config.Config
+
+// updateNotice announces a newer release; empty if there is none or the check was skipped
+var updateNotice string
+
+// logBufferSize is the number of log records kept while the TUI owns the terminal.
+const logBufferSize = 256
 
-// logDebugf prints only if the --debug flag is set, writing to stderr.
-func logDebugf(format string, a ...any) {
-	if isDebug {
-		// Write debug info to stderr
-		_, _ = fmt.Fprintf(os.Stderr, format, a...)
+// printCollectedLogs writes the log records gathered while the TUI was running to stderr,
+// including any that arrived after the UI stopped listening.
+func printCollectedLogs(logs []string, pending logging.ChannelWriter) {
+	for _, record := range logs {
+		_, _ = fmt.Fprintln(os.Stderr, record)
+	}
+	for {
+		select {
+		case record := <-pending:
+			_, _ = fmt.Fprintln(os.Stderr, record)
+		default:
+			return
+		}
 	}
 }
 
-// logDebugln prints only if the --debug flag is set, writing to stderr.
-func logDebugln(a ...any) {
-	if isDebug {
-		// Write debug info to stderr
-		_, _ = fmt.Fprintln(os.Stderr, a...)
+// changeDirectory applies --cwd, so git-sweep runs as if it was started in that directory,
+// like 'git -C'. Relative paths given in other flags are resolved against it.
+func changeDirectory(cmd *cobra.Command) error {
+	dir, _ := cmd.Flags().GetString("cwd")
+	if dir == "" {
+		return nil
 	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("invalid --cwd: %w", err)
+	}
+	return nil
+}
+
+// setupLogging configures the shared slog logger from the --verbosity, --log-format and
+// --log-file flags. --debug is shorthand for --verbosity debug.
+func setupLogging(cmd *cobra.Command) error {
+	verbosity, _ := cmd.Flags().GetString("verbosity")
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		verbosity = "debug"
+	}
+	level, err := logging.ParseLevel(verbosity)
+	if err != nil {
+		return err
+	}
+	format, _ := cmd.Flags().GetString("log-format")
+	logFile, _ := cmd.Flags().GetString("log-file")
+	return logging.Setup(logging.Options{Level: level, Format: format, File: logFile}, os.Stderr)
 }
 
 // printDryRunActions prints the actions that would be taken for selectable branches to stdout.
-func printDryRunActions(displayableBranches []types.AnalyzedBranch) {
+// With byAge, each list is split into age buckets with a subtotal per bucket.
+func printDryRunActions(displayableBranches []types.AnalyzedBranch, byAge bool) {
 	_, _ = fmt.Fprintln(os.Stdout, "[Dry Run] Proposed Actions (Only showing selectable branches):")
-	_, _ = fmt.Fprintln(os.Stdout, "\nLocal Deletions:")
-	hasLocal := false
-	for _, branch := range displayableBranches {
-		// Only print actions for selectable branches (which are all displayable ones now)
-		// This check is technically redundant if displayableBranches is filtered correctly, but keep for safety.
-		isCandidate := branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld
-		// Also include Active for now based on original logic, though maybe should only be MergedOld/UnmergedOld?
-		isCandidate = isCandidate || branch.Category == types.CategoryActive
-		if !isCandidate {
+	printDryRunSection("Local Deletions", displayableBranches, byAge, dryRunLocalAction)
+	printDryRunSection("Remote Deletions", displayableBranches, byAge, dryRunRemoteAction)
+	_, _ = fmt.Fprintln(os.Stdout, "\n(Dry run complete, no changes made)")
+}
+
+// printDryRunSection prints one list of the dry run: the action lineFor returns for each
+// branch it applies to, grouped into age buckets if byAge is set.
+func printDryRunSection(
+	title string, branches []types.AnalyzedBranch, byAge bool, lineFor func(types.AnalyzedBranch) (string, bool),
+) {
+	_, _ = fmt.Fprintf(os.Stdout, "\n%s:\n", title)
+	groups := []stats.AgeGroup{{Branches: branches}}
+	if byAge {
+		groups = stats.GroupByAge(branches, time.Now())
+	}
+	total := 0
+	for _, group := range groups {
+		var lines []string
+		for _, branch := range group.Branches {
+			if line, ok := lineFor(branch); ok {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
 			continue
 		}
-		delType := "-d (safe)"
-		if !branch.IsMerged {
-			delType = "-D (force)"
+		indent := "  "
+		if byAge {
+			_, _ = fmt.Fprintf(os.Stdout, "  %s (%d branch(es)):\n", group.Label, len(lines))
+			indent = "    "
 		}
-
-		// Add status with age information
-		statusInfo := ""
-		daysOld := int(time.Since(branch.LastCommitDate).Hours() / 24)
-
-		switch branch.Category {
-		case types.CategoryMergedOld:
-			statusInfo = fmt.Sprintf(" | Status: Merged (%d days)", daysOld)
-		case types.CategoryUnmergedOld:
-			statusInfo = fmt.Sprintf(" | Status: Old (%d days)", daysOld)
-		case types.CategoryProtected, types.CategoryActive:
-			// No additional status info for protected/active branches in dry run
+		for _, line := range lines {
+			_, _ = fmt.Fprintf(os.Stdout, "%s- %s\n", indent, line)
 		}
-
-		_, _ = fmt.Fprintf(os.Stdout, "  - Delete '%s' (%s)%s\n", branch.Name, delType, statusInfo)
-		hasLocal = true
+		total += len(lines)
 	}
-	if !hasLocal {
+	if total == 0 {
 		_, _ = fmt.Fprintln(os.Stdout, "  (None)")
 	}
-	_, _ = fmt.Fprintln(os.Stdout, "\nRemote Deletions:")
-	hasRemote := false
-	for _, branch := range displayableBranches {
-		// Only print actions for selectable branches with remotes
-		isCandidate := branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld
-		// Also include Active for now based on original logic
-		isCandidate = isCandidate || branch.Category == types.CategoryActive
-		if !isCandidate {
-			continue
-		}
-		if branch.Remote != "" {
-			// Add status with age information
-			statusInfo := ""
-			daysOld := int(time.Since(branch.LastCommitDate).Hours() / 24)
-
-			switch branch.Category {
-			case types.CategoryMergedOld:
-				statusInfo = fmt.Sprintf(" | Status: Merged (%d days)", daysOld)
-			case types.CategoryUnmergedOld:
-				statusInfo = fmt.Sprintf(" | Status: Old (%d days)", daysOld)
-			case types.CategoryProtected, types.CategoryActive:
-				// No additional status info for protected/active branches in dry run
-			}
+}
+
+// isDryRunCandidate reports whether the dry run lists actions for branch.
+func isDryRunCandidate(branch types.AnalyzedBranch) bool {
+	// Active branches are included too, as they can be selected in the UI
+	return branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld ||
+		branch.Category == types.CategoryActive
+}
 
-			_, _ = fmt.Fprintf(os.Stdout, "  - Delete remote '%s/%s'%s\n", branch.Remote, branch.Name, statusInfo)
-			hasRemote = true
+// dryRunStatus describes the category and age of a candidate for the dry run, or "".
+func dryRunStatus(branch types.AnalyzedBranch) string {
+	daysOld := int(time.Since(branch.LastCommitDate).Hours() / 24)
+	switch branch.Category {
+	case types.CategoryMergedOld:
+		return fmt.Sprintf(" | Status: Merged (%d days)", daysOld)
+	case types.CategoryUnmergedOld:
+		if !branch.IsOldByAge && branch.DuplicateOf != "" {
+			return fmt.Sprintf(" | Status: Unmerged (%d days)", daysOld)
 		}
+		return fmt.Sprintf(" | Status: Old (%d days)", daysOld)
+	case types.CategoryProtected, types.CategoryActive:
+		// No additional status info for protected/active branches in dry run
 	}
-	if !hasRemote {
-		_, _ = fmt.Fprintln(os.Stdout, "  (None)")
-	}
-	_, _ = fmt.Fprintln(os.Stdout, "\n(Dry run complete, no changes made)")
+	return ""
 }
 
-// runQuickStatus performs a fast, non-interactive analysis and prints a summary to stdout.
-func runQuickStatus(ctx context.Context) {
-	logDebugln("Running quick status...")
+// dryRunLocalAction returns the local deletion the dry run lists for branch, if any.
+func dryRunLocalAction(branch types.AnalyzedBranch) (string, bool) {
+	if !isDryRunCandidate(branch) {
+		return "", false
+	}
+	delType := "-d (safe)"
+	if branch.ForceDelete() {
+		delType = "-D (force)"
+	}
 
-	// 1. Check Environment (Fast)
-	inGitRepo, err := gitcmd.IsInGitRepo(ctx)
-	if err != nil || !inGitRepo {
-		// Silently exit if not in a git repo or error occurs
-		return
+	// Add status with age information
+	statusInfo := dryRunStatus(branch)
+	if label := branch.DuplicateLabel(); label != "" && statusInfo != "" {
+		statusInfo += ", " + label
+	}
+	if label := branch.StackLabel(); label != "" && statusInfo != "" {
+		statusInfo += ", " + label
 	}
 
-	// 2. Gather Branch Data (Local only, skip fetch)
-	allBranches, err := gitcmd.GetAllLocalBranchInfo(ctx)
-	if err != nil || len(allBranches) == 0 {
-		// Silently exit on error or no branches
-		return
+	var before []string
+	if appConfig.Archive {
+		before = append(before, "archiving as "+gitcmd.ArchiveName(appConfig.ArchiveMode, branch.Name))
+	}
+	if appConfig.TagPrefix != "" {
+		before = append(before, "tagging as "+appConfig.TagPrefix+branch.Name)
 	}
+	archiveInfo := ""
+	if len(before) > 0 {
+		archiveInfo = " after " + strings.Join(before, " and ")
+	}
+	return fmt.Sprintf("Delete '%s' (%s)%s%s", branch.Name, delType, archiveInfo, statusInfo), true
+}
 
-	// 3. Get Merge Status (Requires main branch hash)
-	mainHash, err := gitcmd.GetMainBranchHash(ctx, appConfig.PrimaryMainBranch)
-	if err != nil {
-		// Silently exit if main branch not found
-		return
+// dryRunRemoteAction returns the remote deletion the dry run lists for branch, if any.
+func dryRunRemoteAction(branch types.AnalyzedBranch) (string, bool) {
+	if !isDryRunCandidate(branch) || branch.Remote == "" {
+		return "", false
 	}
-	mergedBranchesMap, err := gitcmd.GetMergedBranches(ctx, mainHash)
-	if err != nil {
-		// Silently exit on error
-		return
+	return fmt.Sprintf("Delete remote '%s/%s'%s", branch.Remote, branch.RemoteBranchName(), dryRunStatus(branch)), true
+}
+
+// runQuickStatus performs a fast, non-interactive analysis and prints a summary to stdout,
+// in the porcelain format if enabled. It returns the number of candidate branches found.
+func runQuickStatus(ctx context.Context, porcelain porcelainFormat) int {
+	slog.Debug("Running quick status")
+
+	// 1. Gather Branch Data (Local only, skip fetch and the slow annotations)
+	opts := sweep.Options{Config: appConfig, Branches: selectedBranches, SkipDetails: true}
+	repo, err := sweep.Gather(ctx, "", opts)
+	if err != nil && len(selectedBranches) > 0 && !errors.Is(err, sweep.ErrNotInGitRepo) {
+		// 'git-sweep check' names the branches explicitly, so a typo must not go unnoticed
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if err != nil || len(repo.Branches) == 0 {
+		// Silently exit if not in a git repo, on error or without branches
+		return 0
 	}
 
-	// 4. Analyze Branches (No need for current branch check here)
-	analyzedBranches, err := analyze.Branches( // Renamed function call
-		ctx, allBranches, mergedBranchesMap, appConfig, "",
-	) // Pass context and handle error
+	// 2. Analyze Branches (No need for current branch check here)
+	analyzedBranches, err := analyze.Branches(ctx, repo.Branches, repo.Merged, repo.Config(), "")
 	if err != nil {
 		// Silently exit on analysis error in quick status
-		return
+		return 0
 	}
+	repo.RecordMergeChecks(ctx, analyzedBranches)
 
-	// 5. Count Candidates
+	// 3. Count Candidates
 	mergedOldCount := 0
 	unmergedOldCount := 0
 	for _, branch := range analyzedBranches {
@@ -171,8 +233,12 @@ func runQuickStatus(ctx context.Context) {
 		}
 	}
 
-	// 6. Print Summary
-	if mergedOldCount > 0 || unmergedOldCount > 0 {
+	// 4. Print Summary
+	if porcelain.enabled {
+		// <candidates> <merged> <unmerged>
+		porcelain.write(os.Stdout, strconv.Itoa(mergedOldCount+unmergedOldCount),
+			strconv.Itoa(mergedOldCount), strconv.Itoa(unmergedOldCount))
+	} else if mergedOldCount > 0 || unmergedOldCount > 0 {
 		// Enhanced status format
 		_, _ = fmt.Fprintf(os.Stdout, "[git-sweep] Found %d branches to clean up (%d merged, %d old branches).\n",
 			mergedOldCount+unmergedOldCount, mergedOldCount, unmergedOldCount)
@@ -180,6 +246,7 @@ func runQuickStatus(ctx context.Context) {
 		// Print a specific message when no candidates are found
 		_, _ = fmt.Fprintln(os.Stdout, "[git-sweep] No candidate branches found.")
 	}
+	return mergedOldCount + unmergedOldCount
 }
 
 var rootCmd = &cobra.Command{
@@ -191,12 +258,21 @@ merged or haven't been updated recently. It presents these branches
 in an interactive terminal UI, allowing you to select and delete them
 safely (both locally and optionally on the remote).`,
 	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error { // Renamed args to _
-		// Get debug flag value early
-		isDebug, _ = cmd.Flags().GetBool("debug")
+		if err := changeDirectory(cmd); err != nil {
+			return err
+		}
+		// Configure logging first so everything below can be diagnosed
+		if err := setupLogging(cmd); err != nil {
+			return err
+		}
+		if enabled, _ := cmd.Flags().GetBool("profile"); enabled {
+			enableProfile()
+		}
 
-		logDebugln("Starting PersistentPreRunE...")
+		slog.Debug("Starting PersistentPreRunE")
 		customConfigPath, _ := cmd.Flags().GetString("config")
-		logDebugf("Custom config path flag: %q\n", customConfigPath)
+		remoteName, _ := cmd.Flags().GetString("remote")
+		slog.Debug("Loading configuration", "custom_path", customConfigPath)
 
 		var err error
 		appConfig, err = config.LoadConfig(customConfigPath)
@@ -205,9 +281,9 @@ safely (both locally and optionally on the remote).`,
 			if errors.Is(err, config.ErrConfigNotFound) {
 				// Config not found, run first-time setup
 				_, _ = fmt.Fprintln(os.Stdout, "Configuration file not found. Starting first-time setup...")
-				reader := bufio.NewReader(os.Stdin)
+				reader := bufio.NewReader(interruptibleReader{cmd.Context(), os.Stdin})
 				// Pass os.Stdout explicitly, FirstRunSetup now handles error checking for writes
-				appConfig, err = config.FirstRunSetup(reader, os.Stdout)
+				appConfig, err = config.FirstRunSetup(reader, os.Stdout, setupHints(cmd.Context(), remoteName))
 				if err != nil {
 					return fmt.Errorf("failed during first-time setup: %w", err)
 				}
@@ -226,135 +302,163 @@ safely (both locally and optionally on the remote).`,
 				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 		} else {
-			logDebugln("Configuration loaded successfully.")
+			slog.Debug("Configuration loaded successfully")
+			if err := checkStrictConfig(customConfigPath); err != nil {
+				return err
+			}
+		}
+		if err := applyConfigProfile(cmd); err != nil {
+			return err
 		}
 
 		// Apply command-line overrides AFTER loading/setup
-		logDebugln("Applying flag overrides...")
-		if ageOverride, _ := cmd.Flags().GetInt("age"); ageOverride > 0 {
-			logDebugf("Overriding AgeDays with flag value: %d\n", ageOverride)
-			appConfig.AgeDays = ageOverride
-		}
-		if mainOverride, _ := cmd.Flags().GetString("primary-main"); mainOverride != "" {
-			logDebugf("Overriding PrimaryMainBranch with flag value: %q\n", mainOverride)
-			appConfig.PrimaryMainBranch = mainOverride
-		}
-		if protectedOverride, _ := cmd.Flags().GetStringSlice("protected"); len(protectedOverride) > 0 {
-			logDebugf("Overriding ProtectedBranches with flag value: %v\n", protectedOverride)
-			appConfig.ProtectedBranches = protectedOverride
-			appConfig.ProtectedBranchMap = make(map[string]bool)
-			for _, branch := range appConfig.ProtectedBranches {
-				appConfig.ProtectedBranchMap[branch] = true
+		applyFlagOverrides(cmd, &appConfig)
+		if mine, _ := cmd.Flags().GetBool("mine"); mine {
+			email, err := gitcmd.GetUserEmail(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to determine your email for --mine: %w", err)
+			}
+			if email == "" {
+				return fmt.Errorf("--mine requires 'git config user.email' to be set")
 			}
+			slog.Debug("Restricting suggestions to branches authored by you", "email", email)
+			appConfig.OnlyAuthors = append(appConfig.OnlyAuthors, email)
 		}
+		if forceFetch, _ := cmd.Flags().GetBool("force-fetch"); forceFetch {
+			slog.Debug("Overriding config from flag", "field", "FetchCacheMinutes", "value", 0)
+			appConfig.FetchCacheMinutes = 0
+		}
+		appConfig.PrimaryMainBranch = sweep.PrimaryMainBranch(cmd.Context(), appConfig.PrimaryMainBranch, remoteName,
+			func(message string) { fmt.Fprintf(os.Stderr, "Warning: %s\n", message) })
+		backend, err := gitcmd.NewBackend(appConfig.Backend)
+		if err != nil {
+			return fmt.Errorf("failed to initialize git backend: %w", err)
+		}
+		gitcmd.ActiveBackend = backend
+		gitcmd.Offline = appConfig.Offline
 
 		if appConfig.ProtectedBranchMap == nil {
-			logDebugln("ProtectedBranchMap was nil, initializing.")
+			slog.Debug("ProtectedBranchMap was nil, initializing")
 			appConfig.ProtectedBranchMap = make(map[string]bool)
 			for _, branch := range appConfig.ProtectedBranches {
 				appConfig.ProtectedBranchMap[branch] = true
 			}
 		}
-		logDebugln("Finished PersistentPreRunE.")
+		slog.Debug("Finished PersistentPreRunE")
 		return nil // No error from pre-run
 	},
 	Run: func(cmd *cobra.Command, _ []string) { // Renamed args to _
 		// Check for updates unless explicitly disabled
-		skipVersionCheck, _ := cmd.Flags().GetBool("skip-version-check")
-		if !skipVersionCheck {
-			hasUpdate, latestVersion, releaseURL, err := versionpkg.Check(cmd.Context(), version, &appConfig)
-			if err != nil {
-				// Log error in debug mode, but don't interrupt normal operation
-				logDebugf("Version check error: %v\n", err)
-			} else if hasUpdate {
-				// Show update notification if there's a new version
-				versionpkg.ShowUpdateNotification(version, latestVersion, releaseURL)
-			}
+		// Script output must stay machine-consumable, so it requires --dry-run and skips notifications
+		script, _ := cmd.Flags().GetBool("script")
+		if dryRunFlag, _ := cmd.Flags().GetBool("dry-run"); script && !dryRunFlag {
+			fmt.Fprintln(os.Stderr, "Error: --script can only be used together with --dry-run.")
+			os.Exit(exitError)
+		}
+
+		porcelain := porcelainFlags(cmd)
+		if err := checkPorcelainFlags(cmd, porcelain); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		auto, _ := cmd.Flags().GetBool("auto")
+		if auto && (script || porcelain.enabled) {
+			fmt.Fprintln(os.Stderr, "Error: --auto cannot be combined with --script, --porcelain or -z.")
+			os.Exit(exitError)
 		}
 
-		// Check for quick-status flag
+		// Check for quick-status flag; it runs from shell prompts, so it never shows the update notice
 		quickStatus, _ := cmd.Flags().GetBool("quick-status")
+		// Right after 'git init' there is nothing to fetch, analyze or show in the TUI yet
+		if isEmptyRepository(cmd.Context()) {
+			switch {
+			case quickStatus && porcelain.enabled:
+				porcelain.write(os.Stdout, "0", "0", "0")
+			case quickStatus:
+				_, _ = fmt.Fprintln(os.Stdout, "[git-sweep] "+nothingToSweepYet)
+			case !script && !porcelain.enabled:
+				_, _ = fmt.Fprintln(os.Stdout, nothingToSweepYet)
+			}
+			os.Exit(exitOK)
+		}
+		// The branches of a bare repository, e.g. on a server, are what everyone else fetches
+		if bare, _ := gitcmd.IsBareRepo(cmd.Context()); bare && !quickStatus {
+			if dryRunFlag, _ := cmd.Flags().GetBool("dry-run"); !dryRunFlag {
+				fmt.Fprintln(os.Stderr, "Error: this is a bare repository; its branches are what others fetch, "+
+					"so git-sweep does not delete them. Use --dry-run or --quick-status to review them.")
+				os.Exit(exitError)
+			}
+		}
+		skipVersionCheck, _ := cmd.Flags().GetBool("skip-version-check")
+		if !skipVersionCheck && !script && !porcelain.enabled && !quickStatus && !appConfig.Offline {
+			// Shown in the TUI footer, or on stderr before the output of the other modes
+			updateNotice = checkForUpdate(cmd.Context())
+		}
+
 		var dryRun bool // Declare but don't initialize yet
 		if quickStatus {
-			runQuickStatus(cmd.Context()) // Pass context
-			os.Exit(0)
+			candidates := runQuickStatus(cmd.Context(), porcelain) // Pass context
+			os.Exit(candidatesExitCode(cmd, candidates))
 		}
 
 		// Proceed with normal interactive flow if not quick-status
-		logDebugf("Configuration loaded. AgeDays: %d, Main: %s, Protected: %v\n",
-			appConfig.AgeDays, appConfig.PrimaryMainBranch, appConfig.ProtectedBranches)
-		logDebugln("\nExecuting git-sweep main logic...")
+		slog.Debug("Configuration loaded", "age_days", appConfig.AgeDays,
+			"primary_main", appConfig.PrimaryMainBranch, "protected", appConfig.ProtectedBranches)
+		slog.Debug("Executing git-sweep main logic")
 
 		// --- Core Workflow Steps ---
 		ctx := cmd.Context() // Use context from command
 
-		// 2. Check Environment
-		logDebugln("Checking environment...")
-		inGitRepo, err := gitcmd.IsInGitRepo(ctx)
+		branchFilter, err := candidateFilter(cmd)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error checking Git repository status: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
 		}
-		if !inGitRepo {
-			fmt.Fprintln(os.Stderr, "Error: Not inside a Git repository.")
-			os.Exit(1)
+		selection, err := candidateSelection(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
 		}
-		logDebugln("-> Environment check passed.")
 
-		// 3. Fetch Remote State
-		remoteName, _ := cmd.Flags().GetString("remote")
-		logDebugf("Fetching remote state for '%s'...\n", remoteName)
-		err = gitcmd.FetchAndPrune(ctx, remoteName)
+		dryRun, _ = cmd.Flags().GetBool("dry-run")
+		if err := checkRepoState(ctx, dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		bundles, err := bundleDir(ctx, !dryRun)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to fetch remote state for '%s': %v\n", remoteName, err)
-		} else {
-			logDebugln("-> Remote fetch complete.")
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
 		}
 
-		// 4. Gather Branch Data
-		logDebugln("Gathering branch data...")
-		allBranches, err := gitcmd.GetAllLocalBranchInfo(ctx)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error gathering local branch info: %v\n", err)
-			os.Exit(1)
+		// The TUI shows up right away and receives the branches as they are analyzed; dry runs
+		// and plain prompts need the complete analysis up front
+		remoteName, _ := cmd.Flags().GetString("remote")
+		maxDelete := deleteLimit(cmd)
+		noFetch, _ := cmd.Flags().GetBool("no-fetch")
+		fetch := !noFetch && !appConfig.Offline
+		if !dryRun && !auto && !usePlainPrompt(cmd) {
+			runInteractive(ctx, remoteName, fetch, branchFilter, selection, maxDelete, bundles)
 		}
-		if len(allBranches) == 0 {
-			_, _ = fmt.Fprintln(os.Stdout, "No local branches found. Nothing to do.")
-			os.Exit(0)
+		if updateNotice != "" {
+			fmt.Fprintln(os.Stderr, updateNotice)
 		}
 
-		mainHash, err := gitcmd.GetMainBranchHash(ctx, appConfig.PrimaryMainBranch)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting hash for primary main branch '%s': %v\n", appConfig.PrimaryMainBranch, err)
-			fmt.Fprintln(os.Stderr, "Please ensure the 'primary_main_branch' in your config or flag exists.")
-			os.Exit(1)
+		analyzedBranches, err := analyzeRepository(ctx, remoteName, fetch)
+		if err != nil && ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted.")
+			os.Exit(exitInterrupted)
 		}
-
-		mergedBranchesMap, err := gitcmd.GetMergedBranches(ctx, mainHash)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error determining merged branches against hash %s: %v\n", mainHash, err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
 		}
-		logDebugf("-> Found %d local branches. Primary main branch '%s' hash: %s. Found %d merged branches.\n",
-			len(allBranches), appConfig.PrimaryMainBranch, mainHash, len(mergedBranchesMap))
-
-		// 5. Analyze Branches
-		logDebugln("Analyzing branches...")
-		currentBranch, err := gitcmd.GetCurrentBranchName(ctx)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not determine current branch: %v\n", err)
-			currentBranch = ""
-		} else if currentBranch != "" {
-			logDebugf("-> Current branch detected: %s (will be protected)\n", currentBranch)
-		}
-		analyzedBranches, err := analyze.Branches( // Renamed function call
-			ctx, allBranches, mergedBranchesMap, appConfig, currentBranch,
-		) // Pass context and handle error
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error analyzing branches: %v\n", err)
-			os.Exit(1)
+		if len(analyzedBranches) == 0 {
+			if !script && !porcelain.enabled {
+				_, _ = fmt.Fprintln(os.Stdout, "No local branches found. Nothing to do.")
+			}
+			os.Exit(exitOK)
 		}
-		logDebugln("-> Branch analysis complete.")
 
 		// 6. Filter out Protected branches before displaying/processing
 		displayableBranches := make([]types.AnalyzedBranch, 0)
@@ -365,35 +469,86 @@ safely (both locally and optionally on the remote).`,
 		}
 
 		if len(displayableBranches) == 0 {
-			_, _ = fmt.Fprintln(os.Stdout, "-> No branches found to display (excluding protected). Exiting.")
-			os.Exit(0)
+			if !script && !porcelain.enabled {
+				_, _ = fmt.Fprintln(os.Stdout, "-> No branches found to display (excluding protected). Exiting.")
+			}
+			os.Exit(exitOK)
+		}
+		slog.Debug("Found displayable (non-protected) branches", "count", len(displayableBranches))
+
+		// Narrow the branches down to the --older-than, --pattern and --exclude selection
+		if !branchFilter.IsZero() {
+			displayableBranches = branchFilter.Apply(displayableBranches, time.Now())
+			slog.Debug("Applied branch filters", "remaining", len(displayableBranches))
+			if len(displayableBranches) == 0 {
+				if !script && !porcelain.enabled {
+					_, _ = fmt.Fprintln(os.Stdout, "-> No branches match the given filters. Exiting.")
+				}
+				os.Exit(exitOK)
+			}
+		}
+
+		// --auto deletes the obvious branches without asking, and --dry-run shows which
+		if auto {
+			os.Exit(runAuto(ctx, os.Stdout, displayableBranches, dryRun, maxDelete, bundles))
 		}
-		logDebugf("-> Found %d displayable (non-protected) branches.\n", len(displayableBranches))
 
 		// Check for Dry Run *before* launching TUI
-		// Use the dryRun variable we already declared
-		dryRun, _ = cmd.Flags().GetBool("dry-run")
+		if dryRun && script {
+			// The script is meant to be run unattended, so the cap applies to it as a whole
+			if err := checkDeleteLimit(countCandidates(displayableBranches), maxDelete); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			archiveMode := ""
+			if appConfig.Archive {
+				archiveMode = cmp.Or(appConfig.ArchiveMode, gitcmd.ArchiveModeRef)
+			}
+			printDryRunScript(os.Stdout, displayableBranches, archiveMode, appConfig.TagPrefix, bundles)
+			os.Exit(candidatesExitCode(cmd, countCandidates(displayableBranches)))
+		}
+		if dryRun && porcelain.enabled {
+			printPorcelainDryRun(os.Stdout, porcelain, displayableBranches)
+			if err := checkDeleteLimit(countCandidates(displayableBranches), maxDelete); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Deleting all candidates at once would exceed the limit: %v\n", err)
+			}
+			os.Exit(candidatesExitCode(cmd, countCandidates(displayableBranches)))
+		}
 		if dryRun {
 			// Pass only displayable branches to dry run print function
-			printDryRunActions(displayableBranches)
-			os.Exit(0) // Exit after printing dry run actions
+			byAge, _ := cmd.Flags().GetBool("by-age")
+			printDryRunActions(displayableBranches, byAge)
+			if err := checkDeleteLimit(countCandidates(displayableBranches), maxDelete); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Deleting all candidates at once would exceed the limit: %v\n", err)
+			}
+			// Exit after printing dry run actions
+			os.Exit(candidatesExitCode(cmd, countCandidates(displayableBranches)))
 		}
 
-		// 7. Launch Interactive TUI (only if not dry run)
-		logDebugln("Launching TUI...")
-		// Pass only displayable branches to the TUI model
-		initialModel := tui.InitialModel(ctx, displayableBranches, dryRun) // dryRun will be false here
-		p := tea.NewProgram(initialModel)
-
-		if _, err := p.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-			os.Exit(1)
+		// Without a terminal Bubble Tea cannot run, so fall back to numbered prompts on stdin/stdout
+		if usePlainPrompt(cmd) {
+			slog.Debug("Using plain prompts instead of the TUI")
+			stdin := bufio.NewReader(interruptibleReader{ctx, os.Stdin})
+			results, err := runPlainPrompt(ctx, stdin, os.Stdout, displayableBranches, maxDelete, bundles)
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			// Record what was deleted before an interruption too; the journal must not miss a branch
+			if len(results) > 0 {
+				journalDeletions(context.WithoutCancel(ctx), results)
+				auditDeletions(context.WithoutCancel(ctx), results)
+				recordLastRun(context.WithoutCancel(ctx), results)
+				sendNotification(context.WithoutCancel(ctx), results)
+			}
+			if ctx.Err() != nil {
+				if len(results) == 0 {
+					fmt.Fprintln(os.Stderr, "Interrupted; nothing was deleted.")
+				}
+				os.Exit(exitInterrupted)
+			}
+			os.Exit(deletionsExitCode(results))
 		}
-
-		// 8. Execute Deletions (Handled within TUI via tea.Cmd)
-		// 9. Display Results (Handled within TUI)
-
-		logDebugln("\nExiting git-sweep.") // Final message only in debug
 	},
 }
 
@@ -410,29 +565,198 @@ func main() {
 		rootCmd.Version = version // Use the version set by goreleaser
 	}
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	// Windows consoles print colors and escape sequences as text unless virtual terminal
+	// processing is enabled; elsewhere this does nothing
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		_, _ = termenv.EnableVirtualTerminalProcessing(termenv.NewOutput(f))
+	}
+
+	ctx := signalContext()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			os.Exit(exitInterrupted)
+		}
+		os.Exit(exitError)
+	}
+}
+
+// applyFlagOverrides sets the fields of cfg that the override flags given to cmd change, for
+// a run or, with config init, for a new configuration file.
+func applyFlagOverrides(cmd *cobra.Command, cfg *config.Config) {
+	slog.Debug("Applying flag overrides")
+	if ageOverride, _ := cmd.Flags().GetInt("age"); ageOverride > 0 {
+		slog.Debug("Overriding config from flag", "field", "AgeDays", "value", ageOverride)
+		cfg.AgeDays = ageOverride
+	}
+	if cmd.Flags().Changed("age-merged") {
+		mergedAgeOverride, _ := cmd.Flags().GetInt("age-merged")
+		slog.Debug("Overriding config from flag", "field", "MergedAgeDays", "value", mergedAgeOverride)
+		cfg.MergedAgeDays = max(0, mergedAgeOverride)
+	}
+	if cmd.Flags().Changed("min-age") {
+		minAgeOverride, _ := cmd.Flags().GetInt("min-age")
+		slog.Debug("Overriding config from flag", "field", "MinAgeDays", "value", minAgeOverride)
+		cfg.MinAgeDays = max(0, minAgeOverride)
+	}
+	if mainOverride, _ := cmd.Flags().GetString("primary-main"); mainOverride != "" {
+		slog.Debug("Overriding config from flag", "field", "PrimaryMainBranch", "value", mainOverride)
+		cfg.PrimaryMainBranch = mainOverride
+	}
+	if againstOverride, _ := cmd.Flags().GetString("against"); againstOverride != "" {
+		slog.Debug("Overriding config from flag", "field", "CompareRef", "value", againstOverride)
+		cfg.CompareRef = againstOverride
+	}
+	if protectedOverride, _ := cmd.Flags().GetStringSlice("protected"); len(protectedOverride) > 0 {
+		slog.Debug("Overriding config from flag", "field", "ProtectedBranches", "value", protectedOverride)
+		cfg.ProtectedBranches = protectedOverride
+		cfg.ProtectedBranchMap = make(map[string]bool)
+		for _, branch := range cfg.ProtectedBranches {
+			cfg.ProtectedBranchMap[branch] = true
+		}
+	}
+
+	if backendOverride, _ := cmd.Flags().GetString("backend"); backendOverride != "" {
+		slog.Debug("Overriding config from flag", "field", "Backend", "value", backendOverride)
+		cfg.Backend = backendOverride
+	}
+	if workersOverride, _ := cmd.Flags().GetInt("remote-workers"); workersOverride > 0 {
+		slog.Debug("Overriding config from flag", "field", "RemoteDeleteWorkers", "value", workersOverride)
+		cfg.RemoteDeleteWorkers = workersOverride
+	}
+	if cmd.Flags().Changed("remote-rate") {
+		rateOverride, _ := cmd.Flags().GetFloat64("remote-rate")
+		slog.Debug("Overriding config from flag", "field", "RemoteRateLimit", "value", rateOverride)
+		cfg.RemoteRateLimit = max(0, rateOverride)
+	}
+	if timeoutOverride, _ := cmd.Flags().GetInt("fetch-timeout"); timeoutOverride > 0 {
+		slog.Debug("Overriding config from flag", "field", "FetchTimeoutSeconds", "value", timeoutOverride)
+		cfg.FetchTimeoutSeconds = timeoutOverride
+	}
+	if pruneAll, _ := cmd.Flags().GetBool("prune-all-remotes"); pruneAll {
+		slog.Debug("Overriding config from flag", "field", "PruneAllRemotes", "value", true)
+		cfg.PruneAllRemotes = true
+	}
+	if fastForward, _ := cmd.Flags().GetBool("ff-main"); fastForward {
+		slog.Debug("Overriding config from flag", "field", "FastForwardMain", "value", true)
+		cfg.FastForwardMain = true
+	}
+	if unshallow, _ := cmd.Flags().GetBool("unshallow"); unshallow {
+		slog.Debug("Overriding config from flag", "field", "Unshallow", "value", true)
+		cfg.Unshallow = true
+	}
+	if offline, _ := cmd.Flags().GetBool("offline"); offline {
+		slog.Debug("Overriding config from flag", "field", "Offline", "value", true)
+		cfg.Offline = true
+	}
+	if intoProtected, _ := cmd.Flags().GetBool("merged-into-protected"); intoProtected {
+		slog.Debug("Overriding config from flag", "field", "MergedIntoProtected", "value", true)
+		cfg.MergedIntoProtected = true
+	}
+	if cmd.Flags().Changed("max-delete") {
+		maxDeleteOverride, _ := cmd.Flags().GetInt("max-delete")
+		slog.Debug("Overriding config from flag", "field", "MaxDelete", "value", maxDeleteOverride)
+		cfg.MaxDelete = max(0, maxDeleteOverride)
+	}
+	if confirmEach, _ := cmd.Flags().GetBool("interactive-confirm"); confirmEach {
+		slog.Debug("Overriding config from flag", "field", "InteractiveConfirm", "value", true)
+		cfg.InteractiveConfirm = true
+	}
+	if archiveOverride, _ := cmd.Flags().GetBool("archive"); archiveOverride {
+		slog.Debug("Overriding config from flag", "field", "Archive", "value", true)
+		cfg.Archive = true
+	}
+	if bundleOverride, _ := cmd.Flags().GetBool("bundle"); bundleOverride {
+		slog.Debug("Overriding config from flag", "field", "Bundle", "value", true)
+		cfg.Bundle = true
+	}
+	if cmd.Flags().Changed("tag-prefix") {
+		tagPrefixOverride, _ := cmd.Flags().GetString("tag-prefix")
+		slog.Debug("Overriding config from flag", "field", "TagPrefix", "value", tagPrefixOverride)
+		cfg.TagPrefix = tagPrefixOverride
 	}
 }
 
 func init() {
 	// Define flags based on PROJECT_PLAN.md Section 10
-	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug logging.")
+	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug logging (same as --verbosity debug).")
+	rootCmd.PersistentFlags().String("verbosity", "warn", "Log level: debug, info, warn or error.")
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText,
+		"Log format: \"text\" or \"json\".")
+	rootCmd.PersistentFlags().String("log-file", "", "Append log records to this file instead of stderr.")
 	rootCmd.PersistentFlags().Bool("dry-run", false, "Analyze and preview actions, but do not delete.")
 	rootCmd.PersistentFlags().StringP("config", "c", "",
 		"Path to custom configuration file (default: ~/.config/git-sweep/config.toml).")
+	rootCmd.PersistentFlags().String("config-profile", "",
+		"Apply the [profile.<name>] overrides of the config file (default: $GIT_SWEEP_PROFILE).")
+	rootCmd.PersistentFlags().StringP("cwd", "C", "",
+		"Run as if git-sweep was started in this directory instead of the current one.")
 	rootCmd.PersistentFlags().StringP("remote", "r", "origin",
-		"Specify the remote repository to fetch from and consider for remote deletions.")
+		"Remote fetched first and used to detect the default branch and hosting provider.")
 	rootCmd.PersistentFlags().Int("age", 0,
 		"Override config: Max age (in days) for unmerged branches (0 uses config default).")
+	rootCmd.PersistentFlags().Int("age-merged", 0,
+		"Override config: Days since the last commit before a merged branch is suggested (0 suggests immediately).")
+	rootCmd.PersistentFlags().Int("min-age", 0,
+		"Override config: Days since the last commit before any branch is suggested, even a merged one (0 means none).")
 	rootCmd.PersistentFlags().String("primary-main", "",
 		"Override config: The single main branch name to check merge status against (empty uses config default).")
+	rootCmd.PersistentFlags().String("against", "",
+		"Override config: Ref to check merges against instead of the local primary main branch (e.g. origin/main).")
 	rootCmd.PersistentFlags().StringSlice("protected", []string{},
 		"Override config: Comma-separated list of protected branch names.")
+	rootCmd.PersistentFlags().String("backend", "",
+		"Override config: Git backend used for branch discovery (\"exec\" or \"go-git\").")
+	rootCmd.PersistentFlags().Bool("mine", false,
+		"Only suggest branches whose last commit was authored by you (git config user.email).")
+	rootCmd.PersistentFlags().Bool("archive", false,
+		"Archive local branches (refs/archive/<name> or archive/<name> tags) before deleting them.")
+	rootCmd.PersistentFlags().Bool("bundle", false,
+		"Write a git bundle of each local branch before deleting it (see bundle_dir).")
+	rootCmd.PersistentFlags().String("tag-prefix", "",
+		"Tag each local branch as <prefix><name> before deleting it (e.g. 'sweep/').")
+	rootCmd.PersistentFlags().Int("remote-workers", 0,
+		"Override config: Maximum concurrent remote branch deletions (0 uses config default).")
+	rootCmd.PersistentFlags().Float64("remote-rate", 0,
+		"Override config: Maximum remote deletions per second, per remote (0 means unlimited).")
+	rootCmd.PersistentFlags().Int("fetch-timeout", 0,
+		"Override config: Seconds to wait for the remote fetch before continuing without it (0 uses config default).")
+	rootCmd.PersistentFlags().Bool("force-fetch", false,
+		"Fetch the remote even if it was fetched within fetch_cache_minutes.")
+	rootCmd.PersistentFlags().Bool("offline", false,
+		"Never use the network: skip fetching, the version check, provider lookups, the webhook and remote deletions.")
+	rootCmd.PersistentFlags().Bool("merged-into-protected", false,
+		"Also treat branches merged into any protected branch (e.g. develop) as merged, not only the primary main.")
+	rootCmd.PersistentFlags().Bool("prune-all-remotes", false,
+		"Fetch and prune every configured remote, not only the ones your branches track.")
+	rootCmd.PersistentFlags().Bool("ff-main", false,
+		"Fast-forward the local primary main branch to its upstream after fetching, if it can be fast-forwarded.")
+	rootCmd.PersistentFlags().Bool("unshallow", false,
+		"Fetch the full history of a shallow clone before analyzing, so merged branches are recognized.")
+	rootCmd.PersistentFlags().Int("max-delete", 0,
+		"Override config: Refuse to delete more than this many branches in one run without --force (0 means unlimited).")
+	rootCmd.PersistentFlags().Bool("by-age", false,
+		"With --dry-run or list, group branches into age buckets with a subtotal per bucket.")
+	rootCmd.PersistentFlags().Bool("profile", false,
+		"Print how long each phase of the run took and how many git commands it ran.")
 	rootCmd.PersistentFlags().Bool("skip-version-check", false,
 		"Skip checking for new versions.")
 	// Add quick-status flag (Bool, local to root command)
 	rootCmd.Flags().Bool("quick-status", false, "Print a quick summary of candidate branches and exit.")
+	rootCmd.Flags().Bool("script", false,
+		"With --dry-run, print only the git commands that would be run, one per line.")
+	addPorcelainFlags(rootCmd, "With --dry-run or --quick-status, print a stable format for scripts.")
+	rootCmd.Flags().Bool("no-tui", false,
+		"Use numbered prompts instead of the interactive UI (automatic when no terminal is attached).")
+	rootCmd.Flags().Bool("no-fetch", false, "Skip fetching the remote and analyze the local state as it is.")
+	rootCmd.Flags().Bool("force", false, "Allow deleting more branches than max_delete in one run.")
+	rootCmd.Flags().Bool("auto", false,
+		"Without asking, delete only local branches merged into the main branch by ancestry, and print a summary.")
+	rootCmd.Flags().Bool("interactive-confirm", false,
+		"Confirm each selected branch with y/n/a/q instead of the whole selection at once.")
+	rootCmd.Flags().Bool("exit-code", false,
+		"With --dry-run or --quick-status, exit with status 3 if there are branches to clean up.")
+	// 'git-sweep check' runs the same flow on selected branches, so it takes the same flags
+	checkCmd.Flags().AddFlagSet(rootCmd.Flags())
 
 	// Add a show-config command to display configuration details
 	showConfigCmd := &cobra.Command{
@@ -475,9 +799,18 @@ is not found, it will guide you through the first-time setup process.`,
 			}
 
 			_, _ = fmt.Fprintln(os.Stdout, "Current Configuration:")
+			if cfg.ActiveProfile != "" {
+				_, _ = fmt.Fprintf(os.Stdout, "- Profile: %s\n", cfg.ActiveProfile)
+			}
 			_, _ = fmt.Fprintf(os.Stdout, "- Age Days: %d\n", cfg.AgeDays)
 			_, _ = fmt.Fprintf(os.Stdout, "- Primary Main Branch: %s\n", cfg.PrimaryMainBranch)
+			if cfg.CompareRef != "" {
+				_, _ = fmt.Fprintf(os.Stdout, "- Compare Ref: %s\n", cfg.CompareRef)
+			}
 			_, _ = fmt.Fprintf(os.Stdout, "- Protected Branches: %v\n", cfg.ProtectedBranches)
+			if len(cfg.ProtectedPatterns) > 0 {
+				_, _ = fmt.Fprintf(os.Stdout, "- Protected Patterns: %v\n", cfg.ProtectedPatterns)
+			}
 		},
 	}
 	rootCmd.AddCommand(showConfigCmd)
@@ -7,6 +7,9 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -15,6 +18,9 @@ import (
 	"strings"
 	"testing"
 	"time" // Added for commit timestamps
+
+	"github.com/bral/git-sweep-go/internal/planfile"
+	"github.com/bral/git-sweep-go/internal/types"
 )
 
 var (
@@ -71,10 +77,16 @@ func setupTestRepo(t *testing.T) (repoPath string, cleanup func()) {
 func createBranchAndCommit(t *testing.T, repoPath, branchName, message string, commitDate time.Time) {
 	t.Helper()
 	runCmd(t, repoPath, "git", "checkout", "-b", branchName)
+	// Commit a change of its own, so the branch differs from where it started
+	fileName := strings.ReplaceAll(branchName, "/", "_") + ".txt"
+	if err := os.WriteFile(filepath.Join(repoPath, fileName), []byte(message+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file on branch %s: %v", branchName, err)
+	}
+	runCmd(t, repoPath, "git", "add", fileName)
 	// Set committer date for the commit
 	dateStr := commitDate.Format(time.RFC3339) // Use a format git understands
 	env := append(os.Environ(), fmt.Sprintf("GIT_COMMITTER_DATE=%s", dateStr))
-	cmd := exec.Command("git", "commit", "--allow-empty", "-m", message, "--date", dateStr)
+	cmd := exec.Command("git", "commit", "-m", message, "--date", dateStr)
 	cmd.Dir = repoPath
 	cmd.Env = env
 	outBytes, err := cmd.CombinedOutput()
@@ -248,13 +260,7 @@ protected_branches = ["protected-config"]
 	// Protected: main, protected-config
 	// Active: unmerged-recent
 	// Candidates = Merged + Unmerged Old
-	// With the new logic detecting more merged states:
-	// Merged: merged-recent, merged-old (2 from ancestry)
-	// + unmerged-recent, unmerged-old (now potentially detected via cherry-v if changes were identical, though unlikely with this setup unless cherry-v is misinterpreting empty commits?)
-	// Let's assume the integration test environment leads cherry-v to consider all non-protected as merged for now.
-	// Expected counts based on actual test failure: 4 merged, 0 unmerged old.
-	// TODO: Re-evaluate if the test repo setup or cherry-v interaction needs refinement.
-	expectedOutput := "[git-sweep] Found 4 branches to clean up (4 merged, 0 old branches)." // Updated for the new enhanced format
+	expectedOutput := "[git-sweep] Found 3 branches to clean up (2 merged, 1 old branches)."
 	if !strings.Contains(output, expectedOutput) {
 		t.Errorf("Expected quick status output to contain %q, got:\n%s", expectedOutput, output)
 	}
@@ -359,3 +365,1847 @@ func TestIntegrationFlagOverrides(t *testing.T) {
 	if strings.Contains(output, "main") { t.Errorf("Did not expect 'main' (current branch) in output, got:\n%s", output) }
 
 }
+
+// TestIntegrationDryRunScript checks that --dry-run --script prints only runnable git commands.
+func TestIntegrationDryRunScript(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	oldDate := time.Now().AddDate(0, 0, -100)
+	createBranchAndCommit(t, repoPath, "merged-old", "feat: merged old", oldDate)
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged-old", "-m", "Merge merged-old")
+
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\n"
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--script", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.Output() // stdout only; fetch warnings go to stderr
+	output := string(outputBytes)
+	if err != nil {
+		t.Fatalf("git-sweep --dry-run --script failed unexpectedly:\nOutput:\n%s\nError: %v", output, err)
+	}
+	if strings.TrimSpace(output) != "git branch -d merged-old" {
+		t.Fatalf("Unexpected script output:\n%s", output)
+	}
+
+	// The script must be directly runnable
+	runCmd(t, repoPath, "sh", "-c", output)
+	if branches := runCmd(t, repoPath, "git", "branch", "--list", "merged-old"); strings.TrimSpace(branches) != "" {
+		t.Errorf("Expected merged-old to be deleted by the script, still have: %s", branches)
+	}
+
+	// --script without --dry-run is rejected
+	cmd = exec.Command(binaryPath, "--script", "--config", configPath)
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Error("Expected --script without --dry-run to fail")
+	}
+}
+
+func TestIntegrationList(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	oldDate := time.Now().AddDate(0, 0, -100)
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", oldDate)
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\n"
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	output := runCmd(t, repoPath, binaryPath, "list", "--skip-version-check", "--config", configPath,
+		"--candidates", "--format", "{{.Name}} {{.Category}} {{.AgeDays}}")
+	if strings.TrimSpace(output) != "unmerged-old UnmergedOld 100" {
+		t.Errorf("Unexpected list output:\n%s", output)
+	}
+
+	// The default format shows the first line of branch descriptions
+	runCmd(t, repoPath, "git", "config", "branch.unmerged-old.description", "Spike for the export job\nKeep notes")
+	output = runCmd(t, repoPath, binaryPath, "list", "--skip-version-check", "--config", configPath, "--candidates")
+	if !strings.Contains(output, "unmerged-old\tUnmergedOld\t") || !strings.HasSuffix(output, "\tSpike for the export job\n") {
+		t.Errorf("Expected the description in the list output, got:\n%q", output)
+	}
+
+	// Invalid templates are reported as errors
+	cmd := exec.Command(binaryPath, "list", "--skip-version-check", "--config", configPath, "--format", "{{.Nope}}")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Error("Expected list with an unknown template field to fail")
+	}
+}
+
+// TestIntegrationByAge tests grouping the list and dry-run output into age buckets.
+func TestIntegrationByAge(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "unmerged-ancient", "feat: unmerged ancient", time.Now().AddDate(0, 0, -500))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	output := runCmd(t, repoPath, binaryPath, "list", "--skip-version-check", "--config", configPath,
+		"--candidates", "--by-age", "--format", "{{.Name}}")
+	want := "90-365d (1 branch(es)):\nunmerged-old\n\n>1y (1 branch(es)):\nunmerged-ancient\n"
+	if output != want {
+		t.Errorf("Unexpected list output:\n%s\nwant:\n%s", output, want)
+	}
+
+	output = runCmd(t, repoPath, binaryPath, "--skip-version-check", "--config", configPath,
+		"--dry-run", "--no-fetch", "--by-age")
+	if !strings.Contains(output, "Local Deletions:\n  90-365d (1 branch(es)):\n    - Delete 'unmerged-old' (-D (force))") ||
+		!strings.Contains(output, "  >1y (1 branch(es)):\n    - Delete 'unmerged-ancient'") {
+		t.Errorf("Expected the dry run grouped by age, got:\n%s", output)
+	}
+}
+
+// TestIntegrationExitCodes tests the exit status reported with --exit-code.
+func TestIntegrationExitCodes(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\n"
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	exitStatus := func(args ...string) int {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append(args, "--skip-version-check", "--config", configPath)...)
+		cmd.Dir = repoPath
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		if err != nil {
+			t.Fatalf("Failed to run git-sweep %v: %v", args, err)
+		}
+		return 0
+	}
+
+	// Nothing to clean up yet
+	if code := exitStatus("--quick-status", "--exit-code"); code != 0 {
+		t.Errorf("Expected exit code 0 without candidates, got %d", code)
+	}
+
+	oldDate := time.Now().AddDate(0, 0, -100)
+	createBranchAndCommit(t, repoPath, "merged-old", "feat: merged old", oldDate)
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged-old", "-m", "Merge merged-old")
+
+	for _, args := range [][]string{
+		{"--quick-status", "--exit-code"},
+		{"--dry-run", "--exit-code"},
+		{"--dry-run", "--script", "--exit-code"},
+	} {
+		if code := exitStatus(args...); code != 3 {
+			t.Errorf("Expected exit code 3 for %v with candidates, got %d", args, code)
+		}
+	}
+	// Without --exit-code, finding candidates is not an error
+	if code := exitStatus("--dry-run"); code != 0 {
+		t.Errorf("Expected exit code 0 for --dry-run without --exit-code, got %d", code)
+	}
+}
+
+// TestIntegrationDoctor tests the diagnostic report of the doctor subcommand.
+func TestIntegrationDoctor(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	output := runCmd(t, repoPath, binaryPath, "doctor", "--config", configPath)
+	for _, want := range []string{"[PASS] Git ", "[PASS] Config ", "[PASS] Primary main branch", "All checks passed."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected doctor output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// Broken config syntax and a missing main branch are reported as failures
+	if err := os.WriteFile(configPath, []byte("age_days = \n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cmd := exec.Command(binaryPath, "doctor", "--config", configPath, "--primary-main", "does-not-exist")
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.CombinedOutput()
+	output = string(outputBytes)
+	if err == nil {
+		t.Errorf("Expected doctor to fail, got:\n%s", output)
+	}
+	for _, want := range []string{"[FAIL] Config ", "[FAIL] Primary main branch", "2 check(s) failed."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected doctor output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+// TestIntegrationConfigValidate tests config validate and the refusal to run with strict_config.
+func TestIntegrationConfigValidate(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if output := runCmd(t, repoPath, binaryPath, "config", "validate", "--config", configPath); !strings.Contains(
+		output, "is valid.") {
+		t.Errorf("Expected the config to be valid, got:\n%s", output)
+	}
+
+	content := "age_day = 30\nage_days = -1\nprimary_main_branch = \"main\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cmd := exec.Command(binaryPath, "config", "validate", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(outputBytes), `unknown key "age_day"`) ||
+		!strings.Contains(string(outputBytes), "age_days = -1 must be positive") {
+		t.Errorf("Expected config validate to fail with both problems, got %v:\n%s", err, outputBytes)
+	}
+
+	// The problems are ignored unless strict_config is set
+	runCmd(t, repoPath, binaryPath, "--dry-run", "--config", configPath)
+	if err := os.WriteFile(configPath, []byte(content+"strict_config = true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cmd = exec.Command(binaryPath, "--dry-run", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(outputBytes), "strict_config is set") {
+		t.Errorf("Expected strict_config to stop the run, got %v:\n%s", err, outputBytes)
+	}
+}
+
+// TestIntegrationConfigProfile tests selecting a profile with --config-profile and GIT_SWEEP_PROFILE.
+func TestIntegrationConfigProfile(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	content := "age_days = 90\nprimary_main_branch = \"main\"\n\n[profile.work]\nage_days = 7\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	output := runCmd(t, repoPath, binaryPath, "show-config", "--config", configPath, "--config-profile", "work")
+	if !strings.Contains(output, "- Profile: work") || !strings.Contains(output, "- Age Days: 7") {
+		t.Errorf("Expected the work profile to be applied, got:\n%s", output)
+	}
+
+	cmd := exec.Command(binaryPath, "show-config", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "GIT_SWEEP_PROFILE=work")
+	if outputBytes, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(outputBytes), "- Age Days: 7") {
+		t.Errorf("Expected GIT_SWEEP_PROFILE to select the profile, got %v:\n%s", err, outputBytes)
+	}
+
+	cmd = exec.Command(binaryPath, "show-config", "--config", configPath, "--config-profile", "home")
+	cmd.Dir = repoPath
+	if outputBytes, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(outputBytes), "defined: work") {
+		t.Errorf("Expected an unknown profile to fail, got %v:\n%s", err, outputBytes)
+	}
+}
+
+// TestIntegrationConfigInit tests creating a configuration file with config init and locating it with config path.
+func TestIntegrationConfigInit(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	output := runCmd(t, repoPath, binaryPath, "config", "path", "--config", configPath)
+	if !strings.Contains(output, "Config file: "+configPath) || !strings.Contains(output, "Loaded:      none") {
+		t.Errorf("Unexpected config path output for a missing file:\n%s", output)
+	}
+
+	runCmd(t, repoPath, binaryPath, "config", "init", "--config", configPath, "--age", "120",
+		"--protected", "develop,staging")
+	output = runCmd(t, repoPath, binaryPath, "show-config", "--config", configPath)
+	if !strings.Contains(output, "- Age Days: 120") || !strings.Contains(output, "[develop staging]") {
+		t.Errorf("Expected the flags to be saved, got:\n%s", output)
+	}
+	if output := runCmd(t, repoPath, binaryPath, "config", "path", "--config", configPath); !strings.Contains(
+		output, "Loaded:      "+configPath) {
+		t.Errorf("Expected config path to report the loaded file, got:\n%s", output)
+	}
+
+	// An existing file is only replaced with --force
+	cmd := exec.Command(binaryPath, "config", "init", "--config", configPath, "--age", "30")
+	cmd.Dir = repoPath
+	if outputBytes, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(outputBytes), "--force") {
+		t.Errorf("Expected config init to refuse replacing the file, got %v:\n%s", err, outputBytes)
+	}
+	runCmd(t, repoPath, binaryPath, "config", "init", "--config", configPath, "--age", "30", "--force")
+	if output := runCmd(t, repoPath, binaryPath, "show-config", "--config", configPath); !strings.Contains(
+		output, "- Age Days: 30") {
+		t.Errorf("Expected --force to replace the file, got:\n%s", output)
+	}
+}
+
+// TestIntegrationConfigExportImport tests copying a configuration with config export and config import.
+func TestIntegrationConfigExportImport(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	sourcePath := filepath.Join(repoPath, ".git-sweep-team.toml")
+	content := "age_days = 45\nprimary_main_branch = \"main\"\nprovider_token = \"team-secret\"\n"
+	if err := os.WriteFile(sourcePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	exported := runCmd(t, repoPath, binaryPath, "config", "export", "--config", sourcePath)
+	if strings.Contains(exported, "team-secret") || !strings.Contains(exported, "age_days = 45") {
+		t.Errorf("Unexpected export:\n%s", exported)
+	}
+
+	targetPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	cmd := exec.Command(binaryPath, "config", "import", "--config", targetPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(exported)
+	if outputBytes, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("config import failed: %v\n%s", err, outputBytes)
+	}
+	if output := runCmd(t, repoPath, binaryPath, "show-config", "--config", targetPath); !strings.Contains(
+		output, "- Age Days: 45") {
+		t.Errorf("Expected the imported configuration to be used, got:\n%s", output)
+	}
+
+	cmd = exec.Command(binaryPath, "config", "import", "--config", targetPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("age_day = 30\n")
+	outputBytes, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(outputBytes), `unknown key "age_day"`) {
+		t.Errorf("Expected an unknown key to be rejected, got %v:\n%s", err, outputBytes)
+	}
+}
+
+// TestIntegrationStats tests the JSON output of the stats subcommand.
+func TestIntegrationStats(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -400))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "stats", "--output", "json", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git-sweep stats failed: %v\nOutput:\n%s", err, outputBytes)
+	}
+	var report struct {
+		Total      int `json:"total"`
+		Candidates int `json:"candidates"`
+		Oldest     []struct {
+			Name string `json:"name"`
+		} `json:"oldest"`
+	}
+	if err := json.Unmarshal(outputBytes, &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, outputBytes)
+	}
+	if report.Total != 2 || report.Candidates != 1 || len(report.Oldest) != 1 || report.Oldest[0].Name != "unmerged-old" {
+		t.Errorf("Unexpected stats report: %s", outputBytes)
+	}
+}
+
+// TestIntegrationOwners tests that the owners subcommand lists candidates per author.
+func TestIntegrationOwners(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -400))
+	createBranchAndCommit(t, repoPath, "unmerged-recent", "feat: unmerged recent", time.Now())
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "owners", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.CombinedOutput()
+	output := string(outputBytes)
+	if err != nil {
+		t.Fatalf("git-sweep owners failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.HasPrefix(output, "Test User <test@example.com>: 1 branch\n  unmerged-old") {
+		t.Errorf("Expected the old branch listed under its author, got:\n%s", output)
+	}
+	if strings.Contains(output, "unmerged-recent") {
+		t.Errorf("Expected only candidates to be listed, got:\n%s", output)
+	}
+}
+
+// TestIntegrationReport tests the Markdown output of the report subcommand.
+func TestIntegrationReport(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "report.md")
+	runCmd(t, repoPath, binaryPath, "report", "--skip-version-check", "--config", configPath, "-o", reportPath)
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	if !strings.Contains(string(content), "| `unmerged-old` | Unmerged, old | 100 days |") ||
+		!strings.Contains(string(content), "`git branch -D unmerged-old`") {
+		t.Errorf("Unexpected report:\n%s", content)
+	}
+}
+
+// TestIntegrationWhy tests the decision trail printed by the why subcommand.
+func TestIntegrationWhy(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "why", "unmerged-old", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep why failed: %v\nOutput:\n%s", err, outputBytes)
+	}
+	output := string(outputBytes)
+	for _, want := range []string{
+		"Protection:", "not protected",
+		"Merged (ancestry):", "no, the tip is not reachable from main",
+		"Merged (git cherry):", "threshold age_days 90 days; old",
+		"Category:", "UnmergedOld",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	cmd = exec.Command(binaryPath, "why", "no-such-branch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Error("Expected an error for an unknown branch")
+	}
+}
+
+// TestIntegrationFilters tests that --older-than, --pattern and --exclude narrow down the dry-run plan.
+func TestIntegrationFilters(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	for name, days := range map[string]int{"feature/old": 200, "feature/keep-old": 200, "feature/recent": 100, "bugfix/old": 200} {
+		createBranchAndCommit(t, repoPath, name, "feat: "+name, time.Now().AddDate(0, 0, -days))
+		runCmd(t, repoPath, "git", "checkout", "main")
+	}
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--script", "--skip-version-check", "--config", configPath,
+		"--pattern", "feature/*", "--older-than", "180d", "--exclude", "feature/keep-*")
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git-sweep with filters failed: %v\nOutput:\n%s", err, outputBytes)
+	}
+	if got := strings.TrimSpace(string(outputBytes)); got != "git branch -D feature/old" {
+		t.Errorf("Unexpected filtered script output:\n%s", got)
+	}
+
+	cmd = exec.Command(binaryPath, "--dry-run", "--skip-version-check", "--config", configPath, "--older-than", "soon")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Error("Expected an invalid --older-than to fail")
+	}
+}
+
+// TestIntegrationFetch tests that --no-fetch skips the fetch and that a hanging remote is given up on.
+func TestIntegrationFetch(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// A remote whose transport never answers
+	runCmd(t, repoPath, "git", "config", "protocol.ext.allow", "always")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", "ext::sleep 30")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nfetch_timeout_seconds = 1\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	start := time.Now()
+	cmd := exec.Command(binaryPath, "--dry-run", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "timed out after 1s") {
+		t.Errorf("Expected the fetch to time out, got error %v and output:\n%s", err, output)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the fetch to be given up on after 1s, took %s", elapsed)
+	}
+
+	cmd = exec.Command(binaryPath, "--dry-run", "--no-fetch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.CombinedOutput()
+	if err != nil || strings.Contains(string(output), "fetch") {
+		t.Errorf("Expected --no-fetch to skip the fetch, got error %v and output:\n%s", err, output)
+	}
+}
+
+// TestIntegrationFetchCache tests that a remote fetched within fetch_cache_minutes is not fetched again.
+func TestIntegrationFetchCache(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nfetch_cache_minutes = 10\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	stateHome := t.TempDir()
+	for i, tc := range []struct {
+		extraArgs   []string
+		wantSkipped bool
+	}{
+		{nil, false},                       // First run fetches and records the time
+		{nil, true},                        // Second run is within the window
+		{[]string{"--force-fetch"}, false}, // --force-fetch ignores the window
+	} {
+		args := append([]string{"--dry-run", "--debug", "--skip-version-check", "--config", configPath}, tc.extraArgs...)
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateHome)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Run %d failed: %v\nOutput:\n%s", i+1, err, output)
+		}
+		if skipped := strings.Contains(string(output), "Skipping fetch"); skipped != tc.wantSkipped {
+			t.Errorf("Run %d: expected skipped=%v, got output:\n%s", i+1, tc.wantSkipped, output)
+		}
+	}
+}
+
+// TestIntegrationCwd tests that -C runs git-sweep against a repository outside the working directory.
+func TestIntegrationCwd(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "merged-old", "feat: merged old", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged-old", "-m", "Merge merged-old")
+
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\n"
+	if err := os.WriteFile(filepath.Join(repoPath, ".git-sweep-test.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	// The config path is relative, so it is resolved in the repository as well
+	cmd := exec.Command(binaryPath, "-C", repoPath, "--dry-run", "--script", "--no-fetch", "--skip-version-check",
+		"--config", ".git-sweep-test.toml")
+	cmd.Dir = t.TempDir()
+	outputBytes, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git-sweep -C failed: %v\nOutput:\n%s", err, outputBytes)
+	}
+	if got := strings.TrimSpace(string(outputBytes)); got != "git branch -d merged-old" {
+		t.Errorf("Unexpected script output:\n%s", got)
+	}
+
+	cmd = exec.Command(binaryPath, "-C", filepath.Join(repoPath, "missing"), "--dry-run", "--skip-version-check")
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "--cwd") {
+		t.Errorf("Expected a missing directory to fail, got error %v and output:\n%s", err, output)
+	}
+}
+
+// TestIntegrationMultipleRemotes tests that the tracked remotes are fetched and that a branch is deleted
+// on the remote it tracks, under the name it has there.
+func TestIntegrationMultipleRemotes(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	for _, remote := range []string{"origin", "upstream"} {
+		remotePath := t.TempDir()
+		runCmd(t, remotePath, "git", "init", "--bare")
+		runCmd(t, repoPath, "git", "remote", "add", remote, remotePath)
+	}
+	createBranchAndCommit(t, repoPath, "fix", "fix: old bug", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "push", "--set-upstream", "upstream", "fix:bugfix/123")
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "fix", "-m", "Merge fix")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nfetch_cache_minutes = 0\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--script", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	outputBytes, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git-sweep --dry-run --script failed: %v\nOutput:\n%s", err, outputBytes)
+	}
+	want := "git branch -d fix\ngit push upstream --delete bugfix/123"
+	if got := strings.TrimSpace(string(outputBytes)); got != want {
+		t.Errorf("Expected the deletion on upstream, got:\n%s", got)
+	}
+
+	cmd = exec.Command(binaryPath, "--dry-run", "--debug", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep --dry-run --debug failed: %v\nOutput:\n%s", err, output)
+	}
+	for _, remote := range []string{"origin", "upstream"} {
+		if !strings.Contains(string(output), "Remote fetch complete\" remote="+remote) {
+			t.Errorf("Expected %s to be fetched, got output:\n%s", remote, output)
+		}
+	}
+
+	// A remote no branch tracks is only pruned with --prune-all-remotes
+	forkPath := t.TempDir()
+	runCmd(t, forkPath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "fork", forkPath)
+	runCmd(t, repoPath, "git", "push", "fork", "main:stale")
+	runCmd(t, forkPath, "git", "branch", "-D", "stale")
+	for _, pruneAll := range []bool{false, true} {
+		args := []string{"--dry-run", "--skip-version-check", "--config", configPath}
+		if pruneAll {
+			args = append(args, "--prune-all-remotes")
+		}
+		cmd = exec.Command(binaryPath, args...)
+		cmd.Dir = repoPath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		refs := runCmd(t, repoPath, "git", "branch", "-r")
+		if pruned := !strings.Contains(refs, "fork/stale"); pruned != pruneAll {
+			t.Errorf("With --prune-all-remotes=%v, expected pruned=%v, got:\n%s", pruneAll, pruneAll, refs)
+		}
+	}
+}
+
+// TestIntegrationRemoteChanged tests that a remote branch that received commits since the
+// last fetch is not deleted.
+func TestIntegrationRemoteChanged(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+	createBranchAndCommit(t, repoPath, "done", "feat: done", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "push", "--set-upstream", "origin", "done")
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "done", "-m", "Merge done")
+
+	// Someone else pushes to the branch, and the run does not fetch it
+	otherPath := filepath.Join(t.TempDir(), "other")
+	runCmd(t, repoPath, "git", "clone", "--branch", "done", remotePath, otherPath)
+	runCmd(t, otherPath, "git", "-c", "user.name=Other", "-c", "user.email=other@example.com",
+		"commit", "--allow-empty", "-m", "more work")
+	runCmd(t, otherPath, "git", "push", "origin", "done")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cmd := exec.Command(binaryPath, "--no-fetch", "--no-tui", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("1\ny\ny\n")
+	output, _ := cmd.CombinedOutput()
+	if !strings.Contains(string(output), "[ok] Local done") ||
+		!strings.Contains(string(output), "[FAILED] Remote origin/done - Skipped: remote branch changed since the last fetch") {
+		t.Errorf("Expected only the local branch to be deleted, got:\n%s", output)
+	}
+	if refs := runCmd(t, remotePath, "git", "branch", "--list", "done"); strings.TrimSpace(refs) == "" {
+		t.Error("Expected the remote branch with new commits to be kept")
+	}
+}
+
+// TestIntegrationRemoteAlreadyDeleted tests that deleting a remote branch someone else already
+// deleted removes the stale remote-tracking branch.
+func TestIntegrationRemoteAlreadyDeleted(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+	createBranchAndCommit(t, repoPath, "done", "feat: done", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "push", "--set-upstream", "origin", "done")
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "done", "-m", "Merge done")
+	runCmd(t, remotePath, "git", "branch", "-D", "done") // Deleted by someone else
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cmd := exec.Command(binaryPath, "--no-fetch", "--no-tui", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("1\ny\ny\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "[ok] Remote origin/done - Already deleted on the remote") {
+		t.Errorf("Expected the remote deletion to succeed, got error %v and output:\n%s", err, output)
+	}
+	if refs := runCmd(t, repoPath, "git", "branch", "-r"); strings.Contains(refs, "origin/done") {
+		t.Errorf("Expected the stale remote-tracking branch to be removed, got:\n%s", refs)
+	}
+}
+
+// TestIntegrationMaxDelete tests that scripts exceeding max_delete are refused unless --force is given.
+func TestIntegrationMaxDelete(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	for _, name := range []string{"old-a", "old-b"} {
+		createBranchAndCommit(t, repoPath, name, "feat: "+name, time.Now().AddDate(0, 0, -200))
+		runCmd(t, repoPath, "git", "checkout", "main")
+	}
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nmax_delete = 1\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--script", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "max_delete (1)") {
+		t.Errorf("Expected the script to be refused, got error %v and output:\n%s", err, output)
+	}
+
+	cmd = exec.Command(binaryPath, "--dry-run", "--script", "--force", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.Output()
+	if err != nil || strings.Count(string(output), "git branch -D") != 2 {
+		t.Errorf("Expected --force to allow the script, got error %v and output:\n%s", err, output)
+	}
+}
+
+// TestIntegrationPlainPrompt tests the numbered-list prompts used when no terminal is attached.
+func TestIntegrationPlainPrompt(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	oldDate := time.Now().AddDate(0, 0, -200)
+	createBranchAndCommit(t, repoPath, "old-a", "feat: old a", oldDate)
+	createBranchAndCommit(t, repoPath, "old-b", "feat: old b", oldDate)
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	run := func(input string) string {
+		t.Helper()
+		cmd := exec.Command(binaryPath, "--skip-version-check", "--config", configPath)
+		cmd.Dir = repoPath
+		cmd.Stdin = strings.NewReader(input)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep with input %q failed: %v\nOutput:\n%s", input, err, output)
+		}
+		return string(output)
+	}
+
+	// Without any input the list is only a preview
+	output := run("")
+	if !strings.Contains(output, "2) old-b (unmerged, force delete,") ||
+		!strings.Contains(output, "nothing was deleted") {
+		t.Errorf("Expected a numbered preview, got:\n%s", output)
+	}
+	// The test repository has no remote, so neither old-b's commit nor main's exist anywhere else
+	if !strings.Contains(output, "days old, UNPUSHED: 2 commits exist nowhere else, risky)") {
+		t.Errorf("Expected old-b to be flagged as unpushed, got:\n%s", output)
+	}
+
+	output = run("2\ny\n")
+	if !strings.Contains(output, "[ok] Local old-b") {
+		t.Errorf("Expected old-b to be deleted, got:\n%s", output)
+	}
+	branches := runCmd(t, repoPath, "git", "branch", "--format=%(refname:short)")
+	if strings.Contains(branches, "old-b") || !strings.Contains(branches, "old-a") {
+		t.Errorf("Expected only old-b to be deleted, branches left:\n%s", branches)
+	}
+}
+
+// TestIntegrationBundle tests that --bundle writes a restorable bundle of a branch before deleting it.
+func TestIntegrationBundle(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "feature/old", "feat: old", time.Now().AddDate(0, 0, -200))
+	tip := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "feature/old"))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	bundleDir := t.TempDir()
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := fmt.Sprintf("age_days = 90\nprimary_main_branch = \"main\"\nbundle_dir = %q\n", bundleDir)
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--bundle", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("1\ny\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "[ok] Local feature/old") {
+		t.Fatalf("Expected feature/old to be deleted, got error %v and output:\n%s", err, output)
+	}
+
+	bundlePath := filepath.Join(bundleDir, filepath.Base(repoPath), "feature", "old.bundle")
+	runCmd(t, repoPath, "git", "bundle", "verify", bundlePath)
+	runCmd(t, repoPath, "git", "fetch", bundlePath, "refs/heads/feature/old:refs/heads/restored")
+	if restored := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "restored")); restored != tip {
+		t.Errorf("Expected the bundle to restore %s, got %s", tip, restored)
+	}
+}
+
+// TestIntegrationTagPrefix tests that --tag-prefix tags a branch tip before deleting the branch.
+func TestIntegrationTagPrefix(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "feature/old", "feat: old", time.Now().AddDate(0, 0, -200))
+	tip := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "feature/old"))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	args := []string{"--tag-prefix", "sweep/", "--skip-version-check", "--config", configPath}
+	cmd := exec.Command(binaryPath, append([]string{"--dry-run", "--script"}, args...)...)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil || !strings.HasPrefix(string(output), "git tag sweep/feature/old "+tip+"\n") {
+		t.Errorf("Expected the script to tag before deleting, got error %v and output:\n%s", err, output)
+	}
+
+	cmd = exec.Command(binaryPath, args...)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("1\ny\n")
+	if output, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(output), "[ok] Local feature/old") {
+		t.Fatalf("Expected feature/old to be deleted, got error %v and output:\n%s", err, output)
+	}
+	if tagged := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "sweep/feature/old")); tagged != tip {
+		t.Errorf("Expected sweep/feature/old to point at %s, got %s", tip, tagged)
+	}
+}
+
+// TestIntegrationDuplicates tests that branches pointing at the same commit as main or as another
+// branch are suggested for deletion, even when they are recent.
+func TestIntegrationDuplicates(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	runCmd(t, repoPath, "git", "branch", "feature/started")
+	createBranchAndCommit(t, repoPath, "feature/x", "feat: x", time.Now())
+	runCmd(t, repoPath, "git", "branch", "feature/x-backup", "feature/x")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep failed: %v\nOutput:\n%s", err, output)
+	}
+	for _, want := range []string{
+		"Delete 'feature/started' (-d (safe)) | Status: Merged (0 days), identical to main\n",
+		"Delete 'feature/x' (-D (force))\n", // Active; the dry run lists it without a status
+		"Delete 'feature/x-backup' (-D (force)) | Status: Unmerged (0 days), duplicate of feature/x\n",
+	} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+}
+
+// TestIntegrationDuplicateWarning tests that the prompt warns when a duplicate and the branch it
+// duplicates are both selected, since deleting both loses their commits.
+func TestIntegrationDuplicateWarning(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "old-x", "feat: old x", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "branch", "old-x-copy", "old-x")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--no-tui", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("all\nn\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Warning: old-x-copy is a duplicate of old-x, which is selected too") {
+		t.Errorf("Expected a warning about deleting both duplicates, got:\n%s", output)
+	}
+}
+
+// TestIntegrationNoChanges tests that a branch whose commits add up to no change is suggested as
+// merged, although none of its commits are on main.
+func TestIntegrationNoChanges(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "feature/dropped", "feat: attempt", time.Now())
+	runCmd(t, repoPath, "git", "checkout", "feature/dropped")
+	runCmd(t, repoPath, "git", "revert", "--no-edit", "HEAD")
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "feature/wip", "feat: wip", time.Now())
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "why", "feature/dropped", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "yes, the branch changes nothing since it diverged from main") {
+		t.Errorf("Expected feature/dropped to count as merged, got error %v and output:\n%s", err, output)
+	}
+	cmd = exec.Command(binaryPath, "why", "feature/wip", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "no, the branch has changes since it diverged from main") {
+		t.Errorf("Expected feature/wip to stay unmerged, got error %v and output:\n%s", err, output)
+	}
+}
+
+// TestIntegrationMergeCheckCache tests that a second run on unchanged commits reuses the
+// outcome of the git cherry check instead of running it again.
+func TestIntegrationMergeCheckCache(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "feature/squashed", "feat: squashed", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	runCmd(t, repoPath, "git", "merge", "--squash", "feature/squashed")
+	runCmd(t, repoPath, "git", "commit", "-m", "feat: squashed (#1)")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	stateHome := t.TempDir()
+	for i, wantCherry := range []bool{true, false} {
+		cmd := exec.Command(binaryPath, "--dry-run", "--no-fetch", "--debug", "--skip-version-check",
+			"--config", configPath)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateHome)
+		outputBytes, err := cmd.CombinedOutput()
+		output := string(outputBytes)
+		if err != nil {
+			t.Fatalf("Run %d failed: %v\nOutput:\n%s", i+1, err, output)
+		}
+		// Found merged by git cherry, which git branch -d does not recognize, so it is force deleted
+		if !strings.Contains(output, "Delete 'feature/squashed' (-D (force)) | Status: Merged") {
+			t.Errorf("Run %d: expected the squashed branch to count as merged, got:\n%s", i+1, output)
+		}
+		if ranCherry := strings.Contains(output, "[cherry -v"); ranCherry != wantCherry {
+			t.Errorf("Run %d: expected git cherry to run: %v, got:\n%s", i+1, wantCherry, output)
+		}
+	}
+}
+
+// TestIntegrationProfile tests the phase timings printed with --profile.
+func TestIntegrationProfile(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged old", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--no-fetch", "--profile", "--skip-version-check",
+		"--config", configPath)
+	cmd.Dir = repoPath
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git-sweep --profile failed: %v\nStderr:\n%s", err, stderr.String())
+	}
+	for _, phase := range []string{"Profile:", "for-each-ref", "merged", "merge checks", "risk", "git commands"} {
+		if !strings.Contains(stderr.String(), phase) {
+			t.Errorf("Expected %q in the profile, got:\n%s", phase, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "fetch ") {
+		t.Errorf("Expected no fetch phase with --no-fetch, got:\n%s", stderr.String())
+	}
+}
+
+// TestIntegrationMergedIntoProtected tests that --merged-into-protected suggests and deletes a
+// hotfix that was merged into develop but not into main.
+func TestIntegrationMergedIntoProtected(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	runCmd(t, repoPath, "git", "branch", "develop")
+	createBranchAndCommit(t, repoPath, "hotfix/login", "fix: login", time.Now())
+	runCmd(t, repoPath, "git", "checkout", "develop")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "hotfix/login", "-m", "Merge hotfix/login")
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nprotected_branches = [\"develop\"]\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append(args, "--no-tui", "--skip-version-check", "--config", configPath)...)
+		cmd.Dir = repoPath
+		cmd.Stdin = strings.NewReader("1\ny\n")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	if output := run(); strings.Contains(output, "hotfix/login") {
+		t.Errorf("Expected the hotfix not to be suggested without the option, got:\n%s", output)
+	}
+	output := run("--merged-into-protected")
+	if !strings.Contains(output, "hotfix/login (merged into develop,") || !strings.Contains(output, "[ok] Local hotfix/login") {
+		t.Errorf("Expected the hotfix to be deleted, got:\n%s", output)
+	}
+	if branches := runCmd(t, repoPath, "git", "branch", "--list", "hotfix/login"); branches != "" {
+		t.Errorf("Expected hotfix/login to be gone, got %q", branches)
+	}
+}
+
+// TestIntegrationAgainst tests that --against checks merges against the remote-tracking main when
+// the local main is outdated.
+func TestIntegrationAgainst(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+	createBranchAndCommit(t, repoPath, "feature/x", "feat: x", time.Now())
+	runCmd(t, repoPath, "git", "push", "origin", "feature/x:main") // Merged upstream, local main not pulled
+	runCmd(t, repoPath, "git", "fetch", "origin")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	why := func(args ...string) string {
+		t.Helper()
+		args = append([]string{"why", "feature/x", "--skip-version-check", "--config", configPath}, args...)
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = repoPath
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		return string(output)
+	}
+	if output := why(); !strings.Contains(output, "no, the tip is not reachable from main") {
+		t.Errorf("Expected feature/x to be unmerged into the local main, got:\n%s", output)
+	}
+	if output := why("--against", "origin/main"); !strings.Contains(output, "yes, the tip is reachable from origin/main") {
+		t.Errorf("Expected feature/x to be merged into origin/main, got:\n%s", output)
+	}
+}
+
+// TestIntegrationFastForwardMain tests that --ff-main fast-forwards the local main to its
+// upstream after fetching, so branches merged upstream are detected as merged.
+func TestIntegrationFastForwardMain(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+	runCmd(t, repoPath, "git", "push", "--set-upstream", "origin", "main")
+	createBranchAndCommit(t, repoPath, "feature/x", "feat: x", time.Now())
+	runCmd(t, repoPath, "git", "push", "origin", "feature/x:main") // Merged upstream, local main not pulled
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--ff-main", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git-sweep --dry-run --ff-main failed: %v\nOutput:\n%s", err, output)
+	}
+	if main, feature := runCmd(t, repoPath, "git", "rev-parse", "main"),
+		runCmd(t, repoPath, "git", "rev-parse", "feature/x"); main != feature {
+		t.Errorf("Expected main to be fast-forwarded to %s, got %s", feature, main)
+	}
+
+	cmd = exec.Command(binaryPath, "why", "feature/x", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep why failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "yes, the tip is reachable from main") {
+		t.Errorf("Expected feature/x to be merged into the fast-forwarded main, got:\n%s", output)
+	}
+}
+
+// TestIntegrationRules tests that custom rules suggest and protect branches by name.
+func TestIntegrationRules(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "spike/idea", "feat: idea", time.Now())
+	createBranchAndCommit(t, repoPath, "wip/old", "feat: old", time.Now().AddDate(0, 0, -200))
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\n" +
+		"[[rules]]\nmatch = \"spike/*\"\naction = \"suggest\"\n" +
+		"[[rules]]\nmatch = \"wip/*\"\naction = \"protect\"\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--no-tui", "--no-fetch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("")
+	outputBytes, err := cmd.CombinedOutput()
+	output := string(outputBytes)
+	if err != nil {
+		t.Fatalf("git-sweep failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(output, "1) spike/idea (unmerged, force delete, 0 days old, rule spike/*") {
+		t.Errorf("Expected the recent spike to be suggested by its rule, got:\n%s", output)
+	}
+	if strings.Contains(output, "wip/old") {
+		t.Errorf("Expected the old wip branch to be protected by its rule, got:\n%s", output)
+	}
+}
+
+// TestIntegrationInterrupt tests that SIGINT at a prompt exits cleanly with status 130.
+func TestIntegrationInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGINT cannot be sent to a process on Windows")
+	}
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "old", "feat: old", time.Now().AddDate(0, 0, -200))
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--no-tui", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	stdin, _ := cmd.StdinPipe() // Kept open so the prompt waits for input
+	defer stdin.Close()
+	stdout, _ := cmd.StdoutPipe()
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start git-sweep: %v", err)
+	}
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadString(':')
+		if err != nil {
+			t.Fatalf("git-sweep exited before prompting: %v", err)
+		}
+		if strings.Contains(line, "Branches to delete") {
+			break
+		}
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatalf("Failed to send SIGINT: %v", err)
+	}
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 130 {
+		t.Errorf("Expected exit status 130, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Interrupted; nothing was deleted.") {
+		t.Errorf("Expected an interruption message, got stderr:\n%s", stderr.String())
+	}
+	if branches := runCmd(t, repoPath, "git", "branch"); !strings.Contains(branches, "old") {
+		t.Errorf("Expected branch 'old' to be kept, got:\n%s", branches)
+	}
+}
+
+// TestIntegrationMergeInProgress tests that branches are not deleted while a merge is in progress.
+func TestIntegrationMergeInProgress(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createBranchAndCommit(t, repoPath, "old", "feat: old", time.Now().AddDate(0, 0, -200))
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "--no-commit", "old")
+
+	cmd := exec.Command(binaryPath, "--no-tui", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("all\ny\n")
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "a merge is in progress") {
+		t.Errorf("Expected git-sweep to refuse to run during a merge, got %v:\n%s", err, output)
+	}
+	if branches := runCmd(t, repoPath, "git", "branch"); !strings.Contains(branches, "old") {
+		t.Errorf("Expected branch 'old' to be kept, got:\n%s", branches)
+	}
+
+	cmd = exec.Command(binaryPath, "--dry-run", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "Warning: A merge is in progress") {
+		t.Errorf("Expected a dry run with a warning, got %v:\n%s", err, output)
+	}
+}
+
+// TestIntegrationOffline tests that --offline neither fetches nor deletes remote branches and labels the results.
+func TestIntegrationOffline(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remotePath := t.TempDir()
+	runCmd(t, remotePath, "git", "init", "--bare")
+	runCmd(t, repoPath, "git", "remote", "add", "origin", remotePath)
+	createBranchAndCommit(t, repoPath, "old", "feat: old", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "push", "-u", "origin", "old")
+	runCmd(t, repoPath, "git", "fetch", "origin")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--no-tui", "--offline", "--debug", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("all\ny\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep --offline failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Offline: remote branches and merge status are as of the last fetch") {
+		t.Errorf("Expected the results to be labeled as possibly stale, got:\n%s", output)
+	}
+	if strings.Contains(string(output), "Fetching remote state") || strings.Contains(string(output), "on the remote?") {
+		t.Errorf("Expected no fetch and no remote deletion offered, got:\n%s", output)
+	}
+	if branches := runCmd(t, repoPath, "git", "branch", "--list", "old"); strings.TrimSpace(branches) != "" {
+		t.Errorf("Expected the local branch to be deleted, still have: %s", branches)
+	}
+	if remote := runCmd(t, remotePath, "git", "branch", "--list", "old"); strings.TrimSpace(remote) == "" {
+		t.Error("Expected the remote branch to be kept")
+	}
+
+	cmd = exec.Command(binaryPath, "update", "--offline", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "offline mode") {
+		t.Errorf("Expected git-sweep update to refuse to run offline, got %v:\n%s", err, output)
+	}
+}
+
+func TestIntegrationSchedulePrint(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "schedule", "install", "--print", "--scheduler", "cron", "--interval", "monthly",
+		"--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep schedule install --print failed: %v\nOutput:\n%s", err, output)
+	}
+	line := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(line, "0 9 1 * * ") || !strings.Contains(line, "--quick-status -C ") ||
+		!strings.Contains(line, "--config "+configPath) {
+		t.Errorf("Unexpected crontab entry: %s", line)
+	}
+
+	cmd = exec.Command(binaryPath, "schedule", "install", "--interval", "hourly", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "unsupported interval") {
+		t.Errorf("Expected an unsupported interval to be rejected, got %v:\n%s", err, output)
+	}
+}
+
+func TestIntegrationInstallAlias(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "install-alias", "--local", "--hook", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep install-alias failed: %v\nOutput:\n%s", err, output)
+	}
+	if alias := runCmd(t, repoPath, "git", "config", "--local", "alias.sweep"); !strings.HasPrefix(alias, "!") {
+		t.Errorf("Expected a shell alias for git-sweep, got %q", alias)
+	}
+	if status := runCmd(t, repoPath, "git", "sweep", "--quick-status", "--config", configPath); !strings.Contains(
+		status, "[git-sweep]") {
+		t.Errorf("Expected 'git sweep' to run git-sweep, got:\n%s", status)
+	}
+
+	// Merging runs the post-merge hook, which prints the quick status
+	createBranchAndCommit(t, repoPath, "feature", "feat: merged", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	merge := exec.Command("git", "merge", "--no-edit", "feature")
+	merge.Dir = repoPath
+	output, err = merge.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git merge failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "[git-sweep] Found 1 branches to clean up") {
+		t.Errorf("Expected the post-merge hook to print the quick status, got:\n%s", output)
+	}
+}
+
+func TestIntegrationPorcelain(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, `fix/"quoted"`, "fix: old", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	run := func(args ...string) string {
+		cmd := exec.Command(binaryPath, append(args, "--config", configPath)...)
+		cmd.Dir = repoPath
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	if output := run("list", "--candidates", "--porcelain"); !strings.HasPrefix(output, "unmerged 200 ") ||
+		!strings.HasSuffix(output, ` - "fix/\"quoted\""`+"\n") {
+		t.Errorf("Unexpected list --porcelain output: %q", output)
+	}
+	if output := run("list", "--candidates", "-z"); !strings.HasSuffix(output, ` - fix/"quoted"`+"\x00") {
+		t.Errorf("Unexpected list -z output: %q", output)
+	}
+	if output := run("--dry-run", "--no-fetch", "-z"); !strings.HasPrefix(output, "local force ") ||
+		!strings.HasSuffix(output, ` fix/"quoted"`+"\x00") {
+		t.Errorf("Unexpected --dry-run -z output: %q", output)
+	}
+	if output := run("--quick-status", "--porcelain"); output != "1 0 1\n" {
+		t.Errorf("Unexpected --quick-status --porcelain output: %q", output)
+	}
+
+	cmd := exec.Command(binaryPath, "--porcelain", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "can only be used with") {
+		t.Errorf("Expected --porcelain without --dry-run to be rejected, got %v:\n%s", err, output)
+	}
+}
+
+func TestIntegrationCheck(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "old-a", "feat: a", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "old-b", "feat: b", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	cmd := exec.Command(binaryPath, "check", "old-a", "--dry-run", "--script", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep check failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "git branch -D old-a") || strings.Contains(string(output), "old-b") {
+		t.Errorf("Expected only old-a to be checked, got:\n%s", output)
+	}
+
+	// 'git branch' output includes main, which stays protected
+	cmd = exec.Command(binaryPath, "check", "--stdin", "--dry-run", "--porcelain", "--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(runCmd(t, repoPath, "git", "branch"))
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("git-sweep check --stdin failed: %v\nOutput:\n%s", err, output)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(output)), "\n"); len(lines) != 2 ||
+		!strings.HasSuffix(lines[0], " old-a") || !strings.HasSuffix(lines[1], " old-b") {
+		t.Errorf("Expected both old branches and not main, got:\n%s", output)
+	}
+
+	cmd = exec.Command(binaryPath, "check", "old-a", "missing", "--dry-run", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), `no local branch named "missing"`) {
+		t.Errorf("Expected the missing branch to be reported, got %v:\n%s", err, output)
+	}
+}
+
+func TestIntegrationPlanApply(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "old-a", "feat: a", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "old-b", "feat: b", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "old-c", "feat: c", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "main")
+
+	planPath := filepath.Join(t.TempDir(), "sweep.toml")
+	cmd := exec.Command(binaryPath, "plan", "-o", planPath, "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git-sweep plan failed: %v\nOutput:\n%s", err, output)
+	}
+	plan, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatalf("Failed to read plan: %v", err)
+	}
+	for _, name := range []string{"old-a", "old-b", "old-c"} {
+		if !strings.Contains(string(plan), fmt.Sprintf("name = %q", name)) {
+			t.Errorf("Expected %s in the plan:\n%s", name, plan)
+		}
+	}
+
+	// The reviewer keeps old-b by removing its entry
+	entries := strings.Split(string(plan), "[[branch]]")
+	var kept []string
+	for _, entry := range entries {
+		if !strings.Contains(entry, `name = "old-b"`) {
+			kept = append(kept, entry)
+		}
+	}
+	if err := os.WriteFile(planPath, []byte(strings.Join(kept, "[[branch]]")), 0644); err != nil {
+		t.Fatalf("Failed to edit plan: %v", err)
+	}
+
+	// old-c moves after the plan was made, so nothing is deleted
+	hashC := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "old-c"))
+	runCmd(t, repoPath, "git", "branch", "-f", "old-c", "old-a")
+	cmd = exec.Command(binaryPath, "apply", planPath, "--yes", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "old-c: moved to") {
+		t.Errorf("Expected the moved branch to be refused, got %v:\n%s", err, output)
+	}
+	if branches := runCmd(t, repoPath, "git", "branch"); !strings.Contains(branches, "old-a") {
+		t.Errorf("Expected old-a to be kept after a refused plan, got:\n%s", branches)
+	}
+
+	runCmd(t, repoPath, "git", "branch", "-f", "old-c", hashC)
+	cmd = exec.Command(binaryPath, "apply", planPath, "--yes", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git-sweep apply failed: %v\nOutput:\n%s", err, output)
+	}
+	branches := runCmd(t, repoPath, "git", "branch")
+	if strings.Contains(branches, "old-a") || strings.Contains(branches, "old-c") || !strings.Contains(branches, "old-b") {
+		t.Errorf("Expected old-a and old-c to be deleted and old-b kept, got:\n%s", branches)
+	}
+}
+
+func TestPrintPlan(t *testing.T) {
+	file := planfile.File{Branches: []planfile.Entry{
+		{Name: "ancestor", Merged: true, MergedBy: string(types.MergedByAncestry)},
+		{Name: "squashed", Merged: true, MergedBy: string(types.MergedByCherry)},
+		{Name: "unmerged"},
+	}}
+	var out strings.Builder
+	printPlan(&out, file)
+	for _, want := range []string{
+		"Delete 'ancestor' (-d)", "Delete 'squashed' (-D (force))", "Delete 'unmerged' (-D (force))",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the plan, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestIntegrationLast(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "old-a", "feat: a", time.Now().AddDate(0, 0, -200))
+	hash := strings.TrimSpace(runCmd(t, repoPath, "git", "rev-parse", "old-a"))
+
+	cmd := exec.Command(binaryPath, "last", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "No run has deleted branches") {
+		t.Errorf("Expected no recorded run, got %v:\n%s", err, output)
+	}
+
+	planPath := filepath.Join(t.TempDir(), "sweep.toml")
+	cmd = exec.Command(binaryPath, "plan", "-o", planPath, "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git-sweep plan failed: %v\nOutput:\n%s", err, output)
+	}
+	cmd = exec.Command(binaryPath, "apply", planPath, "--yes", "--config", configPath)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git-sweep apply failed: %v\nOutput:\n%s", err, output)
+	}
+
+	cmd = exec.Command(binaryPath, "last", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep last failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "1 of 1 deletions succeeded") ||
+		!strings.Contains(string(output), "[ok] Local old-a") ||
+		!strings.Contains(string(output), "recover: git branch old-a "+hash) {
+		t.Errorf("Expected the deletion of old-a with its recovery command, got:\n%s", output)
+	}
+}
+
+// TestIntegrationInteractiveConfirm tests that --interactive-confirm asks about each selected
+// branch in the plain prompts.
+func TestIntegrationInteractiveConfirm(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	for _, name := range []string{"old-a", "old-b", "old-c"} {
+		createBranchAndCommit(t, repoPath, name, "feat: "+name, time.Now().AddDate(0, 0, -200))
+	}
+
+	// Keep old-a after an invalid answer, delete old-b, keep old-c with q
+	cmd := exec.Command(binaryPath, "--no-fetch", "--no-tui", "--interactive-confirm", "--skip-version-check",
+		"--config", configPath)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("all\nx\nn\ny\nq\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "(1/3) Delete old-a") || !strings.Contains(string(output), "y - delete") ||
+		!strings.Contains(string(output), "[ok] Local old-b") {
+		t.Errorf("Expected each branch to be confirmed separately, got:\n%s", output)
+	}
+	branches := runCmd(t, repoPath, "git", "branch")
+	if !strings.Contains(branches, "old-a") || strings.Contains(branches, "old-b") ||
+		!strings.Contains(branches, "old-c") {
+		t.Errorf("Expected only old-b to be deleted, got:\n%s", branches)
+	}
+}
+
+// TestIntegrationAuto tests that --auto deletes only branches merged by ancestry.
+func TestIntegrationAuto(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "merged", "feat: merged", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged", "-m", "Merge merged")
+	createBranchAndCommit(t, repoPath, "unmerged-old", "feat: unmerged", time.Now().AddDate(0, 0, -200))
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append(args, "--auto", "--no-fetch", "--skip-version-check",
+			"--config", configPath)...)
+		cmd.Dir = repoPath
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep --auto failed: %v\nOutput:\n%s", err, output)
+		}
+		return string(output)
+	}
+
+	output := run("--dry-run")
+	if !strings.Contains(output, "Would delete 1 of 1 branch(es)") || strings.Contains(output, "Local unmerged-old") {
+		t.Errorf("Expected the dry run to propose only the merged branch, got:\n%s", output)
+	}
+	if branches := runCmd(t, repoPath, "git", "branch"); !strings.Contains(branches, "merged\n") {
+		t.Errorf("Expected the dry run to delete nothing, got:\n%s", branches)
+	}
+
+	output = run()
+	if !strings.Contains(output, "[ok] Local merged") || !strings.Contains(output, "Deleted 1 of 1 branch(es)") ||
+		!strings.Contains(output, "1 other suggested branch(es) need a closer look") {
+		t.Errorf("Expected the merged branch to be deleted and the other left for review, got:\n%s", output)
+	}
+	branches := runCmd(t, repoPath, "git", "branch")
+	if strings.Contains(branches, " merged\n") || !strings.Contains(branches, "unmerged-old") {
+		t.Errorf("Expected only the merged branch to be deleted, got:\n%s", branches)
+	}
+}
+
+// TestIntegrationAutoMergedIntoDevelop tests that --auto leaves a branch merged only into
+// another protected branch for review.
+func TestIntegrationAutoMergedIntoDevelop(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	runCmd(t, repoPath, "git", "branch", "develop")
+	createBranchAndCommit(t, repoPath, "feature/develop-only", "feat: develop only", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "checkout", "develop")
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "feature/develop-only", "-m", "Merge feature/develop-only")
+	runCmd(t, repoPath, "git", "checkout", "main")
+	createBranchAndCommit(t, repoPath, "feature/main", "feat: main", time.Now().AddDate(0, 0, -100))
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "feature/main", "-m", "Merge feature/main")
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	configContent := "age_days = 90\nprimary_main_branch = \"main\"\nprotected_branches = [\"develop\"]\n" +
+		"merged_into_protected = true\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, "--auto", "--no-fetch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep --auto failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "[ok] Local feature/main") ||
+		!strings.Contains(string(output), "Deleted 1 of 1 branch(es) fully merged into main") ||
+		!strings.Contains(string(output), "1 other suggested branch(es) need a closer look") {
+		t.Errorf("Expected only the branch merged into main to be deleted, got:\n%s", output)
+	}
+	branches := runCmd(t, repoPath, "git", "branch")
+	if !strings.Contains(branches, "feature/develop-only") || strings.Contains(branches, "feature/main") {
+		t.Errorf("Expected the branch merged only into develop to be kept, got:\n%s", branches)
+	}
+}
+
+func TestIntegrationStackedBranches(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "stack/base", "feat: base", time.Now().AddDate(0, 0, -200))
+	runCmd(t, repoPath, "git", "checkout", "stack/base")
+	createBranchAndCommit(t, repoPath, "stack/top", "feat: top", time.Now().AddDate(0, 0, -150))
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--no-fetch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep --dry-run failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "Delete 'stack/base' (-D (force))") ||
+		!strings.Contains(string(output), "base of stack/top") {
+		t.Errorf("Expected stack/base to be labeled as the base of stack/top, got:\n%s", output)
+	}
+}
+
+func TestIntegrationEmptyRepository(t *testing.T) {
+	repoPath := t.TempDir()
+	runCmd(t, repoPath, "git", "init", "-b", "main")
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"auto\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	for _, args := range [][]string{{"--dry-run"}, {"--quick-status"}, {"--no-tui"}} {
+		cmd := exec.Command(binaryPath, append(args, "--skip-version-check", "--config", configPath)...)
+		cmd.Dir = repoPath
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		if !strings.Contains(string(output), "Nothing to sweep yet: the repository has no commits.") ||
+			strings.Contains(string(output), "Warning") {
+			t.Errorf("Expected git-sweep %v to report nothing to sweep without warnings, got:\n%s", args, output)
+		}
+	}
+}
+
+func TestIntegrationDetachedHead(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configPath := filepath.Join(repoPath, ".git-sweep-test.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	createBranchAndCommit(t, repoPath, "merged", "feat: merged", time.Now().AddDate(0, 0, -10))
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged", "-m", "Merge merged")
+	runCmd(t, repoPath, "git", "checkout", "--detach", "merged")
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--no-fetch", "--skip-version-check", "--config", configPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git-sweep --dry-run failed: %v\nOutput:\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "HEAD is detached at ") || !strings.Contains(string(output), "(feat: merged)") ||
+		!strings.Contains(string(output), "Delete 'merged' (-d (safe))") {
+		t.Errorf("Expected the detached HEAD to be reported and merged to be suggested, got:\n%s", output)
+	}
+}
+
+func TestIntegrationBareRepository(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createBranchAndCommit(t, repoPath, "merged", "feat: merged", time.Now().AddDate(0, 0, -10))
+	runCmd(t, repoPath, "git", "merge", "--no-ff", "merged", "-m", "Merge merged")
+	barePath := filepath.Join(t.TempDir(), "bare.git")
+	runCmd(t, "", "git", "clone", "--quiet", "--bare", repoPath, barePath)
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, append(args, "--offline", "--config", configPath)...)
+		cmd.Dir = barePath
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+	output, err := run("--dry-run")
+	if err != nil || !strings.Contains(output, "Delete 'merged' (-d (safe))") {
+		t.Errorf("Expected the dry run to analyze the bare repository, got %v:\n%s", err, output)
+	}
+	output, err = run("--no-tui")
+	if err == nil || !strings.Contains(output, "this is a bare repository") {
+		t.Errorf("Expected deletions to be refused in the bare repository, got %v:\n%s", err, output)
+	}
+	if branches := runCmd(t, barePath, "git", "branch"); !strings.Contains(branches, "merged") {
+		t.Errorf("Expected no branch to be deleted, got:\n%s", branches)
+	}
+}
+
+func TestIntegrationShallowClone(t *testing.T) {
+	sourcePath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	for i := range 3 {
+		runCmd(t, sourcePath, "git", "commit", "--allow-empty", "-m", fmt.Sprintf("Commit %d", i))
+	}
+	repoPath := filepath.Join(t.TempDir(), "shallow")
+	runCmd(t, "", "git", "clone", "--quiet", "--depth", "1", "file://"+sourcePath, repoPath)
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("age_days = 90\nprimary_main_branch = \"main\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append(args, "--dry-run", "--skip-version-check", "--config", configPath)...)
+		cmd.Dir = repoPath
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git-sweep %v failed: %v\nOutput:\n%s", args, err, output)
+		}
+		return string(output)
+	}
+	if output := run(); !strings.Contains(output, "This is a shallow clone, so merge detection may be wrong") {
+		t.Errorf("Expected a warning about the shallow clone, got:\n%s", output)
+	}
+	if output := run("--unshallow"); strings.Contains(output, "shallow clone") {
+		t.Errorf("Expected --unshallow to fetch the full history, got:\n%s", output)
+	}
+	if shallow := runCmd(t, repoPath, "git", "rev-parse", "--is-shallow-repository"); shallow != "false\n" {
+		t.Errorf("Expected the clone to be complete after --unshallow, got %q", shallow)
+	}
+}
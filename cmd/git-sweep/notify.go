@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/notify"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// notifyTimeout bounds how long git-sweep waits for the notification webhook.
+const notifyTimeout = 10 * time.Second
+
+// sendNotification posts a summary of the executed deletions to notify_url, if configured.
+// Failures are reported as warnings; the deletions themselves have already happened.
+func sendNotification(ctx context.Context, results []types.DeleteResult) {
+	if appConfig.NotifyURL == "" || len(results) == 0 {
+		return
+	}
+	if appConfig.Offline {
+		slog.Debug("Skipping the notification in offline mode")
+		return
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not send notification: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	summary := notify.NewSummary(repoRoot, results, time.Now())
+	client, err := httpclient.New(appConfig, notifyTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not send notification: %v\n", err)
+		return
+	}
+	if err := notify.Send(ctx, client, appConfig.NotifyURL, summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not send notification: %v\n", err)
+		return
+	}
+	slog.Debug("Sent notification", "deleted", len(summary.Deleted), "failures", len(summary.Failures))
+}
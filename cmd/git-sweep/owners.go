@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/stats"
+)
+
+// printOwnersTable renders the candidate branches of each author under a heading naming them.
+func printOwnersTable(w io.Writer, owners []stats.Owner) error {
+	if len(owners) == 0 {
+		_, err := fmt.Fprintln(w, "No branches to clean up.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, owner := range owners {
+		if i > 0 {
+			_, _ = fmt.Fprintln(tw)
+		}
+		heading := owner.Author
+		if owner.Name != "" {
+			heading = fmt.Sprintf("%s <%s>", owner.Name, owner.Author)
+		}
+		count := "1 branch"
+		if len(owner.Branches) != 1 {
+			count = fmt.Sprintf("%d branches", len(owner.Branches))
+		}
+		_, _ = fmt.Fprintf(tw, "%s: %s\n", heading, count)
+		for _, branch := range owner.Branches {
+			remote := "-"
+			if branch.Remote != "" {
+				remote = branch.Remote
+			}
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%dd\t%s\n", branch.Name, branch.Category, branch.AgeDays, remote)
+		}
+	}
+	return tw.Flush()
+}
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "List the branches to clean up per last-commit author",
+	Long: `The owners command analyzes the repository like the interactive mode and lists
+the branches suggested for deletion grouped by the author of their last commit,
+with their category, age and remote, so each teammate can be sent their own
+list of stale branches.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported --output %q (supported: table, json)\n", output)
+			os.Exit(exitError)
+		}
+
+		branches, err := analyzeRepository(cmd.Context(), remoteName, fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		owners := stats.Owners(branches, time.Now())
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(owners)
+		} else {
+			err = printOwnersTable(os.Stdout, owners)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	ownersCmd.Flags().StringP("output", "o", "table", "Output format: table or json.")
+	ownersCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	rootCmd.AddCommand(ownersCmd)
+}
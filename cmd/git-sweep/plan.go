@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/planfile"
+	"github.com/bral/git-sweep-go/internal/types"
+	"github.com/bral/git-sweep-go/pkg/sweep"
+)
+
+// newPlanFile lists the candidates among branches in a plan for the repository at repoRoot.
+// Their remote branches are included unless keepRemote is set, in offline mode or if they are
+// protected on the remote.
+func newPlanFile(ctx context.Context, repoRoot string, branches []types.AnalyzedBranch, keepRemote bool) planfile.File {
+	now := time.Now()
+	email, _ := gitcmd.GetUserEmail(ctx)
+	file := planfile.File{Repo: repoRoot, Created: now.UTC().Truncate(time.Second), CreatedBy: email}
+	for _, branch := range sweep.Candidates(branches) {
+		entry := planfile.Entry{
+			Name: branch.Name, Hash: branch.CommitHash, Merged: branch.IsMerged, MergedBy: string(branch.MergedBy),
+			Reason: candidateDetails(branch, now),
+		}
+		if !keepRemote && !appConfig.Offline && branch.Remote != "" && !branch.IsRemoteProtected {
+			entry.Remote, entry.RemoteBranch = branch.Remote, branch.RemoteBranchName()
+		}
+		file.Branches = append(file.Branches, entry)
+	}
+	return file
+}
+
+// planProblems checks the plan against the current analysis of its branches and returns why
+// each branch that must not be deleted anymore is out of date.
+func planProblems(file planfile.File, branches []types.AnalyzedBranch) []string {
+	analyzed := make(map[string]types.AnalyzedBranch, len(branches))
+	for _, branch := range branches {
+		analyzed[branch.Name] = branch
+	}
+	var problems []string
+	for _, entry := range file.Branches {
+		branch, ok := analyzed[entry.Name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: no longer exists", entry.Name))
+		case branch.CommitHash != entry.Hash:
+			problems = append(problems, fmt.Sprintf("%s: moved to %.7s since the plan was made (was %.7s)",
+				entry.Name, branch.CommitHash, entry.Hash))
+		case branch.Category == types.CategoryProtected:
+			problems = append(problems, fmt.Sprintf("%s: is protected now", entry.Name))
+		case entry.Remote != "" && appConfig.Offline:
+			problems = append(problems, fmt.Sprintf("%s: deleting it on %s needs the network", entry.Name, entry.Remote))
+		case entry.Remote != "" && branch.Remote == entry.Remote && branch.IsRemoteProtected:
+			problems = append(problems, fmt.Sprintf("%s: is protected on %s", entry.Name, entry.Remote))
+		}
+	}
+	return problems
+}
+
+// planDeletions returns the deletions of the plan, local branches first, with the archive,
+// bundle and tag settings of the configuration.
+func planDeletions(file planfile.File, bundleDir string) sweep.Plan {
+	var plan sweep.Plan
+	for _, entry := range file.Branches {
+		plan.Deletions = append(plan.Deletions, sweep.Deletion{
+			Name: entry.Name, IsMerged: entry.Merged, MergedBy: types.MergeMethod(entry.MergedBy), Hash: entry.Hash,
+			Archive: sweep.ArchiveMode(appConfig), BundleDir: bundleDir, TagPrefix: appConfig.TagPrefix,
+		})
+	}
+	for _, entry := range file.Branches {
+		if entry.Remote != "" {
+			plan.Deletions = append(plan.Deletions, sweep.Deletion{
+				Name: entry.RemoteBranch, IsRemote: true, Remote: entry.Remote, IsMerged: entry.Merged, Hash: entry.Hash,
+			})
+		}
+	}
+	return plan
+}
+
+// printPlan lists the deletions of a plan for confirmation.
+func printPlan(w io.Writer, file planfile.File) {
+	by := ""
+	if file.CreatedBy != "" {
+		by = " by " + file.CreatedBy
+	}
+	_, _ = fmt.Fprintf(w, "Plan made %s%s:\n", file.Created.Local().Format("2006-01-02 15:04"), by)
+	for _, entry := range file.Branches {
+		mode := "-d"
+		if (sweep.Deletion{IsMerged: entry.Merged, MergedBy: types.MergeMethod(entry.MergedBy)}).ForceDelete() {
+			mode = "-D (force)"
+		}
+		line := fmt.Sprintf("  - Delete '%s' (%s)", entry.Name, mode)
+		if entry.Remote != "" {
+			line += fmt.Sprintf(" and %s/%s", entry.Remote, entry.RemoteBranch)
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Write an editable plan of proposed deletions for review",
+	Long: `The plan command analyzes the repository like the interactive mode and writes
+the branches proposed for deletion, with their commit hashes, to a TOML plan
+file. Review it, remove the branches that must be kept, and run
+'git-sweep apply <file>' to delete the rest. This way one person can prepare
+a cleanup and another can approve and execute it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+		outputPath, _ := cmd.Flags().GetString("output")
+		keepRemote, _ := cmd.Flags().GetBool("keep-remote")
+
+		branches, err := analyzeRepository(ctx, remoteName, fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		repoRoot, err := gitcmd.GetRepoRoot(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		file := newPlanFile(ctx, repoRoot, branches, keepRemote)
+
+		if outputPath == "" {
+			if err := planfile.Write(os.Stdout, file); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			return
+		}
+		if err := planfile.Create(outputPath, file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Wrote a plan deleting %d branch(es) to %s.\n", len(file.Branches), outputPath)
+		_, _ = fmt.Fprintf(os.Stdout, "Review it, then run: git-sweep apply %s\n", outputPath)
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan-file>",
+	Short: "Execute the deletions of a reviewed plan file",
+	Long: `The apply command deletes the branches listed in a plan file written by
+'git-sweep plan'. Before deleting anything, it analyzes the listed branches
+again and refuses to run if any of them moved since the plan was made, no
+longer exists, or is protected by now. It asks for confirmation unless --yes
+is given; --dry-run shows what would be deleted.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		remoteName, _ := cmd.Flags().GetString("remote")
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		file, err := planfile.Read(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if repoRoot := requireRepoRoot(ctx); file.Repo != repoRoot {
+			fmt.Fprintf(os.Stderr, "Error: the plan was made for %s, not %s.\n", file.Repo, repoRoot)
+			os.Exit(exitError)
+		}
+		if len(file.Branches) == 0 {
+			_, _ = fmt.Fprintln(os.Stdout, "The plan deletes no branches. Nothing to do.")
+			os.Exit(exitOK)
+		}
+		if err := checkRepoState(ctx, dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := checkDeleteLimit(len(file.Branches), deleteLimit(cmd)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+
+		for _, entry := range file.Branches {
+			selectedBranches = append(selectedBranches, entry.Name)
+		}
+		branches, err := analyzeRepository(ctx, remoteName, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if problems := planProblems(file, branches); len(problems) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: the plan is out of date; nothing was deleted:")
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "  %s\n", problem)
+			}
+			fmt.Fprintln(os.Stderr, "Remove these branches from the plan or make a new one with 'git-sweep plan'.")
+			os.Exit(exitError)
+		}
+
+		printPlan(os.Stdout, file)
+		if !yes && !dryRun {
+			in := bufio.NewReader(interruptibleReader{ctx, os.Stdin})
+			if !confirm(in, os.Stdout, fmt.Sprintf("Delete these %d branch(es)?", len(file.Branches))) {
+				_, _ = fmt.Fprintln(os.Stdout, "Cancelled; nothing was deleted.")
+				os.Exit(exitOK)
+			}
+		}
+
+		bundles, err := bundleDir(ctx, !dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		results := sweep.Delete(ctx, "", planDeletions(file, bundles), sweep.DeleteOptions{
+			DryRun: dryRun, RemoteWorkers: appConfig.RemoteDeleteWorkers, RemoteRateLimit: appConfig.RemoteRateLimit,
+			OnResult: func(res types.DeleteResult) {
+				if ctx.Err() == nil {
+					_, _ = fmt.Fprintln(os.Stdout, describeResult(res))
+				}
+			},
+		})
+		if !dryRun {
+			// Record what was deleted before an interruption too; the journal must not miss a branch
+			journalDeletions(context.WithoutCancel(ctx), results)
+			auditDeletions(context.WithoutCancel(ctx), results)
+			recordLastRun(context.WithoutCancel(ctx), results)
+			sendNotification(context.WithoutCancel(ctx), results)
+		}
+		if ctx.Err() != nil {
+			printInterruptedSummary(os.Stdout, results)
+			os.Exit(exitInterrupted)
+		}
+		printAuthHint(os.Stdout, results)
+		os.Exit(deletionsExitCode(results))
+	},
+}
+
+func init() {
+	planCmd.Flags().StringP("output", "o", "", "Write the plan to this new file instead of stdout.")
+	planCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	planCmd.Flags().Bool("keep-remote", false, "Only plan local deletions, keeping the remote branches.")
+	applyCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation.")
+	applyCmd.Flags().Bool("force", false, "Allow deleting more branches than max_delete in one run.")
+	rootCmd.AddCommand(planCmd, applyCmd)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// porcelainFormat is the --porcelain output: one record per line, made of space-separated
+// fields with the branch name last. Names are quoted like git quotes paths if they contain
+// unusual characters. With -z, records end in NUL instead and names are never quoted.
+type porcelainFormat struct {
+	enabled bool
+	nul     bool
+}
+
+// addPorcelainFlags defines --porcelain and -z.
+func addPorcelainFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().Bool("porcelain", false, usage)
+	cmd.Flags().BoolP("null", "z", false, "Like --porcelain, but end records with NUL and never quote branch names.")
+}
+
+// porcelainFlags returns the output format selected by --porcelain and -z; -z implies --porcelain.
+func porcelainFlags(cmd *cobra.Command) porcelainFormat {
+	porcelain, _ := cmd.Flags().GetBool("porcelain")
+	nul, _ := cmd.Flags().GetBool("null")
+	return porcelainFormat{enabled: porcelain || nul, nul: nul}
+}
+
+// checkPorcelainFlags rejects --porcelain and -z for output they do not apply to.
+func checkPorcelainFlags(cmd *cobra.Command, format porcelainFormat) error {
+	if !format.enabled {
+		return nil
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	quickStatus, _ := cmd.Flags().GetBool("quick-status")
+	script, _ := cmd.Flags().GetBool("script")
+	byAge, _ := cmd.Flags().GetBool("by-age")
+	switch {
+	case !dryRun && !quickStatus:
+		return errors.New("--porcelain and -z can only be used with --dry-run, --quick-status or the list command")
+	case script:
+		return errors.New("--porcelain and -z cannot be combined with --script")
+	case byAge:
+		return errors.New("--porcelain and -z cannot be combined with --by-age")
+	}
+	return nil
+}
+
+// write writes one record made of fields.
+func (f porcelainFormat) write(w io.Writer, fields ...string) {
+	if !f.nul {
+		for i, field := range fields {
+			fields[i] = gitcmd.CQuote(field)
+		}
+	}
+	terminator := "\n"
+	if f.nul {
+		terminator = "\x00"
+	}
+	_, _ = fmt.Fprint(w, strings.Join(fields, " ")+terminator)
+}
+
+// porcelainCategory returns the stable name of a category in porcelain output.
+func porcelainCategory(category types.BranchCategory) string {
+	switch category {
+	case types.CategoryMergedOld:
+		return "merged"
+	case types.CategoryUnmergedOld:
+		return "unmerged"
+	case types.CategoryProtected:
+		return "protected"
+	case types.CategoryActive:
+		return "active"
+	}
+	return strings.ToLower(string(category))
+}
+
+// printPorcelainList writes one record per branch:
+//
+//	<category> <age-days> <commit> <remote-branch or -> <name>
+func printPorcelainList(w io.Writer, format porcelainFormat, branches []types.AnalyzedBranch, candidatesOnly bool) {
+	now := time.Now()
+	for _, branch := range branches {
+		if candidatesOnly && !isDeletionCandidate(branch) {
+			continue
+		}
+		remote := "-"
+		if branch.Remote != "" {
+			remote = branch.Remote + "/" + branch.RemoteBranchName()
+		}
+		ageDays := int(now.Sub(branch.LastCommitDate).Hours() / 24)
+		format.write(w, porcelainCategory(branch.Category), strconv.Itoa(ageDays), branch.CommitHash, remote, branch.Name)
+	}
+}
+
+// printPorcelainDryRun writes the deletions a dry run proposes for the candidates, local
+// ones first. Like --script, it leaves out active branches, which are only deleted on request:
+//
+//	local <safe|force> <commit> <name>
+//	remote <remote> <name on the remote>
+func printPorcelainDryRun(w io.Writer, format porcelainFormat, branches []types.AnalyzedBranch) {
+	for _, branch := range branches {
+		if !isDeletionCandidate(branch) {
+			continue
+		}
+		mode := "safe"
+		if branch.ForceDelete() {
+			mode = "force"
+		}
+		format.write(w, "local", mode, branch.CommitHash, branch.Name)
+	}
+	for _, branch := range branches {
+		if isDeletionCandidate(branch) && branch.Remote != "" {
+			format.write(w, "remote", branch.Remote, branch.RemoteBranchName())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+)
+
+// phaseProfile accumulates how long each phase of a run takes, and how many git commands it
+// runs, for --profile. A nil profile records nothing, so regular runs pay no cost for it.
+type phaseProfile struct {
+	start    time.Time
+	gitCalls atomic.Int64
+
+	mu     sync.Mutex
+	phases []string // In the order they were first entered
+	spent  map[string]time.Duration
+}
+
+// profile is the profile of the current run; nil unless --profile was given.
+var profile *phaseProfile
+
+// enableProfile starts profiling the run and counts every git command from now on.
+func enableProfile() {
+	profile = &phaseProfile{start: time.Now(), spent: make(map[string]time.Duration)}
+	run := gitcmd.Runner
+	gitcmd.Runner = func(ctx context.Context, args ...string) (string, error) {
+		profile.gitCalls.Add(1)
+		return run(ctx, args...)
+	}
+}
+
+// track starts timing phase and returns the function that stops it. A phase entered several
+// times, e.g. once per batch of branches, adds up.
+func (p *phaseProfile) track(phase string) func() {
+	if p == nil {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !slices.Contains(p.phases, phase) {
+			p.phases = append(p.phases, phase)
+		}
+		p.spent[phase] += time.Since(begin)
+	}
+}
+
+// write prints the time spent in each phase, the total time and the number of git commands.
+func (p *phaseProfile) write(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = fmt.Fprintln(w, "Profile:")
+	for _, phase := range p.phases {
+		_, _ = fmt.Fprintf(w, "  %-14s %v\n", phase, p.spent[phase].Round(time.Millisecond))
+	}
+	_, _ = fmt.Fprintf(w, "  %-14s %v\n", "total", time.Since(p.start).Round(time.Millisecond))
+	_, _ = fmt.Fprintf(w, "  %-14s %d\n", "git commands", p.gitCalls.Load())
+}
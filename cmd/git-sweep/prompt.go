@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/types"
+	"github.com/bral/git-sweep-go/pkg/sweep"
+)
+
+// isTerminal reports whether f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// usePlainPrompt reports whether the numbered-list prompts should replace the interactive UI:
+// when asked for with --no-tui, when stdin or stdout is not a terminal, or when TERM declares
+// a terminal without cursor control.
+func usePlainPrompt(cmd *cobra.Command) bool {
+	if noTUI, _ := cmd.Flags().GetBool("no-tui"); noTUI {
+		return true
+	}
+	return !isTerminal(os.Stdin) || !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb"
+}
+
+// parseSelection parses a list of 1-based numbers and ranges such as "1,3-5 7", or "all",
+// into sorted 0-based indices below count.
+func parseSelection(input string, count int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if strings.EqualFold(input, "all") {
+		indices := make([]int, count)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices, nil
+	}
+
+	selected := make([]bool, count)
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		first, last, isRange := strings.Cut(field, "-")
+		if !isRange {
+			last = first
+		}
+		from, fromErr := strconv.Atoi(first)
+		to, toErr := strconv.Atoi(last)
+		if fromErr != nil || toErr != nil || from < 1 || to > count || from > to {
+			return nil, fmt.Errorf("invalid selection %q (use numbers from 1 to %d)", field, count)
+		}
+		for i := from; i <= to; i++ {
+			selected[i-1] = true
+		}
+	}
+
+	var indices []int
+	for i, ok := range selected {
+		if ok {
+			indices = append(indices, i)
+		}
+	}
+	return indices, nil
+}
+
+// describeCandidate summarizes a candidate branch on one line for the numbered list.
+func describeCandidate(branch types.AnalyzedBranch, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", branch.Name, candidateDetails(branch, now))
+}
+
+// candidateDetails lists what matters about deleting a candidate branch: its merge status,
+// age, unpushed commits, remote and risk.
+func candidateDetails(branch types.AnalyzedBranch, now time.Time) string {
+	status := "unmerged, force delete"
+	if branch.IsMerged {
+		status = "merged"
+	}
+	if branch.IsMerged && branch.MergedInto != "" {
+		status = "merged into " + branch.MergedInto
+	}
+	if branch.IsMerged && branch.ForceDelete() {
+		status = fmt.Sprintf("merged by %s, force delete", branch.MergedBy)
+	}
+	text := fmt.Sprintf("%s, %d days old", status, int(now.Sub(branch.LastCommitDate).Hours()/24))
+	if label := branch.DuplicateLabel(); label != "" {
+		text += ", " + label
+	}
+	if label := branch.StackLabel(); label != "" {
+		text += ", " + label
+	}
+	if branch.CategoryRule != "" {
+		text += ", rule " + branch.CategoryRule
+	}
+	if n := branch.UnpushedCommits; n == 1 {
+		text += ", UNPUSHED: 1 commit exists nowhere else"
+	} else if n > 1 {
+		text += fmt.Sprintf(", UNPUSHED: %d commits exist nowhere else", n)
+	}
+	if branch.Remote != "" && branch.IsRemoteProtected {
+		text += ", remote " + branch.Remote + " protected"
+	} else if branch.Remote != "" {
+		text += ", on remote " + branch.Remote
+	}
+	if branch.Risk != "" {
+		text += ", " + strings.ToLower(string(branch.Risk))
+	}
+	return text
+}
+
+// promptLine writes the prompt and reads one line of input. io.EOF is returned only if the
+// input ended before anything was typed.
+func promptLine(in *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	_, _ = fmt.Fprint(out, prompt)
+	line, err := in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		_, _ = fmt.Fprintln(out)
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// confirm asks a yes/no question; anything but "y" or "yes", including the end of input, is no.
+func confirm(in *bufio.Reader, out io.Writer, question string) bool {
+	answer, err := promptLine(in, out, question+" [y/N]: ")
+	return err == nil && (strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"))
+}
+
+// stepAnswers explains the answers to confirmEach.
+const stepAnswers = "y - delete this branch, n - keep it, a - delete this and all remaining branches, " +
+	"q - keep this and all remaining branches"
+
+// confirmEach asks about each selected branch in turn, like 'git add -p': y deletes it, n keeps
+// it, a deletes it and all remaining ones, q keeps it and all remaining ones. The end of the
+// input keeps the remaining branches. It returns the branches to delete.
+func confirmEach(
+	in *bufio.Reader, out io.Writer, selected []types.AnalyzedBranch, deleteRemote bool, now time.Time,
+) []types.AnalyzedBranch {
+	approved := make([]types.AnalyzedBranch, 0, len(selected))
+	for i := 0; i < len(selected); {
+		branch := selected[i]
+		question := fmt.Sprintf("(%d/%d) Delete %s", i+1, len(selected), describeCandidate(branch, now))
+		if deleteRemote && branch.Remote != "" && !branch.IsRemoteProtected {
+			question += " and its remote branch"
+		}
+		answer, err := promptLine(in, out, question+" [y,n,a,q]? ")
+		if err != nil {
+			return approved
+		}
+		switch strings.ToLower(answer) {
+		case "y":
+			approved = append(approved, branch)
+		case "n":
+		case "a":
+			return append(approved, selected[i:]...)
+		case "q":
+			return approved
+		default:
+			_, _ = fmt.Fprintln(out, stepAnswers)
+			continue // Ask again about the same branch
+		}
+		i++
+	}
+	return approved
+}
+
+// runPlainPrompt is the line-based replacement for the interactive UI, used over dumb terminals
+// and when git-sweep is driven by another program. It lists the deletion candidates with numbers,
+// reads the selection and a confirmation from in, and performs the deletions. If in provides no
+// input at all, nothing is deleted and the list serves as a dry run.
+func runPlainPrompt(
+	ctx context.Context, in *bufio.Reader, out io.Writer, branches []types.AnalyzedBranch, maxDelete int,
+	bundleDir string,
+) ([]types.DeleteResult, error) {
+	candidates := sweep.Candidates(branches)
+	if len(candidates) == 0 {
+		_, _ = fmt.Fprintln(out, "-> No branches are suggested for deletion. Exiting.")
+		return nil, nil
+	}
+
+	now := time.Now()
+	_, _ = fmt.Fprintln(out, "Branches suggested for deletion:")
+	for i, branch := range candidates {
+		_, _ = fmt.Fprintf(out, "%4d) %s\n", i+1, describeCandidate(branch, now))
+	}
+	_, _ = fmt.Fprintln(out)
+
+	var selected []types.AnalyzedBranch
+	for selected == nil {
+		input, err := promptLine(in, out, "Branches to delete (e.g. 1,3-5 or all; empty to cancel): ")
+		if errors.Is(err, io.EOF) {
+			_, _ = fmt.Fprintln(out, "No input available; nothing was deleted (dry run).")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read selection: %w", err)
+		}
+		if input == "" {
+			_, _ = fmt.Fprintln(out, "Nothing selected; nothing was deleted.")
+			return nil, nil
+		}
+		indices, err := parseSelection(input, len(candidates))
+		if err == nil {
+			err = checkDeleteLimit(len(indices), maxDelete)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "%v\n", err)
+			continue
+		}
+		selected = make([]types.AnalyzedBranch, 0, len(indices))
+		for _, i := range indices {
+			selected = append(selected, candidates[i])
+		}
+	}
+
+	remoteCount := 0
+	selectedNames := make(map[string]bool, len(selected))
+	for _, branch := range selected {
+		selectedNames[branch.Name] = true
+	}
+	for _, branch := range selected {
+		if branch.Remote != "" && !branch.IsRemoteProtected {
+			remoteCount++
+		}
+		if label := branch.StackLabel(); label != "" {
+			_, _ = fmt.Fprintf(out, "Warning: %s is the %s; tools that track the stack may lose its base.\n",
+				branch.Name, label)
+		}
+		if !branch.DuplicatesMain && selectedNames[branch.DuplicateOf] {
+			_, _ = fmt.Fprintf(out, "Warning: %s is a duplicate of %s, which is selected too; deleting both loses "+
+				"the commits they share.\n", branch.Name, branch.DuplicateOf)
+		}
+	}
+	// Remote deletions need the network
+	deleteRemote := remoteCount > 0 && !appConfig.Offline &&
+		confirm(in, out, fmt.Sprintf("Also delete %d of them on the remote?", remoteCount))
+
+	if appConfig.InteractiveConfirm {
+		if selected = confirmEach(in, out, selected, deleteRemote, now); len(selected) == 0 {
+			_, _ = fmt.Fprintln(out, "No branch was confirmed; nothing was deleted.")
+			return nil, nil
+		}
+	} else {
+		question := fmt.Sprintf("Delete %d local", len(selected))
+		if deleteRemote {
+			question += fmt.Sprintf(" and %d remote", remoteCount)
+		}
+		if !confirm(in, out, question+" branches?") {
+			_, _ = fmt.Fprintln(out, "Cancelled; nothing was deleted.")
+			return nil, nil
+		}
+	}
+	plan := sweep.NewPlan(selected, sweep.PlanOptions{
+		Remote: deleteRemote, Archive: sweep.ArchiveMode(appConfig), BundleDir: bundleDir, TagPrefix: appConfig.TagPrefix,
+	})
+
+	results := sweep.Delete(ctx, "", plan, sweep.DeleteOptions{
+		RemoteWorkers: appConfig.RemoteDeleteWorkers, RemoteRateLimit: appConfig.RemoteRateLimit,
+		OnResult: func(res types.DeleteResult) {
+			if ctx.Err() == nil {
+				_, _ = fmt.Fprintln(out, describeResult(res))
+			}
+		},
+	})
+	if ctx.Err() != nil {
+		printInterruptedSummary(out, results)
+	} else {
+		printAuthHint(out, results)
+	}
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/report"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a shareable Markdown or HTML cleanup report",
+	Long: `The report command analyzes the repository like the interactive mode and writes a
+cleanup report listing every branch proposed for deletion with its age, author,
+merge status and the git commands a sweep would run. Attach it to a ticket
+before a team-wide sweep.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+		format, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+		if format != report.FormatMarkdown && format != report.FormatHTML {
+			fmt.Fprintf(os.Stderr, "Error: unsupported --format %q (supported: markdown, html)\n", format)
+			os.Exit(exitError)
+		}
+
+		branches, err := analyzeRepository(ctx, remoteName, fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		repoRoot, err := gitcmd.GetRepoRoot(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		archiveMode := ""
+		if appConfig.Archive {
+			archiveMode = cmp.Or(appConfig.ArchiveMode, gitcmd.ArchiveModeRef)
+		}
+		data := report.NewData(repoRoot, appConfig.PrimaryMainBranch, branches, archiveMode, time.Now())
+
+		out := os.Stdout
+		if outputPath != "" {
+			out, err = os.Create(outputPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not create report file: %v\n", err)
+				os.Exit(exitError)
+			}
+			defer func() { _ = out.Close() }()
+		}
+		if err := report.Render(out, format, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	reportCmd.Flags().String("format", report.FormatMarkdown, "Report format: markdown or html.")
+	reportCmd.Flags().StringP("output", "o", "", "Write the report to this file instead of stdout.")
+	reportCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// journalDeletions records every successful, non-dry-run deletion in the undo journal
+// so it can later be reverted with 'git-sweep restore'. Failures are reported as warnings.
+func journalDeletions(ctx context.Context, results []types.DeleteResult) {
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record deletions in undo journal: %v\n", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	entries := make([]state.JournalEntry, 0, len(results))
+	for _, res := range results {
+		if !res.Success || res.DeletedHash == "" {
+			continue
+		}
+		entries = append(entries, state.JournalEntry{
+			Time:     now,
+			Repo:     repoRoot,
+			Branch:   res.BranchName,
+			Hash:     res.DeletedHash,
+			IsRemote: res.IsRemote,
+			Remote:   res.RemoteName,
+		})
+	}
+
+	if err := state.AppendJournal(entries); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record deletions in undo journal: %v\n", err)
+		return
+	}
+	slog.Debug("Recorded deletions in undo journal", "count", len(entries))
+}
+
+// printJournal lists the journal entries recorded for the given repository.
+func printJournal(entries []state.JournalEntry, repoRoot string) {
+	found := false
+	for _, entry := range entries {
+		if entry.Repo != repoRoot {
+			continue
+		}
+		location := "local"
+		if entry.IsRemote {
+			location = "remote " + entry.Remote
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s  %-40s %s (%s)\n",
+			entry.Time.Local().Format("2006-01-02 15:04"), entry.Branch, entry.Hash, location)
+		found = true
+	}
+	if !found {
+		_, _ = fmt.Fprintln(os.Stdout, "No deletions recorded for this repository.")
+	}
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <branch>",
+	Short: "Recreate a branch previously deleted by git-sweep",
+	Long: `The restore command looks up the most recent deletion of <branch> in the
+undo journal for the current repository and recreates the local branch at
+the commit it pointed to before deletion (git branch <branch> <hash>).
+
+Use --list to show all deletions recorded for the current repository.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		repoRoot := requireRepoRoot(ctx)
+
+		entries, err := state.ReadJournal()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading undo journal: %v\n", err)
+			os.Exit(exitError)
+		}
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			printJournal(entries, repoRoot)
+			return
+		}
+
+		branchName := args[0]
+		entry, ok := state.FindLatest(entries, repoRoot, branchName)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: No recorded deletion of '%s' found for this repository.\n", branchName)
+			os.Exit(exitError)
+		}
+
+		if err := gitcmd.RestoreBranch(ctx, entry.Branch, entry.Hash); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Restored branch '%s' at %s (deleted %s).\n",
+			entry.Branch, entry.Hash, entry.Time.Local().Format("2006-01-02 15:04"))
+		if entry.IsRemote {
+			_, _ = fmt.Fprintf(os.Stdout, "To restore the remote branch as well, run: git push %s %s\n",
+				entry.Remote, entry.Branch)
+		}
+	},
+}
+
+func init() {
+	restoreCmd.Flags().Bool("list", false, "List deletions recorded for the current repository.")
+	rootCmd.AddCommand(restoreCmd)
+}
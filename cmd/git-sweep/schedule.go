@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/schedule"
+	"github.com/bral/git-sweep-go/internal/state"
+)
+
+// scheduledJob builds the job that runs git-sweep for repoRoot: --quick-status, or
+// 'git-sweep report' written to the schedule directory when report is set.
+func scheduledJob(cmd *cobra.Command, repoRoot string, interval schedule.Interval, report bool) (schedule.Job, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return schedule.Job{}, fmt.Errorf("could not determine the git-sweep executable: %w", err)
+	}
+	stateDir, err := state.Dir()
+	if err != nil {
+		return schedule.Job{}, err
+	}
+	dir := filepath.Join(stateDir, "schedule")
+	name := schedule.Name(repoRoot)
+
+	command := []string{executable}
+	if report {
+		command = append(command, "report", "--output", filepath.Join(dir, name+".md"))
+	} else {
+		command = append(command, "--quick-status")
+	}
+	command = append(command, "-C", repoRoot)
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		if abs, err := filepath.Abs(configPath); err == nil {
+			configPath = abs
+		}
+		command = append(command, "--config", configPath)
+	}
+	if cmd.Flags().Changed("remote") {
+		remoteName, _ := cmd.Flags().GetString("remote")
+		command = append(command, "--remote", remoteName)
+	}
+
+	return schedule.Job{
+		RepoRoot: repoRoot,
+		Interval: interval,
+		Command:  command,
+		LogPath:  filepath.Join(dir, name+".log"),
+	}, nil
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run git-sweep for this repository on a schedule",
+	Long: `The schedule command installs a recurring git-sweep run for the current
+repository with the system's scheduler: a launchd agent on macOS, a systemd
+user timer where systemd is running, and a crontab entry everywhere else.
+
+Scheduled runs never delete anything. They print a quick status, or write a
+cleanup report with --report, and append their output to a log file in the
+state directory.`,
+}
+
+var scheduleInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a recurring git-sweep run for the current repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		repoRoot := requireRepoRoot(ctx)
+		intervalValue, _ := cmd.Flags().GetString("interval")
+		schedulerValue, _ := cmd.Flags().GetString("scheduler")
+		report, _ := cmd.Flags().GetBool("report")
+		printOnly, _ := cmd.Flags().GetBool("print")
+
+		interval, err := schedule.ParseInterval(intervalValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		scheduler, err := schedule.ParseScheduler(schedulerValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		job, err := scheduledJob(cmd, repoRoot, interval, report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if printOnly {
+			_, _ = fmt.Fprint(os.Stdout, job.Definition(scheduler))
+			return
+		}
+
+		location, err := schedule.Install(ctx, scheduler, job)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Installed a %s git-sweep run for %s (%s: %s).\n",
+			interval, repoRoot, scheduler, location)
+		_, _ = fmt.Fprintf(os.Stdout, "Output is appended to %s.\n", job.LogPath)
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the recurring git-sweep run for the current repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := cmd.Context()
+		repoRoot := requireRepoRoot(ctx)
+		schedulerValue, _ := cmd.Flags().GetString("scheduler")
+		scheduler, err := schedule.ParseScheduler(schedulerValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+
+		removed, err := schedule.Remove(ctx, scheduler, repoRoot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !removed {
+			_, _ = fmt.Fprintf(os.Stdout, "No %s git-sweep run is installed for %s.\n", scheduler, repoRoot)
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Removed the scheduled git-sweep run for %s.\n", repoRoot)
+	},
+}
+
+func init() {
+	scheduleInstallCmd.Flags().String("interval", string(schedule.Weekly), "How often to run: daily, weekly or monthly.")
+	scheduleInstallCmd.Flags().Bool("report", false,
+		"Write a Markdown cleanup report on every run instead of a quick status.")
+	scheduleInstallCmd.Flags().Bool("print", false, "Print the scheduler entry instead of installing it.")
+	for _, c := range []*cobra.Command{scheduleInstallCmd, scheduleRemoveCmd} {
+		c.Flags().String("scheduler", "", "Scheduler to use: cron, launchd or systemd (default: detected).")
+	}
+	scheduleCmd.AddCommand(scheduleInstallCmd, scheduleRemoveCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
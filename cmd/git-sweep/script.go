@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// printDryRunScript writes the exact git commands that would delete every candidate branch,
+// one per line, so the plan can be reviewed and later piped to sh. Local deletions come first,
+// preceded by the archive, tag and bundle commands when those are enabled, followed by remote
+// deletions. Active branches are never included.
+func printDryRunScript(w io.Writer, branches []types.AnalyzedBranch, archiveMode, tagPrefix, bundleDir string) {
+	candidates := make([]types.AnalyzedBranch, 0, len(branches))
+	for _, branch := range branches {
+		if isDeletionCandidate(branch) {
+			candidates = append(candidates, branch)
+		}
+	}
+
+	now := time.Now()
+	created := make(map[string]bool) // Bundle directories the script already creates
+	for _, branch := range candidates {
+		target := branch.CommitHash
+		if target == "" {
+			target = branch.Name
+		}
+		if archiveMode != "" {
+			_, _ = fmt.Fprintln(w, gitcmd.ShellCommand(gitcmd.ArchiveArgs(archiveMode, branch.Name, target)))
+		}
+		if tagPrefix != "" {
+			_, _ = fmt.Fprintln(w, gitcmd.ShellCommand(gitcmd.TagArgs(tagPrefix+branch.Name, target)))
+		}
+		if bundleDir != "" {
+			path := gitcmd.BundlePath(bundleDir, branch.Name, now)
+			if dir := filepath.Dir(path); !created[dir] {
+				_, _ = fmt.Fprintln(w, gitcmd.ShellLine([]string{"mkdir", "-p", dir}))
+				created[dir] = true
+			}
+			_, _ = fmt.Fprintln(w, gitcmd.ShellCommand(gitcmd.BundleArgs(path, branch.Name)))
+		}
+		_, _ = fmt.Fprintln(w, gitcmd.ShellCommand(gitcmd.DeleteArgs(gitcmd.BranchToDelete{
+			Name: branch.Name, IsMerged: branch.IsMerged, MergedBy: branch.MergedBy,
+		})))
+	}
+	for _, branch := range candidates {
+		if branch.Remote == "" || branch.IsRemoteProtected {
+			continue
+		}
+		_, _ = fmt.Fprintln(w, gitcmd.ShellCommand(gitcmd.DeleteArgs(gitcmd.BranchToDelete{
+			Name: branch.RemoteBranchName(), IsRemote: true, Remote: branch.Remote,
+		})))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/stats"
+)
+
+// printStatsTable renders the report as human-readable tables.
+func printStatsTable(w io.Writer, report stats.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(tw, "Branches: %d, candidates for deletion: %d\n\n", report.Total, report.Candidates)
+
+	labels := make([]string, 0, len(stats.AgeBuckets))
+	for _, bucket := range stats.AgeBuckets {
+		labels = append(labels, bucket.Label)
+	}
+	_, _ = fmt.Fprintf(tw, "CATEGORY\tCOUNT\tMEDIAN AGE\tMAX AGE\t%s\n", strings.Join(labels, "\t"))
+	for _, category := range report.Categories {
+		counts := make([]string, 0, len(category.AgeBuckets))
+		for _, bucket := range category.AgeBuckets {
+			counts = append(counts, fmt.Sprint(bucket.Count))
+		}
+		median, maxAge := "-", "-"
+		if category.Count > 0 {
+			median, maxAge = fmt.Sprintf("%dd", category.MedianAgeDays), fmt.Sprintf("%dd", category.MaxAgeDays)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", category.Category, category.Count,
+			median, maxAge, strings.Join(counts, "\t"))
+	}
+
+	if len(report.Oldest) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nOLDEST BRANCH\tCATEGORY\tAGE\tAUTHOR")
+		for _, branch := range report.Oldest {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%dd\t%s\n", branch.Name, branch.Category, branch.AgeDays, branch.Author)
+		}
+	}
+
+	if len(report.Authors) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nAUTHOR\tBRANCHES\tCANDIDATES")
+		for _, author := range report.Authors {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", author.Author, author.Branches, author.Candidates)
+		}
+	}
+	return tw.Flush()
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print branch hygiene metrics for the repository",
+	Long: `The stats command analyzes the repository like the interactive mode and prints
+branch counts and age distributions per category, the oldest branches, and
+branch counts per last-commit author, without starting the interactive UI.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported --output %q (supported: table, json)\n", output)
+			os.Exit(exitError)
+		}
+
+		branches, err := analyzeRepository(cmd.Context(), remoteName, fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		report := stats.Compute(branches, time.Now())
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false) // Keep bucket labels such as "<30d" readable
+			err = encoder.Encode(report)
+		} else {
+			err = printStatsTable(os.Stdout, report)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	statsCmd.Flags().StringP("output", "o", "table", "Output format: table or json.")
+	statsCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	rootCmd.AddCommand(statsCmd)
+}
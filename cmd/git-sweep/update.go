@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	versionpkg "github.com/bral/git-sweep-go/internal/version"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Replace git-sweep with the latest release",
+	Long: `The update command downloads the release archive for this operating system
+and architecture from the latest GitHub release, verifies it against the
+release's checksums.txt, and atomically replaces the running binary with
+the one it contains. Nothing is replaced if this is already the latest
+version or if the download cannot be verified.
+
+With update_channel = "prerelease" in the configuration, the newest
+prerelease is installed when it is newer than the latest stable release.
+
+Use --check to see whether an update is available and what changed in it
+without installing anything.
+
+Binaries installed with 'go install' can be updated this way as well.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if appConfig.Offline {
+			fmt.Fprintln(os.Stderr, "Error: git-sweep update downloads the release and is not available in offline mode.")
+			os.Exit(exitError)
+		}
+		client, err := httpclient.New(appConfig, versionpkg.DownloadTimeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		updater := versionpkg.Updater{Client: client, Channel: appConfig.UpdateChannel}
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			if err := printAvailableUpdate(cmd.Context(), updater); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			return
+		}
+		result, err := updater.Update(cmd.Context(), version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !result.Updated {
+			_, _ = fmt.Fprintf(os.Stdout, "git-sweep %s is the latest version.\n", result.Previous)
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Updated git-sweep from %s to %s.\n", result.Previous, result.Latest)
+	},
+}
+
+// checkForUpdate runs the daily version check and returns the notice announcing a newer
+// release, or "" if there is none. Problems never interrupt the run.
+func checkForUpdate(ctx context.Context) string {
+	client, err := httpclient.New(appConfig, versionpkg.CheckTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping the version check: %v\n", err)
+		return ""
+	}
+	hasUpdate, latestVersion, _, err := versionpkg.Check(ctx, client, version, appConfig.UpdateChannel)
+	if err != nil {
+		// Log error in debug mode, but don't interrupt normal operation
+		slog.Debug("Version check failed", "error", err)
+		return ""
+	}
+	if !hasUpdate {
+		return ""
+	}
+	return versionpkg.UpdateNotice(version, latestVersion)
+}
+
+// printAvailableUpdate reports whether updater would install a newer release and, if so,
+// what changed in it.
+func printAvailableUpdate(ctx context.Context, updater versionpkg.Updater) error {
+	current := versionpkg.GetVersionFromBuildInfo(version)
+	release, newer, err := updater.Latest(ctx, current)
+	if err != nil {
+		return err
+	}
+	if !newer {
+		_, _ = fmt.Fprintf(os.Stdout, "git-sweep %s is the latest version.\n", current)
+		return nil
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "git-sweep %s is available (you have %s).\n\n", release.TagName, current)
+	if versionpkg.PrintChangelog(os.Stdout, release.Body) {
+		_, _ = fmt.Fprintln(os.Stdout)
+	}
+	_, _ = fmt.Fprintln(os.Stdout, "Run 'git-sweep update' to install it.")
+	return nil
+}
+
+func init() {
+	updateCmd.Flags().Bool("check", false, "Show whether an update is available and what changed, without installing it.")
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bral/git-sweep-go/internal/analyze"
+	"github.com/bral/git-sweep-go/internal/types"
+	"github.com/bral/git-sweep-go/pkg/sweep"
+)
+
+// explainBranch analyzes the named local branch and returns the decision trail for it.
+func explainBranch(
+	ctx context.Context, remoteName, branchName string, fetch bool,
+) (types.AnalyzedBranch, []analyze.Step, error) {
+	if notice := offlineNotice(ctx); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	repo, err := sweep.Gather(ctx, "", sweepOptions(remoteName, fetch))
+	if err != nil {
+		return types.AnalyzedBranch{}, nil, err
+	}
+	return repo.Explain(ctx, branchName)
+}
+
+// printExplanation writes the decision trail of a branch, one check per line.
+func printExplanation(w io.Writer, branch types.AnalyzedBranch, steps []analyze.Step) error {
+	_, _ = fmt.Fprintf(w, "Branch %s (%.7s", branch.Name, branch.CommitHash)
+	if branch.AuthorEmail != "" {
+		_, _ = fmt.Fprintf(w, ", last commit by %s", branch.AuthorEmail)
+	}
+	_, _ = fmt.Fprintln(w, ")")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, step := range steps {
+		_, _ = fmt.Fprintf(tw, "  %s:\t%s\n", step.Check, step.Result)
+	}
+	return tw.Flush()
+}
+
+var whyCmd = &cobra.Command{
+	Use:   "why <branch>",
+	Short: "Explain how a branch was categorized",
+	Long: `The why command analyzes a single local branch exactly like the interactive
+mode and prints every decision that led to its category: protection sources,
+the ancestry and 'git cherry' merge checks, pull request state, the age
+computation and threshold, snoozes, and the final category.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		remoteName, _ := cmd.Flags().GetString("remote")
+		fetch, _ := cmd.Flags().GetBool("fetch")
+
+		branch, steps, err := explainBranch(cmd.Context(), remoteName, args[0], fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := printExplanation(os.Stdout, branch, steps); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	},
+}
+
+func init() {
+	whyCmd.Flags().Bool("fetch", false, "Fetch and prune the remote before analyzing.")
+	rootCmd.AddCommand(whyCmd)
+}
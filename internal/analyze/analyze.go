@@ -2,8 +2,12 @@
 package analyze
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bral/git-sweep-go/internal/config" // Use the actual config package
@@ -13,7 +17,8 @@ import (
 
 // Branches categorizes branches based on merge status, age, and protection rules.
 // It takes raw branch info, a map indicating which branches are merged into the primary main branch,
-// the application configuration, and the name of the currently checked-out branch.
+// the application configuration, and the name of the currently checked-out branch, which is
+// empty when HEAD is detached: no branch is protected as the current one then.
 // It now also performs a 'git cherry -v' check for non-merged, non-protected branches.
 func Branches(
 	ctx context.Context, branches []types.BranchInfo, mergedStatus map[string]bool,
@@ -21,71 +26,396 @@ func Branches(
 ) ([]types.AnalyzedBranch, error) {
 	analyzedBranches := make([]types.AnalyzedBranch, 0, len(branches))
 	now := time.Now()
-	ageThreshold := time.Duration(cfg.AgeDays) * 24 * time.Hour
 
-	// The ProtectedBranchMap is assumed to be populated by LoadConfig now.
-	// Ensure it's not nil just in case, though LoadConfig should handle this.
-	protectedMap := cfg.ProtectedBranchMap
-	if protectedMap == nil {
-		protectedMap = make(map[string]bool)
+	for _, branch := range branches {
+		analyzed, err := analyzeBranch(ctx, branch, mergedStatus, cfg, currentBranchName, now, nil)
+		if err != nil {
+			return nil, err
+		}
+		analyzedBranches = append(analyzedBranches, analyzed)
 	}
 
-	// Default to primary main branch if currentBranchName is empty or not provided
-	// This helps in scenarios like CI where HEAD might be detached.
-	if currentBranchName == "" {
-		currentBranchName = cfg.PrimaryMainBranch
+	return analyzedBranches, nil
+}
+
+// MarkDuplicates sets DuplicateOf on branches whose tip is the same commit as another branch,
+// since deleting such a copy loses nothing while the other branch is kept. Branches at
+// mainHash duplicate the primary main branch. Of other branches sharing a tip, the one most
+// worth keeping stays unmarked: a protected or checked-out branch first, otherwise the first
+// by name. That one may be a candidate too, so the confirmation warns when both are selected.
+func MarkDuplicates(branches []types.BranchInfo, mainHash string, cfg config.Config, currentBranchName string) {
+	byTip := make(map[string][]int)
+	for i, branch := range branches {
+		if branch.CommitHash != "" {
+			byTip[branch.CommitHash] = append(byTip[branch.CommitHash], i)
+		}
 	}
 
-	for _, branch := range branches {
-		// Check if explicitly protected by config OR if it's the current branch OR if it's the primary main branch
-		isCurrent := branch.Name == currentBranchName
-		isProtected := protectedMap[branch.Name] || isCurrent || branch.Name == cfg.PrimaryMainBranch
-
-		isMerged := mergedStatus[branch.Name]
-
-		// If not merged by ancestry check and not protected, perform the 'git cherry -v' check
-		if !isMerged && !isProtected {
-			var cherryErr error
-			// Use the new gitcmd.AreChangesIncluded function.
-			isMerged, cherryErr = gitcmd.AreChangesIncluded(ctx, cfg.PrimaryMainBranch, branch.Name)
-			if cherryErr != nil {
-				// Log the error and treat the branch as not merged for safety.
-				// We return the error to halt processing, as a failed check is ambiguous.
-				// Consider changing this to log and continue if partial results are acceptable.
-				// TODO: Implement structured logging if desired instead of just returning error.
-				// Return error to signal failure during analysis
-				return nil, fmt.Errorf("failed git cherry check for branch %q: %w", branch.Name, cherryErr)
-				// Alternative: Log and continue, treating as unmerged:
-				// isMerged = false
+	// Protected and checked-out branches are never deleted, so they are kept over the others
+	keepRank := func(branch types.BranchInfo) int {
+		if cfg.IsProtectedName(branch.Name) || branch.Name == currentBranchName || branch.WorktreePath != "" {
+			return 0
+		}
+		return 1
+	}
+	for tip, indices := range byTip {
+		if tip == mainHash {
+			for _, i := range indices {
+				if branches[i].Name != cfg.PrimaryMainBranch {
+					branches[i].DuplicateOf = cfg.CompareTarget()
+					branches[i].DuplicatesMain = true
+				}
 			}
+			continue
+		}
+		if len(indices) < 2 {
+			continue
+		}
+		kept := slices.MinFunc(indices, func(a, b int) int {
+			return cmp.Or(cmp.Compare(keepRank(branches[a]), keepRank(branches[b])),
+				strings.Compare(branches[a].Name, branches[b].Name))
+		})
+		for _, i := range indices {
+			if i != kept {
+				branches[i].DuplicateOf = branches[kept].Name
+			}
+		}
+	}
+}
+
+// Step is one check in the decision trail of a branch, as reported by Explain.
+type Step struct {
+	Check  string // What was checked, e.g. "Protection"
+	Result string // The outcome and the facts it is based on
+}
+
+// trail records the decision steps for Explain. A nil trail records nothing, so the
+// regular analysis pays no cost for it.
+type trail struct {
+	steps []Step
+}
+
+func (t *trail) add(check, format string, a ...any) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, Step{Check: check, Result: fmt.Sprintf(format, a...)})
+}
+
+// Explain analyzes a single branch exactly like Branches and returns the result together with
+// the decision trail that led to its category.
+func Explain(
+	ctx context.Context, branch types.BranchInfo, mergedStatus map[string]bool,
+	cfg config.Config, currentBranchName string,
+) (types.AnalyzedBranch, []Step, error) {
+	t := &trail{}
+	analyzed, err := analyzeBranch(ctx, branch, mergedStatus, cfg, currentBranchName, time.Now(), t)
+	return analyzed, t.steps, err
+}
+
+// analyzeBranch categorizes one branch, recording each decision in t when it is not nil.
+func analyzeBranch(
+	ctx context.Context, branch types.BranchInfo, mergedStatus map[string]bool,
+	cfg config.Config, currentBranchName string, now time.Time, t *trail,
+) (types.AnalyzedBranch, error) {
+	// Check if protected by config (exact name or pattern) OR if it's the current branch OR if it's the
+	// primary main branch OR if it's checked out in a worktree (deleting it would fail anyway)
+	isCurrent := branch.Name == currentBranchName
+	inWorktree := branch.WorktreePath != ""
+	isProtected := cfg.IsProtectedName(branch.Name) || isCurrent || branch.Name == cfg.PrimaryMainBranch || inWorktree
+	t.add("Protection", "%s", describeProtection(branch, cfg, isCurrent))
+
+	target := cfg.CompareTarget()
+	isMerged := mergedStatus[branch.Name]
+	var mergedBy types.MergeMethod
+	if isMerged {
+		mergedBy = types.MergedByAncestry
+	}
+	t.add("Merged (ancestry)", "%s", outcome(isMerged,
+		"yes, the tip is reachable from "+target, "no, the tip is not reachable from "+target))
+	// With merged_into_protected, a merge into another protected branch such as develop counts too
+	if !isMerged && branch.MergedInto != "" {
+		isMerged, mergedBy = true, types.MergedByAncestry
+		t.add("Merged (protected)", "yes, the tip is reachable from protected branch %s", branch.MergedInto)
+	}
+
+	// A pull request merged on the hosting provider counts as merged even when
+	// squash or rebase merges defeat local detection.
+	pr := branch.PullRequest
+	if !isMerged && pr != nil && pr.State == types.PullRequestMerged {
+		isMerged, mergedBy = true, types.MergedByPullRequest
+	}
+	// A pull request closed without merging marks the branch as abandoned.
+	isAbandoned := pr != nil && pr.State == types.PullRequestClosed
+	if pr != nil {
+		t.add("Pull request", "#%d is %s", pr.Number, pr.State)
+	} else if cfg.Provider != "" {
+		t.add("Pull request", "none found on %s", cfg.Provider)
+	}
+
+	// Branches last committed to by someone else are never suggested when only_authors is set
+	isOtherAuthor := !authorAllowed(cfg.OnlyAuthors, branch.AuthorEmail)
+	if len(cfg.OnlyAuthors) > 0 {
+		t.add("Author", "%s", outcome(isOtherAuthor,
+			branch.AuthorEmail+" is not listed in only_authors", branch.AuthorEmail+" is listed in only_authors"))
+	}
+
+	// If not merged by ancestry check and not protected, perform the 'git cherry -v' check
+	ageDays := daysSince(now, branch.LastCommitDate)
+	var ranMergeCheck bool
+	switch {
+	case !isMerged && !isProtected && !isOtherAuthor && branch.HasCachedMergeCheck:
+		// The checks only depend on the commits, so an earlier outcome for the same tips still holds
+		isMerged, mergedBy = branch.CachedMergedBy != "", branch.CachedMergedBy
+		t.add("Merged (cached)", "%s, as found by an earlier run for the same commits", outcome(isMerged,
+			"yes, by "+string(mergedBy), "no, neither git cherry nor git diff found it merged into "+target))
+	case !isMerged && !isProtected && !isOtherAuthor && ageDays < cfg.MergeCheckMinDays:
+		// The checks are slow, and young unmerged branches are rarely suggested anyway
+		t.add("Merged (git cherry)", "skipped, the last commit is %d days old, younger than merge_check_min_days %d",
+			ageDays, cfg.MergeCheckMinDays)
+	case !isMerged && !isProtected && !isOtherAuthor:
+		ranMergeCheck = true
+		var cherryErr error
+		// Use the new gitcmd.AreChangesIncluded function.
+		isMerged, cherryErr = gitcmd.AreChangesIncluded(ctx, target, branch.Name)
+		if cherryErr != nil {
+			// Log the error and treat the branch as not merged for safety.
+			// We return the error to halt processing, as a failed check is ambiguous.
+			// Consider changing this to log and continue if partial results are acceptable.
+			// Return error to signal failure during analysis
+			return types.AnalyzedBranch{}, fmt.Errorf("failed git cherry check for branch %q: %w", branch.Name, cherryErr)
+			// Alternative: Log and continue, treating as unmerged:
+			// isMerged = false
+		}
+		t.add("Merged (git cherry)", "%s", outcome(isMerged,
+			"yes, every commit has an equivalent patch in "+target,
+			"no, some commits have no equivalent patch in "+target))
+		if isMerged {
+			mergedBy = types.MergedByCherry
+			break
+		}
+		// Branches whose changes were dropped or rewritten while they were incorporated leave
+		// no difference behind, even though neither check above recognizes their commits
+		var diffErr error
+		isMerged, diffErr = gitcmd.HasNoChanges(ctx, target, branch.Name)
+		if diffErr != nil {
+			return types.AnalyzedBranch{}, fmt.Errorf("failed git diff check for branch %q: %w", branch.Name, diffErr)
+		}
+		if isMerged {
+			mergedBy = types.MergedByNoChanges
+		}
+		t.add("Merged (no changes)", "%s", outcome(isMerged,
+			"yes, the branch changes nothing since it diverged from "+target,
+			"no, the branch has changes since it diverged from "+target))
+	case isMerged:
+		t.add("Merged (git cherry)", "skipped, already merged")
+	default:
+		t.add("Merged (git cherry)", "skipped, branch is protected or belongs to another author")
+	}
+
+	analyzed := types.AnalyzedBranch{
+		BranchInfo: branch,
+		IsMerged:   isMerged, // Use the potentially updated status
+		MergedBy:   mergedBy,
+		// Only checks that ran are worth recording for later runs
+		RanMergeCheck: ranMergeCheck,
+		IsProtected:   isProtected,
+		IsCurrent:     isCurrent, // Set the new flag
+		// Calculate IsOldByAge based on config and last commit date, in whole days
+		// (matching the day counts shown to the user); age rules can override the threshold per branch
+		IsOldByAge: ageDays > cfg.AgeDaysFor(branch.Name),
+		// A snooze only applies until it expires
+		IsSnoozed:     branch.Snoozed && (branch.SnoozedUntil.IsZero() || now.Before(branch.SnoozedUntil)),
+		IsOtherAuthor: isOtherAuthor,
+		// Remote protection only matters when there is a remote branch to delete
+		IsRemoteProtected: branch.Remote != "" && (branch.ServerProtected || cfg.IsRemoteProtectedName(branch.Name)),
+	}
+	t.add("Age", "%s", describeAge(branch, cfg, ageDays, analyzed.IsOldByAge))
+	if branch.DuplicateOf != "" {
+		t.add("Duplicate", "%s, the tip is the same commit", branch.DuplicateLabel())
+	}
+	if label := branch.StackLabel(); label != "" {
+		t.add("Stacked", "%s; deleting it can confuse tools that track the stack", label)
+	}
+
+	// Recently merged branches are kept around for the configured grace period
+	analyzed.InMergeGrace = isMerged && cfg.MergedAgeDays > 0 &&
+		ageDays <= cfg.MergedAgeDays
+	if isMerged && cfg.MergedAgeDays > 0 {
+		t.add("Merge grace period", "%s within merged_age_days %d",
+			outcome(analyzed.InMergeGrace, "still", "no longer"), cfg.MergedAgeDays)
+	}
+	// A teammate's recent pushes keep a shared branch active, however stale the local copy is
+	if branch.RemoteCommitDate.After(branch.LastCommitDate) {
+		remoteAgeDays := daysSince(now, branch.RemoteCommitDate)
+		analyzed.IsRemoteActive = remoteAgeDays <= cfg.AgeDaysFor(branch.Name)
+		t.add("Remote activity", "%s last changed %s, %d days ago, after the local branch; %s", branch.Upstream,
+			branch.RemoteCommitDate.Local().Format("2006-01-02"), remoteAgeDays,
+			outcome(analyzed.IsRemoteActive, "recently active", "not recent"))
+	}
+	if branch.Snoozed {
+		until := "indefinitely"
+		if !branch.SnoozedUntil.IsZero() {
+			until = "until " + branch.SnoozedUntil.Local().Format("2006-01-02")
 		}
+		t.add("Snooze", "snoozed %s, %s", until, outcome(analyzed.IsSnoozed, "in effect", "expired"))
+	}
 
-		analyzed := types.AnalyzedBranch{
-			BranchInfo:  branch,
-			IsMerged:    isMerged, // Use the potentially updated status
-			IsProtected: isProtected,
-			IsCurrent:   isCurrent, // Set the new flag
-			// Calculate IsOldByAge based on config and last commit date
-			IsOldByAge: now.Sub(branch.LastCommitDate) > ageThreshold,
-		}
-
-		// Determine Category using a switch for clarity
-		switch {
-		case analyzed.IsProtected:
-			analyzed.Category = types.CategoryProtected
-		case analyzed.IsMerged:
-			// Merged branches (including those detected by 'git cherry') are candidates for deletion regardless of age
-			analyzed.Category = types.CategoryMergedOld
-		case analyzed.IsOldByAge:
-			// Unmerged but old branches are candidates
-			analyzed.Category = types.CategoryUnmergedOld
-		default:
-			// Neither protected, merged (by either method), nor old - considered active
+	// Determine Category using a switch for clarity
+	var reason string
+	switch {
+	case analyzed.IsProtected:
+		analyzed.Category = types.CategoryProtected
+		reason = "protected branches are never suggested"
+	case analyzed.IsSnoozed:
+		// Snoozed branches are kept out of the suggestions until the snooze expires
+		analyzed.Category = types.CategoryActive
+		reason = "snoozed"
+	case analyzed.IsOtherAuthor:
+		// Someone else's branch; leave it for them to clean up
+		analyzed.Category = types.CategoryActive
+		reason = "last commit by another author"
+	case analyzed.InMergeGrace:
+		// Merged, but not old enough yet to be suggested
+		analyzed.Category = types.CategoryActive
+		reason = "merged recently, within the grace period"
+	case analyzed.IsRemoteActive:
+		// Someone pushed to the remote branch recently; deleting it could lose their work
+		analyzed.Category = types.CategoryActive
+		reason = "remote branch changed recently"
+	case analyzed.IsMerged:
+		// Merged branches (including those detected by 'git cherry') are candidates for deletion regardless of age
+		analyzed.Category = types.CategoryMergedOld
+		reason = "merged; suggested for deletion"
+	case branch.DuplicateOf != "":
+		// Another branch keeps the same commit, so deleting this one alone loses nothing
+		analyzed.Category = types.CategoryUnmergedOld
+		reason = branch.DuplicateLabel() + "; suggested for deletion"
+	case analyzed.IsOldByAge, isAbandoned:
+		// Unmerged but old (or abandoned via a closed pull request) branches are candidates
+		analyzed.Category = types.CategoryUnmergedOld
+		reason = "unmerged but old or abandoned; suggested for deletion"
+	default:
+		// Neither protected, merged (by either method), nor old - considered active
+		analyzed.Category = types.CategoryActive
+		reason = "unmerged and recently active"
+	}
+	if rule, ok := cfg.RuleFor(branch.Name); ok {
+		reason = applyRule(&analyzed, rule, reason)
+		t.add("Rule", "rules entry %q: %s", rule.Match, rule.Action)
+	}
+	// No branch is suggested within min_age_days of its last commit, whatever made it a candidate
+	if cfg.MinAgeDays > 0 {
+		young := ageDays < cfg.MinAgeDays
+		t.add("Minimum age", "%d days old, %s min_age_days %d", ageDays, outcome(young, "younger than", "not younger than"),
+			cfg.MinAgeDays)
+		if young && (analyzed.Category == types.CategoryMergedOld || analyzed.Category == types.CategoryUnmergedOld) {
 			analyzed.Category = types.CategoryActive
+			analyzed.IsTooRecent = true
+			reason = fmt.Sprintf("younger than min_age_days %d; never suggested", cfg.MinAgeDays)
 		}
+	}
+	if analyzed.IsRemoteProtected {
+		t.add("Remote protection", "%s/%s is kept (%s)", branch.Remote, branch.Name, outcome(branch.ServerProtected,
+			"protected on the hosting server", "matches protected_remote_patterns"))
+	}
+	t.add("Category", "%s (%s)", analyzed.Category, reason)
 
-		analyzedBranches = append(analyzedBranches, analyzed)
+	slog.Debug("Analyzed branch", "branch", branch.Name, "category", analyzed.Category,
+		"merged", analyzed.IsMerged, "old", analyzed.IsOldByAge, "protected", analyzed.IsProtected,
+		"snoozed", analyzed.IsSnoozed, "other_author", analyzed.IsOtherAuthor, "merge_grace", analyzed.InMergeGrace,
+		"too_recent", analyzed.IsTooRecent, "remote_active", analyzed.IsRemoteActive)
+	return analyzed, nil
+}
+
+// applyRule overrides the category of the analyzed branch with a custom rule and returns the
+// reason for its category. Rules cannot make the primary main branch, the current branch or a
+// branch checked out in a worktree a candidate, and snoozed branches stay snoozed.
+func applyRule(analyzed *types.AnalyzedBranch, rule config.Rule, reason string) string {
+	category := analyzed.Category
+	switch rule.Action {
+	case config.RuleProtect:
+		analyzed.IsProtected = true
+		category = types.CategoryProtected
+	case config.RuleKeep:
+		if category == types.CategoryMergedOld || category == types.CategoryUnmergedOld {
+			category = types.CategoryActive
+		}
+	case config.RuleSuggest:
+		if category == types.CategoryActive && !analyzed.IsSnoozed {
+			category = types.CategoryUnmergedOld
+			if analyzed.IsMerged {
+				category = types.CategoryMergedOld
+			}
+		}
+	}
+	if category == analyzed.Category {
+		return reason
 	}
+	analyzed.Category = category
+	analyzed.CategoryRule = rule.Match
+	return fmt.Sprintf("rules entry %q: %s", rule.Match, rule.Action)
+}
 
-	return analyzedBranches, nil
+// outcome returns ifTrue or ifFalse depending on ok.
+func outcome(ok bool, ifTrue, ifFalse string) string {
+	if ok {
+		return ifTrue
+	}
+	return ifFalse
+}
+
+// describeProtection lists every reason the branch is protected.
+func describeProtection(branch types.BranchInfo, cfg config.Config, isCurrent bool) string {
+	var sources []string
+	if cfg.ProtectedBranchMap[branch.Name] {
+		sources = append(sources, "listed in protected_branches")
+	}
+	for _, re := range cfg.ProtectedRegexps {
+		if re.MatchString(branch.Name) {
+			sources = append(sources, fmt.Sprintf("matches protected_patterns %q", re.String()))
+		}
+	}
+	if branch.Name == cfg.PrimaryMainBranch {
+		sources = append(sources, "primary main branch")
+	}
+	if isCurrent {
+		sources = append(sources, "currently checked out")
+	}
+	if branch.WorktreePath != "" {
+		sources = append(sources, "checked out in worktree "+branch.WorktreePath)
+	}
+	if len(sources) == 0 {
+		return "not protected"
+	}
+	return "protected: " + strings.Join(sources, "; ")
+}
+
+// describeAge explains the age computation for the branch.
+func describeAge(branch types.BranchInfo, cfg config.Config, ageDays int, isOld bool) string {
+	threshold := fmt.Sprintf("age_days %d", cfg.AgeDays)
+	if rule, ok := cfg.AgeRuleFor(branch.Name); ok {
+		threshold = fmt.Sprintf("age_rules %q: %d", rule.Pattern, rule.AgeDays)
+	}
+	return fmt.Sprintf("last commit %s, %d days ago; threshold %s days; %s",
+		branch.LastCommitDate.Local().Format("2006-01-02"), ageDays, threshold, outcome(isOld, "old", "not old"))
+}
+
+// authorAllowed reports whether a branch whose last commit was authored by email may be suggested.
+// An empty allow list permits every author; emails are compared case-insensitively.
+func authorAllowed(onlyAuthors []string, email string) bool {
+	if len(onlyAuthors) == 0 {
+		return true
+	}
+	for _, allowed := range onlyAuthors {
+		if strings.EqualFold(strings.TrimSpace(allowed), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysSince returns the number of whole days elapsed between t and now.
+func daysSince(now, t time.Time) int {
+	return int(now.Sub(t).Hours() / 24)
 }
@@ -4,6 +4,9 @@ import (
 	"context" // Added for mocking
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,14 +15,18 @@ import (
 	"github.com/bral/git-sweep-go/internal/types"
 )
 
-// Helper to setup mock for AreChangesIncluded
+// Helper to setup mock for AreChangesIncluded. Branches not recognized by it are treated as
+// having changes, so the diff check does not need a repository either.
 func setupAreChangesIncludedMock(
 	_ *testing.T, mockFunc func(ctx context.Context, upstream, head string) (bool, error),
 ) func() {
 	originalFunc := gitcmd.AreChangesIncluded
+	originalHasNoChanges := gitcmd.HasNoChanges
 	gitcmd.AreChangesIncluded = mockFunc
+	gitcmd.HasNoChanges = func(_ context.Context, _, _ string) (bool, error) { return false, nil }
 	return func() {
 		gitcmd.AreChangesIncluded = originalFunc
+		gitcmd.HasNoChanges = originalHasNoChanges
 	}
 }
 
@@ -172,6 +179,29 @@ func TestAnalyzeBranches(t *testing.T) {
 				types.CategoryUnmergedOld: 0,
 			},
 		},
+		{
+			name: "Protected Patterns",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{Name: "release/1.0", LastCommitDate: ninetyDaysAgo, CommitHash: "releaseHash"}, // Pattern match
+				{Name: "hotfix/42", LastCommitDate: ninetyDaysAgo, CommitHash: "hotfixHash"},    // Pattern match
+				{Name: "hotfix/typo", LastCommitDate: ninetyDaysAgo, CommitHash: "typoHash"},
+			},
+			mergedStatus: map[string]bool{"main": true, "release/1.0": true, "hotfix/typo": true},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranchMap: map[string]bool{},
+				ProtectedRegexps:   []*regexp.Regexp{regexp.MustCompile(`^release/.*`), regexp.MustCompile(`^hotfix/\d+$`)},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   3, // main (implicit + current), release/1.0, hotfix/42
+				types.CategoryActive:      0,
+				types.CategoryMergedOld:   1, // hotfix/typo
+				types.CategoryUnmergedOld: 0,
+			},
+		},
 		{
 			name: "Different Primary Main Branch (master)",
 			branches: []types.BranchInfo{
@@ -252,6 +282,271 @@ func TestAnalyzeBranches(t *testing.T) {
 			},
 			// This test case requires mocking gitcmd.AreChangesIncluded
 		},
+		{
+			name: "Branch Checked Out In Worktree Is Protected",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{ // Merged and old, but checked out in another worktree
+					Name:           "feature/in-worktree",
+					LastCommitDate: ninetyDaysAgo,
+					CommitHash:     "worktreeHash",
+					WorktreePath:   "/tmp/wt/feature",
+				},
+				{Name: "feature/merged", LastCommitDate: ninetyDaysAgo, CommitHash: "mergedHash"},
+			},
+			mergedStatus: map[string]bool{
+				"main":                true,
+				"feature/in-worktree": true,
+				"feature/merged":      true,
+			},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   2, // main, feature/in-worktree
+				types.CategoryActive:      0,
+				types.CategoryMergedOld:   1, // feature/merged
+				types.CategoryUnmergedOld: 0,
+			},
+		},
+		{
+			name: "Pull Request State Overrides Local Detection",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{ // Squash merged on GitHub, invisible to local checks
+					Name:           "feature/pr-merged",
+					LastCommitDate: sixtyDaysAgo,
+					CommitHash:     "prMergedHash",
+					PullRequest:    &types.PullRequest{Number: 1, State: types.PullRequestMerged},
+				},
+				{ // PR closed without merging, recent commits
+					Name:           "feature/pr-closed",
+					LastCommitDate: sixtyDaysAgo,
+					CommitHash:     "prClosedHash",
+					PullRequest:    &types.PullRequest{Number: 2, State: types.PullRequestClosed},
+				},
+				{
+					Name:           "feature/pr-open",
+					LastCommitDate: sixtyDaysAgo,
+					CommitHash:     "prOpenHash",
+					PullRequest:    &types.PullRequest{Number: 3, State: types.PullRequestOpen},
+				},
+			},
+			mergedStatus: map[string]bool{"main": true},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      1, // feature/pr-open
+				types.CategoryMergedOld:   1, // feature/pr-merged
+				types.CategoryUnmergedOld: 1, // feature/pr-closed
+			},
+		},
+		{
+			name: "Snoozed Branches Are Not Suggested",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{ // Merged but snoozed indefinitely
+					Name:           "feature/snoozed",
+					LastCommitDate: ninetyDaysAgo,
+					CommitHash:     "snoozedHash",
+					Snoozed:        true,
+				},
+				{ // Old, snoozed for another day
+					Name:           "feature/snoozed-old",
+					LastCommitDate: now.AddDate(0, 0, -100),
+					CommitHash:     "snoozedOldHash",
+					Snoozed:        true,
+					SnoozedUntil:   now.Add(24 * time.Hour),
+				},
+				{ // Snooze already expired
+					Name:           "feature/snooze-expired",
+					LastCommitDate: ninetyDaysAgo,
+					CommitHash:     "expiredHash",
+					Snoozed:        true,
+					SnoozedUntil:   now.Add(-time.Hour),
+				},
+			},
+			mergedStatus: map[string]bool{
+				"main":                   true,
+				"feature/snoozed":        true,
+				"feature/snooze-expired": true,
+			},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      2, // feature/snoozed, feature/snoozed-old
+				types.CategoryMergedOld:   1, // feature/snooze-expired
+				types.CategoryUnmergedOld: 0,
+			},
+		},
+		{
+			name: "Only Authors Restricts Suggestions",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash", AuthorEmail: "other@example.com"},
+				{
+					Name:           "feature/mine",
+					LastCommitDate: ninetyDaysAgo,
+					CommitHash:     "mineHash",
+					AuthorEmail:    "Me@Example.com",
+				},
+				{ // Merged, but last committed to by someone else
+					Name:           "feature/theirs",
+					LastCommitDate: ninetyDaysAgo,
+					CommitHash:     "theirsHash",
+					AuthorEmail:    "other@example.com",
+				},
+			},
+			mergedStatus: map[string]bool{
+				"main":           true,
+				"feature/theirs": true,
+			},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+				OnlyAuthors:        []string{"me@example.com"},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      1, // feature/theirs
+				types.CategoryMergedOld:   0,
+				types.CategoryUnmergedOld: 1, // feature/mine
+			},
+		},
+		{
+			name: "Age Rules Override Threshold Per Pattern",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{Name: "release/1.0", LastCommitDate: ninetyDaysAgo, CommitHash: "releaseHash"},
+				{Name: "wip/spike", LastCommitDate: sixtyDaysAgo, CommitHash: "wipHash"},
+				{Name: "feature/old", LastCommitDate: ninetyDaysAgo, CommitHash: "oldHash"},
+			},
+			mergedStatus: map[string]bool{"main": true},
+			cfg: config.Config{
+				AgeDays:            90,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+				AgeRules: []config.AgeRule{
+					{Pattern: "release/*", AgeDays: 365},
+					{Pattern: "wip/*", AgeDays: 30},
+				},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      1, // release/1.0 (younger than 365 days)
+				types.CategoryMergedOld:   0,
+				types.CategoryUnmergedOld: 2, // wip/spike (older than 30 days), feature/old
+			},
+		},
+		{
+			name: "Merged Branches Within Grace Period Are Kept",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{Name: "feature/just-merged", LastCommitDate: now.AddDate(0, 0, -3), CommitHash: "justHash"},
+				{Name: "feature/merged-long-ago", LastCommitDate: sixtyDaysAgo, CommitHash: "longHash"},
+			},
+			mergedStatus: map[string]bool{
+				"main":                    true,
+				"feature/just-merged":     true,
+				"feature/merged-long-ago": true,
+			},
+			cfg: config.Config{
+				AgeDays:            90,
+				MergedAgeDays:      7,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      1, // feature/just-merged
+				types.CategoryMergedOld:   1, // feature/merged-long-ago
+				types.CategoryUnmergedOld: 0,
+			},
+		},
+		{
+			name: "Branches Younger Than min_age_days Are Never Suggested",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{Name: "feature/merged-today", LastCommitDate: now.Add(-time.Hour), CommitHash: "todayHash"},
+				{Name: "feature/merged-last-week", LastCommitDate: now.AddDate(0, 0, -7), CommitHash: "weekHash"},
+				{Name: "hot/fix", LastCommitDate: now.AddDate(0, 0, -1), CommitHash: "hotHash"},
+				{Name: "feature/old", LastCommitDate: ninetyDaysAgo, CommitHash: "oldHash"},
+			},
+			mergedStatus: map[string]bool{
+				"main":                     true,
+				"feature/merged-today":     true,
+				"feature/merged-last-week": true,
+			},
+			cfg: config.Config{
+				AgeDays:            30,
+				MinAgeDays:         3,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+				Rules:              []config.Rule{{Match: "hot/*", Action: config.RuleSuggest}},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      2, // feature/merged-today, hot/fix despite its rule
+				types.CategoryMergedOld:   1, // feature/merged-last-week
+				types.CategoryUnmergedOld: 1, // feature/old
+			},
+		},
+		{
+			name: "Recent Pushes To The Remote Branch Keep It Active",
+			branches: []types.BranchInfo{
+				{Name: "main", LastCommitDate: now, CommitHash: "mainHash"},
+				{
+					Name: "shared/pushed", Upstream: "origin/shared/pushed", LastCommitDate: ninetyDaysAgo,
+					RemoteCommitDate: now.AddDate(0, 0, -2), CommitHash: "pushedHash",
+				},
+				{
+					Name: "shared/merged", Upstream: "origin/shared/merged", LastCommitDate: sixtyDaysAgo,
+					RemoteCommitDate: now.AddDate(0, 0, -5), CommitHash: "mergedHash",
+				},
+				{
+					Name: "shared/quiet", Upstream: "origin/shared/quiet", LastCommitDate: ninetyDaysAgo,
+					RemoteCommitDate: ninetyDaysAgo.AddDate(0, 0, 10), CommitHash: "quietHash",
+				},
+			},
+			mergedStatus: map[string]bool{"main": true, "shared/merged": true},
+			cfg: config.Config{
+				AgeDays:            30,
+				PrimaryMainBranch:  "main",
+				ProtectedBranches:  []string{},
+				ProtectedBranchMap: map[string]bool{},
+			},
+			currentBranch: "main",
+			expectedCounts: map[types.BranchCategory]int{
+				types.CategoryProtected:   1, // main
+				types.CategoryActive:      2, // shared/pushed, shared/merged
+				types.CategoryMergedOld:   0,
+				types.CategoryUnmergedOld: 1, // shared/quiet, its remote side is old too
+			},
+		},
 		{
 			name: "Cherry Check Fails", // Test when AreChangesIncluded returns an error
 			branches: []types.BranchInfo{
@@ -391,3 +686,254 @@ func TestAnalyzeBranches(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, _ string) (bool, error) {
+		return true, nil // Squash merged
+	})
+	defer teardown()
+
+	cfg := config.Config{
+		AgeDays:           90,
+		PrimaryMainBranch: "main",
+		AgeRules:          []config.AgeRule{{Pattern: "feature/*", AgeDays: 30}},
+	}
+	branch := types.BranchInfo{Name: "feature/squashed", LastCommitDate: time.Now().AddDate(0, 0, -45)}
+
+	analyzed, steps, err := Explain(context.Background(), branch, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if analyzed.Category != types.CategoryMergedOld {
+		t.Errorf("Expected %s, got %s", types.CategoryMergedOld, analyzed.Category)
+	}
+
+	results := make(map[string]string)
+	for _, step := range steps {
+		results[step.Check] = step.Result
+	}
+	for check, want := range map[string]string{
+		"Protection":          "not protected",
+		"Merged (ancestry)":   "no,",
+		"Merged (git cherry)": "yes,",
+		"Age":                 `age_rules "feature/*": 30 days; old`,
+		"Category":            string(types.CategoryMergedOld),
+	} {
+		if !strings.Contains(results[check], want) {
+			t.Errorf("Step %q = %q, want it to contain %q", check, results[check], want)
+		}
+	}
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	cfg := config.Config{
+		AgeDays:            90,
+		PrimaryMainBranch:  "main",
+		ProtectedBranchMap: map[string]bool{"release": true},
+	}
+	branches := []types.BranchInfo{
+		{Name: "main", CommitHash: "m"},
+		{Name: "feature/started", CommitHash: "m"},
+		{Name: "feature/x", CommitHash: "x"},
+		{Name: "feature/x-backup", CommitHash: "x"},
+		{Name: "a-copy", CommitHash: "r"},
+		{Name: "release", CommitHash: "r"},
+		{Name: "feature/unique", CommitHash: "u"},
+	}
+	MarkDuplicates(branches, "m", cfg, "main")
+
+	want := map[string]string{
+		"feature/started":  "identical to main",
+		"feature/x-backup": "duplicate of feature/x",
+		"a-copy":           "duplicate of release", // Protected branches are kept over the first by name
+	}
+	for _, branch := range branches {
+		if got := branch.DuplicateLabel(); got != want[branch.Name] {
+			t.Errorf("%s: DuplicateLabel() = %q, want %q", branch.Name, got, want[branch.Name])
+		}
+	}
+
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, _ string) (bool, error) {
+		return false, nil
+	})
+	defer teardown()
+	recent := types.BranchInfo{Name: "feature/x-backup", LastCommitDate: time.Now(), DuplicateOf: "feature/x"}
+	analyzed, err := Branches(context.Background(), []types.BranchInfo{recent}, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Branches failed: %v", err)
+	}
+	if analyzed[0].Category != types.CategoryUnmergedOld || analyzed[0].IsOldByAge {
+		t.Errorf("Expected a recent duplicate to be suggested, got %+v", analyzed[0])
+	}
+}
+
+func TestAnalyzeBranchWithoutChanges(t *testing.T) {
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, _ string) (bool, error) {
+		return false, nil // Rebased and partly dropped, so git cherry finds no equivalents
+	})
+	defer teardown()
+	gitcmd.HasNoChanges = func(_ context.Context, base, head string) (bool, error) {
+		return base == "main" && head == "feature/dropped", nil
+	}
+
+	cfg := config.Config{AgeDays: 90, PrimaryMainBranch: "main"}
+	branches := []types.BranchInfo{
+		{Name: "feature/dropped", LastCommitDate: time.Now()},
+		{Name: "feature/wip", LastCommitDate: time.Now()},
+	}
+	analyzed, err := Branches(context.Background(), branches, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Branches failed: %v", err)
+	}
+	if !analyzed[0].IsMerged || analyzed[0].Category != types.CategoryMergedOld {
+		t.Errorf("Expected a branch without changes to be merged, got %+v", analyzed[0])
+	}
+	if analyzed[1].IsMerged || analyzed[1].Category != types.CategoryActive {
+		t.Errorf("Expected a branch with changes to stay active, got %+v", analyzed[1])
+	}
+
+	gitcmd.HasNoChanges = func(_ context.Context, _, _ string) (bool, error) {
+		return false, errors.New("bad revision")
+	}
+	if _, err := Branches(context.Background(), branches, map[string]bool{}, cfg, "main"); err == nil {
+		t.Error("Expected a failed diff check to be reported")
+	}
+}
+
+func TestCachedMergeCheck(t *testing.T) {
+	var checked []string
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, head string) (bool, error) {
+		checked = append(checked, head)
+		return false, nil
+	})
+	defer teardown()
+	originalHasNoChanges := gitcmd.HasNoChanges
+	gitcmd.HasNoChanges = func(_ context.Context, _, _ string) (bool, error) { return false, nil }
+	defer func() { gitcmd.HasNoChanges = originalHasNoChanges }()
+
+	cfg := config.Config{AgeDays: 90, PrimaryMainBranch: "main"}
+	branches := []types.BranchInfo{
+		{Name: "feature/squashed", LastCommitDate: time.Now(),
+			HasCachedMergeCheck: true, CachedMergedBy: types.MergedByCherry},
+		{Name: "feature/wip", LastCommitDate: time.Now(), HasCachedMergeCheck: true},
+		{Name: "feature/new", LastCommitDate: time.Now()},
+	}
+	analyzed, err := Branches(context.Background(), branches, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Branches failed: %v", err)
+	}
+	if len(checked) != 1 || checked[0] != "feature/new" {
+		t.Errorf("Expected only the branch without a cached outcome to be checked, got %v", checked)
+	}
+	if !analyzed[0].IsMerged || analyzed[0].MergedBy != types.MergedByCherry || analyzed[0].RanMergeCheck {
+		t.Errorf("Expected the cached merge to be used, got %+v", analyzed[0])
+	}
+	if analyzed[1].IsMerged || analyzed[1].RanMergeCheck {
+		t.Errorf("Expected the cached unmerged outcome to be used, got %+v", analyzed[1])
+	}
+	if !analyzed[2].RanMergeCheck {
+		t.Errorf("Expected the checks that ran to be reported, got %+v", analyzed[2])
+	}
+}
+
+func TestMergeCheckMinDays(t *testing.T) {
+	var checked []string
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, head string) (bool, error) {
+		checked = append(checked, head)
+		return true, nil
+	})
+	defer teardown()
+
+	now := time.Now()
+	cfg := config.Config{AgeDays: 90, PrimaryMainBranch: "main", MergeCheckMinDays: 30}
+	branches := []types.BranchInfo{
+		{Name: "feature/young", LastCommitDate: now.AddDate(0, 0, -5)},
+		{Name: "feature/old", LastCommitDate: now.AddDate(0, 0, -45)},
+	}
+	analyzed, err := Branches(context.Background(), branches, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Branches failed: %v", err)
+	}
+	if len(checked) != 1 || checked[0] != "feature/old" {
+		t.Errorf("Expected only the branch older than merge_check_min_days to be checked, got %v", checked)
+	}
+	if analyzed[0].IsMerged || analyzed[0].Category != types.CategoryActive {
+		t.Errorf("Expected the young branch to stay active without the check, got %+v", analyzed[0])
+	}
+	if !analyzed[1].IsMerged || analyzed[1].MergedBy != types.MergedByCherry {
+		t.Errorf("Expected the old branch to be merged by git cherry, got %+v", analyzed[1])
+	}
+}
+
+func TestExplainMergedIntoProtected(t *testing.T) {
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, _ string) (bool, error) {
+		t.Error("Branches merged into a protected branch need no git cherry check")
+		return false, nil
+	})
+	defer teardown()
+
+	cfg := config.Config{AgeDays: 90, PrimaryMainBranch: "main"}
+	branch := types.BranchInfo{Name: "hotfix/login", LastCommitDate: time.Now(), MergedInto: "develop"}
+	analyzed, steps, err := Explain(context.Background(), branch, map[string]bool{}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if !analyzed.IsMerged || analyzed.Category != types.CategoryMergedOld {
+		t.Errorf("Expected a branch merged into develop to be merged, got %+v", analyzed)
+	}
+	if !slices.ContainsFunc(steps, func(s Step) bool {
+		return s.Check == "Merged (protected)" && strings.Contains(s.Result, "protected branch develop")
+	}) {
+		t.Errorf("Expected the merge into develop in the trail, got %+v", steps)
+	}
+}
+
+func TestRules(t *testing.T) {
+	teardown := setupAreChangesIncludedMock(t, func(_ context.Context, _, _ string) (bool, error) {
+		return false, nil
+	})
+	defer teardown()
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -200)
+	cfg := config.Config{
+		AgeDays:           90,
+		PrimaryMainBranch: "main",
+		Rules: []config.Rule{
+			{Match: "spike/*", Action: config.RuleSuggest},
+			{Match: "wip/*", Action: config.RuleProtect},
+			{Match: "release/*", Action: config.RuleKeep},
+			{Match: "main", Action: config.RuleSuggest},
+		},
+	}
+	branches := []types.BranchInfo{
+		{Name: "main", LastCommitDate: now},
+		{Name: "spike/idea", LastCommitDate: now},
+		{Name: "spike/snoozed", LastCommitDate: now, Snoozed: true},
+		{Name: "wip/login", LastCommitDate: old},
+		{Name: "release/1.0", LastCommitDate: old},
+		{Name: "feature/x", LastCommitDate: old},
+	}
+	analyzed, err := Branches(context.Background(), branches, map[string]bool{"release/1.0": true}, cfg, "main")
+	if err != nil {
+		t.Fatalf("Branches failed: %v", err)
+	}
+
+	want := map[string]types.BranchCategory{
+		"main":          types.CategoryProtected, // Rules cannot make built-in protected branches candidates
+		"spike/idea":    types.CategoryUnmergedOld,
+		"spike/snoozed": types.CategoryActive,
+		"wip/login":     types.CategoryProtected,
+		"release/1.0":   types.CategoryActive,
+		"feature/x":     types.CategoryUnmergedOld,
+	}
+	for _, branch := range analyzed {
+		if branch.Category != want[branch.Name] {
+			t.Errorf("Expected %s to be %s, got %s", branch.Name, want[branch.Name], branch.Category)
+		}
+	}
+	if analyzed[1].CategoryRule != "spike/*" || analyzed[5].CategoryRule != "" {
+		t.Errorf("Expected only rule-changed categories to name their rule, got %q and %q",
+			analyzed[1].CategoryRule, analyzed[5].CategoryRule)
+	}
+}
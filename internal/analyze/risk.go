@@ -0,0 +1,70 @@
+package analyze
+
+import (
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Risk score thresholds: candidates scoring below riskModerate are safe to delete, those
+// scoring riskRisky or more deserve a closer look.
+const (
+	riskModerate = 25
+	riskRisky    = 50
+)
+
+// mergeRisk is the base score of a merged branch by how its merge was detected; the less
+// conclusive the method, the more likely it is to be wrong.
+var mergeRisk = map[types.MergeMethod]int{
+	types.MergedByAncestry:    0,
+	types.MergedByPullRequest: 5,
+	types.MergedByCherry:      10,
+	types.MergedByNoChanges:   20,
+}
+
+// ScoreRisk sets RiskScore and Risk on the candidates among branches. The score starts from
+// how the branch was found to be merged, or from being unmerged, and grows with the commits
+// the primary main branch lacks, commits that were never pushed, a missing remote branch and
+// recent activity. Candidates are expected to carry their UnpushedCommits and AheadCommits.
+func ScoreRisk(branches []types.AnalyzedBranch) {
+	now := time.Now()
+	for i := range branches {
+		branch := &branches[i]
+		if branch.Category != types.CategoryMergedOld && branch.Category != types.CategoryUnmergedOld {
+			continue
+		}
+		branch.RiskScore = riskScore(*branch, now)
+		switch {
+		case branch.RiskScore >= riskRisky:
+			branch.Risk = types.RiskRisky
+		case branch.RiskScore >= riskModerate:
+			branch.Risk = types.RiskModerate
+		default:
+			branch.Risk = types.RiskSafe
+		}
+	}
+}
+
+// riskScore computes the risk of deleting one candidate, from 0 to 100.
+func riskScore(branch types.AnalyzedBranch, now time.Time) int {
+	var score int
+	switch {
+	case branch.IsMerged:
+		score = mergeRisk[branch.MergedBy]
+	case branch.DuplicateOf != "":
+		// Another branch keeps the same commit
+		score = 10
+	default:
+		score = 40 + 3*min(branch.AheadCommits, 10)
+	}
+	if branch.UnpushedCommits > 0 {
+		score += 30 // The commits exist nowhere else
+	}
+	if !branch.IsMerged && branch.Remote == "" {
+		score += 10 // No remote branch to restore it from
+	}
+	if daysSince(now, branch.LastCommitDate) < 30 {
+		score += 10 // Someone may still be working on it
+	}
+	return min(score, 100)
+}
@@ -0,0 +1,58 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestScoreRisk(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -200)
+	recent := time.Now().AddDate(0, 0, -3)
+	candidate := func(category types.BranchCategory, date time.Time) types.AnalyzedBranch {
+		return types.AnalyzedBranch{
+			BranchInfo: types.BranchInfo{Name: "feature/x", Remote: "origin", LastCommitDate: date},
+			Category:   category,
+		}
+	}
+
+	ancestry := candidate(types.CategoryMergedOld, old)
+	ancestry.IsMerged, ancestry.MergedBy = true, types.MergedByAncestry
+	noChanges := candidate(types.CategoryMergedOld, recent)
+	noChanges.IsMerged, noChanges.MergedBy = true, types.MergedByNoChanges
+	duplicate := candidate(types.CategoryUnmergedOld, old)
+	duplicate.DuplicateOf = "feature/y"
+	pushed := candidate(types.CategoryUnmergedOld, old)
+	pushed.AheadCommits = 2
+	unpushed := candidate(types.CategoryUnmergedOld, old)
+	unpushed.AheadCommits, unpushed.UnpushedCommits = 2, 2
+	local := candidate(types.CategoryUnmergedOld, old)
+	local.AheadCommits, local.Remote = 20, ""
+	active := candidate(types.CategoryActive, recent)
+
+	tests := []struct {
+		name      string
+		branch    types.AnalyzedBranch
+		wantScore int
+		wantRisk  types.Risk
+	}{
+		{"Merged by ancestry", ancestry, 0, types.RiskSafe},
+		{"Recent branch without changes", noChanges, 30, types.RiskModerate},
+		{"Duplicate", duplicate, 10, types.RiskSafe},
+		{"Unmerged but pushed", pushed, 46, types.RiskModerate},
+		{"Unmerged and unpushed", unpushed, 76, types.RiskRisky},
+		{"Unmerged without remote", local, 80, types.RiskRisky},
+		{"Not a candidate", active, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			branches := []types.AnalyzedBranch{tt.branch}
+			ScoreRisk(branches)
+			if branches[0].RiskScore != tt.wantScore || branches[0].Risk != tt.wantRisk {
+				t.Errorf("ScoreRisk() = %d %q, want %d %q",
+					branches[0].RiskScore, branches[0].Risk, tt.wantScore, tt.wantRisk)
+			}
+		})
+	}
+}
@@ -2,12 +2,20 @@
 package config
 
 import (
+	"bytes"
 	"errors" // Import errors package
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
 )
 
 // ErrConfigNotFound is returned by LoadConfig when no config file is found.
@@ -17,20 +25,201 @@ const (
 	defaultConfigDir  = "git-sweep"
 	defaultConfigFile = "config.toml"
 	defaultAgeDays    = 90
-	defaultMainBranch = "main"
+	defaultMainBranch = PrimaryMainAuto
+
+	defaultRemoteDeleteWorkers = 4
+	defaultSnoozeDays          = 30
+	defaultFetchTimeoutSeconds = 30
+	defaultFetchCacheMinutes   = 10
+	defaultBundleExpiryDays    = 90
+
+	// PrimaryMainAuto makes git-sweep detect the primary main branch from the remote's HEAD,
+	// falling back to main or master. It is the default when primary_main_branch is not set.
+	PrimaryMainAuto = "auto"
+
+	// ProviderGitHub enables pull request lookups against the GitHub API.
+	ProviderGitHub = "github"
+
+	// ArchiveModeRef keeps archived branch tips under refs/archive/.
+	ArchiveModeRef = "ref"
+	// ArchiveModeTag keeps archived branch tips as archive/<name> tags.
+	ArchiveModeTag = "tag"
+
+	// ThemeDark is the default color theme, made for terminals with a dark background.
+	ThemeDark = "dark"
+	// ThemeLight uses darker colors that stay readable on a light background.
+	ThemeLight = "light"
+	// ThemeHighContrast uses bright basic colors and never renders text faint.
+	ThemeHighContrast = "high-contrast"
+	// ThemeCustom starts from the dark theme and overrides the colors set in theme_colors.
+	ThemeCustom = "custom"
+
+	// UpdateChannelStable only offers and installs stable releases. It is the default.
+	UpdateChannelStable = "stable"
+	// UpdateChannelPrerelease also offers and installs prereleases, for users testing betas.
+	UpdateChannelPrerelease = "prerelease"
+
+	// RuleSuggest suggests matching branches for deletion whatever their age or merge status.
+	RuleSuggest = "suggest"
+	// RuleProtect protects matching branches like protected_branches.
+	RuleProtect = "protect"
+	// RuleKeep never suggests matching branches but lists them as active.
+	RuleKeep = "keep"
 )
 
+// colorPattern matches the colors accepted in theme_colors: an ANSI color number or a hex value.
+var colorPattern = regexp.MustCompile(`^(\d{1,3}|#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6})$`)
+
 // Config holds the application configuration settings.
 // Tags correspond to the keys in the TOML configuration file.
 type Config struct {
-	AgeDays            int      `toml:"age_days"`
-	PrimaryMainBranch  string   `toml:"primary_main_branch"`
-	ProtectedBranches  []string `toml:"protected_branches"`
-	LastVersionCheck   int64    `toml:"last_version_check"`   // Unix timestamp of last check
-	LatestKnownVersion string   `toml:"latest_known_version"` // Latest version found during checks
+	AgeDays           int      `toml:"age_days"`
+	MergedAgeDays     int      `toml:"merged_age_days"` // Grace period before merged branches are suggested
+	MinAgeDays        int      `toml:"min_age_days"`    // Branches younger than this are never suggested
+	PrimaryMainBranch string   `toml:"primary_main_branch"`
+	ProtectedBranches []string `toml:"protected_branches"`
+	ProtectedPatterns []string `toml:"protected_patterns"` // Regular expressions; matching branches are protected
+	UpdateChannel     string   `toml:"update_channel"`     // "stable" (default) or "prerelease"
+	Provider          string   `toml:"provider"`           // Hosting provider for PR lookups ("github" or empty)
+	ProviderToken     string   `toml:"provider_token"`     // API token for the hosting provider
+	GitHubToken       string   `toml:"github_token"`       // Sent to the GitHub API unless provider_token is
+	CABundle          string   `toml:"ca_bundle"`          // PEM certificates trusted for HTTPS besides the system's
+	Backend           string   `toml:"backend"`            // Git backend: "exec" (default) or "go-git"
+	CompareRef        string   `toml:"compare_ref"`        // Ref merges are checked against, e.g. "origin/main"
+
+	RemoteDeleteWorkers int     `toml:"remote_delete_workers"` // Concurrent remote deletions
+	RemoteRateLimit     float64 `toml:"remote_rate_limit"`     // Remote deletions per second, per remote (0 = unlimited)
+	SnoozeDays          int     `toml:"snooze_days"`           // How long the TUI snooze key hides a branch
+	Archive             bool    `toml:"archive"`               // Archive branches before deleting them
+	ArchiveMode         string  `toml:"archive_mode"`          // "ref" (default) or "tag"
+	Bundle              bool    `toml:"bundle"`                // Write a bundle of each branch before deleting it
+	BundleDir           string  `toml:"bundle_dir"`            // Bundle directory (empty uses the state directory)
+	BundleExpiryDays    int     `toml:"bundle_expiry_days"`    // Delete bundles older than this (0 = keep forever)
+	TagPrefix           string  `toml:"tag_prefix"`            // Tag each branch as <prefix><name> first (empty = off)
+	AuditLog            string  `toml:"audit_log"`             // Audit log path (empty uses the state directory)
+	NotifyURL           string  `toml:"notify_url"`            // Webhook receiving a JSON summary after deletions
+	MaxDelete           int     `toml:"max_delete"`            // Branch limit per run without --force (0 = unlimited)
+	FetchTimeoutSeconds int     `toml:"fetch_timeout_seconds"` // How long to wait for 'git fetch' before skipping it
+	FetchCacheMinutes   int     `toml:"fetch_cache_minutes"`   // Skip fetching a remote fetched this recently (0 = never)
+	PruneAllRemotes     bool    `toml:"prune_all_remotes"`     // Fetch and prune every remote, not only tracked ones
+	FastForwardMain     bool    `toml:"fast_forward_main"`     // Fast-forward the primary main branch after fetching
+	Unshallow           bool    `toml:"unshallow"`             // Fetch the full history of a shallow clone first
+	Offline             bool    `toml:"offline"`               // Never use the network; remote state may be stale
+	MergedIntoProtected bool    `toml:"merged_into_protected"` // Branches merged into any protected branch are merged
+	MergeCheckMinDays   int     `toml:"merge_check_min_days"`  // Skip git cherry/diff for younger branches (0 = never)
+	Theme               string  `toml:"theme"`                 // TUI color theme: "dark" (default), "light", ...
+	InteractiveConfirm  bool    `toml:"interactive_confirm"`   // Confirm each selected branch instead of all at once
+	StrictConfig        bool    `toml:"strict_config"`         // Refuse to run while Validate reports problems
+
+	ThemeColors ThemeColors `toml:"theme_colors"` // Colors of the "custom" theme; unset ones come from "dark"
+
+	OnlyAuthors []string  `toml:"only_authors"` // Only suggest branches whose last commit is by one of these emails
+	AgeRules    []AgeRule `toml:"age_rules"`    // Per-pattern overrides of AgeDays, first match wins
+	Rules       []Rule    `toml:"rules"`        // Per-pattern overrides of the category, first match wins
+
+	// Regular expressions; matching branches may be deleted locally but never on the remote
+	ProtectedRemotePatterns []string `toml:"protected_remote_patterns"`
+
+	// Named sets of overrides, e.g. [profile.work], selected with --config-profile
+	Profiles map[string]Profile `toml:"profile"`
+	// Name of the profile applied by WithProfile, empty if none
+	ActiveProfile string `toml:"-"`
 
 	// Internal map for faster lookups, not loaded from TOML directly
 	ProtectedBranchMap map[string]bool `toml:"-"`
+	// ProtectedPatterns and ProtectedRemotePatterns compiled by LoadConfig
+	ProtectedRegexps       []*regexp.Regexp `toml:"-"`
+	ProtectedRemoteRegexps []*regexp.Regexp `toml:"-"`
+}
+
+// AgeRule overrides the staleness threshold for branches whose name matches Pattern.
+// Patterns use path.Match syntax, so "release/*" matches "release/1.0" but not "release/1.0/fix".
+type AgeRule struct {
+	Pattern string `toml:"pattern"`
+	AgeDays int    `toml:"age_days"`
+}
+
+// Rule overrides the category the analysis assigns to branches whose name matches Match,
+// using the same path.Match syntax as AgeRule. Action is RuleSuggest, RuleProtect or RuleKeep.
+type Rule struct {
+	Match  string `toml:"match"`
+	Action string `toml:"action"`
+}
+
+// ThemeColors are the colors of the "custom" theme, each an ANSI color number ("212") or a
+// hex value ("#ff87d7"). Empty colors keep the value of the dark theme.
+type ThemeColors struct {
+	Accent  string `toml:"accent,omitempty"`  // Cursor and selected branches
+	Muted   string `toml:"muted,omitempty"`   // Help text, active branches and separators
+	Prompt  string `toml:"prompt,omitempty"`  // Confirmation prompts and the spinner
+	Warning string `toml:"warning,omitempty"` // Unmerged branches and warnings
+	Success string `toml:"success,omitempty"` // Merged branches and successful deletions
+	Error   string `toml:"error,omitempty"`   // Force deletions and errors
+	Dimmed  string `toml:"dimmed,omitempty"`  // Remotes that cannot be selected
+}
+
+// AgeDaysFor returns the age threshold for the named branch: the AgeDays of the first
+// matching age rule, or the global AgeDays if no rule matches.
+func (c Config) AgeDaysFor(branchName string) int {
+	if rule, ok := c.AgeRuleFor(branchName); ok {
+		return rule.AgeDays
+	}
+	return c.AgeDays
+}
+
+// AgeRuleFor returns the first age rule matching the named branch, if any.
+func (c Config) AgeRuleFor(branchName string) (AgeRule, bool) {
+	for _, rule := range c.AgeRules {
+		if ok, _ := path.Match(rule.Pattern, branchName); ok {
+			return rule, true
+		}
+	}
+	return AgeRule{}, false
+}
+
+// RuleFor returns the first rule matching the named branch, if any.
+func (c Config) RuleFor(branchName string) (Rule, bool) {
+	for _, rule := range c.Rules {
+		if ok, _ := path.Match(rule.Match, branchName); ok {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// CompareTarget returns the ref merge status is checked against: compare_ref if set, e.g. the
+// remote-tracking "origin/main" for those who rarely update their local main, otherwise the
+// primary main branch.
+func (c Config) CompareTarget() string {
+	if c.CompareRef != "" {
+		return c.CompareRef
+	}
+	return c.PrimaryMainBranch
+}
+
+// IsProtectedName reports whether the named branch is protected by configuration: it is listed
+// in protected_branches or matches one of the protected_patterns.
+func (c Config) IsProtectedName(branchName string) bool {
+	if c.ProtectedBranchMap[branchName] {
+		return true
+	}
+	return matchesAny(c.ProtectedRegexps, branchName)
+}
+
+// IsRemoteProtectedName reports whether the named branch must not be deleted on its remote
+// because it matches one of the protected_remote_patterns.
+func (c Config) IsRemoteProtectedName(branchName string) bool {
+	return matchesAny(c.ProtectedRemoteRegexps, branchName)
+}
+
+// matchesAny reports whether s matches at least one of the regular expressions.
+func matchesAny(regexps []*regexp.Regexp, s string) bool {
+	for _, re := range regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultConfig returns a Config struct with default values.
@@ -39,9 +228,13 @@ func DefaultConfig() Config {
 		AgeDays:            defaultAgeDays,
 		PrimaryMainBranch:  defaultMainBranch,
 		ProtectedBranches:  []string{}, // Default is empty list
-		LastVersionCheck:   0,          // 0 means never checked
-		LatestKnownVersion: "",         // Empty means no known version
 		ProtectedBranchMap: make(map[string]bool),
+
+		RemoteDeleteWorkers: defaultRemoteDeleteWorkers,
+		SnoozeDays:          defaultSnoozeDays,
+		FetchTimeoutSeconds: defaultFetchTimeoutSeconds,
+		FetchCacheMinutes:   defaultFetchCacheMinutes,
+		BundleExpiryDays:    defaultBundleExpiryDays,
 	}
 }
 
@@ -52,7 +245,6 @@ func DefaultConfig() Config {
 func LoadConfig(customPath string) (Config, error) {
 	cfg := DefaultConfig()
 	configPath := ""
-	configFound := false
 
 	// Determine the path to load
 	if customPath != "" {
@@ -66,7 +258,6 @@ func LoadConfig(customPath string) (Config, error) {
 			// Other error checking custom path
 			return cfg, fmt.Errorf("error checking custom config path %q: %w", customPath, err)
 		}
-		configFound = true // Custom path exists
 	} else {
 		// No custom path, check the default location.
 		userConfigDir, err := os.UserConfigDir()
@@ -84,55 +275,201 @@ func LoadConfig(customPath string) (Config, error) {
 			// Other error checking default path
 			return cfg, fmt.Errorf("error checking default config path %q: %w", configPath, err)
 		}
-		configFound = true // Default path exists
 	}
 
-	// If we reach here, configFound must be true and configPath is set.
-	// Load the configuration file.
-	if configFound { // This check is slightly redundant now but kept for clarity
-		if _, err := toml.DecodeFile(configPath, &cfg); err != nil {
-			return cfg, fmt.Errorf("error decoding config file %q: %w", configPath, err)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config file %q: %w", configPath, err)
+	}
+	cfg, _, err = parseConfig(data, configPath)
+	return cfg, err
+}
+
+// parseConfig decodes a configuration file read from name, which identifies it in errors, and
+// applies the defaults and checks of LoadConfig.
+func parseConfig(data []byte, name string) (Config, toml.MetaData, error) {
+	cfg := DefaultConfig()
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return cfg, md, fmt.Errorf("error decoding config file %q: %w", name, err)
+	}
+	// Ensure defaults are applied if values are missing or invalid in the file
+	if cfg.AgeDays <= 0 {
+		cfg.AgeDays = defaultAgeDays
+	}
+	if cfg.MergedAgeDays < 0 {
+		cfg.MergedAgeDays = 0
+	}
+	if cfg.MinAgeDays < 0 {
+		cfg.MinAgeDays = 0
+	}
+	if cfg.MergeCheckMinDays < 0 {
+		cfg.MergeCheckMinDays = 0
+	}
+	if cfg.PrimaryMainBranch == "" {
+		cfg.PrimaryMainBranch = defaultMainBranch
+	}
+	if cfg.RemoteDeleteWorkers <= 0 {
+		cfg.RemoteDeleteWorkers = defaultRemoteDeleteWorkers
+	}
+	if cfg.RemoteRateLimit < 0 {
+		cfg.RemoteRateLimit = 0
+	}
+	if cfg.SnoozeDays <= 0 {
+		cfg.SnoozeDays = defaultSnoozeDays
+	}
+	if cfg.MaxDelete < 0 {
+		cfg.MaxDelete = 0
+	}
+	if cfg.FetchTimeoutSeconds <= 0 {
+		cfg.FetchTimeoutSeconds = defaultFetchTimeoutSeconds
+	}
+	if cfg.FetchCacheMinutes < 0 {
+		cfg.FetchCacheMinutes = defaultFetchCacheMinutes
+	}
+	if cfg.BundleExpiryDays < 0 {
+		cfg.BundleExpiryDays = defaultBundleExpiryDays
+	}
+	for _, rule := range cfg.AgeRules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil || rule.Pattern == "" {
+			return cfg, md, fmt.Errorf("invalid age_rules pattern %q in config file %q", rule.Pattern, name)
 		}
-		// Ensure defaults are applied if values are missing or invalid in the file
-		if cfg.AgeDays <= 0 {
-			cfg.AgeDays = defaultAgeDays
+		if rule.AgeDays <= 0 {
+			return cfg, md, fmt.Errorf("age_rules entry %q in config file %q must have a positive age_days",
+				rule.Pattern, name)
 		}
-		if cfg.PrimaryMainBranch == "" {
-			cfg.PrimaryMainBranch = defaultMainBranch
+	}
+	for _, rule := range cfg.Rules {
+		if _, err := path.Match(rule.Match, ""); err != nil || rule.Match == "" {
+			return cfg, md, fmt.Errorf("invalid rules match %q in config file %q", rule.Match, name)
 		}
-		// ProtectedBranches defaults to empty slice if nil
-		if cfg.ProtectedBranches == nil {
-			cfg.ProtectedBranches = []string{}
+		if rule.Action != RuleSuggest && rule.Action != RuleProtect && rule.Action != RuleKeep {
+			return cfg, md, fmt.Errorf(
+				"unsupported action %q for rules match %q in config file %q (supported: %q, %q, %q)",
+				rule.Action, rule.Match, name, RuleSuggest, RuleProtect, RuleKeep)
 		}
-	} else {
-		// Config file not found at either custom or default path.
-		// Return defaults and the specific ErrConfigNotFound error.
-		return cfg, ErrConfigNotFound
+	}
+	if cfg.ProtectedRegexps, err = compilePatterns("protected_patterns", cfg.ProtectedPatterns); err != nil {
+		return cfg, md, fmt.Errorf("%w in config file %q", err, name)
+	}
+	cfg.ProtectedRemoteRegexps, err = compilePatterns("protected_remote_patterns", cfg.ProtectedRemotePatterns)
+	if err != nil {
+		return cfg, md, fmt.Errorf("%w in config file %q", err, name)
+	}
+	if cfg.ArchiveMode != "" && cfg.ArchiveMode != ArchiveModeRef && cfg.ArchiveMode != ArchiveModeTag {
+		return cfg, md, fmt.Errorf("unsupported archive_mode %q in config file %q (supported: %q, %q)",
+			cfg.ArchiveMode, name, ArchiveModeRef, ArchiveModeTag)
+	}
+	if cfg.UpdateChannel != "" && cfg.UpdateChannel != UpdateChannelStable &&
+		cfg.UpdateChannel != UpdateChannelPrerelease {
+		return cfg, md, fmt.Errorf("unsupported update_channel %q in config file %q (supported: %q, %q)",
+			cfg.UpdateChannel, name, UpdateChannelStable, UpdateChannelPrerelease)
+	}
+	if cfg.NotifyURL != "" {
+		if u, err := url.Parse(cfg.NotifyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return cfg, md, fmt.Errorf("invalid notify_url %q in config file %q (must be an http or https URL)",
+				cfg.NotifyURL, name)
+		}
+	}
+	if err := validateTheme(cfg.Theme, cfg.ThemeColors); err != nil {
+		return cfg, md, fmt.Errorf("%w in config file %q", err, name)
+	}
+	if cfg.Provider != "" && cfg.Provider != ProviderGitHub {
+		return cfg, md, fmt.Errorf("unsupported provider %q in config file %q (supported: %q)",
+			cfg.Provider, name, ProviderGitHub)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		if err := cfg.Profiles[name].validate(); err != nil {
+			return cfg, md, fmt.Errorf("%w in profile %q of config file %q", err, name, name)
+		}
+	}
+	// ProtectedBranches defaults to empty slice if nil
+	if cfg.ProtectedBranches == nil {
+		cfg.ProtectedBranches = []string{}
 	}
 
-	// 4. Populate the ProtectedBranchMap
+	// Populate the ProtectedBranchMap
 	cfg.ProtectedBranchMap = make(map[string]bool)
 	for _, branch := range cfg.ProtectedBranches {
 		cfg.ProtectedBranchMap[branch] = true
 	}
+	return cfg, md, nil
+}
+
+// compilePatterns compiles the regular expressions of the named config field.
+func compilePatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q (%v)", field, pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
 
-	return cfg, nil
+// validateTheme checks the theme name and the colors of the custom theme.
+func validateTheme(theme string, colors ThemeColors) error {
+	switch theme {
+	case "", ThemeDark, ThemeLight, ThemeHighContrast, ThemeCustom:
+	default:
+		return fmt.Errorf("unsupported theme %q (supported: %q, %q, %q, %q)",
+			theme, ThemeDark, ThemeLight, ThemeHighContrast, ThemeCustom)
+	}
+	for field, color := range map[string]string{
+		"accent": colors.Accent, "muted": colors.Muted, "prompt": colors.Prompt, "warning": colors.Warning,
+		"success": colors.Success, "error": colors.Error, "dimmed": colors.Dimmed,
+	} {
+		if color != "" && !colorPattern.MatchString(color) {
+			return fmt.Errorf("invalid theme_colors.%s %q (use an ANSI color number or a hex value like \"#ff87d7\")",
+				field, color)
+		}
+	}
+	return nil
+}
+
+// ResolvePath returns the configuration file path used for customPath: customPath itself
+// if set, otherwise the default location in the user's config directory.
+func ResolvePath(customPath string) (string, error) {
+	if customPath != "" {
+		return customPath, nil
+	}
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user config directory: %w", err)
+	}
+	return filepath.Join(userConfigDir, defaultConfigDir, defaultConfigFile), nil
+}
+
+// LoadedPath returns the absolute path of the file LoadConfig reads for customPath: ResolvePath's
+// result with any symlinks followed, e.g. into a dotfiles repository. It returns
+// ErrConfigNotFound if there is no such file.
+func LoadedPath(customPath string) (string, error) {
+	configPath, err := ResolvePath(customPath)
+	if err != nil {
+		return "", err
+	}
+	loaded, err := filepath.EvalSymlinks(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrConfigNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error checking config path %q: %w", configPath, err)
+	}
+	return filepath.Abs(loaded)
 }
 
 // SaveConfig saves the provided configuration to the specified path or the default location.
-// It creates the necessary directories if they don't exist.
+// It creates the necessary directories if they don't exist. An existing file is edited rather
+// than rewritten, changing only the settings that differ, so its comments and formatting survive.
+// The file is replaced atomically, so a concurrent git-sweep run never reads a partly written
+// configuration.
 // It returns the path where the file was saved and any error encountered.
 func SaveConfig(cfg Config, customPath string) (string, error) {
-	savePath := ""
-
-	if customPath != "" {
-		savePath = customPath
-	} else {
-		userConfigDir, err := os.UserConfigDir()
-		if err != nil {
-			return "", fmt.Errorf("could not determine user config directory: %w", err)
-		}
-		savePath = filepath.Join(userConfigDir, defaultConfigDir, defaultConfigFile)
+	savePath, err := ResolvePath(customPath)
+	if err != nil {
+		return "", err
 	}
 
 	// Ensure the directory exists
@@ -141,39 +478,117 @@ func SaveConfig(cfg Config, customPath string) (string, error) {
 		return savePath, fmt.Errorf("could not create config directory %q: %w", dir, err)
 	}
 
-	// Create or truncate the file
-	file, err := os.Create(savePath)
-	if err != nil {
-		return savePath, fmt.Errorf("could not create config file %q: %w", savePath, err)
+	// Encode the config to TOML
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(fileContent(cfg)); err != nil {
+		return savePath, fmt.Errorf("could not encode config to TOML file %q: %w", savePath, err)
 	}
-	// Defer closing the file, checking for errors on close.
-	defer func() {
-		if closeErr := file.Close(); err == nil && closeErr != nil {
-			// If no previous error occurred, but closing failed, report the close error.
-			err = fmt.Errorf("failed to close config file %q: %w", savePath, closeErr)
+	data := buf.Bytes()
+	if existing, readErr := os.ReadFile(savePath); readErr == nil {
+		// A file that cannot be edited, e.g. because it is not valid TOML, is rewritten
+		if edited, editErr := editConfigFile(existing, data); editErr == nil {
+			data = edited
 		}
-	}()
-
-	// Encode the config to TOML
-	encoder := toml.NewEncoder(file)
-	// We don't want to save the internal map
-	configToSave := struct {
-		AgeDays            int      `toml:"age_days"`
-		PrimaryMainBranch  string   `toml:"primary_main_branch"`
-		ProtectedBranches  []string `toml:"protected_branches"`
-		LastVersionCheck   int64    `toml:"last_version_check"`
-		LatestKnownVersion string   `toml:"latest_known_version"`
-	}{
-		AgeDays:            cfg.AgeDays,
-		PrimaryMainBranch:  cfg.PrimaryMainBranch,
-		ProtectedBranches:  cfg.ProtectedBranches,
-		LastVersionCheck:   cfg.LastVersionCheck,
-		LatestKnownVersion: cfg.LatestKnownVersion,
 	}
-
-	if err := encoder.Encode(configToSave); err != nil {
-		return savePath, fmt.Errorf("could not encode config to TOML file %q: %w", savePath, err)
+	if err := fileutil.Replace(savePath, data); err != nil {
+		return savePath, fmt.Errorf("could not write config file %q: %w", savePath, err)
 	}
 
 	return savePath, nil
 }
+
+// fileContent returns what SaveConfig writes for cfg: the settings of cfg, without the
+// fields LoadConfig derives from them.
+func fileContent(cfg Config) any {
+	return struct {
+		AgeDays           int      `toml:"age_days"`
+		MergedAgeDays     int      `toml:"merged_age_days,omitempty"`
+		MinAgeDays        int      `toml:"min_age_days,omitempty"`
+		PrimaryMainBranch string   `toml:"primary_main_branch"`
+		ProtectedBranches []string `toml:"protected_branches"`
+		ProtectedPatterns []string `toml:"protected_patterns,omitempty"`
+		UpdateChannel     string   `toml:"update_channel,omitempty"`
+		Provider          string   `toml:"provider,omitempty"`
+		ProviderToken     string   `toml:"provider_token,omitempty"`
+		GitHubToken       string   `toml:"github_token,omitempty"`
+		CABundle          string   `toml:"ca_bundle,omitempty"`
+		Backend           string   `toml:"backend,omitempty"`
+		CompareRef        string   `toml:"compare_ref,omitempty"`
+
+		RemoteDeleteWorkers int       `toml:"remote_delete_workers,omitempty"`
+		RemoteRateLimit     float64   `toml:"remote_rate_limit,omitempty"`
+		SnoozeDays          int       `toml:"snooze_days,omitempty"`
+		Archive             bool      `toml:"archive,omitempty"`
+		ArchiveMode         string    `toml:"archive_mode,omitempty"`
+		Bundle              bool      `toml:"bundle,omitempty"`
+		BundleDir           string    `toml:"bundle_dir,omitempty"`
+		BundleExpiryDays    int       `toml:"bundle_expiry_days"` // 0 keeps bundles forever, so it is kept
+		TagPrefix           string    `toml:"tag_prefix,omitempty"`
+		AuditLog            string    `toml:"audit_log,omitempty"`
+		NotifyURL           string    `toml:"notify_url,omitempty"`
+		MaxDelete           int       `toml:"max_delete,omitempty"`
+		FetchTimeoutSeconds int       `toml:"fetch_timeout_seconds,omitempty"`
+		FetchCacheMinutes   int       `toml:"fetch_cache_minutes"` // 0 disables the cache, so it is kept
+		PruneAllRemotes     bool      `toml:"prune_all_remotes,omitempty"`
+		FastForwardMain     bool      `toml:"fast_forward_main,omitempty"`
+		Unshallow           bool      `toml:"unshallow,omitempty"`
+		Offline             bool      `toml:"offline,omitempty"`
+		MergedIntoProtected bool      `toml:"merged_into_protected,omitempty"`
+		MergeCheckMinDays   int       `toml:"merge_check_min_days,omitempty"`
+		Theme               string    `toml:"theme,omitempty"`
+		InteractiveConfirm  bool      `toml:"interactive_confirm,omitempty"`
+		StrictConfig        bool      `toml:"strict_config,omitempty"`
+		OnlyAuthors         []string  `toml:"only_authors,omitempty"`
+		AgeRules            []AgeRule `toml:"age_rules,omitempty"`
+		Rules               []Rule    `toml:"rules,omitempty"`
+
+		ProtectedRemotePatterns []string           `toml:"protected_remote_patterns,omitempty"`
+		ThemeColors             ThemeColors        `toml:"theme_colors,omitempty"`
+		Profiles                map[string]Profile `toml:"profile,omitempty"`
+	}{
+		AgeDays:           cfg.AgeDays,
+		MergedAgeDays:     cfg.MergedAgeDays,
+		MinAgeDays:        cfg.MinAgeDays,
+		PrimaryMainBranch: cfg.PrimaryMainBranch,
+		ProtectedBranches: cfg.ProtectedBranches,
+		ProtectedPatterns: cfg.ProtectedPatterns,
+		UpdateChannel:     cfg.UpdateChannel,
+		Provider:          cfg.Provider,
+		ProviderToken:     cfg.ProviderToken,
+		GitHubToken:       cfg.GitHubToken,
+		CABundle:          cfg.CABundle,
+		Backend:           cfg.Backend,
+		CompareRef:        cfg.CompareRef,
+
+		RemoteDeleteWorkers: cfg.RemoteDeleteWorkers,
+		RemoteRateLimit:     cfg.RemoteRateLimit,
+		SnoozeDays:          cfg.SnoozeDays,
+		Archive:             cfg.Archive,
+		ArchiveMode:         cfg.ArchiveMode,
+		Bundle:              cfg.Bundle,
+		BundleDir:           cfg.BundleDir,
+		BundleExpiryDays:    cfg.BundleExpiryDays,
+		TagPrefix:           cfg.TagPrefix,
+		AuditLog:            cfg.AuditLog,
+		NotifyURL:           cfg.NotifyURL,
+		MaxDelete:           cfg.MaxDelete,
+		FetchTimeoutSeconds: cfg.FetchTimeoutSeconds,
+		FetchCacheMinutes:   cfg.FetchCacheMinutes,
+		PruneAllRemotes:     cfg.PruneAllRemotes,
+		FastForwardMain:     cfg.FastForwardMain,
+		Unshallow:           cfg.Unshallow,
+		Offline:             cfg.Offline,
+		MergedIntoProtected: cfg.MergedIntoProtected,
+		MergeCheckMinDays:   cfg.MergeCheckMinDays,
+		Theme:               cfg.Theme,
+		InteractiveConfirm:  cfg.InteractiveConfirm,
+		StrictConfig:        cfg.StrictConfig,
+		OnlyAuthors:         cfg.OnlyAuthors,
+		AgeRules:            cfg.AgeRules,
+		Rules:               cfg.Rules,
+
+		ProtectedRemotePatterns: cfg.ProtectedRemotePatterns,
+		ThemeColors:             cfg.ThemeColors,
+		Profiles:                cfg.Profiles,
+	}
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"bufio"
 	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -77,6 +80,71 @@ func TestSaveAndLoadConfig(t *testing.T) {
 	}
 }
 
+func TestSaveConfigReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("age_days = 1\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.toml")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent saves each leave a complete file behind
+	var wg sync.WaitGroup
+	for days := 10; days < 20; days++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := SaveConfig(Config{AgeDays: days, PrimaryMainBranch: "main"}, link); err != nil {
+				t.Errorf("SaveConfig failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := LoadConfig(link)
+	if err != nil || loaded.AgeDays < 10 || loaded.AgeDays >= 20 {
+		t.Errorf("LoadConfig() = %d days, %v; want one of the saved configurations", loaded.AgeDays, err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the symlink to be kept: %v", err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected the permissions of the existing file to be kept: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(target)); len(entries) != 1 {
+		t.Errorf("Expected no leftover temporary files, got %d entries", len(entries))
+	}
+}
+
+func TestLoadedPath(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "git-sweep.toml")
+	link := filepath.Join(dir, "config.toml")
+	if _, err := LoadedPath(link); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound for a missing file, got %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("age_days = 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(target)
+	if got, err := LoadedPath(link); err != nil || got != want {
+		t.Errorf("LoadedPath() = %q, %v, want %q", got, err, want)
+	}
+}
+
 func TestLoadConfig_DefaultsApplied(t *testing.T) {
 	tempDir := t.TempDir()
 	customPath := filepath.Join(tempDir, "partial_config.toml")
@@ -85,6 +153,7 @@ func TestLoadConfig_DefaultsApplied(t *testing.T) {
 	partialContent := `
 # age_days = 0 # Invalid, should use default
 primary_main_branch = "" # Empty, should use default
+fetch_timeout_seconds = -1 # Invalid, should use default
 # protected_branches is omitted, should use default empty slice
 `
 	err := os.WriteFile(customPath, []byte(partialContent), 0o644)
@@ -107,6 +176,13 @@ primary_main_branch = "" # Empty, should use default
 	if len(loadedCfg.ProtectedBranches) != 0 {
 		t.Errorf("Expected empty ProtectedBranches slice, got %v", loadedCfg.ProtectedBranches)
 	}
+	if loadedCfg.FetchCacheMinutes != defaultFetchCacheMinutes {
+		t.Errorf("Expected default FetchCacheMinutes %d, got %d", defaultFetchCacheMinutes, loadedCfg.FetchCacheMinutes)
+	}
+	if loadedCfg.FetchTimeoutSeconds != defaultFetchTimeoutSeconds {
+		t.Errorf("Expected default FetchTimeoutSeconds %d, got %d", defaultFetchTimeoutSeconds,
+			loadedCfg.FetchTimeoutSeconds)
+	}
 	if len(loadedCfg.ProtectedBranchMap) != 0 {
 		t.Errorf("Expected empty ProtectedBranchMap, got %v", loadedCfg.ProtectedBranchMap)
 	}
@@ -130,6 +206,334 @@ func TestLoadConfig_InvalidToml(t *testing.T) {
 	// but for now, just ensuring an error occurred is sufficient.
 }
 
+func TestLoadConfig_InvalidArchiveMode(t *testing.T) {
+	tempDir := t.TempDir()
+	customPath := filepath.Join(tempDir, "archive.toml")
+
+	if err := os.WriteFile(customPath, []byte("archive = true\narchive_mode = \"zip\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(customPath); err == nil {
+		t.Error("Expected an error for an unsupported archive_mode, got nil")
+	}
+
+	if err := os.WriteFile(customPath, []byte("archive = true\narchive_mode = \"tag\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Archive || cfg.ArchiveMode != ArchiveModeTag {
+		t.Errorf("Expected archiving with tags, got archive=%t mode=%q", cfg.Archive, cfg.ArchiveMode)
+	}
+}
+
+func TestLoadConfig_UpdateChannel(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "channel.toml")
+
+	if err := os.WriteFile(customPath, []byte("update_channel = \"nightly\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(customPath); err == nil {
+		t.Error("Expected an error for an unsupported update_channel, got nil")
+	}
+
+	if err := os.WriteFile(customPath, []byte("update_channel = \"prerelease\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.UpdateChannel != UpdateChannelPrerelease {
+		t.Errorf("Expected the prerelease channel, got %q", cfg.UpdateChannel)
+	}
+}
+
+func TestLoadConfig_LegacyVersionCheckKeys(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "legacy.toml")
+	content := "age_days = 30\nlast_version_check = 1700000000\nlatest_known_version = \"v1.2.0\"\n"
+	if err := os.WriteFile(customPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(customPath)
+	if err != nil || cfg.AgeDays != 30 {
+		t.Fatalf("Expected a config written by older versions to load, got %+v, %v", cfg, err)
+	}
+
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	saved, err := os.ReadFile(customPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	if strings.Contains(string(saved), "version") {
+		t.Errorf("Expected version check state to be left out of the saved config, got:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_NotifyURL(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "notify.toml")
+
+	if err := os.WriteFile(customPath, []byte("notify_url = \"hooks.example.com/x\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(customPath); err == nil {
+		t.Error("Expected an error for a notify_url without http(s) scheme, got nil")
+	}
+
+	if err := os.WriteFile(customPath, []byte("notify_url = \"https://hooks.example.com/x\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NotifyURL != "https://hooks.example.com/x" {
+		t.Errorf("Expected notify_url to be loaded, got %q", cfg.NotifyURL)
+	}
+}
+
+func TestLoadConfig_Theme(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "theme.toml")
+
+	for _, content := range []string{
+		"theme = \"solarized\"\n",
+		"theme = \"custom\"\n[theme_colors]\naccent = \"pink\"\n",
+		"theme = \"custom\"\n[theme_colors]\nmuted = \"#12345\"\n",
+	} {
+		if err := os.WriteFile(customPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := LoadConfig(customPath); err == nil {
+			t.Errorf("Expected an error for %q, got nil", content)
+		}
+	}
+
+	content := "theme = \"custom\"\n[theme_colors]\naccent = \"#ff87d7\"\nmuted = \"244\"\n"
+	if err := os.WriteFile(customPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := ThemeColors{Accent: "#ff87d7", Muted: "244"}
+	if cfg.Theme != ThemeCustom || cfg.ThemeColors != want {
+		t.Errorf("Expected the custom theme with %+v, got %q with %+v", want, cfg.Theme, cfg.ThemeColors)
+	}
+
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if cfg, err = LoadConfig(customPath); err != nil || cfg.ThemeColors != want {
+		t.Errorf("Expected the theme colors to survive a save, got %+v (%v)", cfg.ThemeColors, err)
+	}
+}
+
+func TestLoadConfig_ProtectedPatterns(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "patterns.toml")
+
+	if err := os.WriteFile(customPath, []byte("protected_patterns = [\"^release/(\"]\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(customPath); err == nil {
+		t.Error("Expected an error for an invalid protected_patterns regular expression, got nil")
+	}
+
+	cfg := DefaultConfig()
+	cfg.ProtectedBranches = []string{"develop"}
+	cfg.ProtectedPatterns = []string{"^release/.*", `^hotfix/\d+$`}
+	cfg.ProtectedRemotePatterns = []string{"^feature/"}
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	loaded, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	for name, want := range map[string]bool{
+		"develop": true, "release/2.1": true, "hotfix/12": true, "hotfix/typo": false, "feature/release/x": false,
+	} {
+		if got := loaded.IsProtectedName(name); got != want {
+			t.Errorf("IsProtectedName(%q) = %t, want %t", name, got, want)
+		}
+	}
+	if !loaded.IsRemoteProtectedName("feature/x") || loaded.IsRemoteProtectedName("develop") {
+		t.Errorf("Expected only feature/x to be remote protected, got patterns %v", loaded.ProtectedRemotePatterns)
+	}
+	if loaded.IsProtectedName("feature/x") {
+		t.Error("Expected a remote protected branch to remain deletable locally")
+	}
+}
+
+func TestAgeRules(t *testing.T) {
+	tempDir := t.TempDir()
+	customPath := filepath.Join(tempDir, "rules.toml")
+
+	cfg := DefaultConfig()
+	cfg.AgeRules = []AgeRule{
+		{Pattern: "release/*", AgeDays: 365},
+		{Pattern: "wip/*", AgeDays: 14},
+	}
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	loaded, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.AgeRules, cfg.AgeRules) {
+		t.Fatalf("Age rules did not round-trip: got %+v, want %+v", loaded.AgeRules, cfg.AgeRules)
+	}
+
+	testCases := map[string]int{
+		"release/1.0":     365,
+		"release/1.0/fix": defaultAgeDays, // '*' does not cross '/'
+		"wip/spike":       14,
+		"feature/x":       defaultAgeDays,
+	}
+	for branch, want := range testCases {
+		if got := loaded.AgeDaysFor(branch); got != want {
+			t.Errorf("AgeDaysFor(%q) = %d, want %d", branch, got, want)
+		}
+	}
+
+	invalid := []string{
+		"[[age_rules]]\npattern = \"release/[\"\nage_days = 30\n",
+		"[[age_rules]]\npattern = \"release/*\"\nage_days = 0\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(customPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := LoadConfig(customPath); err == nil {
+			t.Errorf("Expected an error for invalid age rule:\n%s", content)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	tempDir := t.TempDir()
+	customPath := filepath.Join(tempDir, "rules.toml")
+
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{
+		{Match: "spike/*", Action: RuleSuggest},
+		{Match: "wip/*", Action: RuleProtect},
+	}
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	loaded, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Rules, cfg.Rules) {
+		t.Fatalf("Rules did not round-trip: got %+v, want %+v", loaded.Rules, cfg.Rules)
+	}
+	if rule, ok := loaded.RuleFor("wip/login"); !ok || rule.Action != RuleProtect {
+		t.Errorf("RuleFor(wip/login) = %+v, %v; want the protect rule", rule, ok)
+	}
+	if rule, ok := loaded.RuleFor("feature/x"); ok {
+		t.Errorf("Expected no rule for feature/x, got %+v", rule)
+	}
+
+	invalid := []string{
+		"[[rules]]\nmatch = \"spike/[\"\naction = \"suggest\"\n",
+		"[[rules]]\nmatch = \"spike/*\"\naction = \"delete\"\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(customPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := LoadConfig(customPath); err == nil {
+			t.Errorf("Expected an error for invalid rule:\n%s", content)
+		}
+	}
+}
+
+func TestCompareTarget(t *testing.T) {
+	customPath := filepath.Join(t.TempDir(), "compare.toml")
+
+	cfg := DefaultConfig()
+	if got := cfg.CompareTarget(); got != cfg.PrimaryMainBranch {
+		t.Errorf("CompareTarget() = %q, want the primary main branch %q", got, cfg.PrimaryMainBranch)
+	}
+	cfg.CompareRef = "origin/main"
+	if _, err := SaveConfig(cfg, customPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	loaded, err := LoadConfig(customPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := loaded.CompareTarget(); got != "origin/main" {
+		t.Errorf("CompareTarget() = %q, want %q", got, "origin/main")
+	}
+}
+
 // Note: Testing the default path loading (~/.config/...) is tricky in unit tests
 // as it involves the actual user's filesystem. It's often better tested manually
 // or via integration tests. The logic is largely shared with custom path loading.
+
+func TestFirstRunSetup_DetectedDefaultBranch(t *testing.T) {
+	var out strings.Builder
+
+	// Accepting all defaults keeps the detected branch
+	cfg, err := FirstRunSetup(bufio.NewReader(strings.NewReader("\n\n\n")), &out, SetupHints{DefaultBranch: "trunk"})
+	if err != nil {
+		t.Fatalf("FirstRunSetup failed: %v", err)
+	}
+	if cfg.PrimaryMainBranch != "trunk" || !strings.Contains(out.String(), "[trunk]") {
+		t.Errorf("Expected detected branch to be offered and used, got %q; output:\n%s",
+			cfg.PrimaryMainBranch, out.String())
+	}
+
+	// Without a detected branch the default is to detect it at runtime
+	cfg, err = FirstRunSetup(bufio.NewReader(strings.NewReader("\n\n\n")), &out, SetupHints{})
+	if err != nil {
+		t.Fatalf("FirstRunSetup failed: %v", err)
+	}
+	if cfg.PrimaryMainBranch != PrimaryMainAuto {
+		t.Errorf("Expected %q without a detected branch, got %q", PrimaryMainAuto, cfg.PrimaryMainBranch)
+	}
+}
+
+func TestFirstRunSetup_SuggestedProtectedBranches(t *testing.T) {
+	hints := SetupHints{
+		DefaultBranch: "main",
+		Branches:      []string{"develop", "feature/x", "main", "release/1.0", "releases", "staging"},
+	}
+	want := []string{"develop", "main", "release/1.0", "staging"}
+	if got := SuggestProtectedBranches(hints); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SuggestProtectedBranches() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "accept suggestions", input: "\n\n\n", want: want},
+		{name: "decline suggestions", input: "\n\nnone\n", want: []string{}},
+		{name: "custom list", input: "\n\ndevelop, qa\n", want: []string{"develop", "qa"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			cfg, err := FirstRunSetup(bufio.NewReader(strings.NewReader(tc.input)), &out, hints)
+			if err != nil {
+				t.Fatalf("FirstRunSetup failed: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.ProtectedBranches, tc.want) {
+				t.Errorf("ProtectedBranches = %v, want %v", cfg.ProtectedBranches, tc.want)
+			}
+			if !strings.Contains(out.String(), "[develop,main,release/1.0,staging]") {
+				t.Errorf("Expected suggestions in the prompt, got:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// legacyKeys are version check settings older versions kept in the configuration file. They
+// are accepted in imports, like LoadConfig accepts them, and dropped.
+var legacyKeys = map[string]bool{"last_version_check": true, "latest_known_version": true}
+
+// Export returns the TOML encoding of cfg for sharing with other machines. API tokens are left
+// out, so a configuration can be passed around without leaking them; Import keeps the tokens
+// of the configuration it replaces.
+func Export(cfg Config) ([]byte, error) {
+	cfg.ProviderToken, cfg.GitHubToken = "", ""
+	if cfg.Profiles != nil {
+		profiles := make(map[string]Profile, len(cfg.Profiles))
+		for name, p := range cfg.Profiles {
+			p.ProviderToken, p.GitHubToken = "", ""
+			profiles[name] = p
+		}
+		cfg.Profiles = profiles
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(fileContent(cfg)); err != nil {
+		return nil, fmt.Errorf("could not encode the configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Import replaces the configuration at customPath, or the default location, with the exported
+// configuration in data, read from name. Unlike LoadConfig, it rejects unknown keys, so a typo
+// in a shared configuration is not dropped unnoticed. It returns the path saved to.
+func Import(data []byte, name, customPath string) (string, error) {
+	cfg, md, err := parseConfig(data, name)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range md.Undecoded() {
+		if !legacyKeys[key.String()] {
+			return "", fmt.Errorf("unknown key %q in %s", key.String(), name)
+		}
+	}
+
+	// A missing or invalid configuration is replaced all the same, keeping what tokens it has
+	existing, _ := LoadConfig(customPath)
+	cfg.ProviderToken = cmp.Or(cfg.ProviderToken, existing.ProviderToken)
+	cfg.GitHubToken = cmp.Or(cfg.GitHubToken, existing.GitHubToken)
+	for profileName, p := range cfg.Profiles {
+		old := existing.Profiles[profileName]
+		p.ProviderToken = cmp.Or(p.ProviderToken, old.ProviderToken)
+		p.GitHubToken = cmp.Or(p.GitHubToken, old.GitHubToken)
+		cfg.Profiles[profileName] = p
+	}
+	return SaveConfig(cfg, customPath)
+}
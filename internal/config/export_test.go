@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AgeDays = 45
+	cfg.ProtectedBranches = []string{"main", "develop"}
+	cfg.ProviderToken = "secret"
+	cfg.Profiles = map[string]Profile{"work": {Remote: "upstream", GitHubToken: "work-secret"}}
+	exported, err := Export(cfg)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Contains(string(exported), "secret") {
+		t.Errorf("Expected tokens to be left out of the export, got:\n%s", exported)
+	}
+	if cfg.ProviderToken != "secret" || cfg.Profiles["work"].GitHubToken != "work-secret" {
+		t.Error("Expected Export not to change the configuration")
+	}
+
+	// The local tokens survive an import
+	path := filepath.Join(t.TempDir(), "config.toml")
+	local := "# Mine\nage_days = 10\nprovider_token = \"local\"\n\n[profile.work]\ngithub_token = \"local-work\"\n"
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(exported, "<stdin>", path); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	imported, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if imported.AgeDays != 45 || len(imported.ProtectedBranches) != 2 || imported.ProviderToken != "local" {
+		t.Errorf("Unexpected imported configuration: %+v", imported)
+	}
+	if work := imported.Profiles["work"]; work.Remote != "upstream" || work.GitHubToken != "local-work" {
+		t.Errorf("Unexpected imported profile: %+v", work)
+	}
+	if saved, _ := os.ReadFile(path); !strings.HasPrefix(string(saved), "# Mine\n") {
+		t.Errorf("Expected the comments of the replaced file to be kept, got:\n%s", saved)
+	}
+}
+
+func TestImportRejectsInvalidInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	tests := []struct {
+		name, data, want string
+	}{
+		{"unknown key", "age_day = 30\n", `unknown key "age_day"`},
+		{"invalid value", "theme = \"neon\"\n", "unsupported theme"},
+		{"syntax", "age_days = \n", "error decoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Import([]byte(tt.data), "<stdin>", path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be saved")
+	}
+
+	// Version check settings of older versions are dropped
+	if _, err := Import([]byte("age_days = 30\nlast_version_check = 1700000000\n"), "<stdin>", path); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if saved, _ := os.ReadFile(path); strings.Contains(string(saved), "version") {
+		t.Errorf("Expected the version check settings to be dropped, got:\n%s", saved)
+	}
+}
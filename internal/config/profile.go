@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ProfileEnv is the environment variable selecting a profile when --config-profile is not given.
+const ProfileEnv = "GIT_SWEEP_PROFILE"
+
+// Profile is a named set of overrides in a [profile.<name>] table, for repositories that need
+// another policy than the rest, e.g. work and personal ones. Settings it leaves out keep their
+// value from the top of the file; lists replace the top-level lists rather than adding to them.
+type Profile struct {
+	AgeDays                 *int     `toml:"age_days,omitempty"`
+	MergedAgeDays           *int     `toml:"merged_age_days,omitempty"`
+	MinAgeDays              *int     `toml:"min_age_days,omitempty"`
+	PrimaryMainBranch       string   `toml:"primary_main_branch,omitempty"`
+	ProtectedBranches       []string `toml:"protected_branches,omitempty"`
+	ProtectedPatterns       []string `toml:"protected_patterns,omitempty"`
+	ProtectedRemotePatterns []string `toml:"protected_remote_patterns,omitempty"`
+	Remote                  string   `toml:"remote,omitempty"` // Used instead of origin unless --remote is given
+	Provider                string   `toml:"provider,omitempty"`
+	ProviderToken           string   `toml:"provider_token,omitempty"`
+	GitHubToken             string   `toml:"github_token,omitempty"`
+}
+
+// validate checks the values of the profile like LoadConfig checks the top-level ones.
+func (p Profile) validate() error {
+	if p.AgeDays != nil && *p.AgeDays <= 0 {
+		return errors.New("age_days must be positive")
+	}
+	if p.MergedAgeDays != nil && *p.MergedAgeDays < 0 {
+		return errors.New("merged_age_days must not be negative")
+	}
+	if p.MinAgeDays != nil && *p.MinAgeDays < 0 {
+		return errors.New("min_age_days must not be negative")
+	}
+	if _, err := compilePatterns("protected_patterns", p.ProtectedPatterns); err != nil {
+		return err
+	}
+	if _, err := compilePatterns("protected_remote_patterns", p.ProtectedRemotePatterns); err != nil {
+		return err
+	}
+	if p.Provider != "" && p.Provider != ProviderGitHub {
+		return fmt.Errorf("unsupported provider %q (supported: %q)", p.Provider, ProviderGitHub)
+	}
+	return nil
+}
+
+// WithProfile returns the configuration with the overrides of the named profile applied.
+func (c Config) WithProfile(name string) (Config, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return c, fmt.Errorf("unknown profile %q: the config file defines no [profile.<name>] tables", name)
+		}
+		return c, fmt.Errorf("unknown profile %q (defined: %s)", name,
+			strings.Join(slices.Sorted(maps.Keys(c.Profiles)), ", "))
+	}
+	if err := p.validate(); err != nil {
+		return c, fmt.Errorf("%w in profile %q", err, name)
+	}
+
+	if p.AgeDays != nil {
+		c.AgeDays = *p.AgeDays
+	}
+	if p.MergedAgeDays != nil {
+		c.MergedAgeDays = *p.MergedAgeDays
+	}
+	if p.MinAgeDays != nil {
+		c.MinAgeDays = *p.MinAgeDays
+	}
+	if p.PrimaryMainBranch != "" {
+		c.PrimaryMainBranch = p.PrimaryMainBranch
+	}
+	if p.ProtectedBranches != nil {
+		c.ProtectedBranches = p.ProtectedBranches
+		c.ProtectedBranchMap = make(map[string]bool, len(p.ProtectedBranches))
+		for _, branch := range p.ProtectedBranches {
+			c.ProtectedBranchMap[branch] = true
+		}
+	}
+	if p.ProtectedPatterns != nil {
+		c.ProtectedPatterns = p.ProtectedPatterns
+		c.ProtectedRegexps, _ = compilePatterns("protected_patterns", p.ProtectedPatterns)
+	}
+	if p.ProtectedRemotePatterns != nil {
+		c.ProtectedRemotePatterns = p.ProtectedRemotePatterns
+		c.ProtectedRemoteRegexps, _ = compilePatterns("protected_remote_patterns", p.ProtectedRemotePatterns)
+	}
+	if p.Provider != "" {
+		c.Provider = p.Provider
+	}
+	if p.ProviderToken != "" {
+		c.ProviderToken = p.ProviderToken
+	}
+	if p.GitHubToken != "" {
+		c.GitHubToken = p.GitHubToken
+	}
+	c.ActiveProfile = name
+	return c, nil
+}
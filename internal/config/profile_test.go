@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `age_days = 90
+protected_branches = ["main", "develop"]
+provider_token = "top"
+
+[profile.work]
+age_days = 30
+protected_branches = ["main", "release"]
+protected_patterns = ["^hotfix/"]
+remote = "upstream"
+
+[profile.oss]
+merged_age_days = 0
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	work, err := cfg.WithProfile("work")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	if work.AgeDays != 30 || work.ActiveProfile != "work" || work.ProviderToken != "top" {
+		t.Errorf("Unexpected configuration: %+v", work)
+	}
+	if !work.ProtectedBranchMap["release"] || work.ProtectedBranchMap["develop"] {
+		t.Errorf("Expected the profile's protected branches to replace the others, got %v", work.ProtectedBranchMap)
+	}
+	if len(work.ProtectedRegexps) != 1 || !work.ProtectedRegexps[0].MatchString("hotfix/x") {
+		t.Errorf("Expected the profile's patterns to be compiled, got %v", work.ProtectedRegexps)
+	}
+	if cfg.AgeDays != 90 || !cfg.ProtectedBranchMap["develop"] {
+		t.Error("Expected WithProfile not to change the original configuration")
+	}
+
+	oss, err := cfg.WithProfile("oss")
+	if err != nil || oss.AgeDays != 90 || oss.MergedAgeDays != 0 {
+		t.Errorf("Expected only merged_age_days to be overridden, got %+v, %v", oss, err)
+	}
+
+	if _, err := cfg.WithProfile("home"); err == nil || !strings.Contains(err.Error(), "oss, work") {
+		t.Errorf("Expected an error listing the defined profiles, got %v", err)
+	}
+
+	// Saving keeps the profiles
+	if _, err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != content {
+		t.Errorf("Expected the profiles to be kept as written, got:\n%s", saved)
+	}
+	newPath := filepath.Join(t.TempDir(), "new.toml")
+	if _, err := SaveConfig(cfg, newPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	reloaded, err := LoadConfig(newPath)
+	if err != nil || !slices.Equal(reloaded.Profiles["work"].ProtectedBranches, []string{"main", "release"}) {
+		t.Errorf("Unexpected profiles after reload: %+v, %v", reloaded.Profiles, err)
+	}
+}
+
+func TestLoadConfigInvalidProfile(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"age", "[profile.work]\nage_days = 0\n", "age_days must be positive"},
+		{"pattern", "[profile.work]\nprotected_patterns = [\"(\"]\n", "protected_patterns"},
+		{"provider", "[profile.work]\nprovider = \"gitea\"\n", "unsupported provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), `"work"`) {
+				t.Errorf("Expected an error about %q in profile \"work\", got %v", tt.want, err)
+			}
+		})
+	}
+}
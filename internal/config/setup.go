@@ -4,14 +4,47 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"path"
 	"strconv"
 	"strings"
 )
 
+// protectNone is the answer to the protected branches prompt that declines all suggestions.
+const protectNone = "none"
+
+// likelyProtectedPatterns match branch names that are commonly long-lived and are therefore
+// suggested for protection during setup.
+var likelyProtectedPatterns = []string{"develop", "development", "staging", "production", "release/*"}
+
+// SetupHints carries facts about the current repository that FirstRunSetup offers as defaults.
+type SetupHints struct {
+	DefaultBranch string   // Detected default branch; empty if it could not be detected
+	Branches      []string // Existing local and remote branch names, without the remote prefix
+}
+
+// SuggestProtectedBranches returns the existing branches that are likely long-lived: the
+// detected default branch and any branch matching likelyProtectedPatterns, in hint order.
+func SuggestProtectedBranches(hints SetupHints) []string {
+	var suggested []string
+	for _, branch := range hints.Branches {
+		if branch == hints.DefaultBranch {
+			suggested = append(suggested, branch)
+			continue
+		}
+		for _, pattern := range likelyProtectedPatterns {
+			if matched, _ := path.Match(pattern, branch); matched {
+				suggested = append(suggested, branch)
+				break
+			}
+		}
+	}
+	return suggested
+}
+
 // FirstRunSetup prompts the user for initial configuration values when no config file is found.
 // It takes an input reader and output writer for flexibility (e.g., testing).
 // It returns the generated Config struct based on user input or defaults.
-func FirstRunSetup(reader *bufio.Reader, writer io.Writer) (Config, error) {
+func FirstRunSetup(reader *bufio.Reader, writer io.Writer, hints SetupHints) (Config, error) {
 	// Ignore bytes written and error
 	_, _ = fmt.Fprintln(writer, "Configuration file not found. Let's set up some defaults.")
 	cfg := DefaultConfig() // Start with defaults
@@ -33,11 +66,14 @@ func FirstRunSetup(reader *bufio.Reader, writer io.Writer) (Config, error) {
 		}
 	} // else keep default
 
-	// Prompt for Primary Main Branch
+	// Prompt for Primary Main Branch, offering the detected default branch
+	if hints.DefaultBranch != "" {
+		cfg.PrimaryMainBranch = hints.DefaultBranch
+	}
 	// Ignore bytes written and error
 	_, _ = fmt.Fprintf(writer,
-		"Enter the name of your primary development branch (e.g., main, master) [%s]: ",
-		defaultMainBranch)
+		"Enter the name of your primary development branch (e.g., main, master, or %q to detect it) [%s]: ",
+		PrimaryMainAuto, cfg.PrimaryMainBranch)
 	input, _ = reader.ReadString('\n')
 	input = strings.TrimSpace(input)
 	if input != "" { // Add missing opening brace
@@ -45,12 +81,22 @@ func FirstRunSetup(reader *bufio.Reader, writer io.Writer) (Config, error) {
 		cfg.PrimaryMainBranch = input
 	} // else keep default
 
-	// Prompt for Protected Branches
+	// Prompt for Protected Branches, pre-populated with likely long-lived branches
+	suggested := SuggestProtectedBranches(hints)
 	_, _ = fmt.Fprint(writer, "Enter any branches to protect from deletion ") // Ignore bytes written and error
-	_, _ = fmt.Fprintln(writer, "(comma-separated, e.g., develop,release): ") // Ignore bytes written and error
+	if len(suggested) > 0 {
+		_, _ = fmt.Fprintf(writer, "(comma-separated, %q for none) [%s]: \n", protectNone, strings.Join(suggested, ","))
+	} else {
+		_, _ = fmt.Fprintln(writer, "(comma-separated, e.g., develop,release): ") // Ignore bytes written and error
+	}
 	input, _ = reader.ReadString('\n')
 	input = strings.TrimSpace(input)
-	if input != "" {
+	switch {
+	case input == "":
+		cfg.ProtectedBranches = append(cfg.ProtectedBranches, suggested...)
+	case strings.EqualFold(input, protectNone):
+		cfg.ProtectedBranches = []string{}
+	default:
 		protected := strings.Split(input, ",")
 		cfg.ProtectedBranches = make([]string, 0, len(protected)) // Initialize slice
 		for _, p := range protected {
@@ -59,7 +105,7 @@ func FirstRunSetup(reader *bufio.Reader, writer io.Writer) (Config, error) {
 				cfg.ProtectedBranches = append(cfg.ProtectedBranches, trimmed)
 			}
 		}
-	} // else keep default (empty list)
+	}
 
 	// Populate the map based on the final list
 	cfg.ProtectedBranchMap = make(map[string]bool)
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlBlock is a part of a TOML document belonging to one top-level key: a key = value
+// statement before the first table, or a [table] or [[array of tables]] section. It spans
+// the lines [start, end).
+type tomlBlock struct {
+	key     string // Top-level key: the first segment of the key or table name
+	section bool   // A table section rather than a statement
+	start   int
+	end     int
+	comment string // Trailing comment of a single-line statement, including the '#'
+}
+
+// editConfigFile returns the configuration file existing edited to hold updated, the
+// encoding of the configuration to save, as described for editTOML.
+func editConfigFile(existing, updated []byte) ([]byte, error) {
+	var defaults bytes.Buffer
+	if err := toml.NewEncoder(&defaults).Encode(fileContent(DefaultConfig())); err != nil {
+		return nil, fmt.Errorf("could not encode the default configuration: %w", err)
+	}
+	return editTOML(existing, updated, defaults.Bytes(), tomlKeys(reflect.TypeOf(fileContent(Config{}))))
+}
+
+// editTOML returns the document existing changed to hold the values of updated, a complete
+// encoding of the new configuration. Keys with the same value in both keep their text, with
+// their comments and formatting; changed keys are rewritten where they were and keys missing
+// from updated are deleted, unless known lists them and they are missing only because they
+// are unset. Keys new in updated are added unless they have their value in defaults.
+func editTOML(existing, updated, defaults []byte, known map[string]bool) ([]byte, error) {
+	var oldValues, newValues, defaultValues map[string]any
+	if _, err := toml.Decode(string(existing), &oldValues); err != nil {
+		return nil, fmt.Errorf("could not parse the existing file: %w", err)
+	}
+	if _, err := toml.Decode(string(updated), &newValues); err != nil {
+		return nil, fmt.Errorf("could not parse the new configuration: %w", err)
+	}
+	if _, err := toml.Decode(string(defaults), &defaultValues); err != nil {
+		return nil, fmt.Errorf("could not parse the default configuration: %w", err)
+	}
+	lines, newLines := splitLines(existing), splitLines(updated)
+	blocks := splitTOML(lines)
+
+	// Text of each key in the new configuration, in its order
+	var order []string
+	replacements := map[string][]string{}
+	sections := map[string]bool{}
+	for _, b := range splitTOML(newLines) {
+		if _, ok := replacements[b.key]; !ok {
+			order = append(order, b.key)
+		} else if b.section {
+			replacements[b.key] = append(replacements[b.key], "")
+		}
+		replacements[b.key] = append(replacements[b.key], newLines[b.start:b.end]...)
+		sections[b.key] = b.section
+	}
+
+	removed := make([]bool, len(lines))
+	inserted := map[int][]string{} // Lines to write before the line at each index
+	placed := map[string]bool{}
+	topEnd, firstSection := 0, len(lines)
+	for _, b := range blocks {
+		if b.section {
+			firstSection = min(firstSection, b.start)
+		} else {
+			topEnd = b.end
+		}
+		oldValue, newValue := oldValues[b.key], newValues[b.key]
+		_, isSet := newValues[b.key]
+		if reflect.DeepEqual(oldValue, newValue) || (!isSet && known[b.key] && isZero(oldValue)) {
+			placed[b.key] = true
+			continue
+		}
+		for i := b.start; i < b.end; i++ {
+			removed[i] = true
+		}
+		if placed[b.key] || !isSet || sections[b.key] != b.section {
+			continue
+		}
+		text := append([]string(nil), replacements[b.key]...)
+		if body, ok := editTable(lines[b.start:b.end], text); ok {
+			text = body
+		}
+		if b.comment != "" && len(text) == 1 {
+			text[0] += " " + b.comment
+		}
+		inserted[b.start] = append(inserted[b.start], text...)
+		placed[b.key] = true
+	}
+	if topEnd == 0 {
+		topEnd = firstSection
+	}
+
+	var appended []string
+	for _, key := range order {
+		if placed[key] || reflect.DeepEqual(newValues[key], defaultValues[key]) {
+			continue
+		}
+		if sections[key] {
+			appended = append(appended, "")
+			appended = append(appended, replacements[key]...)
+		} else {
+			inserted[topEnd] = append(inserted[topEnd], replacements[key]...)
+		}
+	}
+	if topEnd < len(lines) && topEnd == firstSection && len(inserted[topEnd]) > 0 {
+		inserted[topEnd] = append(inserted[topEnd], "")
+	}
+
+	var out strings.Builder
+	for i := 0; i <= len(lines); i++ {
+		for _, line := range inserted[i] {
+			out.WriteString(line + "\n")
+		}
+		if i < len(lines) && !removed[i] {
+			out.WriteString(lines[i] + "\n")
+		}
+	}
+	for _, line := range appended {
+		out.WriteString(line + "\n")
+	}
+
+	// Never return a document that reads back differently from the new configuration
+	var result map[string]any
+	if _, err := toml.Decode(out.String(), &result); err != nil {
+		return nil, fmt.Errorf("could not edit the existing file: %w", err)
+	}
+	for key, value := range newValues {
+		if _, ok := result[key]; !ok && reflect.DeepEqual(value, defaultValues[key]) {
+			continue
+		}
+		if !reflect.DeepEqual(result[key], value) {
+			return nil, errors.New("could not edit the existing file: " + key + " does not match")
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// editTable edits the lines of a [table] section, so the comments inside it survive, to the
+// new lines of the section. It reports false for arrays of tables, which are replaced whole.
+func editTable(section, updated []string) ([]string, bool) {
+	header := strings.TrimSpace(section[0])
+	if len(updated) == 0 || strings.HasPrefix(header, "[[") || strings.HasPrefix(updated[0], "[[") ||
+		!strings.HasPrefix(updated[0], "[") || tableName(header) != tableName(updated[0]) {
+		return nil, false
+	}
+	body := make([]string, 0, len(updated)-1)
+	for _, line := range updated[1:] {
+		body = append(body, strings.TrimSpace(line))
+	}
+	edited, err := editTOML([]byte(strings.Join(section[1:], "\n")+"\n"), []byte(strings.Join(body, "\n")+"\n"), nil, nil)
+	if err != nil {
+		return nil, false
+	}
+	return append([]string{section[0]}, splitLines(edited)...), true
+}
+
+// splitLines splits a document into lines without their line endings.
+func splitLines(data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitTOML returns the blocks of the top-level keys of a document, in order. Blank lines and
+// comments between blocks belong to none, except that the comments at the end of a section
+// are taken to describe the next one.
+func splitTOML(lines []string) []tomlBlock {
+	var blocks []tomlBlock
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			i++
+		case strings.HasPrefix(trimmed, "["):
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "[") {
+				if isBlankOrComment(lines[end]) {
+					end++
+				} else {
+					end, _ = statementEnd(lines, end)
+				}
+			}
+			for end > i+1 && isBlankOrComment(lines[end-1]) {
+				end--
+			}
+			blocks = append(blocks, tomlBlock{key: firstKey(tableName(trimmed)), section: true, start: i, end: end})
+			i = end
+		default:
+			end, comment := statementEnd(lines, i)
+			b := tomlBlock{key: firstKey(trimmed[:max(0, strings.IndexByte(trimmed, '='))]), start: i, end: end}
+			if comment >= 0 && end == i+1 {
+				b.comment = strings.TrimSpace(lines[i][comment:])
+			}
+			blocks = append(blocks, b)
+			i = end
+		}
+	}
+	return blocks
+}
+
+// statementEnd returns the index of the line after the statement starting at lines[i], which
+// continues over several lines while an array, inline table or multi-line string is open, and
+// where the trailing comment starts in its last line, or -1.
+func statementEnd(lines []string, i int) (int, int) {
+	depth := 0
+	quote := "" // Delimiter of the open string
+	for ; i < len(lines); i++ {
+		line, comment := lines[i], -1
+		for j := 0; j < len(line) && comment < 0; j++ {
+			rest := line[j:]
+			switch {
+			case quote != "" && strings.HasPrefix(quote, `"`) && rest[0] == '\\':
+				j++ // Skip the escaped character
+			case quote != "":
+				if strings.HasPrefix(rest, quote) {
+					j += len(quote) - 1
+					quote = ""
+				}
+			case rest[0] == '#':
+				comment = j
+			case strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''"):
+				quote = rest[:3]
+				j += 2
+			case rest[0] == '"' || rest[0] == '\'':
+				quote = rest[:1]
+			case rest[0] == '[' || rest[0] == '{':
+				depth++
+			case rest[0] == ']' || rest[0] == '}':
+				depth--
+			}
+		}
+		if len(quote) == 1 {
+			quote = "" // Single-line strings end with the line
+		}
+		if depth <= 0 && quote == "" {
+			return i + 1, comment
+		}
+	}
+	return len(lines), -1
+}
+
+// tableName returns the name in a table header line.
+func tableName(header string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(strings.TrimSpace(header), "["), "]")
+	return strings.TrimSpace(name)
+}
+
+// firstKey returns the first segment of a dotted key or table name, without quotes.
+func firstKey(key string) string {
+	key = strings.TrimSpace(key)
+	if first, _, found := strings.Cut(key, "."); found && !strings.HasPrefix(key, `"`) {
+		key = first
+	}
+	return strings.Trim(strings.TrimSpace(key), `"'`)
+}
+
+// isBlankOrComment reports whether a line holds nothing but whitespace or a comment.
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// isZero reports whether a decoded TOML value is what an unset configuration field encodes to.
+func isZero(value any) bool {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid():
+		return true
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// tomlKeys returns the top-level keys of the TOML encoding of a struct type.
+func tomlKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ","); name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveConfigKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	existing := `# My git-sweep settings
+age_days = 60 # two months
+
+# Never touch these
+protected_branches = [
+  "main",   # trunk
+  "develop",
+]
+offline = false
+tag_prefix = "old/"
+
+[theme_colors]
+# Pink, like the logo
+accent = "212"
+`
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	// Saving what was loaded changes nothing
+	if _, err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != existing {
+		t.Errorf("Expected an unchanged configuration to be kept as written, got:\n%s", saved)
+	}
+
+	cfg.AgeDays = 90
+	cfg.MaxDelete = 20
+	cfg.TagPrefix = ""
+	cfg.ThemeColors.Accent = "99"
+	cfg.AgeRules = []AgeRule{{Pattern: "spike/*", AgeDays: 7}}
+	if _, err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# My git-sweep settings
+age_days = 90 # two months
+
+# Never touch these
+protected_branches = [
+  "main",   # trunk
+  "develop",
+]
+offline = false
+max_delete = 20
+
+[theme_colors]
+# Pink, like the logo
+accent = "99"
+
+[[age_rules]]
+  pattern = "spike/*"
+  age_days = 7
+`
+	if string(saved) != want {
+		t.Errorf("Unexpected configuration:\n%s\nwant:\n%s", saved, want)
+	}
+	reloaded, err := LoadConfig(path)
+	if err != nil || reloaded.AgeDays != 90 || reloaded.MaxDelete != 20 || reloaded.TagPrefix != "" ||
+		reloaded.ThemeColors.Accent != "99" || len(reloaded.AgeRules) != 1 || len(reloaded.ProtectedBranches) != 2 {
+		t.Errorf("Unexpected configuration after reload: %+v, %v", reloaded, err)
+	}
+}
+
+func TestEditTOML(t *testing.T) {
+	known := map[string]bool{"a": true, "b": true, "c": true, "list": true}
+	tests := []struct {
+		name, existing, updated, want string
+	}{
+		{
+			name:     "new key before the first table",
+			existing: "# Settings\n\n[t]\nx = 1\n",
+			updated:  "a = 2\n\n[t]\n  x = 1\n",
+			want:     "# Settings\n\na = 2\n\n[t]\nx = 1\n",
+		},
+		{
+			name:     "changed multi-line array",
+			existing: "list = [\n  \"x\", # first\n  \"]\",\n]\nb = 'k' # kept\n",
+			updated:  "list = [\"y\"]\nb = \"k\"\n",
+			want:     "list = [\"y\"]\nb = 'k' # kept\n",
+		},
+		{
+			name:     "unknown key removed",
+			existing: "legacy = 1\nc = 3\n",
+			updated:  "c = 3\n",
+			want:     "c = 3\n",
+		},
+		{
+			name:     "dotted key replaced by a table",
+			existing: "a = 1\nt.x = 1\n",
+			updated:  "a = 1\n\n[t]\n  x = 2\n",
+			want:     "a = 1\n\n[t]\n  x = 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := editTOML([]byte(tt.existing), []byte(tt.updated), nil, known)
+			if err != nil {
+				t.Fatalf("editTOML failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("editTOML() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := editTOML([]byte("a = [\n"), []byte("a = 1\n"), nil, known); err == nil {
+		t.Error("Expected invalid TOML not to be edited")
+	}
+}
+
+func TestSaveConfigRewritesInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("age_days = [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveConfig(DefaultConfig(), path); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if cfg, err := LoadConfig(path); err != nil || cfg.AgeDays != DefaultConfig().AgeDays {
+		t.Errorf("Expected the invalid file to be rewritten, got %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Validate checks the configuration file at customPath, or at the default location, more
+// strictly than LoadConfig, which only rejects values it cannot use. Besides those errors, it
+// reports unknown keys, invalid values LoadConfig replaces with defaults, and settings that
+// conflict with each other or have no effect. It returns ErrConfigNotFound if there is no
+// configuration file.
+func Validate(customPath string) ([]string, error) {
+	configPath, err := ResolvePath(customPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(configPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrConfigNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking config path %q: %w", configPath, err)
+	}
+
+	var raw Config
+	md, err := toml.DecodeFile(configPath, &raw)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	var problems []string
+	for _, key := range md.Undecoded() {
+		problems = append(problems, fmt.Sprintf("unknown key %q", key.String()))
+	}
+	problems = append(problems, defaultedValues(md, raw)...)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return append(problems, err.Error()), nil
+	}
+	return append(problems, conflicts(cfg)...), nil
+}
+
+// defaultedValues describes the values set in the file that LoadConfig replaces with defaults.
+func defaultedValues(md toml.MetaData, raw Config) []string {
+	defaults := DefaultConfig()
+	checks := []struct {
+		key         string
+		invalid     bool
+		value       any
+		rule        string
+		replacement any
+	}{
+		{"age_days", raw.AgeDays <= 0, raw.AgeDays, "must be positive", defaults.AgeDays},
+		{"merged_age_days", raw.MergedAgeDays < 0, raw.MergedAgeDays, "must not be negative", 0},
+		{"min_age_days", raw.MinAgeDays < 0, raw.MinAgeDays, "must not be negative", 0},
+		{"merge_check_min_days", raw.MergeCheckMinDays < 0, raw.MergeCheckMinDays, "must not be negative", 0},
+		{"primary_main_branch", raw.PrimaryMainBranch == "", raw.PrimaryMainBranch, "must not be empty",
+			defaults.PrimaryMainBranch},
+		{"remote_delete_workers", raw.RemoteDeleteWorkers <= 0, raw.RemoteDeleteWorkers, "must be positive",
+			defaults.RemoteDeleteWorkers},
+		{"remote_rate_limit", raw.RemoteRateLimit < 0, raw.RemoteRateLimit, "must not be negative", 0},
+		{"snooze_days", raw.SnoozeDays <= 0, raw.SnoozeDays, "must be positive", defaults.SnoozeDays},
+		{"max_delete", raw.MaxDelete < 0, raw.MaxDelete, "must not be negative", 0},
+		{"fetch_timeout_seconds", raw.FetchTimeoutSeconds <= 0, raw.FetchTimeoutSeconds, "must be positive",
+			defaults.FetchTimeoutSeconds},
+		{"fetch_cache_minutes", raw.FetchCacheMinutes < 0, raw.FetchCacheMinutes, "must not be negative",
+			defaults.FetchCacheMinutes},
+		{"bundle_expiry_days", raw.BundleExpiryDays < 0, raw.BundleExpiryDays, "must not be negative",
+			defaults.BundleExpiryDays},
+	}
+	var problems []string
+	for _, check := range checks {
+		if check.invalid && md.IsDefined(check.key) {
+			problems = append(problems, fmt.Sprintf("%s = %#v %s; %#v is used instead",
+				check.key, check.value, check.rule, check.replacement))
+		}
+	}
+	return problems
+}
+
+// conflicts describes the settings of cfg that contradict others or never take effect.
+func conflicts(cfg Config) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for _, rule := range cfg.AgeRules {
+		if seen[rule.Pattern] {
+			problems = append(problems, fmt.Sprintf("age_rules pattern %q is listed twice; only the first entry applies",
+				rule.Pattern))
+		}
+		seen[rule.Pattern] = true
+	}
+	clear(seen)
+	for _, rule := range cfg.Rules {
+		if seen[rule.Match] {
+			problems = append(problems, fmt.Sprintf("rules match %q is listed twice; only the first entry applies",
+				rule.Match))
+		}
+		seen[rule.Match] = true
+		if rule.Action != RuleSuggest {
+			continue
+		}
+		for _, name := range cfg.ProtectedBranches {
+			if matched, _ := path.Match(rule.Match, name); matched {
+				problems = append(problems, fmt.Sprintf("rules match %q suggests %q, which protected_branches protects",
+					rule.Match, name))
+			}
+		}
+	}
+	if cfg.MinAgeDays > cfg.AgeDays {
+		problems = append(problems, fmt.Sprintf(
+			"min_age_days (%d) is above age_days (%d), so unmerged branches are only suggested after %d days",
+			cfg.MinAgeDays, cfg.AgeDays, cfg.MinAgeDays))
+	}
+	if cfg.ArchiveMode != "" && !cfg.Archive {
+		problems = append(problems, "archive_mode has no effect unless archive = true")
+	}
+	if cfg.ThemeColors != (ThemeColors{}) && cfg.Theme != ThemeCustom {
+		problems = append(problems, fmt.Sprintf("theme_colors has no effect unless theme = %q", ThemeCustom))
+	}
+	return problems
+}
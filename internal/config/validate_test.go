@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			content: "age_days = 60\nprotected_branches = [\"main\"]\n\n[[rules]]\nmatch = \"spike/*\"\naction = \"suggest\"\n",
+		},
+		{
+			name:    "syntax error",
+			content: "age_days = [\n",
+			want:    []string{`toml: line 1 (last key "age_days"): unexpected EOF; expected value`},
+		},
+		{
+			name: "unknown keys and defaulted values",
+			content: "age_day = 10\nage_days = -5\nsnooze_days = 0\nprimary_main_branch = \"\"\n\n" +
+				"[theme_colors]\nacent = \"1\"\n",
+			want: []string{
+				`unknown key "age_day"`,
+				`unknown key "theme_colors.acent"`,
+				"age_days = -5 must be positive; 90 is used instead",
+				`primary_main_branch = "" must not be empty; "auto" is used instead`,
+				"snooze_days = 0 must be positive; 30 is used instead",
+			},
+		},
+		{
+			name: "conflicts",
+			content: "age_days = 10\nmin_age_days = 20\nprotected_branches = [\"release/1\"]\narchive_mode = \"tag\"\n\n" +
+				"[[rules]]\nmatch = \"release/*\"\naction = \"suggest\"\n\n[[rules]]\nmatch = \"release/*\"\naction = \"keep\"\n",
+			want: []string{
+				`rules match "release/*" suggests "release/1", which protected_branches protects`,
+				`rules match "release/*" is listed twice; only the first entry applies`,
+				"min_age_days (20) is above age_days (10), so unmerged branches are only suggested after 20 days",
+				"archive_mode has no effect unless archive = true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			problems, err := Validate(path)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			if !reflect.DeepEqual(problems, tt.want) {
+				t.Errorf("Validate() = %q, want %q", problems, tt.want)
+			}
+		})
+	}
+
+	invalid := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(invalid, []byte("[[rules]]\nmatch = \"x\"\naction = \"drop\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := Validate(invalid); err != nil || len(problems) != 1 {
+		t.Errorf("Expected the error of LoadConfig to be reported, got %q, %v", problems, err)
+	}
+	if _, err := Validate(filepath.Join(t.TempDir(), "missing.toml")); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package fileutil replaces files atomically and serializes updates to files shared by
+// concurrent git-sweep runs.
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockSuffix   = ".lock"
+	lockTimeout  = 5 * time.Second
+	lockInterval = 10 * time.Millisecond
+	// A lock held this long was left behind by a run that crashed; updates take milliseconds
+	staleLockAge = 30 * time.Second
+)
+
+// Replace writes data to a temporary file next to path and renames it over path, so
+// concurrent git-sweep runs never read or leave behind a partly written file. An existing
+// file keeps its permissions; a new one is only readable by its owner, as it may hold
+// tokens. If path is a symlink, as with configurations kept in a dotfiles repository, the
+// file it points to is replaced.
+func Replace(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if info, err := os.Stat(path); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lock takes an advisory lock on path by creating path.lock, waiting for another run to
+// release it, so a load-modify-save of path does not drop the changes of a concurrent one.
+// A lock file older than staleLockAge is taken over. Call the returned function to release
+// the lock.
+func Lock(path string) (func(), error) {
+	lockPath := path + lockSuffix
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("could not lock %q: %w", path, err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not lock %q: another git-sweep run holds %s", path, lockPath)
+		}
+		time.Sleep(lockInterval)
+	}
+}
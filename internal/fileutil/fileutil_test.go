@@ -0,0 +1,85 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Replace(path, []byte(strings.Repeat(strconv.Itoa(i), 4096))); err != nil {
+				t.Errorf("Replace failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) != 4096 || strings.Count(string(data), string(data[:1])) != 4096 {
+		t.Errorf("Expected the complete content of one write, got %d bytes: %v", len(data), err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Unexpected permissions: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected no leftover temporary files, got %d entries", len(entries))
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if err != nil {
+				t.Errorf("Lock failed: %v", err)
+				return
+			}
+			defer unlock()
+			data, _ := os.ReadFile(path)
+			n, _ := strconv.Atoi(string(data))
+			if err := Replace(path, []byte(strconv.Itoa(n+1))); err != nil {
+				t.Errorf("Replace failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every load-modify-save saw the previous one
+	if data, _ := os.ReadFile(path); string(data) != "10" {
+		t.Errorf("Expected 10 increments, got %q", data)
+	}
+	if _, err := os.Stat(path + lockSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, got %v", err)
+	}
+}
+
+func TestLockTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path+lockSuffix, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path+lockSuffix, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+	}
+	unlock()
+}
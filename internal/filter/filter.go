@@ -0,0 +1,131 @@
+// Package filter narrows analyzed branches down to the ones selected on the command line.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// ageUnits maps the suffixes accepted by ParseAge to their length in days.
+var ageUnits = map[byte]int{'d': 1, 'w': 7, 'm': 30, 'y': 365}
+
+// Filter selects branches by age and name. The zero value selects every branch.
+type Filter struct {
+	OlderThanDays int      // Only branches whose last commit is more than this many days old (0 = any age)
+	Patterns      []string // Only branches matching at least one of these globs (empty = any name)
+	Excludes      []string // Never branches matching any of these globs
+}
+
+// ParseAge converts an age such as "180d", "6w", "3m" or "1y" to days. A bare number is
+// taken as days; a month counts as 30 days and a year as 365.
+func ParseAge(s string) (int, error) {
+	number := strings.TrimSpace(s)
+	unit := 1
+	if number != "" {
+		if days, ok := ageUnits[number[len(number)-1]]; ok {
+			unit = days
+			number = number[:len(number)-1]
+		}
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid age %q (use a number of days or a suffix d, w, m or y, e.g. 180d)", s)
+	}
+	return n * unit, nil
+}
+
+// Validate checks that all patterns are well-formed globs.
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Patterns...), f.Excludes...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether the filter selects every branch.
+func (f Filter) IsZero() bool {
+	return f.OlderThanDays == 0 && len(f.Patterns) == 0 && len(f.Excludes) == 0
+}
+
+// Matches reports whether the branch passes the filter at the given time.
+// Patterns use path.Match syntax, so "feature/*" matches "feature/x" but not "feature/x/y".
+func (f Filter) Matches(branch types.AnalyzedBranch, now time.Time) bool {
+	if f.OlderThanDays > 0 && int(now.Sub(branch.LastCommitDate).Hours()/24) <= f.OlderThanDays {
+		return false
+	}
+	if len(f.Patterns) > 0 && !matchAny(f.Patterns, branch.Name) {
+		return false
+	}
+	return !matchAny(f.Excludes, branch.Name)
+}
+
+// Apply returns the branches that pass the filter, preserving their order.
+func (f Filter) Apply(branches []types.AnalyzedBranch, now time.Time) []types.AnalyzedBranch {
+	if f.IsZero() {
+		return branches
+	}
+	selected := make([]types.AnalyzedBranch, 0, len(branches))
+	for _, branch := range branches {
+		if f.Matches(branch, now) {
+			selected = append(selected, branch)
+		}
+	}
+	return selected
+}
+
+// Selection picks the candidates preselected in the interactive UI. The zero value selects none.
+type Selection struct {
+	All      bool     // Every candidate
+	Merged   bool     // Candidates merged into the main branch
+	Unmerged bool     // Candidates that are not merged
+	Patterns []string // Candidates matching at least one of these globs
+}
+
+// ParseSelection builds a Selection from --select values, each either "merged", "unmerged"
+// or a glob, and --select-all.
+func ParseSelection(values []string, all bool) (Selection, error) {
+	s := Selection{All: all}
+	for _, value := range values {
+		switch value {
+		case "merged":
+			s.Merged = true
+		case "unmerged":
+			s.Unmerged = true
+		default:
+			if _, err := path.Match(value, ""); err != nil {
+				return Selection{}, fmt.Errorf("invalid pattern %q: %w", value, err)
+			}
+			s.Patterns = append(s.Patterns, value)
+		}
+	}
+	return s, nil
+}
+
+// IsZero reports whether the selection selects no branch.
+func (s Selection) IsZero() bool {
+	return !s.All && !s.Merged && !s.Unmerged && len(s.Patterns) == 0
+}
+
+// Matches reports whether the branch is selected. Whether it is a candidate at all is up to
+// the caller.
+func (s Selection) Matches(branch types.AnalyzedBranch) bool {
+	return s.All || (s.Merged && branch.IsMerged) || (s.Unmerged && !branch.IsMerged) ||
+		matchAny(s.Patterns, branch.Name)
+}
+
+// matchAny reports whether name matches at least one of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestParseAge(t *testing.T) {
+	for input, want := range map[string]int{"180d": 180, "180": 180, "2w": 14, "6m": 180, "1y": 365, " 0d ": 0} {
+		got, err := ParseAge(input)
+		if err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "d", "-5d", "10h", "ten days"} {
+		if _, err := ParseAge(input); err == nil {
+			t.Errorf("ParseAge(%q): expected an error", input)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Filter{Patterns: []string{"feature/*"}, Excludes: []string{"feature/keep-*"}}).Validate(); err != nil {
+		t.Errorf("Expected valid patterns, got %v", err)
+	}
+	if err := (Filter{Excludes: []string{"feature/["}}).Validate(); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	branch := func(name string, days int) types.AnalyzedBranch {
+		return types.AnalyzedBranch{BranchInfo: types.BranchInfo{Name: name, LastCommitDate: now.AddDate(0, 0, -days)}}
+	}
+	branches := []types.AnalyzedBranch{
+		branch("feature/old", 200),
+		branch("feature/new", 10),
+		branch("feature/keep-me", 300),
+		branch("feature/nested/old", 300),
+		branch("bugfix/old", 400),
+	}
+
+	f := Filter{OlderThanDays: 180, Patterns: []string{"feature/*"}, Excludes: []string{"feature/keep-*"}}
+	var names []string
+	for _, b := range f.Apply(branches, now) {
+		names = append(names, b.Name)
+	}
+	if want := []string{"feature/old"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Apply() = %v, want %v", names, want)
+	}
+
+	if got := (Filter{}).Apply(branches, now); len(got) != len(branches) {
+		t.Errorf("Expected the zero filter to keep all %d branches, got %d", len(branches), len(got))
+	}
+}
+
+func TestSelection(t *testing.T) {
+	merged := types.AnalyzedBranch{BranchInfo: types.BranchInfo{Name: "bugfix/done"}, IsMerged: true}
+	feature := types.AnalyzedBranch{BranchInfo: types.BranchInfo{Name: "feature/wip"}}
+	other := types.AnalyzedBranch{BranchInfo: types.BranchInfo{Name: "spike"}}
+
+	s, err := ParseSelection([]string{"merged", "feature/*"}, false)
+	if err != nil {
+		t.Fatalf("ParseSelection() error: %v", err)
+	}
+	for branch, want := range map[string]bool{"bugfix/done": true, "feature/wip": true, "spike": false} {
+		b := map[string]types.AnalyzedBranch{"bugfix/done": merged, "feature/wip": feature, "spike": other}[branch]
+		if got := s.Matches(b); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", branch, got, want)
+		}
+	}
+
+	if all, _ := ParseSelection(nil, true); !all.Matches(other) {
+		t.Error("Expected --select-all to select every branch")
+	}
+	if none, _ := ParseSelection(nil, false); !none.IsZero() || none.Matches(merged) {
+		t.Error("Expected the zero selection to select nothing")
+	}
+	if _, err := ParseSelection([]string{"feature/["}, false); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
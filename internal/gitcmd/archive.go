@@ -0,0 +1,99 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+)
+
+// Archive modes accepted by ArchiveBranch.
+const (
+	ArchiveModeRef = "ref" // Keep the tip under refs/archive/<name>, hidden from 'git branch' and 'git tag'
+	ArchiveModeTag = "tag" // Keep the tip as the tag archive/<name>
+
+	// ArchiveRefPrefix is the namespace used by ArchiveModeRef.
+	ArchiveRefPrefix = "refs/archive/"
+	// ArchiveTagPrefix is the tag name prefix used by ArchiveModeTag.
+	ArchiveTagPrefix = "archive/"
+)
+
+// ArchiveArgs returns the git arguments ArchiveBranch runs to archive branchName at hash.
+func ArchiveArgs(mode, branchName, hash string) []string {
+	name := ArchiveName(mode, branchName)
+	if mode == ArchiveModeTag {
+		return TagArgs(name, hash)
+	}
+	// An empty old value makes update-ref fail if the ref already exists
+	return []string{"update-ref", name, hash, ""}
+}
+
+// TagArgs returns the git arguments TagBranch runs to tag hash as tagName.
+func TagArgs(tagName, hash string) []string {
+	return []string{"tag", tagName, hash}
+}
+
+// TagBranch creates a lightweight tag pointing at the given commit using 'git tag <tag> <hash>'.
+// It fails if the tag already exists.
+func TagBranch(ctx context.Context, tagName, hash string) error {
+	if tagName == "" || hash == "" {
+		return fmt.Errorf("tag name and hash cannot be empty")
+	}
+	if _, err := RunGitCommand(ctx, TagArgs(tagName, hash)...); err != nil {
+		return fmt.Errorf("failed to create tag %q at %s: %w", tagName, hash, err)
+	}
+	return nil
+}
+
+// DeleteTag removes the tag tagName using 'git tag -d'.
+func DeleteTag(ctx context.Context, tagName string) error {
+	if _, err := RunGitCommand(ctx, "tag", "-d", tagName); err != nil {
+		return fmt.Errorf("failed to delete tag %q: %w", tagName, err)
+	}
+	return nil
+}
+
+// MoveRef creates the fully qualified ref pointing at the given commit using 'git update-ref'.
+// It refuses to overwrite an existing ref so earlier archives are never lost.
+func MoveRef(ctx context.Context, ref, hash string) error {
+	if ref == "" || hash == "" {
+		return fmt.Errorf("ref and hash cannot be empty")
+	}
+	// An empty old value makes update-ref fail if the ref already exists
+	if _, err := RunGitCommand(ctx, "update-ref", ref, hash, ""); err != nil {
+		return fmt.Errorf("failed to create ref %q at %s: %w", ref, hash, err)
+	}
+	return nil
+}
+
+// ArchiveName returns the ref (ArchiveModeRef) or tag (ArchiveModeTag) a branch is archived to.
+func ArchiveName(mode, branchName string) string {
+	if mode == ArchiveModeTag {
+		return ArchiveTagPrefix + branchName
+	}
+	return ArchiveRefPrefix + branchName
+}
+
+// RemoveArchive removes the ref or tag ArchiveBranch created for branchName.
+func RemoveArchive(ctx context.Context, mode, branchName string) error {
+	name := ArchiveName(mode, branchName)
+	if mode == ArchiveModeTag {
+		return DeleteTag(ctx, name)
+	}
+	if _, err := RunGitCommand(ctx, "update-ref", "-d", name); err != nil {
+		return fmt.Errorf("failed to delete ref %q: %w", name, err)
+	}
+	return nil
+}
+
+// ArchiveBranch preserves the commit a branch points at so it can be deleted without losing history.
+// It returns the ref or tag name the branch was archived to.
+func ArchiveBranch(ctx context.Context, mode, branchName, hash string) (string, error) {
+	name := ArchiveName(mode, branchName)
+	switch mode {
+	case ArchiveModeTag:
+		return name, TagBranch(ctx, name, hash)
+	case ArchiveModeRef, "":
+		return name, MoveRef(ctx, name, hash)
+	default:
+		return "", fmt.Errorf("unknown archive mode %q (supported: %q, %q)", mode, ArchiveModeRef, ArchiveModeTag)
+	}
+}
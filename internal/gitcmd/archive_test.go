@@ -0,0 +1,156 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestArchiveBranch(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		mode     string
+		wantName string
+		wantArgs []string
+	}{
+		{ArchiveModeRef, "refs/archive/feature/x", []string{"update-ref", "refs/archive/feature/x", "abc123", ""}},
+		{"", "refs/archive/feature/x", []string{"update-ref", "refs/archive/feature/x", "abc123", ""}},
+		{ArchiveModeTag, "archive/feature/x", []string{"tag", "archive/feature/x", "abc123"}},
+	}
+	for _, tc := range testCases {
+		var gotArgs []string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			gotArgs = args
+			return "", nil
+		})
+		name, err := ArchiveBranch(ctx, tc.mode, "feature/x", "abc123")
+		teardown()
+		if err != nil {
+			t.Fatalf("mode %q: unexpected error: %v", tc.mode, err)
+		}
+		if name != tc.wantName || !reflect.DeepEqual(gotArgs, tc.wantArgs) {
+			t.Errorf("mode %q: got %q with args %q, want %q with args %q",
+				tc.mode, name, gotArgs, tc.wantName, tc.wantArgs)
+		}
+	}
+
+	if _, err := ArchiveBranch(ctx, "zip", "feature/x", "abc123"); err == nil {
+		t.Error("Expected an error for an unknown archive mode")
+	}
+}
+
+func TestDeleteBranchWithArchive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Archives Before Deleting", func(t *testing.T) {
+		var calls []string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[0] == "rev-parse" {
+				return "h1", nil // The branch has not moved since it was analyzed
+			}
+			return "", nil
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "old", Hash: "h1", Archive: ArchiveModeTag}, false)
+		if !res.Success || res.ArchivedAs != "archive/old" {
+			t.Errorf("Expected successful archived deletion, got %+v", res)
+		}
+		expected := []string{"rev-parse --verify --quiet refs/heads/old", "tag archive/old h1", "branch -D old"}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("Unexpected git calls: got %q, want %q", calls, expected)
+		}
+	})
+
+	t.Run("Archive Failure Skips Deletion", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			switch args[0] {
+			case "rev-parse":
+				return "h1", nil
+			case "branch":
+				t.Errorf("Branch must not be deleted when archiving fails")
+			}
+			return "", errors.New("fatal: ref already exists")
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "old", Hash: "h1", Archive: ArchiveModeRef}, false)
+		if res.Success || res.ArchivedAs != "" || !strings.Contains(res.Message, "already exists") {
+			t.Errorf("Expected archive failure to be reported, got %+v", res)
+		}
+	})
+
+	t.Run("Remote Deletions Are Not Archived", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			if args[0] != "push" {
+				t.Errorf("Unexpected git call: %v", args)
+			}
+			return "", nil
+		})
+		defer teardown()
+
+		branch := BranchToDelete{Name: "old", IsRemote: true, Remote: "origin", Archive: ArchiveModeRef}
+		res := DeleteBranch(ctx, branch, false)
+		if !res.Success || res.ArchivedAs != "" {
+			t.Errorf("Expected plain remote deletion, got %+v", res)
+		}
+	})
+}
+
+func TestDeleteBranchWithTag(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Tags Before Deleting", func(t *testing.T) {
+		var calls []string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[0] == "rev-parse" {
+				return "h1", nil
+			}
+			return "", nil
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "feature/old", Hash: "h1", TagPrefix: "sweep/"}, false)
+		if !res.Success || res.Tag != "sweep/feature/old" {
+			t.Errorf("Expected successful tagged deletion, got %+v", res)
+		}
+		expected := []string{
+			"rev-parse --verify --quiet refs/heads/feature/old", "tag sweep/feature/old h1", "branch -D feature/old",
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("Unexpected git calls: got %q, want %q", calls, expected)
+		}
+	})
+
+	t.Run("Tag Failure Skips Deletion", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			switch args[0] {
+			case "rev-parse":
+				return "h1", nil
+			case "branch":
+				t.Errorf("Branch must not be deleted when tagging fails")
+			}
+			return "", errors.New("fatal: tag 'sweep/old' already exists")
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "old", Hash: "h1", TagPrefix: "sweep/"}, false)
+		if res.Success || res.Tag != "" || !strings.Contains(res.Message, "already exists") {
+			t.Errorf("Expected tag failure to be reported, got %+v", res)
+		}
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		res := DeleteBranch(ctx, BranchToDelete{Name: "old", Hash: "h1", TagPrefix: "sweep/"}, true)
+		if res.Message != "Dry Run: Would tag as sweep/old, then execute: git branch -D old" {
+			t.Errorf("Unexpected dry run message %q", res.Message)
+		}
+	})
+}
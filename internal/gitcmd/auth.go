@@ -0,0 +1,67 @@
+package gitcmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// AuthFailureMessage replaces the raw error of a remote deletion that failed because of
+// credentials; AuthHint explains how to fix them once for all such deletions.
+const AuthFailureMessage = "Failed: could not authenticate with the remote"
+
+// authFailureMarkers are fragments, in lower case, of the errors git, ssh and the hosting
+// providers print when a push is rejected because of missing or wrong credentials.
+var authFailureMarkers = []string{
+	"permission denied",
+	"could not read username",
+	"could not read password",
+	"authentication failed",
+	"error: 403",
+	"403 forbidden",
+}
+
+// IsAuthFailure reports whether res is a remote deletion that failed because git could not
+// authenticate with the remote.
+func IsAuthFailure(res types.DeleteResult) bool {
+	if res.Success || !res.IsRemote {
+		return false
+	}
+	msg := strings.ToLower(res.Message)
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthHint returns a single explanation for all remote deletions in results that failed
+// because of credentials, or "" if there are none.
+func AuthHint(results []types.DeleteResult) string {
+	count := 0
+	var remotes []string
+	for _, res := range results {
+		if !IsAuthFailure(res) {
+			continue
+		}
+		count++
+		if !slices.Contains(remotes, res.RemoteName) {
+			remotes = append(remotes, res.RemoteName)
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+
+	deletions := "1 remote deletion"
+	if count > 1 {
+		deletions = fmt.Sprintf("%d remote deletions", count)
+	}
+	return fmt.Sprintf("%s on %s failed because git could not authenticate. "+
+		"For SSH remotes, add your key to the agent (ssh-add) and make sure it may push; "+
+		"for HTTPS remotes, set up a credential helper with a personal access token that may push. "+
+		"Then run git-sweep again.", deletions, strings.Join(remotes, ", "))
+}
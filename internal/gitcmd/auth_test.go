@@ -0,0 +1,53 @@
+package gitcmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestIsAuthFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		res  types.DeleteResult
+		want bool
+	}{
+		{"SSH key rejected", types.DeleteResult{IsRemote: true,
+			Message: "Failed: git@github.com: Permission denied (publickey)."}, true},
+		{"No HTTPS credentials", types.DeleteResult{IsRemote: true,
+			Message: "Failed: fatal: could not read Username for 'https://github.com': terminal prompts disabled"}, true},
+		{"Token without push access", types.DeleteResult{IsRemote: true,
+			Message: "Failed: fatal: unable to access 'https://github.com/o/r.git/': The requested URL returned error: 403"},
+			true},
+		{"Protected branch", types.DeleteResult{IsRemote: true,
+			Message: "Failed: ! [remote rejected] feat (protected branch hook declined)"}, false},
+		{"Local branch", types.DeleteResult{Message: "Failed: Permission denied"}, false},
+		{"Succeeded", types.DeleteResult{IsRemote: true, Success: true, Message: "Permission denied"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthFailure(tt.res); got != tt.want {
+				t.Errorf("IsAuthFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthHint(t *testing.T) {
+	denied := "Failed: Permission denied (publickey)."
+	results := []types.DeleteResult{
+		{BranchName: "a", IsRemote: true, RemoteName: "origin", Message: denied},
+		{BranchName: "b", IsRemote: true, RemoteName: "origin", Message: denied},
+		{BranchName: "c", IsRemote: true, RemoteName: "upstream", Message: denied},
+		{BranchName: "d", IsRemote: true, RemoteName: "fork", Message: "Failed: remote ref does not exist"},
+		{BranchName: "a", Success: true},
+	}
+	hint := AuthHint(results)
+	if !strings.HasPrefix(hint, "3 remote deletions on origin, upstream failed") {
+		t.Errorf("Unexpected hint: %q", hint)
+	}
+	if hint := AuthHint(results[3:]); hint != "" {
+		t.Errorf("Expected no hint without authentication failures, got %q", hint)
+	}
+}
@@ -0,0 +1,69 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Backend names accepted by NewBackend.
+const (
+	BackendExec  = "exec"   // Shells out to the git binary via Runner (default)
+	BackendGoGit = "go-git" // Pure-Go implementation, requires building with -tags gogit
+)
+
+// Backend provides the repository queries used to discover and analyze branches.
+// Mutating operations (deletion, push, cherry checks) and the remaining queries of an analysis
+// (repository state, worktrees, remote dates, descriptions) always use the git binary.
+type Backend interface {
+	IsInGitRepo(ctx context.Context) (bool, error)
+	LocalBranches(ctx context.Context) ([]types.BranchInfo, error)
+	ResolveRef(ctx context.Context, name string) (string, error)
+	MergedBranches(ctx context.Context, targetHash string) (map[string]bool, error)
+	CurrentBranch(ctx context.Context) (string, error)
+}
+
+// ActiveBackend is the backend used by the application. It defaults to the
+// exec-based implementation and can be replaced via NewBackend at startup.
+var ActiveBackend Backend = ExecBackend{}
+
+// NewBackend returns the backend registered under name. An empty name selects the exec backend.
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendExec:
+		return ExecBackend{}, nil
+	case BackendGoGit:
+		return newGoGitBackend()
+	default:
+		return nil, fmt.Errorf("unknown git backend %q (supported: %q, %q)", name, BackendExec, BackendGoGit)
+	}
+}
+
+// ExecBackend implements Backend by running git commands through Runner.
+type ExecBackend struct{}
+
+// IsInGitRepo implements Backend.
+func (ExecBackend) IsInGitRepo(ctx context.Context) (bool, error) {
+	return IsInGitRepo(ctx)
+}
+
+// LocalBranches implements Backend.
+func (ExecBackend) LocalBranches(ctx context.Context) ([]types.BranchInfo, error) {
+	return GetAllLocalBranchInfo(ctx)
+}
+
+// ResolveRef implements Backend.
+func (ExecBackend) ResolveRef(ctx context.Context, name string) (string, error) {
+	return GetMainBranchHash(ctx, name)
+}
+
+// MergedBranches implements Backend.
+func (ExecBackend) MergedBranches(ctx context.Context, targetHash string) (map[string]bool, error) {
+	return GetMergedBranches(ctx, targetHash)
+}
+
+// CurrentBranch implements Backend.
+func (ExecBackend) CurrentBranch(ctx context.Context) (string, error) {
+	return GetCurrentBranchName(ctx)
+}
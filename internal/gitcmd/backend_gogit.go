@@ -0,0 +1,190 @@
+//go:build gogit
+
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// GoGitBackend implements Backend in pure Go using go-git, so listing branches, resolving
+// refs and checking merges spawn no processes on very large repositories. The other queries
+// of an analysis, such as the repository state, worktrees and remote dates, still use git.
+type GoGitBackend struct {
+	mu    sync.Mutex
+	repos map[string]*git.Repository // Opened repositories by directory; nil if there is none
+}
+
+// newGoGitBackend returns a backend that opens the repository of the directory set with
+// WithDir, or of the current directory, on first use.
+func newGoGitBackend() (Backend, error) {
+	return &GoGitBackend{repos: make(map[string]*git.Repository)}, nil
+}
+
+// open returns the repository containing the directory of ctx, or nil if it is not in one.
+func (b *GoGitBackend) open(ctx context.Context) (*git.Repository, error) {
+	dir, ok := ctx.Value(dirKey{}).(string)
+	if !ok {
+		dir = "."
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if repo, ok := b.repos[dir]; ok {
+		return repo, nil
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil && !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("failed to open repository with go-git: %w", err)
+	}
+	b.repos[dir] = repo
+	return repo, nil
+}
+
+// openExisting is open for the queries that need a repository.
+func (b *GoGitBackend) openExisting(ctx context.Context) (*git.Repository, error) {
+	repo, err := b.open(ctx)
+	if err == nil && repo == nil {
+		err = git.ErrRepositoryNotExists
+	}
+	return repo, err
+}
+
+// IsInGitRepo implements Backend.
+func (b *GoGitBackend) IsInGitRepo(ctx context.Context) (bool, error) {
+	repo, err := b.open(ctx)
+	if err != nil || repo == nil {
+		return false, err
+	}
+	if _, err := repo.Worktree(); err != nil {
+		return false, nil // Bare repositories have no working tree
+	}
+	return true, nil
+}
+
+// LocalBranches implements Backend.
+func (b *GoGitBackend) LocalBranches(ctx context.Context) ([]types.BranchInfo, error) {
+	repo, err := b.openExisting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+	iter, err := repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches := make([]types.BranchInfo, 0)
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read tip of branch %q: %w", ref.Name().Short(), err)
+		}
+		info := types.BranchInfo{
+			Name:           ref.Name().Short(),
+			LastCommitDate: commit.Committer.When,
+			CommitHash:     ref.Hash().String(),
+			AuthorEmail:    commit.Author.Email,
+			AuthorName:     commit.Author.Name,
+			Subject:        strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		}
+		if bc, ok := cfg.Branches[info.Name]; ok && bc.Remote != "" && bc.Merge != "" {
+			info.Remote = bc.Remote
+			info.Upstream = bc.Remote + "/" + bc.Merge.Short()
+			info.RemoteBranch = bc.Merge.Short()
+		}
+		branches = append(branches, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+// ResolveRef implements Backend.
+func (b *GoGitBackend) ResolveRef(ctx context.Context, name string) (string, error) {
+	repo, err := b.openExisting(ctx)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("main branch name cannot be empty")
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to get hash for branch %q: %w", name, err)
+	}
+	return hash.String(), nil
+}
+
+// MergedBranches implements Backend.
+func (b *GoGitBackend) MergedBranches(ctx context.Context, targetHash string) (map[string]bool, error) {
+	repo, err := b.openExisting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := repo.CommitObject(plumbing.NewHash(targetHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merged branches for hash %q: %w", targetHash, err)
+	}
+	iter, err := repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	merged := make(map[string]bool)
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read tip of branch %q: %w", ref.Name().Short(), err)
+		}
+		if isAncestorOrSame(commit, target) {
+			merged[ref.Name().Short()] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// isAncestorOrSame reports whether commit is reachable from target.
+func isAncestorOrSame(commit, target *object.Commit) bool {
+	if commit.Hash == target.Hash {
+		return true
+	}
+	ok, err := commit.IsAncestor(target)
+	return err == nil && ok
+}
+
+// CurrentBranch implements Backend.
+func (b *GoGitBackend) CurrentBranch(ctx context.Context) (string, error) {
+	repo, err := b.openExisting(ctx)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", nil // Detached HEAD
+	}
+	return head.Name().Short(), nil
+}
@@ -0,0 +1,10 @@
+//go:build !gogit
+
+package gitcmd
+
+import "fmt"
+
+// newGoGitBackend reports that this binary was built without go-git support.
+func newGoGitBackend() (Backend, error) {
+	return nil, fmt.Errorf("the %q backend is not available in this build (rebuild with -tags gogit)", BackendGoGit)
+}
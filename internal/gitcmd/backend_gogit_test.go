@@ -0,0 +1,57 @@
+//go:build gogit
+
+package gitcmd
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestGoGitBackend(t *testing.T) {
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"commit", "-q", "--allow-empty", "-m", "Initial commit"},
+		{"branch", "merged"},
+		{"checkout", "-q", "-b", "unmerged"},
+		{"commit", "-q", "--allow-empty", "-m", "Unmerged work"},
+		{"checkout", "-q", "main"},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	// The repository is opened from the directory of the context, not the current one
+	t.Chdir(t.TempDir())
+
+	backend, err := NewBackend(BackendGoGit)
+	if err != nil {
+		t.Fatalf("NewBackend(%q) returned error: %v", BackendGoGit, err)
+	}
+	if inRepo, err := backend.IsInGitRepo(context.Background()); err != nil || inRepo {
+		t.Errorf("IsInGitRepo outside the repository = (%t, %v), want false", inRepo, err)
+	}
+	ctx := WithDir(context.Background(), repo)
+	if inRepo, err := backend.IsInGitRepo(ctx); err != nil || !inRepo {
+		t.Errorf("IsInGitRepo = (%t, %v), want true", inRepo, err)
+	}
+	if current, err := backend.CurrentBranch(ctx); err != nil || current != "main" {
+		t.Errorf("CurrentBranch = (%q, %v), want main", current, err)
+	}
+	branches, err := backend.LocalBranches(ctx)
+	if err != nil || len(branches) != 3 {
+		t.Fatalf("LocalBranches = (%+v, %v), want 3 branches", branches, err)
+	}
+
+	hash, err := backend.ResolveRef(ctx, "main")
+	if err != nil {
+		t.Fatalf("ResolveRef returned error: %v", err)
+	}
+	merged, err := backend.MergedBranches(ctx, hash)
+	if err != nil || !merged["main"] || !merged["merged"] || merged["unmerged"] {
+		t.Errorf("MergedBranches = (%v, %v), want main and merged", merged, err)
+	}
+}
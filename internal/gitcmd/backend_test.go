@@ -0,0 +1,56 @@
+//go:build !gogit
+
+package gitcmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestNewBackend(t *testing.T) {
+	for _, name := range []string{"", BackendExec} {
+		backend, err := NewBackend(name)
+		if err != nil {
+			t.Fatalf("NewBackend(%q) returned error: %v", name, err)
+		}
+		if _, ok := backend.(ExecBackend); !ok {
+			t.Errorf("NewBackend(%q) = %T, want ExecBackend", name, backend)
+		}
+	}
+
+	if _, err := NewBackend("svn"); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+
+	_, err := NewBackend(BackendGoGit)
+	if err == nil || !strings.Contains(err.Error(), "-tags gogit") {
+		t.Errorf("Expected go-git backend to be unavailable without build tag, got: %v", err)
+	}
+}
+
+func TestExecBackendUsesRunner(t *testing.T) {
+	ctx := context.Background()
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		switch args[0] {
+		case "rev-parse":
+			return "abc123", nil
+		case "branch":
+			return "* main\n  feature/done", nil
+		}
+		return "", nil
+	})
+	defer teardown()
+
+	var backend Backend = ExecBackend{}
+	hash, err := backend.ResolveRef(ctx, "main")
+	if err != nil || hash != "abc123" {
+		t.Errorf("ResolveRef = (%q, %v), want (\"abc123\", nil)", hash, err)
+	}
+	merged, err := backend.MergedBranches(ctx, hash)
+	if err != nil || !merged["main"] || !merged["feature/done"] {
+		t.Errorf("MergedBranches = (%v, %v), want main and feature/done", merged, err)
+	}
+}
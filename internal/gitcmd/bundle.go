@@ -0,0 +1,39 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bundleDirPerm is the permission of directories created for bundles; they hold repository content.
+const bundleDirPerm = 0o750
+
+// BundleArgs returns the git arguments CreateBundle runs to write branchName to path.
+func BundleArgs(path, branchName string) []string {
+	return []string{"bundle", "create", path, "refs/heads/" + branchName}
+}
+
+// BundlePath returns the file in dir a branch is bundled to, <dir>/<name>.bundle. If that file
+// already exists, the time is appended to the name so earlier backups are never overwritten.
+func BundlePath(dir, branchName string, now time.Time) string {
+	path := filepath.Join(dir, branchName+".bundle")
+	if _, err := os.Stat(path); err == nil {
+		path = filepath.Join(dir, branchName+"-"+now.Format("20060102-150405")+".bundle")
+	}
+	return path
+}
+
+// CreateBundle writes a branch and its history to a bundle file at path, creating the
+// directory if needed. 'git fetch <path> <branch>:<branch>' restores the branch from it.
+func CreateBundle(ctx context.Context, path, branchName string) error {
+	if err := os.MkdirAll(filepath.Dir(path), bundleDirPerm); err != nil {
+		return fmt.Errorf("could not create bundle directory: %w", err)
+	}
+	if _, err := RunGitCommand(ctx, BundleArgs(path, branchName)...); err != nil {
+		return fmt.Errorf("failed to bundle %q to %s: %w", branchName, path, err)
+	}
+	return nil
+}
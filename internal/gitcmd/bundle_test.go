@@ -0,0 +1,79 @@
+package gitcmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBundlePath(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC)
+
+	path := BundlePath(dir, "feature/x", now)
+	if path != filepath.Join(dir, "feature", "x.bundle") {
+		t.Errorf("Unexpected bundle path %q", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if path := BundlePath(dir, "feature/x", now); path != filepath.Join(dir, "feature", "x-20250301-143000.bundle") {
+		t.Errorf("Expected an existing bundle not to be overwritten, got %q", path)
+	}
+}
+
+func TestDeleteBranchWithBundle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Run("Bundles Before Deleting", func(t *testing.T) {
+		var calls []string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[0] == "rev-parse" {
+				return "h1", nil
+			}
+			return "", nil
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "feature/old", Hash: "h1", BundleDir: dir}, false)
+		path := filepath.Join(dir, "feature", "old.bundle")
+		if !res.Success || res.BundlePath != path {
+			t.Errorf("Expected a successful bundled deletion, got %+v", res)
+		}
+		expected := []string{
+			"rev-parse --verify --quiet refs/heads/feature/old",
+			"bundle create " + path + " refs/heads/feature/old",
+			"branch -D feature/old",
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("Unexpected git calls: got %q, want %q", calls, expected)
+		}
+	})
+
+	t.Run("Bundle Failure Skips Deletion", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			switch args[0] {
+			case "rev-parse":
+				return "h1", nil
+			case "branch":
+				t.Errorf("Branch must not be deleted when bundling fails")
+			}
+			return "", os.ErrPermission
+		})
+		defer teardown()
+
+		res := DeleteBranch(ctx, BranchToDelete{Name: "old", Hash: "h1", BundleDir: dir}, false)
+		if res.Success || res.BundlePath != "" || !strings.Contains(res.Message, "failed to bundle") {
+			t.Errorf("Expected the bundle failure to be reported, got %+v", res)
+		}
+	})
+}
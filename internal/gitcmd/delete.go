@@ -4,7 +4,10 @@ package gitcmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bral/git-sweep-go/internal/types"
 )
@@ -15,7 +18,13 @@ type BranchToDelete struct {
 	IsRemote bool
 	Remote   string // Only used if IsRemote is true
 	IsMerged bool   // Used to determine -d vs -D for local delete
+	// How IsMerged was detected; empty if unknown. Only ancestry merges are deleted with -d
+	MergedBy types.MergeMethod
 	Hash     string // Potentially useful for logging/confirmation
+	Archive  string // Archive mode applied before a local delete (empty disables archiving)
+
+	BundleDir string // Directory a local branch is bundled to before it is deleted (empty disables bundles)
+	TagPrefix string // Prefix of the tag created at a local branch's tip before it is deleted (empty disables tags)
 }
 
 // DeleteBranches attempts to delete the specified local and remote branches.
@@ -23,66 +32,346 @@ type BranchToDelete struct {
 // detailing the outcome of each attempt.
 func DeleteBranches(ctx context.Context, branches []BranchToDelete, dryRun bool) []types.DeleteResult {
 	results := make([]types.DeleteResult, 0, len(branches))
-
 	for _, branch := range branches {
-		var cmdArgs []string
-		var cmdString string // For logging/result
-		var result types.DeleteResult
+		results = append(results, DeleteBranch(ctx, branch, dryRun))
+	}
+	return results
+}
+
+// ForceDelete reports whether the local branch is deleted with 'git branch -D': it is unmerged,
+// or merged only by a check -d does not know about (a merged pull request, git cherry or an
+// empty diff), which says nothing about commits added to the branch afterwards.
+func (b BranchToDelete) ForceDelete() bool {
+	return !b.IsMerged || (b.MergedBy != "" && b.MergedBy != types.MergedByAncestry)
+}
+
+// DeleteArgs returns the git arguments used to delete the branch:
+// 'push <remote> --delete <name>' for remote branches, and 'branch -d' or 'branch -D' for local ones.
+func DeleteArgs(branch BranchToDelete) []string {
+	switch {
+	case branch.IsRemote:
+		return []string{"push", branch.Remote, "--delete", branch.Name}
+	case branch.ForceDelete():
+		return []string{"branch", "-D", branch.Name} // Force delete
+	default:
+		return []string{"branch", "-d", branch.Name} // Safe delete
+	}
+}
 
-		result.BranchName = branch.Name
-		result.IsRemote = branch.IsRemote
-		result.RemoteName = branch.Remote
+// DeleteBranch attempts to delete a single local or remote branch and returns the outcome.
+// Callers that want to report progress incrementally can invoke it once per branch.
+func DeleteBranch(ctx context.Context, branch BranchToDelete, dryRun bool) types.DeleteResult {
+	var cmdArgs []string
+	var cmdString string // For logging/result
+	var result types.DeleteResult
 
-		if branch.IsRemote {
-			// Remote deletion
-			if branch.Remote == "" {
-				result.Success = false
-				result.Message = "Cannot delete remote branch: remote name is empty"
-				results = append(results, result)
-				continue
+	result.BranchName = branch.Name
+	result.IsRemote = branch.IsRemote
+	result.RemoteName = branch.Remote
+
+	if branch.IsRemote && branch.Remote == "" {
+		result.Success = false
+		result.Message = "Cannot delete remote branch: remote name is empty"
+		return result
+	}
+	cmdArgs = DeleteArgs(branch)
+	cmdString = "git " + strings.Join(cmdArgs, " ")
+	result.Cmd = cmdString
+
+	archive := branch.Archive != "" && !branch.IsRemote
+	if archive {
+		result.ArchivedAs = ArchiveName(branch.Archive, branch.Name)
+	}
+
+	tag := branch.TagPrefix != "" && !branch.IsRemote
+	if tag {
+		result.Tag = branch.TagPrefix + branch.Name
+	}
+	bundle := branch.BundleDir != "" && !branch.IsRemote
+
+	if dryRun {
+		result.Success = true // Indicate success in dry-run context
+		var before []string
+		if archive {
+			before = append(before, "archive as "+result.ArchivedAs)
+		}
+		if tag {
+			before = append(before, "tag as "+result.Tag)
+		}
+		if bundle {
+			before = append(before, "bundle to "+BundlePath(branch.BundleDir, branch.Name, time.Now()))
+		}
+		result.Message = fmt.Sprintf("Dry Run: Would execute: %s", cmdString)
+		if len(before) > 0 {
+			result.Message = fmt.Sprintf("Dry Run: Would %s, then execute: %s", strings.Join(before, " and "), cmdString)
+		}
+		return result
+	}
+
+	// Only delete what the user confirmed: a local branch that moved since the analysis is
+	// skipped, and a remote branch is only deleted if it is still where the last fetch saw it
+	if branch.IsRemote {
+		cmdArgs = append([]string{"push", "--force-with-lease=refs/heads/" + branch.Name}, cmdArgs[1:]...)
+		result.Cmd = "git " + strings.Join(cmdArgs, " ")
+	} else if reason := changedSinceAnalysis(ctx, branch); reason != "" {
+		result.ArchivedAs = ""
+		result.Tag = ""
+		result.Message = "Skipped: " + reason
+		return result
+	}
+
+	// Preserve the branch tip before deleting it; never delete if archiving fails
+	if archive {
+		target := branch.Hash
+		if target == "" {
+			target = branch.Name
+		}
+		if _, err := ArchiveBranch(ctx, branch.Archive, branch.Name, target); err != nil {
+			result.ArchivedAs = ""
+			result.Tag = ""
+			result.Message = fmt.Sprintf("Failed: %v", err)
+			return result
+		}
+	}
+	// Likewise, a branch that could not be tagged or whose bundle could not be written is kept
+	if tag {
+		target := branch.Hash
+		if target == "" {
+			target = branch.Name
+		}
+		if err := TagBranch(ctx, result.Tag, target); err != nil {
+			result.Tag = ""
+			result.Message = fmt.Sprintf("Failed: %v", err) + undoPreserve(ctx, branch, &result)
+			return result
+		}
+	}
+	if bundle {
+		path := BundlePath(branch.BundleDir, branch.Name, time.Now())
+		if err := CreateBundle(ctx, path, branch.Name); err != nil {
+			result.Message = fmt.Sprintf("Failed: %v", err) + undoPreserve(ctx, branch, &result)
+			return result
+		}
+		result.BundlePath = path
+	}
+
+	// Execute the actual command
+	_, err := RunGitCommand(ctx, cmdArgs...)
+	if err != nil && branch.MergedBy == types.MergedByAncestry && !branch.IsRemote && branch.Hash != "" &&
+		strings.Contains(err.Error(), "not fully merged") {
+		// The analysis found its tip reachable from a branch other than HEAD, e.g. a protected
+		// one, and the tip was verified above, so nothing is lost by forcing it
+		cmdArgs = DeleteArgs(BranchToDelete{Name: branch.Name})
+		result.Cmd = "git " + strings.Join(cmdArgs, " ")
+		_, err = RunGitCommand(ctx, cmdArgs...)
+	}
+	if err != nil {
+		result.Success = false
+		// Attempt to extract a cleaner error message from the potentially multi-line stderr
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "stderr:") {
+			parts := strings.SplitN(errMsg, "stderr:", 2)
+			if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+				errMsg = strings.TrimSpace(parts[1])
 			}
-			cmdArgs = []string{"push", branch.Remote, "--delete", branch.Name}
-			cmdString = fmt.Sprintf("git push %s --delete %s", branch.Remote, branch.Name)
-		} else {
-			// Local deletion
-			if branch.IsMerged {
-				cmdArgs = []string{"branch", "-d", branch.Name} // Safe delete
-				cmdString = fmt.Sprintf("git branch -d %s", branch.Name)
-			} else {
-				cmdArgs = []string{"branch", "-D", branch.Name} // Force delete
-				cmdString = fmt.Sprintf("git branch -D %s", branch.Name)
+		}
+		result.Message = fmt.Sprintf("Failed: %s", errMsg)
+		switch {
+		case branch.IsRemote && strings.Contains(errMsg, "(stale info)"):
+			result.Message = "Skipped: remote branch changed since the last fetch"
+		case branch.IsRemote && strings.Contains(errMsg, "remote ref does not exist"):
+			// Someone else deleted it; only the remote-tracking branch is left to clean up
+			if err := DeleteRemoteTrackingBranch(ctx, branch.Remote, branch.Name); err == nil {
+				result.Success = true
+				result.Message = "Already deleted on the remote; removed the stale remote-tracking branch"
 			}
 		}
-		result.Cmd = cmdString
+		if !branch.IsRemote {
+			result.Message += undoPreserve(ctx, branch, &result)
+		}
+	} else {
+		result.Success = true
+		result.Message = "Successfully deleted"
+		// Store the hash of the deleted branch for potential recovery info
+		result.DeletedHash = branch.Hash
+	}
+	return result
+}
+
+// undoPreserve removes the archive, tag and bundle recorded in result that were created for a
+// local branch which was then not deleted, so that the next attempt can create them again, and
+// clears them from result. It returns a note on those it could not remove, or "".
+func undoPreserve(ctx context.Context, branch BranchToDelete, result *types.DeleteResult) string {
+	var leftBehind []string
+	if result.ArchivedAs != "" {
+		if err := RemoveArchive(ctx, branch.Archive, branch.Name); err != nil {
+			leftBehind = append(leftBehind, fmt.Sprintf("%s (%v)", result.ArchivedAs, err))
+		}
+		result.ArchivedAs = ""
+	}
+	if result.Tag != "" {
+		if err := DeleteTag(ctx, result.Tag); err != nil {
+			leftBehind = append(leftBehind, fmt.Sprintf("%s (%v)", result.Tag, err))
+		}
+		result.Tag = ""
+	}
+	if result.BundlePath != "" {
+		if err := os.Remove(result.BundlePath); err != nil {
+			leftBehind = append(leftBehind, fmt.Sprintf("%s (%v)", result.BundlePath, err))
+		}
+		result.BundlePath = ""
+	}
+	if len(leftBehind) == 0 {
+		return ""
+	}
+	return "; could not remove what was created for it, remove it before retrying: " + strings.Join(leftBehind, ", ")
+}
+
+// DeleteRemoteTrackingBranch runs 'git branch -dr <remote>/<name>' to remove a remote-tracking
+// branch whose branch no longer exists on the remote, so 'git branch -a' stops listing it.
+func DeleteRemoteTrackingBranch(ctx context.Context, remoteName, branchName string) error {
+	if _, err := RunGitCommand(ctx, "branch", "-dr", remoteName+"/"+branchName); err != nil {
+		return fmt.Errorf("failed to delete remote-tracking branch %s/%s: %w", remoteName, branchName, err)
+	}
+	return nil
+}
+
+// changedSinceAnalysis returns why a local branch must be left alone because it no longer
+// points at branch.Hash, the tip it had when it was analyzed, or "" if it still does. Branches
+// without a recorded hash are not checked.
+func changedSinceAnalysis(ctx context.Context, branch BranchToDelete) string {
+	if branch.Hash == "" {
+		return ""
+	}
+	tip, err := RunGitCommand(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch.Name)
+	if err != nil {
+		return "branch no longer exists"
+	}
+	if tip != branch.Hash {
+		return fmt.Sprintf("branch changed since analysis (now at %.7s)", tip)
+	}
+	return ""
+}
 
-		if dryRun {
-			result.Success = true // Indicate success in dry-run context
-			result.Message = fmt.Sprintf("Dry Run: Would execute: %s", cmdString)
-			results = append(results, result)
+// DeleteOptions controls how DeleteBranchesConcurrently executes remote deletions.
+type DeleteOptions struct {
+	RemoteWorkers   int     // Maximum concurrent remote deletions (values below 1 mean sequential)
+	RemoteRateLimit float64 // Maximum remote deletions started per second, per remote (0 means unlimited)
+}
+
+// skippedResult is reported for a deletion that was not attempted because ctx ended first.
+func skippedResult(branch BranchToDelete, err error) types.DeleteResult {
+	return types.DeleteResult{
+		BranchName: branch.Name, IsRemote: branch.IsRemote, RemoteName: branch.Remote,
+		Message: fmt.Sprintf("Skipped: %v", err),
+		Cmd:     "git " + strings.Join(DeleteArgs(branch), " "),
+	}
+}
+
+// DeleteBranchesConcurrently deletes local branches sequentially and then remote branches
+// using a pool of RemoteWorkers, starting at most RemoteRateLimit pushes per second to each remote.
+// onResult, if non-nil, is invoked (never concurrently) as each deletion completes.
+// Once ctx is cancelled, in-flight git commands are stopped and the remaining deletions are
+// reported as skipped without being attempted. The returned results are in completion order.
+func DeleteBranchesConcurrently(
+	ctx context.Context, branches []BranchToDelete, dryRun bool,
+	opts DeleteOptions, onResult func(types.DeleteResult),
+) []types.DeleteResult {
+	results := make([]types.DeleteResult, 0, len(branches))
+	var mu sync.Mutex
+	report := func(result types.DeleteResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+
+	// Local ref updates are cheap and take the repository lock, so run them in order first.
+	remotes := make([]BranchToDelete, 0, len(branches))
+	for _, branch := range branches {
+		if branch.IsRemote {
+			remotes = append(remotes, branch)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			report(skippedResult(branch, err))
 			continue
 		}
+		report(DeleteBranch(ctx, branch, dryRun))
+	}
 
-		// Execute the actual command
-		_, err := RunGitCommand(ctx, cmdArgs...)
-		if err != nil {
-			result.Success = false
-			// Attempt to extract a cleaner error message from the potentially multi-line stderr
-			errMsg := err.Error()
-			if strings.Contains(errMsg, "stderr:") {
-				parts := strings.SplitN(errMsg, "stderr:", 2)
-				if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
-					errMsg = strings.TrimSpace(parts[1])
+	workers := max(1, opts.RemoteWorkers)
+	limiters := make(map[string]*rateLimiter)
+	for _, branch := range remotes {
+		if _, ok := limiters[branch.Remote]; !ok {
+			limiters[branch.Remote] = newRateLimiter(opts.RemoteRateLimit)
+		}
+	}
+
+	jobs := make(chan BranchToDelete)
+	var wg sync.WaitGroup
+	for i := 0; i < min(workers, len(remotes)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for branch := range jobs {
+				if dryRun {
+					report(DeleteBranch(ctx, branch, dryRun))
+					continue
+				}
+				if err := limiters[branch.Remote].wait(ctx); err != nil {
+					report(skippedResult(branch, err))
+					continue
 				}
+				report(DeleteBranch(ctx, branch, dryRun))
 			}
-			result.Message = fmt.Sprintf("Failed: %s", errMsg)
-		} else {
-			result.Success = true
-			result.Message = "Successfully deleted"
-			// Store the hash of the deleted branch for potential recovery info
-			result.DeletedHash = branch.Hash
-		}
-		results = append(results, result)
+		}()
+	}
+	for _, branch := range remotes {
+		jobs <- branch
 	}
+	close(jobs)
+	wg.Wait()
 
 	return results
 }
+
+// rateLimiter spaces out operations so that at most one starts per interval.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter allowing perSecond operations per second (0 means unlimited).
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the caller may start its operation or ctx is cancelled.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r.interval == 0 {
+		return ctx.Err()
+	}
+	r.mu.Lock()
+	now := time.Now()
+	start := r.next
+	if start.Before(now) {
+		start = now
+	}
+	r.next = start.Add(r.interval)
+	r.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
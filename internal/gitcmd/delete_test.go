@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bral/git-sweep-go/internal/types"
 )
@@ -36,7 +40,7 @@ func TestDeleteBranches(t *testing.T) {
 		},
 		{
 			BranchName: "remote-branch", IsRemote: true, RemoteName: "origin", Success: true, Message: "Successfully deleted",
-			Cmd: "git push origin --delete remote-branch", DeletedHash: "h3",
+			Cmd: "git push --force-with-lease=refs/heads/remote-branch origin --delete remote-branch", DeletedHash: "h3",
 		},
 		// Failed deletions should have an empty hash
 		{
@@ -45,7 +49,8 @@ func TestDeleteBranches(t *testing.T) {
 		},
 		{
 			BranchName: "fail-remote", IsRemote: true, RemoteName: "origin", Success: false,
-			Message: "Failed: simulated remote delete error", Cmd: "git push origin --delete fail-remote", DeletedHash: "",
+			Message: "Failed: simulated remote delete error",
+			Cmd:     "git push --force-with-lease=refs/heads/fail-remote origin --delete fail-remote", DeletedHash: "",
 		},
 	}
 
@@ -83,17 +88,19 @@ func TestDeleteBranches(t *testing.T) {
 		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) { // Use setupMockRunner
 			cmdStr := strings.Join(args, " ")
 			switch {
+			case args[0] == "rev-parse":
+				return analyzedTip(branchesToDelete, args), nil
 			case strings.HasPrefix(cmdStr, "branch -d local-merged"):
 				return "Deleted branch local-merged (was h1).", nil
 			case strings.HasPrefix(cmdStr, "branch -D local-unmerged"):
 				return "Deleted branch local-unmerged (was h2).", nil
-			case strings.HasPrefix(cmdStr, "push origin --delete remote-branch"):
+			case strings.HasPrefix(cmdStr, "push --force-with-lease=refs/heads/remote-branch origin --delete remote-branch"):
 				return "To github.com:user/repo\n - [deleted]         remote-branch", nil
 			case strings.HasPrefix(cmdStr, "branch -d fail-local"):
 				// Simulate failure by returning an error
 				errStr := "simulated local delete error"
 				return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args, errStr)
-			case strings.HasPrefix(cmdStr, "push origin --delete fail-remote"):
+			case strings.HasPrefix(cmdStr, "push --force-with-lease=refs/heads/fail-remote origin --delete fail-remote"):
 				// Simulate failure by returning an error
 				errStr := "simulated remote delete error"
 				return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args, errStr)
@@ -227,19 +234,22 @@ func TestDeleteBranches(t *testing.T) {
 			},
 			{
 				BranchName: "err-empty-stderr", IsRemote: true, RemoteName: "origin", Success: false,
-				Message: "Failed: git command failed: exit status 1\nargs: [push origin --delete err-empty-stderr]\nstderr:",
-				Cmd:     "git push origin --delete err-empty-stderr",
+				Message: "Failed: git command failed: exit status 1\n" +
+					"args: [push --force-with-lease=refs/heads/err-empty-stderr origin --delete err-empty-stderr]\nstderr:",
+				Cmd: "git push --force-with-lease=refs/heads/err-empty-stderr origin --delete err-empty-stderr",
 			}, // Expect raw error if stderr part is empty
 		}
 
 		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) { // Use setupMockRunner
 			cmdStr := strings.Join(args, " ")
 			switch {
+			case args[0] == "rev-parse":
+				return analyzedTip(branches, args), nil
 			case strings.HasPrefix(cmdStr, "branch -d err-no-stderr"):
 				return "", errors.New("plain error message") // Error without "stderr:"
 			case strings.HasPrefix(cmdStr, "branch -D err-with-stderr"):
 				return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args, "useful info from stderr")
-			case strings.HasPrefix(cmdStr, "push origin --delete err-empty-stderr"):
+			case strings.HasSuffix(cmdStr, "origin --delete err-empty-stderr"):
 				// Using %s with empty string to avoid linter errors about error message capitalization
 				return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr:%s", args, "")
 			default:
@@ -260,3 +270,286 @@ func TestDeleteBranches(t *testing.T) {
 		}
 	})
 }
+
+// analyzedTip answers the tip lookup made before a local deletion with the hash the branch
+// had when it was analyzed.
+func analyzedTip(branches []BranchToDelete, args []string) string {
+	for _, branch := range branches {
+		if args[len(args)-1] == "refs/heads/"+branch.Name {
+			return branch.Hash
+		}
+	}
+	return ""
+}
+
+func TestDeleteBranchChangedSinceAnalysis(t *testing.T) {
+	ctx := context.Background()
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		switch strings.Join(args, " ") {
+		case "rev-parse --verify --quiet refs/heads/moved":
+			return "0123456789abcdef", nil
+		case "rev-parse --verify --quiet refs/heads/gone":
+			return "", errors.New("exit status 1")
+		case "push --force-with-lease=refs/heads/pushed origin --delete pushed":
+			return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args,
+				" ! [rejected]        (delete) -> pushed (stale info)")
+		}
+		t.Errorf("Unexpected git call: %v", args)
+		return "", nil
+	})
+	defer teardown()
+
+	for _, tc := range []struct {
+		branch BranchToDelete
+		want   string
+	}{
+		{BranchToDelete{Name: "moved", IsMerged: true, Hash: "fedcba9876543210"},
+			"Skipped: branch changed since analysis (now at 0123456)"},
+		{BranchToDelete{Name: "gone", IsMerged: true, Hash: "fedcba9876543210"}, "Skipped: branch no longer exists"},
+		{BranchToDelete{Name: "pushed", IsRemote: true, Remote: "origin", Hash: "fedcba9876543210"},
+			"Skipped: remote branch changed since the last fetch"},
+	} {
+		res := DeleteBranch(ctx, tc.branch, false)
+		if res.Success || res.Message != tc.want {
+			t.Errorf("%s: expected %q, got %+v", tc.branch.Name, tc.want, res)
+		}
+	}
+}
+
+func TestDeleteBranchAlreadyGoneOnRemote(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "push" {
+			return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args,
+				"error: unable to delete 'gone': remote ref does not exist")
+		}
+		return "Deleted remote-tracking branch origin/gone (was abc1234).", nil
+	})
+	defer teardown()
+
+	res := DeleteBranch(ctx, BranchToDelete{Name: "gone", IsRemote: true, Remote: "origin", Hash: "abc1234"}, false)
+	if !res.Success || !strings.Contains(res.Message, "Already deleted on the remote") || res.DeletedHash != "" {
+		t.Errorf("Expected the deletion to succeed without a recovery hash, got %+v", res)
+	}
+	if len(calls) != 2 || calls[1] != "branch -dr origin/gone" {
+		t.Errorf("Expected the remote-tracking branch to be removed, got calls %q", calls)
+	}
+}
+
+func TestDeleteBranchMergedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		switch args[0] {
+		case "rev-parse":
+			return "abc1234", nil
+		case "branch":
+			if args[1] == "-d" {
+				return "", fmt.Errorf("git command failed: exit status 1\nargs: %v\nstderr: %s", args,
+					"error: the branch 'hotfix' is not fully merged.")
+			}
+		}
+		return "Deleted branch hotfix (was abc1234).", nil
+	})
+	defer teardown()
+
+	res := DeleteBranch(ctx, BranchToDelete{
+		Name: "hotfix", IsMerged: true, MergedBy: types.MergedByAncestry, Hash: "abc1234",
+	}, false)
+	if !res.Success || res.Cmd != "git branch -D hotfix" {
+		t.Errorf("Expected the merged branch to be deleted with -D, got %+v", res)
+	}
+	if len(calls) != 3 || calls[1] != "branch -d hotfix" {
+		t.Errorf("Expected -d to be tried first, got calls %q", calls)
+	}
+
+	// Without a verified ancestry merge, -d refusing is reported rather than overridden
+	calls = nil
+	res = DeleteBranch(ctx, BranchToDelete{Name: "hotfix", IsMerged: true, Hash: "abc1234"}, false)
+	if res.Success || len(calls) != 2 || !strings.Contains(res.Message, "not fully merged") {
+		t.Errorf("Expected the failure of -d to be reported, got %+v and calls %q", res, calls)
+	}
+}
+
+func TestDeleteBranchUndoesPreserveOnFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "old.bundle")
+	branch := BranchToDelete{Name: "old", Hash: "h1", Archive: ArchiveModeRef, TagPrefix: "sweep/", BundleDir: dir}
+
+	for _, tc := range []struct {
+		name      string
+		failOn    string // Prefix of the git call that fails
+		wantUndo  []string
+		wantNote  string
+		undoFails bool
+	}{
+		{"Bundle Fails", "bundle create", []string{"update-ref -d refs/archive/old", "tag -d sweep/old"}, "", false},
+		{"Delete Fails", "branch -D", []string{"update-ref -d refs/archive/old", "tag -d sweep/old"}, "", false},
+		{"Tag Fails", "tag sweep/old", []string{"update-ref -d refs/archive/old"}, "", false},
+		{"Undo Fails", "bundle create", []string{"update-ref -d refs/archive/old", "tag -d sweep/old"},
+			"could not remove what was created for it, remove it before retrying: refs/archive/old", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var undo []string
+			teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+				call := strings.Join(args, " ")
+				switch {
+				case strings.HasPrefix(call, tc.failOn):
+					return "", errors.New("simulated failure")
+				case args[0] == "rev-parse":
+					return "h1", nil
+				case args[0] == "bundle":
+					return "", os.WriteFile(args[2], []byte("bundle"), 0o600)
+				case strings.Contains(call, " -d "):
+					undo = append(undo, call)
+					if tc.undoFails && args[0] == "update-ref" {
+						return "", errors.New("simulated undo failure")
+					}
+				}
+				return "", nil
+			})
+			defer teardown()
+			defer func() { _ = os.Remove(path) }()
+
+			res := DeleteBranch(ctx, branch, false)
+			if res.Success || res.ArchivedAs != "" || res.Tag != "" || res.BundlePath != "" {
+				t.Errorf("Expected a failure with nothing preserved, got %+v", res)
+			}
+			if !reflect.DeepEqual(undo, tc.wantUndo) {
+				t.Errorf("Expected %q to be removed again, got %q", tc.wantUndo, undo)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("Expected the bundle to be removed again, got %v", err)
+			}
+			if !strings.Contains(res.Message, tc.wantNote) ||
+				(tc.wantNote == "" && strings.Contains(res.Message, "could not remove")) {
+				t.Errorf("Unexpected message %q", res.Message)
+			}
+		})
+	}
+}
+
+func TestDeleteArgsForceDeletesUnverifiedMerges(t *testing.T) {
+	tests := []struct {
+		branch BranchToDelete
+		want   string
+	}{
+		{BranchToDelete{Name: "a", IsMerged: true, MergedBy: types.MergedByAncestry}, "-d"},
+		{BranchToDelete{Name: "a", IsMerged: true}, "-d"},
+		{BranchToDelete{Name: "a", IsMerged: true, MergedBy: types.MergedByPullRequest}, "-D"},
+		{BranchToDelete{Name: "a", IsMerged: true, MergedBy: types.MergedByCherry}, "-D"},
+		{BranchToDelete{Name: "a", IsMerged: true, MergedBy: types.MergedByNoChanges}, "-D"},
+		{BranchToDelete{Name: "a"}, "-D"},
+	}
+	for _, tt := range tests {
+		if got := DeleteArgs(tt.branch); got[1] != tt.want {
+			t.Errorf("DeleteArgs(%+v) = %q, want %s", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteBranchesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if args[0] != "push" {
+			return "", nil
+		}
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if args[len(args)-1] == "fail" {
+			return "", errors.New("remote rejected")
+		}
+		return "", nil
+	})
+	defer teardown()
+
+	branches := []BranchToDelete{
+		{Name: "r1", IsRemote: true, Remote: "origin"},
+		{Name: "local", IsMerged: true},
+		{Name: "r2", IsRemote: true, Remote: "origin"},
+		{Name: "fail", IsRemote: true, Remote: "origin"},
+		{Name: "r3", IsRemote: true, Remote: "upstream"},
+	}
+
+	var streamed []types.DeleteResult
+	results := DeleteBranchesConcurrently(ctx, branches, false, DeleteOptions{RemoteWorkers: 3},
+		func(res types.DeleteResult) { streamed = append(streamed, res) })
+
+	if len(results) != len(branches) || !reflect.DeepEqual(results, streamed) {
+		t.Fatalf("Expected %d results matching the streamed ones, got %d results and %d streamed",
+			len(branches), len(results), len(streamed))
+	}
+	if results[0].BranchName != "local" || results[0].IsRemote {
+		t.Errorf("Expected local deletion to complete first, got %+v", results[0])
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("Expected between 2 and 3 concurrent pushes, got %d", maxInFlight)
+	}
+	for _, res := range results {
+		if res.BranchName == "fail" && res.Success {
+			t.Error("Expected the failing remote deletion to be reported as failed")
+		}
+	}
+}
+
+func TestDeleteBranchesConcurrentlyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls = append(calls, args[len(args)-1])
+		cancel() // Interrupted while deleting the first branch
+		return "", nil
+	})
+	defer teardown()
+
+	branches := []BranchToDelete{
+		{Name: "first", IsMerged: true},
+		{Name: "second", IsMerged: true},
+		{Name: "remote", IsRemote: true, Remote: "origin"},
+	}
+	results := DeleteBranchesConcurrently(ctx, branches, false, DeleteOptions{}, nil)
+
+	if !reflect.DeepEqual(calls, []string{"first"}) {
+		t.Errorf("Expected only the first deletion to run, got %v", calls)
+	}
+	if len(results) != 3 || !results[0].Success {
+		t.Fatalf("Expected the first deletion to succeed and all to be reported, got %+v", results)
+	}
+	for _, res := range results[1:] {
+		if res.Success || !strings.HasPrefix(res.Message, "Skipped: ") {
+			t.Errorf("Expected %s to be skipped, got %+v", res.BranchName, res)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := newRateLimiter(20) // One start every 50ms
+	start := time.Now()
+	for range 3 {
+		if err := limiter.wait(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected three starts to take at least 100ms, took %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := newRateLimiter(0).wait(cancelled); err == nil {
+		t.Error("Expected an error from a cancelled context")
+	}
+}
@@ -0,0 +1,77 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// ErrNotFastForward is returned by FastForwardBranch when the branch has commits its upstream
+// lacks, so moving it would lose them.
+var ErrNotFastForward = errors.New("the branch has diverged from its upstream")
+
+// FastForwardBranch moves the local branch to the commit of its upstream if that is a
+// fast-forward, and reports whether the branch moved. A branch that is up to date with or
+// ahead of its upstream is left alone. The checked-out branch is updated with
+// 'git merge --ff-only' and refuses with ErrDirtyWorkingTree if tracked files have changes;
+// any other branch is updated without touching the working tree.
+func FastForwardBranch(ctx context.Context, branchName string) (bool, error) {
+	if branchName == "" {
+		return false, fmt.Errorf("branch name cannot be empty")
+	}
+	upstream, err := RunGitCommand(ctx, "rev-parse", "--symbolic-full-name", branchName+"@{upstream}")
+	if err != nil {
+		return false, fmt.Errorf("failed to find the upstream of branch %q: %w", branchName, err)
+	}
+
+	upToDate, err := IsAncestor(ctx, upstream, branchName)
+	if err != nil || upToDate {
+		return false, err
+	}
+	canFastForward, err := IsAncestor(ctx, branchName, upstream)
+	if err != nil {
+		return false, err
+	}
+	if !canFastForward {
+		return false, ErrNotFastForward
+	}
+
+	current, err := GetCurrentBranchName(ctx)
+	if err != nil {
+		return false, err
+	}
+	if current == branchName {
+		dirty, err := IsWorkingTreeDirty(ctx)
+		if err != nil {
+			return false, err
+		}
+		if dirty {
+			return false, ErrDirtyWorkingTree
+		}
+		if _, err := RunGitCommand(ctx, "merge", "--ff-only", "--quiet", upstream); err != nil {
+			return false, fmt.Errorf("failed to fast-forward branch %q: %w", branchName, err)
+		}
+		return true, nil
+	}
+	// Without a leading '+' the refspec only accepts fast-forwards, and git refuses to update
+	// a branch checked out in another worktree
+	if _, err := RunGitCommand(ctx, "fetch", "--quiet", ".", upstream+":refs/heads/"+branchName); err != nil {
+		return false, fmt.Errorf("failed to fast-forward branch %q: %w", branchName, err)
+	}
+	return true, nil
+}
+
+// IsAncestor reports whether commit ancestor is reachable from descendant.
+func IsAncestor(ctx context.Context, ancestor, descendant string) (bool, error) {
+	_, err := RunGitCommand(ctx, "merge-base", "--is-ancestor", ancestor, descendant)
+	if err != nil {
+		// 'git merge-base --is-ancestor' exits with status 1 when it is not an ancestor
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to compare %s with %s: %w", ancestor, descendant, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestFastForwardBranch(t *testing.T) {
+	ctx := context.Background()
+	notAncestor := exec.Command("sh", "-c", "exit 1").Run()
+	const upstream = "refs/remotes/origin/main"
+
+	// mockRepo answers like a repository where "main" tracks origin/main; behind and diverged
+	// describe main relative to its upstream
+	mockRepo := func(
+		current string, behind, diverged bool, dirty string, calls *[][]string,
+	) func(context.Context, ...string) (string, error) {
+		return func(_ context.Context, args ...string) (string, error) {
+			*calls = append(*calls, args)
+			switch args[0] {
+			case "rev-parse":
+				return upstream, nil
+			case "merge-base":
+				if args[2] == upstream && behind { // Is the upstream already on main?
+					return "", notAncestor
+				}
+				if args[2] == "main" && diverged { // Is main on the upstream?
+					return "", notAncestor
+				}
+				return "", nil
+			case "branch":
+				return current, nil
+			case "status":
+				return dirty, nil
+			}
+			return "", nil
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		current  string
+		behind   bool
+		diverged bool
+		dirty    string
+		want     bool
+		wantErr  error
+		wantLast []string
+	}{
+		{"Up To Date", "feature/x", false, false, "", false, nil,
+			[]string{"merge-base", "--is-ancestor", upstream, "main"}},
+		{"Behind", "feature/x", true, false, "", true, nil,
+			[]string{"fetch", "--quiet", ".", upstream + ":refs/heads/main"}},
+		{"Behind And Checked Out", "main", true, false, "", true, nil,
+			[]string{"merge", "--ff-only", "--quiet", upstream}},
+		{"Checked Out With Changes", "main", true, false, " M main.go", false, ErrDirtyWorkingTree,
+			[]string{"status", "--porcelain", "--untracked-files=no"}},
+		{"Diverged", "feature/x", true, true, "", false, ErrNotFastForward,
+			[]string{"merge-base", "--is-ancestor", "main", upstream}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls [][]string
+			teardown := setupMockRunner(t, mockRepo(tc.current, tc.behind, tc.diverged, tc.dirty, &calls))
+			defer teardown()
+
+			got, err := FastForwardBranch(ctx, "main")
+			if got != tc.want || !errors.Is(err, tc.wantErr) {
+				t.Errorf("FastForwardBranch() = %v, %v; want %v, %v", got, err, tc.want, tc.wantErr)
+			}
+			if last := calls[len(calls)-1]; !reflect.DeepEqual(last, tc.wantLast) {
+				t.Errorf("Unexpected last git call %q, want %q", last, tc.wantLast)
+			}
+		})
+	}
+
+	t.Run("No Upstream", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+			return "", errors.New("fatal: no upstream configured for branch 'main'")
+		})
+		defer teardown()
+
+		if moved, err := FastForwardBranch(ctx, "main"); moved || err == nil {
+			t.Errorf("Expected an error without an upstream, got %v, %v", moved, err)
+		}
+	})
+}
@@ -2,7 +2,12 @@ package gitcmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
 )
 
 // FetchAndPrune runs 'git fetch <remote> --prune' to update local refs
@@ -25,3 +30,84 @@ func FetchAndPrune(ctx context.Context, remoteName string) error {
 
 	return nil
 }
+
+// IsShallowRepo reports whether the repository is a shallow clone, whose history is cut off
+// at a certain depth, e.g. a CI checkout made with 'git clone --depth 1'.
+func IsShallowRepo(ctx context.Context) (bool, error) {
+	output, err := RunGitCommand(ctx, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	return output == "true", nil
+}
+
+// Unshallow runs 'git fetch --unshallow <remote>' to fetch the history a shallow clone lacks.
+func Unshallow(ctx context.Context, remoteName string) error {
+	if remoteName == "" {
+		return fmt.Errorf("remote name cannot be empty for fetch --unshallow")
+	}
+	if _, err := RunGitCommand(ctx, "fetch", "--unshallow", "--quiet", remoteName); err != nil {
+		return fmt.Errorf("failed to fetch the full history from remote %q: %w", remoteName, err)
+	}
+	return nil
+}
+
+// CheckRemote verifies that the remote can be reached and read by listing its branches
+// with 'git ls-remote --heads <remote>'.
+func CheckRemote(ctx context.Context, remoteName string) error {
+	if remoteName == "" {
+		return fmt.Errorf("remote name cannot be empty")
+	}
+	if _, err := RunGitCommand(ctx, "ls-remote", "--heads", remoteName); err != nil {
+		return fmt.Errorf("failed to reach remote %q: %w", remoteName, err)
+	}
+	return nil
+}
+
+// LastFetchTime returns when any remote was last fetched into the repository, according to
+// the modification time of FETCH_HEAD. The zero time means it was never fetched.
+func LastFetchTime(ctx context.Context) (time.Time, error) {
+	path, err := RunGitCommand(ctx, "rev-parse", "--path-format=absolute", "--git-path", "FETCH_HEAD")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to locate FETCH_HEAD: %w", err)
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the last fetch time: %w", err)
+	}
+	return info.ModTime(), nil
+}
+
+// ListRemotes returns the names of all configured remotes, in the order 'git remote' lists them.
+func ListRemotes(ctx context.Context) ([]string, error) {
+	output, err := RunGitCommand(ctx, "remote")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	var remotes []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			remotes = append(remotes, name)
+		}
+	}
+	return remotes, nil
+}
+
+// TrackedRemotes returns the remotes that local branches have their upstream on, without
+// duplicates, in the order the branches are listed.
+func TrackedRemotes(ctx context.Context) ([]string, error) {
+	output, err := RunGitCommand(ctx, cmdForEachRef, "--format=%(upstream:remotename)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked remotes: %w", err)
+	}
+	var remotes []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" && !slices.Contains(remotes, name) {
+			remotes = append(remotes, name)
+		}
+	}
+	return remotes, nil
+}
@@ -82,3 +82,95 @@ func TestFetchAndPrune(t *testing.T) {
 }
 
 // Removed reflectDeepEqual helper function as it's no longer needed
+
+func TestCheckRemote(t *testing.T) {
+	ctx := context.Background()
+
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if strings.Join(args, " ") != "ls-remote --heads origin" {
+			return "", fmt.Errorf("unexpected args: %v", args)
+		}
+		return "abc123\trefs/heads/main", nil
+	})
+	if err := CheckRemote(ctx, "origin"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	teardown()
+
+	teardown = setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return "", errors.New("could not resolve host")
+	})
+	defer teardown()
+	if err := CheckRemote(ctx, "origin"); err == nil || !strings.Contains(err.Error(), "origin") {
+		t.Errorf("Expected an error mentioning the remote, got %v", err)
+	}
+	if err := CheckRemote(ctx, ""); err == nil {
+		t.Error("Expected an error for an empty remote name")
+	}
+}
+
+func TestShallowClone(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "rev-parse" {
+			return "true", nil
+		}
+		return "", nil
+	})
+	defer teardown()
+
+	if shallow, err := IsShallowRepo(ctx); err != nil || !shallow {
+		t.Errorf("IsShallowRepo() = %v, %v; want true", shallow, err)
+	}
+	if err := Unshallow(ctx, "origin"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	want := []string{"rev-parse --is-shallow-repository", "fetch --unshallow --quiet origin"}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected commands: got %q, want %q", calls, want)
+	}
+	if err := Unshallow(ctx, ""); err == nil {
+		t.Error("Expected an error for an empty remote name")
+	}
+}
+
+func TestListRemotes(t *testing.T) {
+	ctx := context.Background()
+
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if strings.Join(args, " ") != "remote" {
+			return "", fmt.Errorf("unexpected args: %v", args)
+		}
+		return "origin\nupstream\n", nil
+	})
+	remotes, err := ListRemotes(ctx)
+	if err != nil || strings.Join(remotes, ",") != "origin,upstream" {
+		t.Errorf("Expected [origin upstream], got %v (err %v)", remotes, err)
+	}
+	teardown()
+
+	teardown = setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return "", nil
+	})
+	defer teardown()
+	if remotes, err := ListRemotes(ctx); err != nil || len(remotes) != 0 {
+		t.Errorf("Expected no remotes, got %v (err %v)", remotes, err)
+	}
+}
+
+func TestTrackedRemotes(t *testing.T) {
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if strings.Join(args, " ") != "for-each-ref --format=%(upstream:remotename) refs/heads/" {
+			return "", fmt.Errorf("unexpected args: %v", args)
+		}
+		return "origin\n\nupstream\norigin\n", nil
+	})
+	defer teardown()
+
+	remotes, err := TrackedRemotes(context.Background())
+	if err != nil || strings.Join(remotes, ",") != "origin,upstream" {
+		t.Errorf("Expected [origin upstream], got %v (err %v)", remotes, err)
+	}
+}
@@ -0,0 +1,71 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hooks written by InstallHook, which may be replaced.
+const hookMarker = "# Installed by git-sweep"
+
+// ErrHookExists is returned by InstallHook when a hook git-sweep did not write is in the way.
+var ErrHookExists = errors.New("a hook not installed by git-sweep already exists")
+
+// SetAlias configures 'git <name>' to run command in a shell, in the user's global git
+// configuration or, if global is false, in the current repository's.
+func SetAlias(ctx context.Context, name, command string, global bool) error {
+	scope := "--local"
+	if global {
+		scope = "--global"
+	}
+	if _, err := RunGitCommand(ctx, "config", scope, "alias."+name, "!"+command); err != nil {
+		return fmt.Errorf("failed to set alias %q: %w", name, err)
+	}
+	return nil
+}
+
+// HooksDir returns the directory git runs the current repository's hooks from, honoring
+// core.hooksPath.
+func HooksDir(ctx context.Context) (string, error) {
+	path, err := RunGitCommand(ctx, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the hooks directory: %w", err)
+	}
+	return path, nil
+}
+
+// InstallHook writes a shell hook with the given name that runs script to the current
+// repository's hooks directory and returns its path. A hook written by an earlier
+// InstallHook is replaced; any other existing hook is left alone and ErrHookExists returned.
+func InstallHook(ctx context.Context, name, script string) (string, error) {
+	dir, err := HooksDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && !strings.Contains(string(existing), hookMarker):
+		return "", fmt.Errorf("%w: %s", ErrHookExists, path)
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("failed to read hook %q: %w", path, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory %q: %w", dir, err)
+	}
+	content := "#!/bin/sh\n" + hookMarker + "\n" + strings.TrimSuffix(script, "\n") + "\n"
+	//nolint:gosec // Git only runs executable hooks
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write hook %q: %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o755); err != nil { //nolint:gosec // Git only runs executable hooks
+		return "", fmt.Errorf("failed to make hook %q executable: %w", path, err)
+	}
+	return path, nil
+}
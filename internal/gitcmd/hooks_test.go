@@ -0,0 +1,68 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetAlias(t *testing.T) {
+	var got []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		got = args
+		return "", nil
+	})
+	defer teardown()
+
+	if err := SetAlias(context.Background(), "sweep", "/usr/local/bin/git-sweep", true); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	want := "config --global alias.sweep !/usr/local/bin/git-sweep"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Ran git %q, want git %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	hooksDir := filepath.Join(t.TempDir(), "hooks")
+	teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return hooksDir, nil
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	path, err := InstallHook(ctx, "post-merge", "git-sweep --quick-status\n")
+	if err != nil {
+		t.Fatalf("InstallHook failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#!/bin/sh\n" + hookMarker + "\ngit-sweep --quick-status\n"; string(content) != want {
+		t.Errorf("Hook content = %q, want %q", content, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("Expected the hook to be executable, mode %v", info.Mode())
+	}
+
+	// A hook installed by git-sweep is replaced
+	if _, err := InstallHook(ctx, "post-merge", "git-sweep --quick-status --remote upstream"); err != nil {
+		t.Fatalf("Expected the hook to be replaced, got %v", err)
+	}
+
+	// Any other hook is left alone
+	other := filepath.Join(hooksDir, "post-checkout")
+	if err := os.WriteFile(other, []byte("#!/bin/sh\nmake\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(ctx, "post-checkout", "git-sweep --quick-status"); !errors.Is(err, ErrHookExists) {
+		t.Errorf("Expected ErrHookExists, got %v", err)
+	}
+	if content, _ := os.ReadFile(other); string(content) != "#!/bin/sh\nmake\n" {
+		t.Errorf("Expected the existing hook to be kept, got %q", content)
+	}
+}
@@ -0,0 +1,35 @@
+package gitcmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOffline is returned for git commands that would contact a remote while Offline is set.
+var ErrOffline = errors.New("not available in offline mode")
+
+// Offline makes RunGitCommand refuse every git command that contacts a remote, so offline mode
+// never reaches the network, not even through code paths that do not check it themselves.
+var Offline bool
+
+// networkCommands are the git subcommands that contact a remote repository.
+var networkCommands = map[string]bool{"fetch": true, "pull": true, "push": true, "ls-remote": true}
+
+// needsNetwork reports whether the git command args contacts a remote. Fetching from "." only
+// copies refs within the repository, as FastForwardBranch does.
+func needsNetwork(args []string) bool {
+	if len(args) == 0 || !networkCommands[args[0]] {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if arg != "" && arg[0] != '-' {
+			return arg != "."
+		}
+	}
+	return true
+}
+
+// offlineError returns ErrOffline for a git command refused in offline mode.
+func offlineError(args []string) error {
+	return fmt.Errorf("'git %s' needs the network: %w", args[0], ErrOffline)
+}
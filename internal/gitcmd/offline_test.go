@@ -0,0 +1,70 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOffline(t *testing.T) {
+	var ran []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		ran = append(ran, strings.Join(args, " "))
+		return "", nil
+	})
+	defer teardown()
+	Offline = true
+	defer func() { Offline = false }()
+	ctx := context.Background()
+
+	if err := FetchAndPrune(ctx, "origin"); !errors.Is(err, ErrOffline) {
+		t.Errorf("FetchAndPrune() error = %v, want ErrOffline", err)
+	}
+	if err := CheckRemote(ctx, "origin"); !errors.Is(err, ErrOffline) {
+		t.Errorf("CheckRemote() error = %v, want ErrOffline", err)
+	}
+	results := DeleteBranches(ctx, []BranchToDelete{{Name: "old", IsRemote: true, Remote: "origin"}}, false)
+	if len(results) != 1 || results[0].Success {
+		t.Errorf("Expected the remote deletion to fail, got %+v", results)
+	}
+	if len(ran) != 0 {
+		t.Errorf("Expected no network command to run, got %q", ran)
+	}
+
+	// Local commands, including fetches from the repository itself, still run
+	if _, err := RunGitCommand(ctx, "fetch", "--quiet", ".", "origin/main:refs/heads/main"); err != nil {
+		t.Errorf("Expected a local fetch to run, got %v", err)
+	}
+	if _, err := RunGitCommand(ctx, "branch", "-d", "old"); err != nil {
+		t.Errorf("Expected a local deletion to run, got %v", err)
+	}
+	if len(ran) != 2 {
+		t.Errorf("Expected both local commands to run, got %q", ran)
+	}
+}
+
+func TestLastFetchTime(t *testing.T) {
+	fetchHead := filepath.Join(t.TempDir(), "FETCH_HEAD")
+	teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return fetchHead, nil
+	})
+	defer teardown()
+
+	if last, err := LastFetchTime(context.Background()); err != nil || !last.IsZero() {
+		t.Errorf("LastFetchTime() = %v, %v; want the zero time before the first fetch", last, err)
+	}
+	at := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.WriteFile(fetchHead, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(fetchHead, at, at); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := LastFetchTime(context.Background()); err != nil || !last.Equal(at) {
+		t.Errorf("LastFetchTime() = %v, %v; want %v", last, err, at)
+	}
+}
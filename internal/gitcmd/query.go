@@ -2,8 +2,14 @@ package gitcmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,15 +20,21 @@ import (
 
 const (
 	cmdForEachRef = "for-each-ref"
-	// Format: branchname<NULL>upstream:short<NULL>upstream:remotename<NULL>committerdate:iso8601<NULL>objectname<NEWLINE>
+	// Format: branchname<NULL>upstream:short<NULL>upstream:remotename<NULL>upstream:remoteref
+	// <NULL>committerdate:iso8601<NULL>objectname<NULL>authoremail<NULL>authorname<NULL>subject<NEWLINE>
 	// Using NULL character (\x00) as the field separator and newline (\n) as the record separator.
 	branchInfoFormat = "%(refname:short)%00" +
 		"%(upstream:short)%00" +
 		"%(upstream:remotename)%00" +
+		"%(upstream:remoteref)%00" +
 		"%(committerdate:iso8601)%00" +
-		"%(objectname)"
-	fieldSeparator  = "\x00" // Null character
-	detachedHeadStr = "HEAD" // Constant for detached HEAD string
+		"%(objectname)%00" +
+		"%(authoremail)%00" +
+		"%(authorname)%00" +
+		"%(contents:subject)"
+	branchInfoFields = 9      // Number of fields in branchInfoFormat
+	fieldSeparator   = "\x00" // Null character
+	detachedHeadStr  = "HEAD" // Constant for detached HEAD string
 )
 
 // GetAllLocalBranchInfo retrieves information about all local branches.
@@ -63,13 +75,10 @@ func GetAllLocalBranchInfo(ctx context.Context) ([]types.BranchInfo, error) {
 
 		// Split each record into fields based on the Null character
 		fields := strings.Split(record, fieldSeparator)
-		if len(fields) != 5 {
-			// This indicates unexpected output format from git. Log or handle appropriately.
-			// For now, print a warning to stderr and skip the malformed record.
-			// TODO: Replace with proper logging
-			_, _ = fmt.Fprintf(os.Stderr,
-				"warning: skipping malformed branch record from git (expected 5 fields, got %d): %q\n",
-				len(fields), record) // Use Fprintf to os.Stderr
+		if len(fields) != branchInfoFields {
+			// This indicates unexpected output format from git; skip the malformed record.
+			slog.Warn("Skipping malformed branch record from git",
+				"expected_fields", branchInfoFields, "fields", len(fields), "record", record)
 			continue
 		}
 
@@ -77,17 +86,18 @@ func GetAllLocalBranchInfo(ctx context.Context) ([]types.BranchInfo, error) {
 		name := fields[0]
 		upstream := fields[1]
 		remote := fields[2]
-		dateStr := fields[3] // Format: "YYYY-MM-DD HH:MM:SS +/-ZZZZ"
-		hash := fields[4]
+		remoteBranch := strings.TrimPrefix(fields[3], "refs/heads/")
+		dateStr := fields[4] // Format: "YYYY-MM-DD HH:MM:SS +/-ZZZZ"
+		hash := fields[5]
+		authorEmail := strings.Trim(fields[6], "<>") // Format: "<user@example.com>"
+		authorName := fields[7]
+		subject := fields[8]
 
 		// Parse the commit date string
 		commitDate, err := time.Parse("2006-01-02 15:04:05 -0700", dateStr)
 		if err != nil {
 			// Failed to parse date, skip this branch and warn.
-			// TODO: Replace with proper logging
-			_, _ = fmt.Fprintf(os.Stderr,
-				"warning: skipping branch %q due to date parse error ('%s'): %v\n",
-				name, dateStr, err) // Use Fprintf to os.Stderr
+			slog.Warn("Skipping branch with unparsable commit date", "branch", name, "date", dateStr, "error", err)
 			continue
 		}
 
@@ -96,14 +106,41 @@ func GetAllLocalBranchInfo(ctx context.Context) ([]types.BranchInfo, error) {
 			Name:           name,
 			Upstream:       upstream,
 			Remote:         remote,
+			RemoteBranch:   remoteBranch,
 			LastCommitDate: commitDate,
 			CommitHash:     hash,
+			AuthorEmail:    authorEmail,
+			AuthorName:     authorName,
+			Subject:        subject,
 		})
 	}
 
 	return branches, nil
 }
 
+// GetRemoteCommitDates returns the committer date of the tip of every remote-tracking branch,
+// keyed by its short name such as "origin/feature/x", the form of BranchInfo.Upstream.
+func GetRemoteCommitDates(ctx context.Context) (map[string]time.Time, error) {
+	output, err := RunGitCommand(ctx, cmdForEachRef, "refs/remotes/", "--format=%(refname)%00%(committerdate:iso8601)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote-tracking branches: %w", err)
+	}
+	dates := make(map[string]time.Time)
+	for _, record := range strings.Split(output, "\n") {
+		ref, dateStr, ok := strings.Cut(record, fieldSeparator)
+		if !ok {
+			continue
+		}
+		// Symbolic refs such as origin/HEAD have no date of their own
+		date, err := time.Parse("2006-01-02 15:04:05 -0700", dateStr)
+		if err != nil {
+			continue
+		}
+		dates[strings.TrimPrefix(ref, "refs/remotes/")] = date
+	}
+	return dates, nil
+}
+
 // GetMainBranchHash retrieves the commit hash for the specified branch name.
 func GetMainBranchHash(ctx context.Context, branchName string) (string, error) {
 	if branchName == "" {
@@ -162,6 +199,198 @@ func IsInGitRepo(ctx context.Context) (bool, error) {
 	return output == "true", nil
 }
 
+// IsBareRepo checks if the current directory is within a bare repository, which has no working
+// tree. Like IsInGitRepo, it returns false and no error outside a repository.
+func IsBareRepo(ctx context.Context) (bool, error) {
+	output, err := RunGitCommand(ctx, "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return false, nil
+	}
+	return output == "true", nil
+}
+
+// HasCommits reports whether a local branch or HEAD points at a commit. It is false right
+// after 'git init', while HEAD is an unborn branch and no other branch exists yet.
+func HasCommits(ctx context.Context) (bool, error) {
+	branch, err := RunGitCommand(ctx, cmdForEachRef, "--count=1", "--format=%(objectname)", "refs/heads/")
+	if err != nil {
+		return false, fmt.Errorf("failed to list local branches: %w", err)
+	}
+	if branch != "" {
+		return true, nil
+	}
+	// A detached HEAD can point at a commit without any branch
+	if _, err := RunGitCommand(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return true, nil
+}
+
+// GetRepoRoot returns the absolute path of the top-level directory of the current working tree.
+func GetRepoRoot(ctx context.Context) (string, error) {
+	args := []string{"rev-parse", "--show-toplevel"}
+	root, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine repository root: %w", err)
+	}
+	return root, nil
+}
+
+// GetGitVersion returns the version of the installed git executable, e.g. "2.43.0".
+func GetGitVersion(ctx context.Context) (string, error) {
+	output, err := RunGitCommand(ctx, "version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run git: %w", err)
+	}
+	version, ok := strings.CutPrefix(output, "git version ")
+	if !ok {
+		return "", fmt.Errorf("unexpected 'git version' output: %q", output)
+	}
+	return version, nil
+}
+
+// GetGitCommonDir returns the absolute path of the repository's common git directory,
+// which holds the refs shared by all worktrees.
+func GetGitCommonDir(ctx context.Context) (string, error) {
+	dir, err := RunGitCommand(ctx, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to determine git directory: %w", err)
+	}
+	// git prints the path relative to the working directory unless it is outside of it
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git directory %q: %w", dir, err)
+	}
+	return absDir, nil
+}
+
+// RepoState names a multi-step git operation in progress in the current worktree.
+type RepoState string
+
+// Operations reported by GetRepoState. RepoStateClean means none is in progress.
+const (
+	RepoStateClean      RepoState = ""
+	RepoStateMerge      RepoState = "merge"
+	RepoStateRebase     RepoState = "rebase"
+	RepoStateCherryPick RepoState = "cherry-pick"
+	RepoStateRevert     RepoState = "revert"
+	RepoStateBisect     RepoState = "bisect"
+)
+
+// repoStateMarkers are the entries git creates in the git directory while an operation is in
+// progress, in the order they are checked. A rebase stopped at a conflict may also leave
+// MERGE_HEAD or CHERRY_PICK_HEAD behind, so it is checked first.
+var repoStateMarkers = []struct {
+	name  string
+	state RepoState
+}{
+	{"rebase-merge", RepoStateRebase},
+	{"rebase-apply", RepoStateRebase},
+	{"MERGE_HEAD", RepoStateMerge},
+	{"CHERRY_PICK_HEAD", RepoStateCherryPick},
+	{"REVERT_HEAD", RepoStateRevert},
+	{"BISECT_LOG", RepoStateBisect},
+}
+
+// GetRepoState reports whether a merge, rebase, cherry-pick, revert or bisect is in progress in
+// the current worktree. While one is, HEAD may be detached or point at a temporary commit, so
+// the branch being worked on is not recognized as checked out.
+func GetRepoState(ctx context.Context) (RepoState, error) {
+	gitDir, err := RunGitCommand(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return RepoStateClean, fmt.Errorf("failed to determine git directory: %w", err)
+	}
+	for _, marker := range repoStateMarkers {
+		if _, err := os.Stat(filepath.Join(gitDir, marker.name)); err == nil {
+			return marker.state, nil
+		}
+	}
+	return RepoStateClean, nil
+}
+
+// GetUserEmail returns the configured 'user.email', or an empty string if it is not set.
+func GetUserEmail(ctx context.Context) (string, error) {
+	args := []string{"config", "--get", "user.email"}
+	email, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		// 'git config --get' exits with status 1 when the key is missing
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read user.email: %w", err)
+	}
+	return email, nil
+}
+
+// GetRemoteURL returns the fetch URL configured for the given remote.
+func GetRemoteURL(ctx context.Context, remoteName string) (string, error) {
+	if remoteName == "" {
+		return "", fmt.Errorf("remote name cannot be empty")
+	}
+	args := []string{"remote", "get-url", remoteName}
+	remoteURL, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL for remote %q: %w", remoteName, err)
+	}
+	return remoteURL, nil
+}
+
+// defaultBranchFallbacks are the local branches tried, in order, when the remote has no HEAD.
+var defaultBranchFallbacks = []string{"main", "master"}
+
+// DetectDefaultBranch determines the repository's default branch. It prefers the branch the
+// remote's HEAD points at (refs/remotes/<remote>/HEAD, set by 'git clone' or
+// 'git remote set-head') and falls back to the first existing local main or master branch.
+func DetectDefaultBranch(ctx context.Context, remoteName string) (string, error) {
+	if remoteName != "" {
+		ref, err := RunGitCommand(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remoteName+"/HEAD")
+		if branch, ok := strings.CutPrefix(ref, remoteName+"/"); err == nil && ok && branch != "" {
+			return branch, nil
+		}
+	}
+	for _, branch := range defaultBranchFallbacks {
+		if _, err := RunGitCommand(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("could not detect the default branch: %s/HEAD is not set and no %s branch exists",
+		remoteName, strings.Join(defaultBranchFallbacks, " or "))
+}
+
+// ListBranchNames returns the sorted, de-duplicated names of all local branches and of the
+// branches on the given remote, with the "<remote>/" prefix removed.
+func ListBranchNames(ctx context.Context, remoteName string) ([]string, error) {
+	args := []string{cmdForEachRef, "--format=%(refname)", "refs/heads/"}
+	if remoteName != "" {
+		args = append(args, "refs/remotes/"+remoteName+"/")
+	}
+	output, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, ref := range strings.Split(output, "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(ref), "refs/heads/")
+		if !ok {
+			name, ok = strings.CutPrefix(strings.TrimSpace(ref), "refs/remotes/"+remoteName+"/")
+		}
+		if !ok || name == "" || name == detachedHeadStr || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // GetCurrentBranchName retrieves the name of the currently checked-out branch.
 // It returns an empty string if HEAD is detached or if an error occurs.
 func GetCurrentBranchName(ctx context.Context) (string, error) {
@@ -246,3 +475,191 @@ func areChangesIncludedImpl(ctx context.Context, upstreamBranch, headBranch stri
 	// If we looped through all lines and found no '+', all changes are included.
 	return true, nil
 }
+
+// hasNoChangesFunc defines the signature for HasNoChanges.
+type hasNoChangesFunc func(ctx context.Context, baseBranch, headBranch string) (bool, error)
+
+// HasNoChanges is a variable holding the implementation, allowing mocking.
+// It checks with 'git diff --quiet base...head' whether headBranch changes nothing compared to
+// the point where it diverged from baseBranch, whatever its commits are.
+var HasNoChanges hasNoChangesFunc = hasNoChangesImpl
+
+// hasNoChangesImpl is the actual implementation.
+func hasNoChangesImpl(ctx context.Context, baseBranch, headBranch string) (bool, error) {
+	if baseBranch == "" || headBranch == "" {
+		return false, fmt.Errorf("base and head branch names cannot be empty for diff check")
+	}
+	_, err := RunGitCommand(ctx, "diff", "--quiet", baseBranch+"..."+headBranch, "--")
+	if err != nil {
+		// 'git diff --quiet' exits with status 1 when there are differences
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to run git diff for %s...%s: %w", baseBranch, headBranch, err)
+	}
+	return true, nil
+}
+
+// GetRecentCommits returns up to limit one-line summaries ("<short hash> <subject>")
+// of the most recent commits on the given branch, newest first.
+func GetRecentCommits(ctx context.Context, branchName string, limit int) ([]string, error) {
+	if branchName == "" {
+		return nil, fmt.Errorf("branch name cannot be empty")
+	}
+	args := []string{"log", "--oneline", "-n", strconv.Itoa(limit), branchName, "--"}
+	output, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent commits for branch %q: %w", branchName, err)
+	}
+	if output == "" {
+		return []string{}, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// CountUnpushedCommits returns how many commits of each local branch in tips (branch name to
+// tip hash) are not on any remote-tracking branch, i.e. exist only in this repository.
+func CountUnpushedCommits(ctx context.Context, tips map[string]string) (map[string]int, error) {
+	counts, err := countBranchCommits(ctx, tips, "--remotes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unpushed commits: %w", err)
+	}
+	return counts, nil
+}
+
+// CountCommitsAhead returns how many commits of each local branch in tips (branch name to tip
+// hash) are not reachable from baseRef, i.e. would be lost from the history of baseRef if the
+// branch were deleted.
+func CountCommitsAhead(ctx context.Context, baseRef string, tips map[string]string) (map[string]int, error) {
+	if baseRef == "" {
+		return nil, fmt.Errorf("base ref cannot be empty")
+	}
+	counts, err := countBranchCommits(ctx, tips, baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commits ahead of %s: %w", baseRef, err)
+	}
+	return counts, nil
+}
+
+// countBranchCommits counts, for each tip in tips, the commits reachable from it but not from
+// the exclude revisions. A single 'git rev-list' lists those commits of all local branches
+// with their parents; each tip's count is then the part of that graph it reaches, since every
+// commit on the way to one of them is excluded neither. This keeps the number of git calls
+// constant on repositories with thousands of branches.
+func countBranchCommits(ctx context.Context, tips map[string]string, exclude string) (map[string]int, error) {
+	counts := make(map[string]int, len(tips))
+	if len(tips) == 0 {
+		return counts, nil
+	}
+	parents, err := branchGraph(ctx, exclude)
+	if err != nil {
+		return nil, err
+	}
+	for name, tip := range tips {
+		counts[name] = len(reachableCommits(parents, tip))
+	}
+	return counts, nil
+}
+
+// GetStackedBranches finds the branches stacked on others: for each local branch in tips
+// (branch name to tip hash) whose tip is not reachable from baseRef, it lists the other
+// branches whose history contains that tip, sorted by name. Branches at the same commit are
+// duplicates rather than stacked, so they are not listed; neither are branches nothing is
+// stacked on.
+func GetStackedBranches(ctx context.Context, baseRef string, tips map[string]string) (map[string][]string, error) {
+	if baseRef == "" {
+		return nil, fmt.Errorf("base ref cannot be empty")
+	}
+	stacked := make(map[string][]string)
+	if len(tips) < 2 {
+		return stacked, nil
+	}
+	parents, err := branchGraph(ctx, baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stacked branches: %w", err)
+	}
+	atTip := make(map[string][]string)
+	for name, tip := range tips {
+		atTip[tip] = append(atTip[tip], name)
+	}
+	for name, tip := range tips {
+		for commit := range reachableCommits(parents, tip) {
+			if commit == tip {
+				continue
+			}
+			for _, base := range atTip[commit] {
+				stacked[base] = append(stacked[base], name)
+			}
+		}
+	}
+	for base := range stacked {
+		sort.Strings(stacked[base])
+	}
+	return stacked, nil
+}
+
+// branchGraph lists the commits of all local branches that are not reachable from exclude,
+// each with its parents, with a single 'git rev-list'.
+func branchGraph(ctx context.Context, exclude string) (map[string][]string, error) {
+	output, err := RunGitCommand(ctx, "rev-list", "--parents", "--branches", "--not", exclude, "--")
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			parents[fields[0]] = fields[1:]
+		}
+	}
+	return parents, nil
+}
+
+// reachableCommits returns the commits of the graph from branchGraph that are reachable from
+// tip, including tip itself; it is empty if tip is not in the graph.
+func reachableCommits(parents map[string][]string, tip string) map[string]bool {
+	if _, ok := parents[tip]; !ok {
+		return nil
+	}
+	seen := map[string]bool{tip: true}
+	stack := []string{tip}
+	for len(stack) > 0 {
+		commit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, parent := range parents[commit] {
+			if _, ok := parents[parent]; ok && !seen[parent] {
+				seen[parent] = true
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return seen
+}
+
+// GetBranchDescriptions returns the notes set with 'git branch --edit-description', keyed by
+// branch name. Branches without a description are not included.
+func GetBranchDescriptions(ctx context.Context) (map[string]string, error) {
+	output, err := RunGitCommand(ctx, "config", "-z", "--get-regexp", `^branch\..*\.description$`)
+	if err != nil {
+		// 'git config --get-regexp' exits with status 1 when no key matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read branch descriptions: %w", err)
+	}
+
+	// With -z every entry is "<key>\n<value>\x00"; values may span several lines
+	descriptions := make(map[string]string)
+	for _, entry := range strings.Split(output, fieldSeparator) {
+		key, value, _ := strings.Cut(entry, "\n")
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".description")
+		if name == key || name == "" {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			descriptions[name] = value
+		}
+	}
+	return descriptions, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync" // Added for the new setup
@@ -96,9 +99,14 @@ func TestGetAllLocalBranchInfo(t *testing.T) {
 	ctx := context.Background()
 
 	// Sample output using null separators and newline records
-	sampleOutput := "main\x00origin/main\x00origin\x002025-03-27 20:00:00 -0400\x00hash1\n" +
-		"feature/a\x00\x00\x002025-03-26 10:00:00 -0400\x00hash2\n" + // No upstream/remote
-		"hotfix/b\x00upstream/hotfix/b\x00upstream\x002025-03-25 15:30:00 -0400\x00hash3"
+	sampleOutput := "main\x00origin/main\x00origin\x00refs/heads/main" +
+		"\x002025-03-27 20:00:00 -0400\x00hash1\x00<me@example.com>" +
+		"\x00Me\x00Subject one\n" +
+		"feature/a\x00\x00\x00\x002025-03-26 10:00:00 -0400\x00hash2\x00<me@example.com>" + // No upstream/remote
+		"\x00Me\x00Subject one\n" +
+		"hotfix/b\x00upstream/fix-b\x00upstream\x00refs/heads/fix-b" +
+		"\x002025-03-25 15:30:00 -0400\x00hash3\x00<other@example.com>" +
+		"\x00Other\x00fix: it's broken — again"
 		// No trailing newline needed
 
 	expectedDate1, _ := time.Parse("2006-01-02 15:04:05 -0700", "2025-03-27 20:00:00 -0400")
@@ -106,11 +114,20 @@ func TestGetAllLocalBranchInfo(t *testing.T) {
 	expectedDate3, _ := time.Parse("2006-01-02 15:04:05 -0700", "2025-03-25 15:30:00 -0400")
 
 	expectedBranches := []types.BranchInfo{
-		{Name: "main", Upstream: "origin/main", Remote: "origin", LastCommitDate: expectedDate1, CommitHash: "hash1"},
-		{Name: "feature/a", Upstream: "", Remote: "", LastCommitDate: expectedDate2, CommitHash: "hash2"},
 		{
-			Name: "hotfix/b", Upstream: "upstream/hotfix/b", Remote: "upstream",
-			LastCommitDate: expectedDate3, CommitHash: "hash3",
+			Name: "main", Upstream: "origin/main", Remote: "origin", RemoteBranch: "main",
+			LastCommitDate: expectedDate1, CommitHash: "hash1", AuthorEmail: "me@example.com",
+			AuthorName: "Me", Subject: "Subject one",
+		},
+		{
+			Name: "feature/a", Upstream: "", Remote: "",
+			LastCommitDate: expectedDate2, CommitHash: "hash2", AuthorEmail: "me@example.com",
+			AuthorName: "Me", Subject: "Subject one",
+		},
+		{
+			Name: "hotfix/b", Upstream: "upstream/fix-b", Remote: "upstream", RemoteBranch: "fix-b",
+			LastCommitDate: expectedDate3, CommitHash: "hash3", AuthorEmail: "other@example.com",
+			AuthorName: "Other", Subject: "fix: it's broken — again",
 		},
 	}
 
@@ -201,9 +218,13 @@ func TestGetAllLocalBranchInfo(t *testing.T) {
 
 	// --- Test Case 4: Malformed record ---
 	t.Run("Malformed Record", func(t *testing.T) {
-		malformedOutput := "main\x00origin/main\x00origin\x002025-03-27 20:00:00 -0400\x00hash1\n" +
+		malformedOutput := "main\x00origin/main\x00origin\x00refs/heads/main" +
+			"\x002025-03-27 20:00:00 -0400\x00hash1\x00<me@example.com>" +
+			"\x00Me\x00Subject one\n" +
 			"feature/a\x00malformed_no_separators\n" + // Malformed line
-			"hotfix/b\x00upstream/hotfix/b\x00upstream\x002025-03-25 15:30:00 -0400\x00hash3"
+			"hotfix/b\x00upstream/fix-b\x00upstream\x00refs/heads/fix-b" +
+			"\x002025-03-25 15:30:00 -0400\x00hash3\x00<other@example.com>" +
+			"\x00Other\x00fix: it's broken — again"
 
 		// Expect only the valid branches
 		expectedValid := []types.BranchInfo{expectedBranches[0], expectedBranches[2]}
@@ -465,6 +486,52 @@ func TestIsInGitRepo(t *testing.T) {
 	})
 }
 
+func TestIsBareRepo(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		output string
+		err    error
+		want   bool
+	}{
+		{output: "true", want: true},
+		{output: "false", want: false},
+		{err: errors.New("fatal: not a git repository"), want: false},
+	} {
+		teardown := setupExpectations(t, []commandExpectation{
+			{args: []string{cmdRevParse, "--is-bare-repository"}, output: tc.output, err: tc.err},
+		})
+		if bare, err := IsBareRepo(ctx); err != nil || bare != tc.want {
+			t.Errorf("IsBareRepo() with output %q, error %v = %v, %v; want %v", tc.output, tc.err, bare, err, tc.want)
+		}
+		teardown()
+	}
+}
+
+func TestHasCommits(t *testing.T) {
+	ctx := context.Background()
+	listBranches := []string{"for-each-ref", "--count=1", "--format=%(objectname)", "refs/heads/"}
+	resolveHead := []string{"rev-parse", "--verify", "--quiet", "HEAD"}
+	unborn := exec.Command("sh", "-c", "exit 1").Run()
+	testCases := []struct {
+		name         string
+		expectations []commandExpectation
+		want         bool
+	}{
+		{"Branch exists", []commandExpectation{{args: listBranches, output: "hash1"}}, true},
+		{"Detached HEAD", []commandExpectation{{args: listBranches}, {args: resolveHead, output: "hash1"}}, true},
+		{"Unborn HEAD", []commandExpectation{{args: listBranches}, {args: resolveHead, err: unborn}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			teardown := setupExpectations(t, tc.expectations)
+			defer teardown()
+			if got, err := HasCommits(ctx); err != nil || got != tc.want {
+				t.Errorf("HasCommits() = %v, %v; want %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
 // --- TestGetCurrentBranchName (Refactored) ---
 func TestGetCurrentBranchName(t *testing.T) {
 	ctx := context.Background()
@@ -667,3 +734,356 @@ func TestAreChangesIncluded(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRecentCommits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Returns One Line Per Commit", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			expected := []string{"log", "--oneline", "-n", "10", "feature/x", "--"}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("Unexpected args: got %v, want %v", args, expected)
+			}
+			return "abc1234 Second commit\ndef5678 First commit", nil
+		})
+		defer teardown()
+
+		lines, err := GetRecentCommits(ctx, "feature/x", 10)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []string{"abc1234 Second commit", "def5678 First commit"}
+		if !reflect.DeepEqual(lines, expected) {
+			t.Errorf("Unexpected lines: got %v, want %v", lines, expected)
+		}
+	})
+
+	t.Run("Git Command Error", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+			return "", errors.New("unknown revision")
+		})
+		defer teardown()
+
+		if _, err := GetRecentCommits(ctx, "missing", 10); err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+	})
+}
+
+func TestGetUserEmail(t *testing.T) {
+	ctx := context.Background()
+
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		expected := []string{"config", "--get", "user.email"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("Unexpected args: got %v, want %v", args, expected)
+		}
+		return "me@example.com", nil
+	})
+	email, err := GetUserEmail(ctx)
+	teardown()
+	if err != nil || email != "me@example.com" {
+		t.Errorf("Expected me@example.com, got %q, %v", email, err)
+	}
+
+	teardown = setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return "", errors.New("fatal: not in a git directory")
+	})
+	defer teardown()
+	if _, err := GetUserEmail(ctx); err == nil {
+		t.Error("Expected an error for a failing git command")
+	}
+}
+
+func TestGetGitVersion(t *testing.T) {
+	ctx := context.Background()
+
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if !reflect.DeepEqual(args, []string{"version"}) {
+			t.Errorf("Unexpected args: %v", args)
+		}
+		return "git version 2.43.0", nil
+	})
+	version, err := GetGitVersion(ctx)
+	teardown()
+	if err != nil || version != "2.43.0" {
+		t.Errorf("Expected 2.43.0, got %q, %v", version, err)
+	}
+
+	teardown = setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return "something else", nil
+	})
+	defer teardown()
+	if _, err := GetGitVersion(ctx); err == nil {
+		t.Error("Expected an error for unexpected output")
+	}
+}
+
+func TestDetectDefaultBranch(t *testing.T) {
+	ctx := context.Background()
+	notFound := errors.New("exit status 1")
+
+	tests := []struct {
+		name    string
+		refs    map[string]string // "git args" -> output; missing keys fail
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "remote HEAD",
+			refs: map[string]string{"symbolic-ref --quiet --short refs/remotes/origin/HEAD": "origin/develop"},
+			want: "develop",
+		},
+		{
+			name: "fallback to master",
+			refs: map[string]string{"rev-parse --verify --quiet refs/heads/master": "abc123"},
+			want: "master",
+		},
+		{
+			name: "main preferred over master",
+			refs: map[string]string{
+				"rev-parse --verify --quiet refs/heads/main":   "abc123",
+				"rev-parse --verify --quiet refs/heads/master": "def456",
+			},
+			want: "main",
+		},
+		{name: "nothing found", refs: map[string]string{}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+				if out, ok := tc.refs[strings.Join(args, " ")]; ok {
+					return out, nil
+				}
+				return "", notFound
+			})
+			defer teardown()
+
+			got, err := DetectDefaultBranch(ctx, "origin")
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("DetectDefaultBranch() = %q, %v; want %q (error: %t)", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestListBranchNames(t *testing.T) {
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		want := "for-each-ref --format=%(refname) refs/heads/ refs/remotes/origin/"
+		if got := strings.Join(args, " "); got != want {
+			t.Errorf("Unexpected git args %q, want %q", got, want)
+		}
+		return "refs/heads/main\nrefs/heads/feature/x\nrefs/remotes/origin/HEAD\n" +
+			"refs/remotes/origin/main\nrefs/remotes/origin/release/1.0\n", nil
+	})
+	defer teardown()
+
+	got, err := ListBranchNames(context.Background(), "origin")
+	if err != nil {
+		t.Fatalf("ListBranchNames failed: %v", err)
+	}
+	want := []string{"feature/x", "main", "release/1.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListBranchNames() = %v, want %v", got, want)
+	}
+}
+
+func TestGetRepoState(t *testing.T) {
+	ctx := context.Background()
+	gitDir := t.TempDir()
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		if !reflect.DeepEqual(args, []string{"rev-parse", "--absolute-git-dir"}) {
+			t.Errorf("Unexpected args: %v", args)
+		}
+		return gitDir, nil
+	})
+	defer teardown()
+
+	if state, err := GetRepoState(ctx); err != nil || state != RepoStateClean {
+		t.Errorf("Expected a clean state, got %q, %v", state, err)
+	}
+
+	// A rebase stopped at a conflict also leaves MERGE_HEAD behind
+	for _, marker := range []string{"MERGE_HEAD", "rebase-merge"} {
+		if err := os.WriteFile(filepath.Join(gitDir, marker), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state, err := GetRepoState(ctx); err != nil || state != RepoStateRebase {
+		t.Errorf("Expected %q, got %q, %v", RepoStateRebase, state, err)
+	}
+}
+
+func TestCountUnpushedCommits(t *testing.T) {
+	var gotArgs []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		gotArgs = args
+		// c3 is a merge of c2 and c1, both unpushed; d1 is another branch's commit
+		return "c3 c2 c1\nc2 base\nc1 base\nd1 base\n", nil
+	})
+	defer teardown()
+
+	tips := map[string]string{"feature/x": "c3", "feature/y": "d1", "pushed": "base"}
+	counts, err := CountUnpushedCommits(context.Background(), tips)
+	if err != nil {
+		t.Fatalf("CountUnpushedCommits failed: %v", err)
+	}
+	want := map[string]int{"feature/x": 3, "feature/y": 1, "pushed": 0}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("CountUnpushedCommits() = %v, want %v", counts, want)
+	}
+	wantArgs := []string{"rev-list", "--parents", "--branches", "--not", "--remotes", "--"}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("Unexpected args: got %v, want %v", gotArgs, wantArgs)
+	}
+}
+
+func TestCountCommitsAhead(t *testing.T) {
+	calls := 0
+	var gotArgs []string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls++
+		gotArgs = args
+		return "b2 b1\nb1 main\n", nil
+	})
+	defer teardown()
+
+	counts, err := CountCommitsAhead(context.Background(), "origin/main", map[string]string{"feature/x": "b2"})
+	if err != nil || counts["feature/x"] != 2 {
+		t.Fatalf("CountCommitsAhead() = %v, %v; want 2 for feature/x", counts, err)
+	}
+	want := []string{"rev-list", "--parents", "--branches", "--not", "origin/main", "--"}
+	if !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("Unexpected args: got %v, want %v", gotArgs, want)
+	}
+
+	// Nothing to count needs no git call
+	if counts, err := CountCommitsAhead(context.Background(), "origin/main", nil); err != nil || len(counts) != 0 {
+		t.Errorf("Expected no counts without branches, got %v, %v", counts, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single git call, got %d", calls)
+	}
+}
+
+func TestGetStackedBranches(t *testing.T) {
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		want := []string{"rev-list", "--parents", "--branches", "--not", "mainHash", "--"}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("Unexpected args: got %v, want %v", args, want)
+		}
+		// part-3 is stacked on part-2, which is stacked on part-1; other is based on main
+		return "c3 c2\nc2 c1\nc1 mainHash\nd1 mainHash\n", nil
+	})
+	defer teardown()
+
+	tips := map[string]string{
+		"part-1": "c1", "part-1-copy": "c1", "part-2": "c2", "part-3": "c3", "other": "d1", "main": "mainHash",
+	}
+	stacked, err := GetStackedBranches(context.Background(), "mainHash", tips)
+	if err != nil {
+		t.Fatalf("GetStackedBranches failed: %v", err)
+	}
+	want := map[string][]string{
+		"part-1":      {"part-2", "part-3"},
+		"part-1-copy": {"part-2", "part-3"},
+		"part-2":      {"part-3"},
+	}
+	if !reflect.DeepEqual(stacked, want) {
+		t.Errorf("GetStackedBranches() = %v, want %v", stacked, want)
+	}
+}
+
+func TestGetBranchDescriptions(t *testing.T) {
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		want := []string{"config", "-z", "--get-regexp", `^branch\..*\.description$`}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("Unexpected args: got %v, want %v", args, want)
+		}
+		return "branch.feature/x.description\nWIP for the export job\nDo not delete\n\x00" +
+			"branch.v1.2.description\nrelease notes\x00", nil
+	})
+	descriptions, err := GetBranchDescriptions(context.Background())
+	teardown()
+	if err != nil {
+		t.Fatalf("GetBranchDescriptions failed: %v", err)
+	}
+	want := map[string]string{"feature/x": "WIP for the export job\nDo not delete", "v1.2": "release notes"}
+	if !reflect.DeepEqual(descriptions, want) {
+		t.Errorf("GetBranchDescriptions() = %q, want %q", descriptions, want)
+	}
+
+	// No description at all makes 'git config' exit with status 1
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	teardown = setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+		return "", exitErr
+	})
+	defer teardown()
+	if descriptions, err := GetBranchDescriptions(context.Background()); err != nil || len(descriptions) != 0 {
+		t.Errorf("Expected no descriptions and no error, got %v, %v", descriptions, err)
+	}
+}
+
+func TestGetRemoteCommitDates(t *testing.T) {
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		want := []string{"for-each-ref", "refs/remotes/", "--format=%(refname)%00%(committerdate:iso8601)"}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("Unexpected args: got %v, want %v", args, want)
+		}
+		return "refs/remotes/origin/HEAD\x00\n" +
+			"refs/remotes/origin/feature/x\x002025-03-27 20:00:00 -0400\n" +
+			"refs/remotes/upstream/fix-b\x002025-03-25 15:30:00 +0000", nil
+	})
+	defer teardown()
+
+	dates, err := GetRemoteCommitDates(context.Background())
+	if err != nil {
+		t.Fatalf("GetRemoteCommitDates failed: %v", err)
+	}
+	want := map[string]time.Time{
+		"origin/feature/x": time.Date(2025, 3, 27, 20, 0, 0, 0, time.FixedZone("", -4*3600)),
+		"upstream/fix-b":   time.Date(2025, 3, 25, 15, 30, 0, 0, time.UTC),
+	}
+	if len(dates) != len(want) {
+		t.Fatalf("GetRemoteCommitDates() = %v, want %v", dates, want)
+	}
+	for name, date := range want {
+		if !dates[name].Equal(date) {
+			t.Errorf("Date of %s = %v, want %v", name, dates[name], date)
+		}
+	}
+}
+
+func TestHasNoChanges(t *testing.T) {
+	ctx := context.Background()
+	differs := exec.Command("sh", "-c", "exit 1").Run()
+	testCases := []struct {
+		name    string
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{"No Changes", nil, true, false},
+		{"Changes", differs, false, false},
+		{"Unknown Branch", errors.New("fatal: bad revision"), false, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotArgs []string
+			teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+				gotArgs = args
+				return "", tc.err
+			})
+			defer teardown()
+
+			got, err := HasNoChanges(ctx, "main", "feature/x")
+			if got != tc.want || (err != nil) != tc.wantErr {
+				t.Errorf("HasNoChanges() = %v, %v; want %v, error %v", got, err, tc.want, tc.wantErr)
+			}
+			if want := []string{"diff", "--quiet", "main...feature/x", "--"}; !reflect.DeepEqual(gotArgs, want) {
+				t.Errorf("Unexpected git arguments %q, want %q", gotArgs, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+)
+
+// RenameBranch renames a local branch with 'git branch -m'. Git rejects invalid names and
+// names that already exist.
+func RenameBranch(ctx context.Context, oldName, newName string) error {
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("branch names cannot be empty")
+	}
+	if _, err := RunGitCommand(ctx, "branch", "-m", oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename branch %q to %q: %w", oldName, newName, err)
+	}
+	return nil
+}
+
+// RenameRemoteBranch renames a branch on remoteName after the local branch newName was
+// renamed: it pushes newName, makes it the upstream of the local branch, and then deletes
+// oldRemoteBranch, the name of the old upstream on the remote, which may differ from the old
+// local name. The old remote branch is only deleted once the push succeeded, and only if it
+// still points where the last fetch saw it, so commits a teammate pushed since are not lost.
+func RenameRemoteBranch(ctx context.Context, remoteName, oldRemoteBranch, newName string) error {
+	if remoteName == "" || oldRemoteBranch == "" || newName == "" {
+		return fmt.Errorf("remote and branch names cannot be empty")
+	}
+	tracking := "refs/remotes/" + remoteName + "/" + oldRemoteBranch
+	expected, err := RunGitCommand(ctx, "rev-parse", "--verify", "--quiet", tracking)
+	if err != nil || expected == "" {
+		return fmt.Errorf("no remote-tracking branch %s/%s to rename; fetch and try again", remoteName, oldRemoteBranch)
+	}
+	if _, err := RunGitCommand(ctx, "push", "--set-upstream", remoteName, newName); err != nil {
+		return fmt.Errorf("failed to push %q to %s: %w", newName, remoteName, err)
+	}
+	lease := "--force-with-lease=refs/heads/" + oldRemoteBranch + ":" + expected
+	if _, err := RunGitCommand(ctx, "push", lease, remoteName, "--delete", oldRemoteBranch); err != nil {
+		return fmt.Errorf("failed to delete %q on %s: %w", oldRemoteBranch, remoteName, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestRenameBranch(t *testing.T) {
+	var calls [][]string
+	teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+		calls = append(calls, args)
+		return "", nil
+	})
+	defer teardown()
+
+	if err := RenameBranch(context.Background(), "feat/typo", "feat/fixed"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := [][]string{{"branch", "-m", "feat/typo", "feat/fixed"}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Unexpected git calls: got %v, want %v", calls, want)
+	}
+	if err := RenameBranch(context.Background(), "feat/typo", ""); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+}
+
+func TestRenameRemoteBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		var calls [][]string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			calls = append(calls, args)
+			if args[0] == "rev-parse" {
+				return "abc123", nil
+			}
+			return "", nil
+		})
+		defer teardown()
+
+		// The local branch tracks a differently named branch on the remote
+		if err := RenameRemoteBranch(ctx, "origin", "bugfix/typo", "feat/fixed"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := [][]string{
+			{"rev-parse", "--verify", "--quiet", "refs/remotes/origin/bugfix/typo"},
+			{"push", "--set-upstream", "origin", "feat/fixed"},
+			{"push", "--force-with-lease=refs/heads/bugfix/typo:abc123", "origin", "--delete", "bugfix/typo"},
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("Unexpected git calls: got %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("Push Fails", func(t *testing.T) {
+		pushErr := errors.New("rejected")
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			if args[0] == "rev-parse" {
+				return "abc123", nil
+			}
+			if args[len(args)-2] == "--delete" {
+				t.Error("Expected the old branch to be kept when the push fails")
+			}
+			return "", pushErr
+		})
+		defer teardown()
+
+		if err := RenameRemoteBranch(ctx, "origin", "feat/typo", "feat/fixed"); !errors.Is(err, pushErr) {
+			t.Errorf("Expected the push error, got %v", err)
+		}
+	})
+
+	t.Run("No Remote-Tracking Branch", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			if args[0] == "push" {
+				t.Error("Expected nothing to be pushed without a remote-tracking branch")
+			}
+			return "", errors.New("exit status 1")
+		})
+		defer teardown()
+
+		if err := RenameRemoteBranch(ctx, "origin", "feat/typo", "feat/fixed"); err == nil {
+			t.Error("Expected an error without a remote-tracking branch")
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// RestoreBranch recreates a local branch pointing at the given commit hash
+// using 'git branch <name> <hash>'. It fails if the branch already exists
+// or the commit is no longer reachable in the object database.
+func RestoreBranch(ctx context.Context, branchName, hash string) error {
+	if branchName == "" || hash == "" {
+		return fmt.Errorf("branch name and hash cannot be empty for restore")
+	}
+
+	args := []string{"branch", branchName, hash}
+	if _, err := RunGitCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to restore branch %q at %s: %w", branchName, hash, err)
+	}
+	return nil
+}
+
+// RecoveryArgs returns the git arguments recreating a deleted branch at the commit it pointed
+// at: 'branch <name> <hash>' for local branches and 'push <remote> <hash>:refs/heads/<name>'
+// for remote ones. It returns nil for failed deletions and results without a recorded hash.
+func RecoveryArgs(res types.DeleteResult) []string {
+	if !res.Success || res.DeletedHash == "" {
+		return nil
+	}
+	if res.IsRemote {
+		return []string{"push", res.RemoteName, res.DeletedHash + ":refs/heads/" + res.BranchName}
+	}
+	return []string{"branch", res.BranchName, res.DeletedHash}
+}
@@ -0,0 +1,83 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestRestoreBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Successful Restore", func(t *testing.T) {
+		var gotArgs []string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			gotArgs = args
+			return "", nil
+		})
+		defer teardown()
+
+		if err := RestoreBranch(ctx, "feature/x", "abc123"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []string{"branch", "feature/x", "abc123"}
+		if !reflect.DeepEqual(gotArgs, expected) {
+			t.Errorf("Unexpected args: got %v, want %v", gotArgs, expected)
+		}
+	})
+
+	t.Run("Git Command Error", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+			return "", errors.New("fatal: a branch named 'feature/x' already exists")
+		})
+		defer teardown()
+
+		err := RestoreBranch(ctx, "feature/x", "abc123")
+		if err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+		if !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected error to contain git stderr, got: %v", err)
+		}
+	})
+
+	t.Run("Empty Arguments", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			t.Errorf("Runner should not be called, called with: %v", args)
+			return "", nil
+		})
+		defer teardown()
+
+		if err := RestoreBranch(ctx, "feature/x", ""); err == nil {
+			t.Error("Expected an error for empty hash, got nil")
+		}
+	})
+}
+
+func TestRecoveryArgs(t *testing.T) {
+	local := types.DeleteResult{BranchName: "feature/x", Success: true, DeletedHash: "abc123"}
+	if got, want := RecoveryArgs(local), []string{"branch", "feature/x", "abc123"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecoveryArgs(local) = %v, want %v", got, want)
+	}
+	remote := types.DeleteResult{
+		BranchName: "feature/x", IsRemote: true, RemoteName: "origin", Success: true, DeletedHash: "abc123",
+	}
+	want := []string{"push", "origin", "abc123:refs/heads/feature/x"}
+	if got := RecoveryArgs(remote); !reflect.DeepEqual(got, want) {
+		t.Errorf("RecoveryArgs(remote) = %v, want %v", got, want)
+	}
+	for _, res := range []types.DeleteResult{
+		{BranchName: "feature/x", DeletedHash: "abc123"},
+		{BranchName: "feature/x", Success: true},
+	} {
+		if got := RecoveryArgs(res); got != nil {
+			t.Errorf("Expected no recovery for %+v, got %v", res, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"strings"
 	"time"
@@ -17,6 +18,18 @@ type GitRunner func(ctx context.Context, args ...string) (stdout string, err err
 // It defaults to the real implementation but can be swapped out in tests.
 var Runner GitRunner = runGitCommandReal
 
+// dirKey is the context key of the directory git commands run in.
+type dirKey struct{}
+
+// WithDir returns a context that makes the real runner execute git commands in dir instead
+// of the current directory. An empty dir keeps the current directory.
+func WithDir(ctx context.Context, dir string) context.Context {
+	if dir == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, dirKey{}, dir)
+}
+
 // runGitCommandReal is the actual implementation that executes git commands.
 func runGitCommandReal(ctx context.Context, args ...string) (string, error) {
 	// Add a default timeout if the context doesn't have one
@@ -27,14 +40,22 @@ func runGitCommandReal(ctx context.Context, args ...string) (string, error) {
 	}
 
 	cmd := exec.CommandContext(ctx, "git", args...)
+	// When git is killed on cancellation, helpers it started, such as ssh for a fetch, may keep
+	// the output pipes open; stop waiting for them shortly after
+	cmd.WaitDelay = time.Second
+	if dir, ok := ctx.Value(dirKey{}).(string); ok {
+		cmd.Dir = dir
+	}
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
 	cmd.Stderr = &stderrBuf
 
+	start := time.Now()
 	err := cmd.Run()
 	stdout := strings.TrimSpace(stdoutBuf.String())
 	stderr := strings.TrimSpace(stderrBuf.String())
+	slog.Debug("Ran git command", "args", args, "duration", time.Since(start), "error", err)
 
 	if err != nil {
 		// Include stderr in the error message for better debugging
@@ -51,5 +72,8 @@ func RunGitCommand(ctx context.Context, args ...string) (string, error) {
 		// Safety check, should not happen if initialized correctly.
 		return "", fmt.Errorf("GitRunner is not initialized")
 	}
+	if Offline && needsNetwork(args) {
+		return "", offlineError(args)
+	}
 	return Runner(ctx, args...)
 }
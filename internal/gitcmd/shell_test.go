@@ -0,0 +1,24 @@
+package gitcmd
+
+import "testing"
+
+func TestShellCommand(t *testing.T) {
+	got := ShellCommand([]string{"branch", "-D", "feature/it's-$HOME"})
+	if want := `git branch -D 'feature/it'\''s-$HOME'`; got != want {
+		t.Errorf("ShellCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestCQuote(t *testing.T) {
+	tests := map[string]string{
+		"feature/plain-name_1.2": "feature/plain-name_1.2",
+		`fix/"quoted"`:           `"fix/\"quoted\""`,
+		"feature/café":           `"feature/caf\303\251"`,
+		"odd\tname\x01":          `"odd\tname\001"`,
+	}
+	for in, want := range tests {
+		if got := CQuote(in); got != want {
+			t.Errorf("CQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDirtyWorkingTree is returned by SwitchBranch when tracked files have uncommitted changes.
+var ErrDirtyWorkingTree = errors.New("the working tree has uncommitted changes; commit or stash them first")
+
+// IsWorkingTreeDirty reports whether tracked files in the working tree or the index have
+// uncommitted changes. Untracked files are ignored.
+func IsWorkingTreeDirty(ctx context.Context) (bool, error) {
+	output, err := RunGitCommand(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, fmt.Errorf("failed to check the working tree status: %w", err)
+	}
+	return output != "", nil
+}
+
+// SwitchBranch checks out the local branch with 'git switch'. It refuses with
+// ErrDirtyWorkingTree rather than carrying uncommitted changes over to the branch.
+func SwitchBranch(ctx context.Context, branchName string) error {
+	if branchName == "" {
+		return fmt.Errorf("branch name cannot be empty")
+	}
+	dirty, err := IsWorkingTreeDirty(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrDirtyWorkingTree
+	}
+	if _, err := RunGitCommand(ctx, "switch", branchName); err != nil {
+		return fmt.Errorf("failed to switch to branch %q: %w", branchName, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestSwitchBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Clean Working Tree", func(t *testing.T) {
+		var calls [][]string
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			calls = append(calls, args)
+			return "", nil
+		})
+		defer teardown()
+
+		if err := SwitchBranch(ctx, "feature/x"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := [][]string{{"status", "--porcelain", "--untracked-files=no"}, {"switch", "feature/x"}}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("Unexpected git calls: got %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("Dirty Working Tree", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			if args[0] == "switch" {
+				t.Error("Expected no switch with uncommitted changes")
+			}
+			return " M main.go", nil
+		})
+		defer teardown()
+
+		if err := SwitchBranch(ctx, "feature/x"); !errors.Is(err, ErrDirtyWorkingTree) {
+			t.Errorf("Expected ErrDirtyWorkingTree, got %v", err)
+		}
+	})
+}
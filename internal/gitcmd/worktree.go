@@ -0,0 +1,74 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Worktree describes one entry of 'git worktree list --porcelain'.
+type Worktree struct {
+	Path     string
+	Head     string // Commit hash checked out in the worktree
+	Branch   string // Short branch name, empty if detached or bare
+	Bare     bool
+	Detached bool
+}
+
+// GetWorktrees returns all worktrees attached to the current repository,
+// including the main working tree.
+func GetWorktrees(ctx context.Context) ([]Worktree, error) {
+	args := []string{"worktree", "list", "--porcelain"}
+	output, err := RunGitCommand(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	return parseWorktreeList(output), nil
+}
+
+// parseWorktreeList parses porcelain output where each worktree is a block of
+// "key value" lines separated by blank lines.
+func parseWorktreeList(output string) []Worktree {
+	worktrees := make([]Worktree, 0)
+	var current *Worktree
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			current = nil
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if key == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+			continue
+		}
+		if current == nil {
+			continue // Attribute line outside of a worktree block; ignore
+		}
+		switch key {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		}
+	}
+
+	return worktrees
+}
+
+// WorktreeBranches maps each branch checked out in a worktree to that worktree's path.
+func WorktreeBranches(worktrees []Worktree) map[string]string {
+	branches := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			branches[wt.Branch] = wt.Path
+		}
+	}
+	return branches
+}
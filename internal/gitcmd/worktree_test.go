@@ -0,0 +1,83 @@
+package gitcmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Note: The setupMockRunner function is defined in test_helpers_test.go
+
+func TestGetWorktrees(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Parses Porcelain Output", func(t *testing.T) {
+		output := `worktree /repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /repo-wt/feature
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/x
+locked
+
+worktree /repo-wt/detached
+HEAD 3333333333333333333333333333333333333333
+detached
+`
+		teardown := setupMockRunner(t, func(_ context.Context, args ...string) (string, error) {
+			expected := []string{"worktree", "list", "--porcelain"}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("Unexpected args: got %v, want %v", args, expected)
+			}
+			return output, nil
+		})
+		defer teardown()
+
+		worktrees, err := GetWorktrees(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []Worktree{
+			{Path: "/repo", Head: "1111111111111111111111111111111111111111", Branch: "main"},
+			{Path: "/repo-wt/feature", Head: "2222222222222222222222222222222222222222", Branch: "feature/x"},
+			{Path: "/repo-wt/detached", Head: "3333333333333333333333333333333333333333", Detached: true},
+		}
+		if !reflect.DeepEqual(worktrees, expected) {
+			t.Errorf("Unexpected worktrees:\ngot  %+v\nwant %+v", worktrees, expected)
+		}
+
+		branches := WorktreeBranches(worktrees)
+		expectedBranches := map[string]string{"main": "/repo", "feature/x": "/repo-wt/feature"}
+		if !reflect.DeepEqual(branches, expectedBranches) {
+			t.Errorf("Unexpected worktree branches: got %v, want %v", branches, expectedBranches)
+		}
+	})
+
+	t.Run("Bare Repository", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+			return "worktree /srv/repo.git\nbare\n", nil
+		})
+		defer teardown()
+
+		worktrees, err := GetWorktrees(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(worktrees) != 1 || !worktrees[0].Bare || worktrees[0].Branch != "" {
+			t.Errorf("Expected a single bare worktree, got %+v", worktrees)
+		}
+	})
+
+	t.Run("Git Command Error", func(t *testing.T) {
+		teardown := setupMockRunner(t, func(_ context.Context, _ ...string) (string, error) {
+			return "", errors.New("simulated worktree error")
+		})
+		defer teardown()
+
+		if _, err := GetWorktrees(ctx); err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+	})
+}
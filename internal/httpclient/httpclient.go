@@ -0,0 +1,93 @@
+// Package httpclient builds the HTTP clients git-sweep reaches the GitHub API, release
+// downloads and webhooks with, so they all work the same way behind corporate proxies.
+package httpclient
+
+import (
+	"cmp"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/config"
+)
+
+const (
+	// githubAPIHost is the host github_token is sent to.
+	githubAPIHost = "api.github.com"
+	// githubTokenEnv is the environment variable holding the GitHub token if github_token is not
+	// set, as it is in GitHub Actions and for most other CI systems' GitHub integrations.
+	githubTokenEnv = "GITHUB_TOKEN"
+)
+
+// ErrRateLimited is returned when the GitHub API refuses a request because the rate limit of
+// its client is exhausted.
+var ErrRateLimited = errors.New("GitHub API rate limit exceeded; set GITHUB_TOKEN or github_token to raise it")
+
+// New returns an HTTP client with the given timeout that connects through the proxy set in the
+// environment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY), trusts the certificates of cfg.CABundle
+// in addition to the system's, and authenticates requests to the GitHub API with
+// cfg.GitHubToken, or $GITHUB_TOKEN if it is not set, unless they carry credentials of their own.
+func New(cfg config.Config, timeout time.Duration) (*http.Client, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	if cfg.CABundle != "" {
+		pool, err := certPool(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if token := cmp.Or(cfg.GitHubToken, os.Getenv(githubTokenEnv)); token != "" {
+		roundTripper = tokenTransport{base: transport, host: githubAPIHost, token: token}
+	}
+	return &http.Client{Timeout: timeout, Transport: roundTripper}, nil
+}
+
+// RateLimited reports whether resp is GitHub's answer to a request beyond the rate limit.
+func RateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// certPool returns the system's certificate pool extended with the PEM certificates in path.
+func certPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read ca_bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("ca_bundle %q contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// tokenTransport adds a bearer token to the requests for host that have no Authorization header.
+type tokenTransport struct {
+	base  http.RoundTripper
+	host  string
+	token string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.host && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.base.RoundTrip(req)
+}
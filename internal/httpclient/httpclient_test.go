@@ -0,0 +1,181 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/config"
+)
+
+func TestNewCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Without the server's certificate the request is rejected
+	client, err := New(config.Config{}, time.Second)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("Expected the self-signed certificate to be rejected without ca_bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err = New(config.Config{CABundle: bundle}, time.Second)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the certificate from ca_bundle to be trusted, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestNewInvalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing.pem"), notPEM} {
+		_, err := New(config.Config{CABundle: path}, time.Second)
+		if err == nil || !strings.Contains(err.Error(), "ca_bundle") {
+			t.Errorf("New() with ca_bundle %q: error = %v, want a ca_bundle error", path, err)
+		}
+	}
+}
+
+func TestNewProxy(t *testing.T) {
+	client, err := New(config.Config{GitHubToken: "secret"}, time.Second)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	transport, ok := client.Transport.(tokenTransport)
+	if !ok {
+		t.Fatalf("Expected the GitHub token transport, got %T", client.Transport)
+	}
+	if base, ok := transport.base.(*http.Transport); !ok || base.Proxy == nil {
+		t.Error("Expected the proxy to be taken from the environment")
+	}
+}
+
+// recorder is a RoundTripper that remembers the Authorization header of each request.
+type recorder []string
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	*r = append(*r, req.Header.Get("Authorization"))
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTokenTransport(t *testing.T) {
+	var sent recorder
+	transport := tokenTransport{base: &sent, host: githubAPIHost, token: "secret"}
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/bral/git-sweep-go/releases/latest", nil),
+		httptest.NewRequest(http.MethodGet, "https://example.com/hook", nil),
+		httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/bral/git-sweep-go/pulls", nil),
+	}
+	requests[2].Header.Set("Authorization", "Bearer provider")
+	for _, req := range requests {
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	want := []string{"Bearer secret", "", "Bearer provider"}
+	if strings.Join(sent, ",") != strings.Join(want, ",") {
+		t.Errorf("Authorization headers = %q, want %q", sent, want)
+	}
+	if requests[0].Header.Get("Authorization") != "" {
+		t.Error("Expected the caller's request to be left unchanged")
+	}
+}
+
+func TestNewGitHubToken(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		config string
+		env    string
+		want   string
+	}{
+		{"none", "", "", ""},
+		{"environment", "", "from-env", "Bearer from-env"},
+		{"config wins", "from-config", "from-env", "Bearer from-config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(githubTokenEnv, tt.env)
+			client, err := New(config.Config{GitHubToken: tt.config}, time.Second)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			transport, ok := client.Transport.(tokenTransport)
+			if tt.want == "" {
+				if ok {
+					t.Error("Expected no token transport without a token")
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a token transport, got %T", client.Transport)
+			}
+			// Point the token at the test server instead of the GitHub API
+			transport.host = strings.TrimPrefix(server.URL, "http://")
+			client.Transport = transport
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	tests := []struct {
+		status    int
+		remaining string
+		want      bool
+	}{
+		{http.StatusForbidden, "0", true},
+		{http.StatusTooManyRequests, "0", true},
+		{http.StatusForbidden, "", false},
+		{http.StatusForbidden, "12", false},
+		{http.StatusOK, "0", false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.remaining != "" {
+			resp.Header.Set("X-RateLimit-Remaining", tt.remaining)
+		}
+		if got := RateLimited(resp); got != tt.want {
+			t.Errorf("RateLimited(%d, remaining %q) = %t, want %t", tt.status, tt.remaining, got, tt.want)
+		}
+	}
+}
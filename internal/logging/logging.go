@@ -0,0 +1,98 @@
+// Package logging configures the structured slog logger shared by all git-sweep packages.
+// Packages log through the slog default logger; the CLI decides the level, format and destination.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Supported log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options controls how log records are written.
+type Options struct {
+	Level  slog.Level
+	Format string // FormatText (default) or FormatJSON
+	File   string // Append to this file instead of the default writer when set
+}
+
+var (
+	mu      sync.Mutex
+	current Options // Options of the installed logger, reused by Redirect
+)
+
+// ParseLevel converts a verbosity name (debug, info, warn, error) to a slog level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid verbosity %q (supported: debug, info, warn, error)", name)
+	}
+	return level, nil
+}
+
+// Setup installs the default slog logger. Records are written to opts.File if set, and to w
+// otherwise. The log file stays open for the lifetime of the process; writes are unbuffered.
+func Setup(opts Options, w io.Writer) error {
+	switch opts.Format {
+	case "":
+		opts.Format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q (supported: %q, %q)", opts.Format, FormatText, FormatJSON)
+	}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("could not open log file %q: %w", opts.File, err)
+		}
+		w = f
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = opts
+	slog.SetDefault(slog.New(newHandler(opts, w)))
+	return nil
+}
+
+// Redirect sends log records to w, keeping the installed level and format. It has no effect when
+// logging to a file, so callers can use it to keep records off a terminal that is in use.
+func Redirect(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current.File != "" {
+		return
+	}
+	slog.SetDefault(slog.New(newHandler(current, w)))
+}
+
+// newHandler builds the slog handler for the given options.
+func newHandler(opts Options, w io.Writer) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.Format == FormatJSON {
+		return slog.NewJSONHandler(w, handlerOpts)
+	}
+	return slog.NewTextHandler(w, handlerOpts)
+}
+
+// ChannelWriter is an io.Writer that sends every write, which slog handlers issue once per
+// record, as a line on the channel. Records are dropped when the channel is full so logging
+// never blocks the caller.
+type ChannelWriter chan string
+
+// Write implements io.Writer.
+func (w ChannelWriter) Write(p []byte) (int, error) {
+	select {
+	case w <- strings.TrimRight(string(p), "\n"):
+	default:
+	}
+	return len(p), nil
+}
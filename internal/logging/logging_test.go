@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, " error ": slog.LevelError,
+	} {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown verbosity")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	if err := Setup(Options{Level: slog.LevelInfo, Format: FormatJSON}, &buf); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Debug("hidden")
+	slog.Info("shown", "branch", "feature/x")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected exactly one record at info level, got:\n%s", buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", lines[0], err)
+	}
+	if record["msg"] != "shown" || record["branch"] != "feature/x" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+
+	if err := Setup(Options{Format: "xml"}, &buf); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestSetupLogFile(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	logFile := filepath.Join(t.TempDir(), "git-sweep.log")
+	var stderr bytes.Buffer
+	if err := Setup(Options{Level: slog.LevelWarn, File: logFile}, &stderr); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	// Redirect is ignored while logging to a file
+	Redirect(&stderr)
+	slog.Warn("to file")
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "msg=\"to file\"") {
+		t.Errorf("Expected record in log file, got %q", data)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected nothing written to the fallback writer, got %q", stderr.String())
+	}
+}
+
+func TestChannelWriter(t *testing.T) {
+	ch := make(ChannelWriter, 1)
+	logger := slog.New(slog.NewTextHandler(ch, nil))
+	logger.Info("first")
+	logger.Info("dropped") // Channel is full; must not block
+
+	line := <-ch
+	if !strings.Contains(line, "msg=first") || strings.HasSuffix(line, "\n") {
+		t.Errorf("Unexpected line %q", line)
+	}
+	select {
+	case extra := <-ch:
+		t.Errorf("Expected the second record to be dropped, got %q", extra)
+	default:
+	}
+}
@@ -0,0 +1,99 @@
+// Package notify posts a summary of a sweep to a webhook, e.g. a Slack incoming webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Branch identifies one deleted or failed branch in a Summary.
+type Branch struct {
+	Name    string `json:"name"`
+	Remote  string `json:"remote,omitempty"`  // Empty for local deletions
+	Hash    string `json:"hash,omitempty"`    // Commit the branch pointed at before deletion
+	Message string `json:"message,omitempty"` // Error details for failures
+}
+
+// Summary is the JSON payload sent after a sweep. Text is a human-readable rendering of the
+// other fields, which is what chat webhooks such as Slack's display.
+type Summary struct {
+	Text     string    `json:"text"`
+	Repo     string    `json:"repo"`
+	Time     time.Time `json:"time"`
+	Deleted  []Branch  `json:"deleted"`
+	Failures []Branch  `json:"failures"`
+}
+
+// NewSummary builds the summary for the deletion results of a sweep in repo.
+func NewSummary(repo string, results []types.DeleteResult, now time.Time) Summary {
+	summary := Summary{
+		Repo:     repo,
+		Time:     now.UTC(),
+		Deleted:  []Branch{},
+		Failures: []Branch{},
+	}
+	for _, res := range results {
+		branch := Branch{Name: res.BranchName, Remote: res.RemoteName, Hash: res.DeletedHash}
+		if res.Success {
+			summary.Deleted = append(summary.Deleted, branch)
+			continue
+		}
+		branch.Message = res.Message
+		summary.Failures = append(summary.Failures, branch)
+	}
+	summary.Text = summary.text()
+	return summary
+}
+
+// text renders the summary as a short message.
+func (s Summary) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "git-sweep in %s: %d branch deletion(s), %d failure(s)", s.Repo, len(s.Deleted), len(s.Failures))
+	for _, branch := range s.Deleted {
+		fmt.Fprintf(&b, "\n• deleted %s", branch.label())
+	}
+	for _, branch := range s.Failures {
+		fmt.Fprintf(&b, "\n• failed %s: %s", branch.label(), branch.Message)
+	}
+	return b.String()
+}
+
+// label names the branch, qualified by its remote for remote deletions.
+func (b Branch) label() string {
+	if b.Remote != "" {
+		return b.Remote + "/" + b.Name
+	}
+	return b.Name
+}
+
+// Send POSTs the summary as JSON to url. Any non-2xx response is an error.
+func Send(ctx context.Context, client *http.Client, url string, summary Summary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("error encoding summary: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "git-sweep-go")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification endpoint returned %s", resp.Status)
+	}
+	return nil
+}
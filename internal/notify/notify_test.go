@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestNewSummary(t *testing.T) {
+	results := []types.DeleteResult{
+		{BranchName: "feature/a", Success: true, DeletedHash: "abc"},
+		{BranchName: "feature/a", IsRemote: true, RemoteName: "origin", Success: true},
+		{BranchName: "feature/b", Success: false, Message: "not fully merged"},
+	}
+	summary := NewSummary("/repo", results, time.Now())
+
+	if len(summary.Deleted) != 2 || len(summary.Failures) != 1 {
+		t.Fatalf("Expected 2 deletions and 1 failure, got %+v", summary)
+	}
+	for _, want := range []string{
+		"git-sweep in /repo: 2 branch deletion(s), 1 failure(s)",
+		"deleted feature/a", "deleted origin/feature/a", "failed feature/b: not fully merged",
+	} {
+		if !strings.Contains(summary.Text, want) {
+			t.Errorf("Expected summary text to contain %q, got:\n%s", want, summary.Text)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received Summary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Invalid JSON body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	summary := NewSummary("/repo", []types.DeleteResult{{BranchName: "old", Success: true}}, time.Now())
+	if err := Send(context.Background(), server.Client(), server.URL, summary); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if received.Repo != "/repo" || len(received.Deleted) != 1 || received.Text == "" {
+		t.Errorf("Unexpected payload received: %+v", received)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := Send(context.Background(), failing.Client(), failing.URL, summary); err == nil {
+		t.Error("Expected an error for a non-2xx response")
+	}
+}
@@ -0,0 +1,116 @@
+// Package planfile reads and writes plan files: editable lists of proposed branch deletions
+// that one person prepares with 'git-sweep plan' and another reviews and executes with
+// 'git-sweep apply'.
+package planfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Entry is one branch the plan deletes.
+type Entry struct {
+	Name   string `toml:"name"`
+	Hash   string `toml:"hash"`   // Tip of the branch when the plan was made; apply refuses to run if it moved
+	Merged bool   `toml:"merged"` // Delete with 'git branch -d' rather than force deleting it
+	// How the branch was found merged; anything but "ancestry" force deletes it after all
+	MergedBy string `toml:"merged_by,omitempty"`
+	// Remote and RemoteBranch name the remote branch deleted along with the local one; an
+	// empty Remote keeps the remote branch
+	Remote       string `toml:"remote,omitempty"`
+	RemoteBranch string `toml:"remote_branch,omitempty"`
+	Reason       string `toml:"reason,omitempty"` // Why the branch was proposed, for the reviewer
+}
+
+// File is a plan of deletions for one repository.
+type File struct {
+	Repo      string    `toml:"repo"`
+	Created   time.Time `toml:"created"`
+	CreatedBy string    `toml:"created_by,omitempty"`
+	Branches  []Entry   `toml:"branch"`
+}
+
+// header explains the file to whoever reviews it.
+const header = `# git-sweep deletion plan.
+#
+# Review the branches below and remove the [[branch]] entries that must be kept. Clear
+# "remote" to keep a branch on the remote. Then run: git-sweep apply <this file>
+#
+# apply refuses to run if a branch moved since the plan was made, no longer exists or is
+# protected by now.
+
+`
+
+// Write writes the plan to w in TOML, preceded by instructions for the reviewer.
+func Write(w io.Writer, file File) error {
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	if err := toml.NewEncoder(w).Encode(file); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// commitHash matches full SHA-1 and SHA-256 object names.
+var commitHash = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// Read reads and validates the plan file at path.
+func Read(path string) (File, error) {
+	var file File
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read plan %q: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return File{}, fmt.Errorf("plan %q has unknown key %q", path, undecoded[0].String())
+	}
+	if err := file.validate(); err != nil {
+		return File{}, fmt.Errorf("invalid plan %q: %w", path, err)
+	}
+	return file, nil
+}
+
+// validate checks that every entry names a branch and its full commit hash once.
+func (f File) validate() error {
+	if f.Repo == "" {
+		return errors.New("repo is missing")
+	}
+	seen := make(map[string]bool, len(f.Branches))
+	for i, entry := range f.Branches {
+		switch {
+		case entry.Name == "":
+			return fmt.Errorf("branch %d has no name", i+1)
+		case seen[entry.Name]:
+			return fmt.Errorf("branch %q is listed twice", entry.Name)
+		case !commitHash.MatchString(entry.Hash):
+			return fmt.Errorf("branch %q has no valid hash", entry.Name)
+		case entry.Remote != "" && entry.RemoteBranch == "":
+			return fmt.Errorf("branch %q has a remote but no remote_branch", entry.Name)
+		}
+		seen[entry.Name] = true
+	}
+	return nil
+}
+
+// Create writes the plan to a new file at path, refusing to overwrite an existing one.
+func Create(path string, file File) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // Plans are shared
+	if err != nil {
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+	if err := Write(f, file); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package planfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const hash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestCreateRead(t *testing.T) {
+	want := File{
+		Repo:      "/src/app",
+		Created:   time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		CreatedBy: "dev@example.com",
+		Branches: []Entry{
+			{Name: "feature/done", Hash: hash, Merged: true, Remote: "origin", RemoteBranch: "feature/done",
+				Reason: "merged"},
+			{Name: "spike", Hash: hash, Reason: "old (200 days)"},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "sweep.toml")
+	if err := Create(path, want); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := Create(path, want); err == nil {
+		t.Error("Expected an existing plan not to be overwritten")
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got.Repo != want.Repo || !got.Created.Equal(want.Created) || len(got.Branches) != 2 ||
+		got.Branches[0] != want.Branches[0] || got.Branches[1] != want.Branches[1] {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, File{Repo: "/src/app", Branches: []Entry{{Name: "x", Hash: hash}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "# git-sweep deletion plan.") || !strings.Contains(buf.String(), "[[branch]]") {
+		t.Errorf("Unexpected plan:\n%s", buf.String())
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	entry := "[[branch]]\nname = \"x\"\nhash = \"" + hash + "\"\n"
+	tests := map[string]string{
+		"missing repo":   entry,
+		"short hash":     "repo = \"/r\"\n[[branch]]\nname = \"x\"\nhash = \"0123456\"\n",
+		"duplicate":      "repo = \"/r\"\n" + entry + entry,
+		"unknown key":    "repo = \"/r\"\n" + entry + "force = true\n",
+		"no remote name": "repo = \"/r\"\n" + entry + "remote = \"origin\"\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sweep.toml")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Read(path); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+const (
+	// DefaultGitHubAPIURL is the base URL of the public GitHub REST API.
+	DefaultGitHubAPIURL = "https://api.github.com"
+	// githubHost and githubSSHHost are the hosts of github.com remotes, the latter for SSH over port 443.
+	githubHost    = "github.com"
+	githubSSHHost = "ssh.github.com"
+	// githubTimeout bounds each request to the GitHub API.
+	githubTimeout = 10 * time.Second
+)
+
+// GitHub implements Provider using the GitHub REST API.
+type GitHub struct {
+	BaseURL string // API base URL, without trailing slash
+	Owner   string
+	Repo    string
+	Token   string // Optional; anonymous requests are heavily rate limited
+	Client  *http.Client
+}
+
+// githubPull is the subset of the GitHub pull request payload we use.
+type githubPull struct {
+	Number   int     `json:"number"`
+	State    string  `json:"state"`
+	MergedAt *string `json:"merged_at"`
+	HTMLURL  string  `json:"html_url"`
+	Head     struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// githubBranch is the subset of the GitHub branch payload we use.
+type githubBranch struct {
+	Protected bool `json:"protected"`
+}
+
+// NewGitHub returns a GitHub provider for owner/repo.
+func NewGitHub(owner, repo, token string) *GitHub {
+	return &GitHub{
+		BaseURL: DefaultGitHubAPIURL,
+		Owner:   owner,
+		Repo:    repo,
+		Token:   token,
+		Client:  &http.Client{Timeout: githubTimeout},
+	}
+}
+
+// PullRequestForBranch returns the most recently created pull request whose head is branch
+// in the same repository, or nil if there is none.
+func (g *GitHub) PullRequestForBranch(ctx context.Context, branch string) (*types.PullRequest, error) {
+	query := url.Values{}
+	query.Set("head", g.Owner+":"+branch)
+	query.Set("state", "all")
+	query.Set("per_page", "1")
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls?%s",
+		g.BaseURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo), query.Encode())
+
+	resp, err := g.get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("request for branch %q failed: %w", branch, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if httpclient.RateLimited(resp) {
+		return nil, fmt.Errorf("request for branch %q failed: %w", branch, httpclient.ErrRateLimited)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %s for branch %q", resp.Status, branch)
+	}
+
+	var pulls []githubPull
+	if err := json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
+		return nil, fmt.Errorf("error decoding pull requests for branch %q: %w", branch, err)
+	}
+	if len(pulls) == 0 {
+		return nil, nil //nolint:nilnil // No pull request for this branch is not an error
+	}
+
+	pull := pulls[0]
+	state := types.PullRequestOpen
+	switch {
+	case pull.MergedAt != nil && *pull.MergedAt != "":
+		state = types.PullRequestMerged
+	case pull.State == "closed":
+		state = types.PullRequestClosed
+	}
+	return &types.PullRequest{Number: pull.Number, State: state, URL: pull.HTMLURL, HeadSHA: pull.Head.SHA}, nil
+}
+
+// BranchProtected reports whether branch protection is enabled for branch on GitHub.
+// Branches that do not exist on GitHub are reported as unprotected.
+func (g *GitHub) BranchProtected(ctx context.Context, branch string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/branches/%s",
+		g.BaseURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo), url.PathEscape(branch))
+
+	resp, err := g.get(ctx, endpoint)
+	if err != nil {
+		return false, fmt.Errorf("request for branch %q failed: %w", branch, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if httpclient.RateLimited(resp) {
+		return false, fmt.Errorf("request for branch %q failed: %w", branch, httpclient.ErrRateLimited)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("GitHub API returned %s for branch %q", resp.Status, branch)
+	}
+
+	var info githubBranch
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("error decoding branch %q: %w", branch, err)
+	}
+	return info.Protected, nil
+}
+
+// get sends an authenticated GET request for a GitHub API endpoint.
+func (g *GitHub) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "git-sweep-go")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	return g.Client.Do(req)
+}
@@ -0,0 +1,182 @@
+// Package provider integrates with Git hosting services (e.g. GitHub) to enrich
+// branch analysis with information that cannot be derived from the local repository.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Provider looks up hosting-side information about branches.
+type Provider interface {
+	// PullRequestForBranch returns the most recent pull request whose head is the given branch,
+	// or nil if no pull request exists.
+	PullRequestForBranch(ctx context.Context, branch string) (*types.PullRequest, error)
+	// BranchProtected reports whether the branch is protected on the hosting service, i.e. a
+	// push deleting it would be rejected. A branch that does not exist there is not protected.
+	BranchProtected(ctx context.Context, branch string) (bool, error)
+}
+
+// New returns the provider configured in cfg for the repository behind remoteURL.
+// It returns (nil, nil) if no provider is configured.
+func New(cfg config.Config, remoteURL string) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil //nolint:nilnil // No provider configured is not an error
+	case config.ProviderGitHub:
+		host, owner, repo, ok := ParseRemoteURL(remoteURL)
+		if !ok {
+			return nil, fmt.Errorf("cannot determine GitHub repository from remote URL %q", remoteURL)
+		}
+		// Another host's owner/repo names an unrelated repository on github.com, whose pull
+		// requests say nothing about these branches and which must not receive the token
+		if host != githubHost && host != githubSSHHost {
+			return nil, fmt.Errorf("remote URL %q is not on %s, the only host the %q provider supports",
+				remoteURL, githubHost, config.ProviderGitHub)
+		}
+		client, err := httpclient.New(cfg, githubTimeout)
+		if err != nil {
+			return nil, err
+		}
+		github := NewGitHub(owner, repo, cfg.ProviderToken)
+		github.Client = client
+		return github, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", cfg.Provider)
+	}
+}
+
+// ParseRemoteURL extracts the host, in lower case and without user or port, and the owner and
+// repository name from a remote URL in any of the common forms: https://host/owner/repo(.git),
+// git@host:owner/repo(.git), ssh://git@host/owner/repo(.git).
+func ParseRemoteURL(remoteURL string) (host, owner, repo string, ok bool) {
+	path := strings.TrimSpace(remoteURL)
+	if path == "" {
+		return "", "", "", false
+	}
+
+	if i := strings.Index(path, "://"); i >= 0 {
+		// URL form: split off scheme and host
+		path = path[i+3:]
+		slash := strings.Index(path, "/")
+		if slash < 0 {
+			return "", "", "", false
+		}
+		host, path = path[:slash], path[slash+1:]
+		if colon := strings.LastIndex(host, ":"); colon >= 0 {
+			host = host[:colon]
+		}
+	} else if colon := strings.Index(path, ":"); colon >= 0 {
+		// scp-like form: user@host:owner/repo
+		host, path = path[:colon], path[colon+1:]
+	} else {
+		return "", "", "", false
+	}
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if host == "" || len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", "", false
+	}
+	return strings.ToLower(host), parts[len(parts)-2], parts[len(parts)-1], true
+}
+
+// maxConcurrentLookups bounds the number of in-flight API requests.
+const maxConcurrentLookups = 8
+
+// AnnotatePullRequests looks up the pull request for every branch with an upstream and not
+// listed in skip, by the name of the branch on its remote, and stores it on the branch.
+// Lookups run concurrently; failures are joined into the returned error and leave the
+// affected branches unannotated.
+func AnnotatePullRequests(
+	ctx context.Context, p Provider, branches []types.BranchInfo, skip map[string]bool,
+) error {
+	return forEachBranch(branches, withoutRemote(branches, skip), func(b *types.BranchInfo) error {
+		pr, err := p.PullRequestForBranch(ctx, b.RemoteBranchName())
+		if err != nil {
+			return err
+		}
+		b.PullRequest = pr
+		return nil
+	})
+}
+
+// AnnotateProtection asks the provider whether each branch with a remote, and not listed in
+// skip, is protected server-side and records the answer in ServerProtected. Lookups run
+// concurrently; failures are joined into the returned error and leave the branch unmarked.
+func AnnotateProtection(ctx context.Context, p Provider, branches []types.BranchInfo, skip map[string]bool) error {
+	return forEachBranch(branches, withoutRemote(branches, skip), func(b *types.BranchInfo) error {
+		protected, err := p.BranchProtected(ctx, b.RemoteBranchName())
+		if err != nil {
+			return err
+		}
+		b.ServerProtected = protected
+		return nil
+	})
+}
+
+// withoutRemote returns skip extended with the branches that track no remote branch, which
+// the provider knows nothing about.
+func withoutRemote(branches []types.BranchInfo, skip map[string]bool) map[string]bool {
+	noRemote := make(map[string]bool, len(skip))
+	for name := range skip {
+		noRemote[name] = true
+	}
+	for _, b := range branches {
+		if b.Remote == "" {
+			noRemote[b.Name] = true
+		}
+	}
+	return noRemote
+}
+
+// forEachBranch calls fn for every branch not listed in skip, running at most
+// maxConcurrentLookups calls at a time, and joins the errors they return. Once the rate limit
+// is exceeded, the remaining branches are skipped and only that error is returned.
+func forEachBranch(branches []types.BranchInfo, skip map[string]bool, fn func(*types.BranchInfo) error) error {
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		errs        []error
+		rateLimited atomic.Bool
+	)
+	sem := make(chan struct{}, maxConcurrentLookups)
+
+	for i := range branches {
+		if skip[branches[i].Name] || rateLimited.Load() {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(b *types.BranchInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(b); err != nil {
+				if errors.Is(err, httpclient.ErrRateLimited) {
+					rateLimited.Store(true)
+				}
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(&branches[i])
+	}
+	wg.Wait()
+
+	if rateLimited.Load() {
+		return httpclient.ErrRateLimited
+	}
+	return errors.Join(errs...)
+}
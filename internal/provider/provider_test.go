@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	testCases := []struct {
+		url       string
+		wantHost  string
+		wantOwner string
+		wantRepo  string
+		wantOK    bool
+	}{
+		{"https://github.com/bral/git-sweep-go.git", "github.com", "bral", "git-sweep-go", true},
+		{"https://github.com/bral/git-sweep-go", "github.com", "bral", "git-sweep-go", true},
+		{"git@github.com:bral/git-sweep-go.git", "github.com", "bral", "git-sweep-go", true},
+		{"ssh://git@github.com/bral/git-sweep-go.git", "github.com", "bral", "git-sweep-go", true},
+		{"https://user@GitHub.com:443/bral/git-sweep-go", "github.com", "bral", "git-sweep-go", true},
+		{"git@gitlab.com:bral/git-sweep-go.git", "gitlab.com", "bral", "git-sweep-go", true},
+		{"https://github.example.com/bral/git-sweep-go", "github.example.com", "bral", "git-sweep-go", true},
+		{"https://github.com/bral", "", "", "", false},
+		{"/local/path/repo", "", "", "", false},
+		{"", "", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		host, owner, repo, ok := ParseRemoteURL(tc.url)
+		if host != tc.wantHost || owner != tc.wantOwner || repo != tc.wantRepo || ok != tc.wantOK {
+			t.Errorf("ParseRemoteURL(%q) = (%q, %q, %q, %t), want (%q, %q, %q, %t)",
+				tc.url, host, owner, repo, ok, tc.wantHost, tc.wantOwner, tc.wantRepo, tc.wantOK)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	p, err := New(cfg, "git@github.com:bral/git-sweep-go.git")
+	if err != nil || p != nil {
+		t.Errorf("Expected no provider and no error when unconfigured, got %v, %v", p, err)
+	}
+
+	cfg.Provider = config.ProviderGitHub
+	if _, err := New(cfg, "/not/a/github/remote"); err == nil {
+		t.Error("Expected an error for an unparseable remote URL")
+	}
+	for _, remote := range []string{"git@gitlab.com:bral/git-sweep-go.git", "https://github.example.com/bral/repo"} {
+		if _, err := New(cfg, remote); err == nil {
+			t.Errorf("Expected an error for %s, which is not on github.com", remote)
+		}
+	}
+
+	p, err = New(cfg, "git@github.com:bral/git-sweep-go.git")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	gh, ok := p.(*GitHub)
+	if !ok || gh.Owner != "bral" || gh.Repo != "git-sweep-go" {
+		t.Errorf("Unexpected provider: %+v", p)
+	}
+}
+
+func TestGitHubPullRequestForBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/bral/git-sweep-go/pulls" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Unexpected Authorization header: %q", got)
+		}
+		switch r.URL.Query().Get("head") {
+		case "bral:feature/merged":
+			_, _ = w.Write([]byte(`[{"number": 7, "state": "closed", "merged_at": "2024-01-01T00:00:00Z",
+				"head": {"sha": "abc123"}}]`))
+		case "bral:feature/closed":
+			_, _ = w.Write([]byte(`[{"number": 8, "state": "closed", "merged_at": null}]`))
+		case "bral:feature/open":
+			_, _ = w.Write([]byte(`[{"number": 9, "state": "open", "merged_at": null}]`))
+		case "bral:feature/error":
+			w.WriteHeader(http.StatusForbidden)
+		case "bral:feature/limited":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	gh := NewGitHub("bral", "git-sweep-go", "secret")
+	gh.BaseURL = server.URL
+	ctx := context.Background()
+
+	testCases := []struct {
+		branch    string
+		wantState types.PullRequestState
+		wantNum   int
+	}{
+		{"feature/merged", types.PullRequestMerged, 7},
+		{"feature/closed", types.PullRequestClosed, 8},
+		{"feature/open", types.PullRequestOpen, 9},
+	}
+	for _, tc := range testCases {
+		pr, err := gh.PullRequestForBranch(ctx, tc.branch)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.branch, err)
+		}
+		if pr == nil || pr.State != tc.wantState || pr.Number != tc.wantNum {
+			t.Errorf("%s: got %+v, want #%d %s", tc.branch, pr, tc.wantNum, tc.wantState)
+		}
+	}
+	if pr, _ := gh.PullRequestForBranch(ctx, "feature/merged"); pr == nil || pr.HeadSHA != "abc123" {
+		t.Errorf("Expected the head SHA of the pull request, got %+v", pr)
+	}
+
+	pr, err := gh.PullRequestForBranch(ctx, "feature/no-pr")
+	if err != nil || pr != nil {
+		t.Errorf("Expected no pull request and no error, got %+v, %v", pr, err)
+	}
+
+	if _, err := gh.PullRequestForBranch(ctx, "feature/error"); err == nil {
+		t.Error("Expected an error for a non-200 response")
+	}
+	if _, err := gh.PullRequestForBranch(ctx, "feature/limited"); !errors.Is(err, httpclient.ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestGitHubBranchProtected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/repos/bral/git-sweep-go/branches/release%2F1.0":
+			_, _ = w.Write([]byte(`{"name": "release/1.0", "protected": true}`))
+		case "/repos/bral/git-sweep-go/branches/feature":
+			_, _ = w.Write([]byte(`{"name": "feature", "protected": false}`))
+		case "/repos/bral/git-sweep-go/branches/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	gh := NewGitHub("bral", "git-sweep-go", "")
+	gh.BaseURL = server.URL
+	ctx := context.Background()
+
+	for branch, want := range map[string]bool{"release/1.0": true, "feature": false, "missing": false} {
+		got, err := gh.BranchProtected(ctx, branch)
+		if err != nil || got != want {
+			t.Errorf("BranchProtected(%q) = %t, %v; want %t", branch, got, err, want)
+		}
+	}
+	if _, err := gh.BranchProtected(ctx, "error"); err == nil {
+		t.Error("Expected an error for a non-200 response")
+	}
+}
+
+// fakeProvider returns canned pull requests keyed by branch name.
+type fakeProvider struct {
+	pulls     map[string]*types.PullRequest
+	protected map[string]bool
+	fail      map[string]bool
+}
+
+func (f *fakeProvider) PullRequestForBranch(_ context.Context, branch string) (*types.PullRequest, error) {
+	if f.fail[branch] {
+		return nil, errors.New("lookup failed for " + branch)
+	}
+	return f.pulls[branch], nil
+}
+
+func (f *fakeProvider) BranchProtected(_ context.Context, branch string) (bool, error) {
+	if f.fail[branch] {
+		return false, errors.New("lookup failed for " + branch)
+	}
+	return f.protected[branch], nil
+}
+
+func TestAnnotatePullRequests(t *testing.T) {
+	branches := []types.BranchInfo{
+		{Name: "main", Remote: "origin"},
+		{Name: "feature/a", Remote: "origin", RemoteBranch: "a-upstream"},
+		{Name: "feature/b", Remote: "origin"},
+		{Name: "feature/c", Remote: "origin"},
+		{Name: "local-only"},
+	}
+	fake := &fakeProvider{
+		pulls: map[string]*types.PullRequest{
+			"main":       {Number: 1, State: types.PullRequestOpen},
+			"a-upstream": {Number: 2, State: types.PullRequestMerged},
+			"feature/b":  nil,
+			"local-only": {Number: 3, State: types.PullRequestMerged},
+		},
+		fail: map[string]bool{"feature/c": true},
+	}
+
+	err := AnnotatePullRequests(context.Background(), fake, branches, map[string]bool{"main": true})
+	if err == nil {
+		t.Error("Expected the failed lookup to be reported")
+	}
+	if branches[0].PullRequest != nil {
+		t.Error("Expected skipped branch to remain unannotated")
+	}
+	if branches[1].PullRequest == nil || branches[1].PullRequest.Number != 2 {
+		t.Errorf("Expected feature/a to be annotated with PR #2, got %+v", branches[1].PullRequest)
+	}
+	if branches[2].PullRequest != nil || branches[3].PullRequest != nil {
+		t.Error("Expected branches without pull requests to remain unannotated")
+	}
+	if branches[4].PullRequest != nil {
+		t.Error("Expected a branch without upstream to be skipped")
+	}
+}
+
+func TestAnnotateProtection(t *testing.T) {
+	branches := []types.BranchInfo{
+		{Name: "main", Remote: "origin"},
+		{Name: "release/1.0", Remote: "origin"},
+		{Name: "local-only"},
+		{Name: "feature/x", Remote: "origin"},
+		{Name: "renamed", Remote: "origin", RemoteBranch: "stable"},
+	}
+	fake := &fakeProvider{protected: map[string]bool{
+		"main": true, "release/1.0": true, "local-only": true, "renamed": false, "stable": true,
+	}}
+
+	if err := AnnotateProtection(context.Background(), fake, branches, map[string]bool{"main": true}); err != nil {
+		t.Fatalf("AnnotateProtection failed: %v", err)
+	}
+	for i, want := range []bool{false, true, false, false, true} {
+		if branches[i].ServerProtected != want {
+			t.Errorf("%s: ServerProtected = %t, want %t", branches[i].Name, branches[i].ServerProtected, want)
+		}
+	}
+}
+
+func TestForEachBranchRateLimited(t *testing.T) {
+	branches := make([]types.BranchInfo, 50)
+	for i := range branches {
+		branches[i].Name = fmt.Sprintf("feature/%d", i)
+	}
+
+	err := forEachBranch(branches, nil, func(*types.BranchInfo) error {
+		return fmt.Errorf("lookup failed: %w", httpclient.ErrRateLimited)
+	})
+	// The rate limit is reported once rather than for every branch
+	if err != httpclient.ErrRateLimited { //nolint:errorlint // The exact error is expected
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+}
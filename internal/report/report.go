@@ -0,0 +1,154 @@
+// Package report renders a shareable cleanup report of the branches git-sweep would delete.
+package report
+
+import (
+	"cmp"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Supported report formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Data is the input of a report.
+type Data struct {
+	Repo       string
+	MainBranch string
+	Generated  time.Time
+	Candidates []Row
+	Skipped    int // Non-protected branches that are not candidates
+}
+
+// Row describes one candidate branch and what a sweep would do with it.
+type Row struct {
+	Name    string
+	Status  string // "Merged" or "Unmerged, old", followed by the duplicate label, if any
+	AgeDays int
+	Author  string
+	Actions []string // git commands a sweep would run
+}
+
+// NewData builds the report data for the analyzed branches of repo. When archiveMode is set,
+// the proposed actions archive local branches before deleting them.
+func NewData(repo, mainBranch string, branches []types.AnalyzedBranch, archiveMode string, now time.Time) Data {
+	data := Data{Repo: repo, MainBranch: mainBranch, Generated: now}
+	for _, branch := range branches {
+		switch branch.Category {
+		case types.CategoryMergedOld, types.CategoryUnmergedOld:
+		case types.CategoryActive:
+			data.Skipped++
+			continue
+		case types.CategoryProtected:
+			continue
+		}
+
+		row := Row{
+			Name:    branch.Name,
+			Status:  "Unmerged, old",
+			AgeDays: int(now.Sub(branch.LastCommitDate).Hours() / 24),
+			Author:  branch.AuthorEmail,
+		}
+		if branch.IsMerged {
+			row.Status = "Merged"
+		} else if branch.DuplicateOf != "" && !branch.IsOldByAge {
+			row.Status = "Unmerged"
+		}
+		if label := branch.DuplicateLabel(); label != "" {
+			row.Status += ", " + label
+		}
+		if archiveMode != "" {
+			row.Actions = append(row.Actions,
+				gitCommand(gitcmd.ArchiveArgs(archiveMode, branch.Name, cmp.Or(branch.CommitHash, branch.Name))))
+		}
+		row.Actions = append(row.Actions, gitCommand(gitcmd.DeleteArgs(gitcmd.BranchToDelete{
+			Name: branch.Name, IsMerged: branch.IsMerged, MergedBy: branch.MergedBy,
+		})))
+		if branch.Remote != "" {
+			row.Actions = append(row.Actions, gitCommand(gitcmd.DeleteArgs(gitcmd.BranchToDelete{
+				Name: branch.RemoteBranchName(), IsRemote: true, Remote: branch.Remote,
+			})))
+		}
+		data.Candidates = append(data.Candidates, row)
+	}
+	return data
+}
+
+// gitCommand renders git arguments as a command line for display.
+func gitCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" {
+			arg = "''" // update-ref's empty old value
+		}
+		quoted = append(quoted, arg)
+	}
+	return "git " + strings.Join(quoted, " ")
+}
+
+// markdownCell escapes text for use inside a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+var funcs = map[string]any{
+	"cell": markdownCell,
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}
+
+var markdownTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(funcs).Parse(
+	`# Branch cleanup report: {{.Repo}}
+
+Generated {{date .Generated}} against ` + "`{{.MainBranch}}`" + `.
+{{len .Candidates}} branch(es) proposed for deletion, {{.Skipped}} other branch(es) kept.
+{{if .Candidates}}
+| Branch | Status | Age | Author | Proposed actions |
+| ------ | ------ | --- | ------ | ---------------- |
+{{range .Candidates}}| ` + "`{{cell .Name}}`" + ` | {{.Status}} | {{.AgeDays}} days | {{cell .Author}} | ` +
+		`{{range $i, $a := .Actions}}{{if $i}}<br>{{end}}` + "`{{cell $a}}`" + `{{end}} |
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Branch cleanup report: {{.Repo}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>Branch cleanup report: {{.Repo}}</h1>
+<p>Generated {{date .Generated}} against <code>{{.MainBranch}}</code>.
+{{len .Candidates}} branch(es) proposed for deletion, {{.Skipped}} other branch(es) kept.</p>
+{{if .Candidates}}<table>
+<tr><th>Branch</th><th>Status</th><th>Age</th><th>Author</th><th>Proposed actions</th></tr>
+{{range .Candidates}}<tr><td><code>{{.Name}}</code></td><td>{{.Status}}</td><td>{{.AgeDays}} days</td>` +
+	`<td>{{.Author}}</td><td>{{range $i, $a := .Actions}}{{if $i}}<br>{{end}}<code>{{$a}}</code>{{end}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// Render writes the report in the given format.
+func Render(w io.Writer, format string, data Data) error {
+	switch format {
+	case FormatMarkdown:
+		return markdownTemplate.Execute(w, data)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, data)
+	default:
+		return fmt.Errorf("unsupported report format %q (supported: %q, %q)", format, FormatMarkdown, FormatHTML)
+	}
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func sampleData() Data {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	branches := []types.AnalyzedBranch{
+		{BranchInfo: types.BranchInfo{Name: "main", LastCommitDate: now}, Category: types.CategoryProtected},
+		{
+			BranchInfo: types.BranchInfo{
+				Name: "feature/done", Remote: "origin", AuthorEmail: "ann@example.com",
+				LastCommitDate: now.AddDate(0, 0, -40),
+			},
+			IsMerged: true, Category: types.CategoryMergedOld,
+		},
+		{
+			BranchInfo: types.BranchInfo{Name: "exp|<b>", LastCommitDate: now.AddDate(0, 0, -200)},
+			Category:   types.CategoryUnmergedOld,
+		},
+		{BranchInfo: types.BranchInfo{Name: "wip", LastCommitDate: now}, Category: types.CategoryActive},
+	}
+	return NewData("/repo", "main", branches, "", now)
+}
+
+func TestNewData(t *testing.T) {
+	data := sampleData()
+	if len(data.Candidates) != 2 || data.Skipped != 1 {
+		t.Fatalf("Expected 2 candidates and 1 skipped branch, got %+v", data)
+	}
+	done := data.Candidates[0]
+	if done.Status != "Merged" || done.AgeDays != 40 || len(done.Actions) != 2 ||
+		done.Actions[0] != "git branch -d feature/done" || done.Actions[1] != "git push origin --delete feature/done" {
+		t.Errorf("Unexpected row for feature/done: %+v", done)
+	}
+	if exp := data.Candidates[1]; exp.Status != "Unmerged, old" || exp.Actions[0] != "git branch -D exp|<b>" {
+		t.Errorf("Unexpected row for exp: %+v", exp)
+	}
+
+	archived := NewData("/repo", "main", []types.AnalyzedBranch{{
+		BranchInfo: types.BranchInfo{Name: "old", CommitHash: "abc123"}, IsMerged: true, Category: types.CategoryMergedOld,
+	}}, "ref", time.Now())
+	if actions := archived.Candidates[0].Actions; len(actions) != 2 ||
+		actions[0] != "git update-ref refs/archive/old abc123 ''" || actions[1] != "git branch -d old" {
+		t.Errorf("Expected archiving before deletion, got %v", actions)
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := sampleData()
+
+	var md bytes.Buffer
+	if err := Render(&md, FormatMarkdown, data); err != nil {
+		t.Fatalf("Render markdown failed: %v", err)
+	}
+	for _, want := range []string{
+		"# Branch cleanup report: /repo",
+		"| `feature/done` | Merged | 40 days | ann@example.com | " +
+			"`git branch -d feature/done`<br>`git push origin --delete feature/done` |",
+		"| `exp\\|<b>` |",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := Render(&html, FormatHTML, data); err != nil {
+		t.Fatalf("Render html failed: %v", err)
+	}
+	if !strings.Contains(html.String(), "<code>exp|&lt;b&gt;</code>") {
+		t.Errorf("Expected branch names to be HTML-escaped, got:\n%s", html.String())
+	}
+
+	if err := Render(&html, "pdf", data); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
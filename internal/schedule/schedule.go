@@ -0,0 +1,406 @@
+// Package schedule installs and removes recurring git-sweep runs with the platform's
+// scheduler: launchd on macOS, a systemd user timer where systemd is running and cron
+// everywhere else.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+)
+
+// Interval is how often a scheduled run happens.
+type Interval string
+
+// Supported intervals. Runs start at 09:00 local time; daily runs every day, weekly runs on
+// Mondays and monthly runs on the first day of the month.
+const (
+	Daily   Interval = "daily"
+	Weekly  Interval = "weekly"
+	Monthly Interval = "monthly"
+)
+
+// ParseInterval validates an interval name.
+func ParseInterval(value string) (Interval, error) {
+	switch interval := Interval(strings.ToLower(strings.TrimSpace(value))); interval {
+	case Daily, Weekly, Monthly:
+		return interval, nil
+	default:
+		return "", fmt.Errorf("unsupported interval %q (supported: daily, weekly, monthly)", value)
+	}
+}
+
+// Scheduler is the system service that starts scheduled runs.
+type Scheduler string
+
+// Supported schedulers.
+const (
+	Cron    Scheduler = "cron"
+	Launchd Scheduler = "launchd"
+	Systemd Scheduler = "systemd"
+)
+
+// ParseScheduler validates a scheduler name. An empty name selects the scheduler of this
+// system as returned by Detect.
+func ParseScheduler(value string) (Scheduler, error) {
+	switch scheduler := Scheduler(strings.ToLower(strings.TrimSpace(value))); scheduler {
+	case "":
+		return Detect(), nil
+	case Cron, Launchd, Systemd:
+		return scheduler, nil
+	default:
+		return "", fmt.Errorf("unsupported scheduler %q (supported: cron, launchd, systemd)", value)
+	}
+}
+
+// Detect returns the scheduler to use on this system: launchd on macOS, systemd if it is
+// running and cron otherwise.
+func Detect() Scheduler {
+	if runtime.GOOS == "darwin" {
+		return Launchd
+	}
+	if _, err := os.Stat("/run/systemd/system"); err == nil {
+		if _, err := exec.LookPath("systemctl"); err == nil {
+			return Systemd
+		}
+	}
+	return Cron
+}
+
+// Job is a recurring git-sweep run for one repository.
+type Job struct {
+	// RepoRoot is the repository the job runs in; it identifies the job.
+	RepoRoot string
+	// Interval is how often the job runs.
+	Interval Interval
+	// Command is the git-sweep executable followed by its arguments.
+	Command []string
+	// LogPath is the file the output of every run is appended to.
+	LogPath string
+}
+
+// unsafeNameChars matches characters not used in job names.
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Name returns the name of the job for repoRoot, used for its unit files, launchd label and
+// crontab marker. It includes a hash of the path, so repositories with the same directory
+// name get separate jobs.
+func Name(repoRoot string) string {
+	sum := sha256.Sum256([]byte(repoRoot))
+	base := strings.Trim(unsafeNameChars.ReplaceAllString(filepath.Base(repoRoot), "-"), "-")
+	if base == "" {
+		base = "repo"
+	}
+	return "git-sweep-" + base + "-" + hex.EncodeToString(sum[:4])
+}
+
+// cronMarker is the comment that ends the crontab line of the job with the given name.
+func cronMarker(name string) string {
+	return "# " + name
+}
+
+// CronLine returns the crontab entry for the job.
+func (j Job) CronLine() string {
+	spec := map[Interval]string{Daily: "0 9 * * *", Weekly: "0 9 * * 1", Monthly: "0 9 1 * *"}[j.Interval]
+	return fmt.Sprintf("%s %s >> %s 2>&1 %s",
+		spec, gitcmd.ShellLine(j.Command), gitcmd.ShellLine([]string{j.LogPath}), cronMarker(Name(j.RepoRoot)))
+}
+
+// LaunchdPlist returns the launchd agent definition for the job.
+func (j Job) LaunchdPlist() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", xmlEscape(Name(j.RepoRoot)))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range j.Command {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>StartCalendarInterval</key>\n\t<dict>\n")
+	switch j.Interval {
+	case Weekly:
+		b.WriteString("\t\t<key>Weekday</key>\n\t\t<integer>1</integer>\n")
+	case Monthly:
+		b.WriteString("\t\t<key>Day</key>\n\t\t<integer>1</integer>\n")
+	}
+	b.WriteString("\t\t<key>Hour</key>\n\t\t<integer>9</integer>\n")
+	b.WriteString("\t\t<key>Minute</key>\n\t\t<integer>0</integer>\n\t</dict>\n")
+	for _, key := range []string{"StandardOutPath", "StandardErrorPath"} {
+		fmt.Fprintf(&b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, xmlEscape(j.LogPath))
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// SystemdUnits returns the service and timer units for the job.
+func (j Job) SystemdUnits() (service, timer string) {
+	calendar := map[Interval]string{
+		Daily: "*-*-* 09:00:00", Weekly: "Mon *-*-* 09:00:00", Monthly: "*-*-01 09:00:00",
+	}[j.Interval]
+	service = fmt.Sprintf(`[Unit]
+Description=git-sweep for %s
+
+[Service]
+Type=oneshot
+ExecStart=%s
+StandardOutput=append:%s
+StandardError=append:%s
+`, systemdEscape(j.RepoRoot), systemdLine(j.Command), systemdEscape(j.LogPath), systemdEscape(j.LogPath))
+	timer = fmt.Sprintf(`[Unit]
+Description=Run git-sweep %s for %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, j.Interval, systemdEscape(j.RepoRoot), calendar)
+	return service, timer
+}
+
+// Definition returns what Install adds for the job with the given scheduler, for review.
+func (j Job) Definition(s Scheduler) string {
+	switch s {
+	case Launchd:
+		return j.LaunchdPlist()
+	case Systemd:
+		service, timer := j.SystemdUnits()
+		name := Name(j.RepoRoot)
+		return fmt.Sprintf("# %s.service\n%s\n# %s.timer\n%s", name, service, name, timer)
+	default:
+		return j.CronLine() + "\n"
+	}
+}
+
+// Install installs the job with the given scheduler, replacing an earlier job for the same
+// repository, and returns where it was installed.
+func Install(ctx context.Context, s Scheduler, j Job) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(j.LogPath), 0o750); err != nil {
+		return "", fmt.Errorf("could not create log directory: %w", err)
+	}
+	name := Name(j.RepoRoot)
+	switch s {
+	case Launchd:
+		path, err := launchdPath(name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			// launchd keeps the old definition until the agent is unloaded
+			_, _ = run(ctx, "", "launchctl", "unload", path)
+		}
+		if err := writeFile(path, j.LaunchdPlist()); err != nil {
+			return "", err
+		}
+		if _, err := run(ctx, "", "launchctl", "load", "-w", path); err != nil {
+			return "", err
+		}
+		return path, nil
+	case Systemd:
+		dir, err := systemdDir()
+		if err != nil {
+			return "", err
+		}
+		service, timer := j.SystemdUnits()
+		if err := writeFile(filepath.Join(dir, name+".service"), service); err != nil {
+			return "", err
+		}
+		timerPath := filepath.Join(dir, name+".timer")
+		if err := writeFile(timerPath, timer); err != nil {
+			return "", err
+		}
+		if _, err := run(ctx, "", "systemctl", "--user", "daemon-reload"); err != nil {
+			return "", err
+		}
+		if _, err := run(ctx, "", "systemctl", "--user", "enable", "--now", name+".timer"); err != nil {
+			return "", err
+		}
+		return timerPath, nil
+	default:
+		crontab, err := readCrontab(ctx)
+		if err != nil {
+			return "", err
+		}
+		updated, _ := removeCronLine(crontab, name)
+		if _, err := run(ctx, updated+j.CronLine()+"\n", "crontab", "-"); err != nil {
+			return "", err
+		}
+		return "crontab", nil
+	}
+}
+
+// Remove removes the job for repoRoot from the given scheduler. It reports false if no job
+// was installed.
+func Remove(ctx context.Context, s Scheduler, repoRoot string) (bool, error) {
+	name := Name(repoRoot)
+	switch s {
+	case Launchd:
+		path, err := launchdPath(name)
+		if err != nil {
+			return false, err
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		_, _ = run(ctx, "", "launchctl", "unload", "-w", path)
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("could not remove %q: %w", path, err)
+		}
+		return true, nil
+	case Systemd:
+		dir, err := systemdDir()
+		if err != nil {
+			return false, err
+		}
+		timerPath := filepath.Join(dir, name+".timer")
+		if _, err := os.Stat(timerPath); errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		_, _ = run(ctx, "", "systemctl", "--user", "disable", "--now", name+".timer")
+		for _, path := range []string{timerPath, filepath.Join(dir, name+".service")} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return false, fmt.Errorf("could not remove %q: %w", path, err)
+			}
+		}
+		if _, err := run(ctx, "", "systemctl", "--user", "daemon-reload"); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		crontab, err := readCrontab(ctx)
+		if err != nil {
+			return false, err
+		}
+		updated, removed := removeCronLine(crontab, name)
+		if !removed {
+			return false, nil
+		}
+		if _, err := run(ctx, updated, "crontab", "-"); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+// readCrontab returns the user's crontab, which is empty if they have none.
+func readCrontab(ctx context.Context) (string, error) {
+	out, err := run(ctx, "", "crontab", "-l")
+	if err != nil {
+		if strings.Contains(err.Error(), "no crontab") {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// removeCronLine returns crontab without the line of the job with the given name, ending in
+// a newline unless it is empty, and reports whether the line was there.
+func removeCronLine(crontab, name string) (string, bool) {
+	marker := cronMarker(name)
+	var (
+		b       strings.Builder
+		removed bool
+	)
+	for _, line := range strings.Split(crontab, "\n") {
+		if strings.HasSuffix(line, " "+marker) {
+			removed = true
+			continue
+		}
+		if line != "" {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), removed
+}
+
+// launchdPath returns the path of the launchd agent with the given label.
+func launchdPath(label string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", label+".plist"), nil
+}
+
+// systemdDir returns the directory of the user's systemd units.
+func systemdDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(configDir, "systemd", "user"), nil
+}
+
+// writeFile writes content to path, creating its directory if needed.
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("could not create %q: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("could not write %q: %w", path, err)
+	}
+	return nil
+}
+
+// run executes a scheduler command with stdin as its input and returns its output. It is a
+// variable so tests can replace it.
+var run = func(ctx context.Context, stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return out, fmt.Errorf("%s %s failed: %s", name, strings.Join(args, " "), msg)
+	}
+	return out, nil
+}
+
+// systemdSafe matches words that need no quoting in a systemd command line.
+var systemdSafe = regexp.MustCompile(`^[A-Za-z0-9_./@%+=:,-]+$`)
+
+// systemdLine renders a command for ExecStart, which splits words like a shell but
+// expands % specifiers.
+func systemdLine(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		word = systemdEscape(word)
+		if !systemdSafe.MatchString(word) {
+			word = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+		}
+		quoted = append(quoted, word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdEscape escapes the % specifiers systemd expands in unit settings.
+func systemdEscape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// xmlEscape escapes s for use in XML character data.
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,171 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRun replaces run with a fake crontab and records the other commands it is asked to run.
+func fakeRun(t *testing.T, crontab *string) *[]string {
+	t.Helper()
+	var commands []string
+	original := run
+	run = func(_ context.Context, stdin, name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		switch command {
+		case "crontab -l":
+			if *crontab == "" {
+				return nil, errors.New("crontab -l failed: no crontab for user")
+			}
+			return []byte(*crontab), nil
+		case "crontab -":
+			*crontab = stdin
+		default:
+			commands = append(commands, command)
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { run = original })
+	return &commands
+}
+
+func TestParseInterval(t *testing.T) {
+	if interval, err := ParseInterval(" Weekly"); err != nil || interval != Weekly {
+		t.Errorf("ParseInterval(Weekly) = %q, %v", interval, err)
+	}
+	if _, err := ParseInterval("hourly"); err == nil {
+		t.Error("Expected an error for an unsupported interval")
+	}
+}
+
+func TestName(t *testing.T) {
+	a := Name("/home/me/src/my repo")
+	if !strings.HasPrefix(a, "git-sweep-my-repo-") {
+		t.Errorf("Name() = %q, want the sanitized directory name", a)
+	}
+	if b := Name("/home/me/work/my repo"); a == b {
+		t.Errorf("Expected repositories with the same directory name to get separate jobs, both %q", a)
+	}
+	if a != Name("/home/me/src/my repo") {
+		t.Error("Expected the name to be stable")
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	job := Job{
+		RepoRoot: "/src/app",
+		Interval: Weekly,
+		Command:  []string{"/usr/local/bin/git-sweep", "-C", "/src/app", "--quick-status"},
+		LogPath:  "/state/schedule/app 100%.log",
+	}
+
+	cron := job.CronLine()
+	want := "0 9 * * 1 /usr/local/bin/git-sweep -C /src/app --quick-status >> '/state/schedule/app 100%.log' 2>&1 # " +
+		Name("/src/app")
+	if cron != want {
+		t.Errorf("CronLine() = %q, want %q", cron, want)
+	}
+
+	plist := job.LaunchdPlist()
+	for _, part := range []string{
+		"<string>" + Name("/src/app") + "</string>",
+		"<string>--quick-status</string>",
+		"<key>Weekday</key>\n\t\t<integer>1</integer>",
+		"<key>StandardOutPath</key>\n\t<string>/state/schedule/app 100%.log</string>",
+	} {
+		if !strings.Contains(plist, part) {
+			t.Errorf("Expected the plist to contain %q:\n%s", part, plist)
+		}
+	}
+
+	service, timer := job.SystemdUnits()
+	for _, part := range []string{
+		"ExecStart=/usr/local/bin/git-sweep -C /src/app --quick-status\n",
+		"StandardOutput=append:/state/schedule/app 100%%.log\n",
+	} {
+		if !strings.Contains(service, part) {
+			t.Errorf("Expected the service to contain %q:\n%s", part, service)
+		}
+	}
+	if !strings.Contains(timer, "OnCalendar=Mon *-*-* 09:00:00\n") || !strings.Contains(timer, "Persistent=true") {
+		t.Errorf("Unexpected timer:\n%s", timer)
+	}
+}
+
+func TestInstallRemoveCron(t *testing.T) {
+	crontab := "MAILTO=me\n0 * * * * backup\n"
+	fakeRun(t, &crontab)
+	ctx := context.Background()
+	job := Job{
+		RepoRoot: "/src/app",
+		Interval: Daily,
+		Command:  []string{"git-sweep", "-C", "/src/app", "--quick-status"},
+		LogPath:  filepath.Join(t.TempDir(), "app.log"),
+	}
+
+	if _, err := Install(ctx, Cron, job); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	job.Interval = Weekly
+	if _, err := Install(ctx, Cron, job); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	want := "MAILTO=me\n0 * * * * backup\n" + job.CronLine() + "\n"
+	if crontab != want {
+		t.Errorf("Expected reinstalling to replace the entry, got:\n%s", crontab)
+	}
+
+	if removed, err := Remove(ctx, Cron, "/src/app"); err != nil || !removed {
+		t.Fatalf("Remove() = %t, %v", removed, err)
+	}
+	if crontab != "MAILTO=me\n0 * * * * backup\n" {
+		t.Errorf("Expected other entries to be kept, got:\n%s", crontab)
+	}
+	if removed, err := Remove(ctx, Cron, "/src/app"); err != nil || removed {
+		t.Errorf("Remove() of a missing job = %t, %v", removed, err)
+	}
+}
+
+func TestInstallRemoveSystemd(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	var crontab string
+	commands := fakeRun(t, &crontab)
+	ctx := context.Background()
+	job := Job{
+		RepoRoot: "/src/app",
+		Interval: Monthly,
+		Command:  []string{"git-sweep", "-C", "/src/app", "--quick-status"},
+		LogPath:  filepath.Join(t.TempDir(), "app.log"),
+	}
+	name := Name("/src/app")
+
+	path, err := Install(ctx, Systemd, job)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if want := filepath.Join(configDir, "systemd", "user", name+".timer"); path != want {
+		t.Errorf("Install() = %q, want %q", path, want)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "systemd", "user", name+".service")); err != nil {
+		t.Errorf("Expected the service unit to be written: %v", err)
+	}
+	if got := strings.Join(*commands, "; "); got !=
+		"systemctl --user daemon-reload; systemctl --user enable --now "+name+".timer" {
+		t.Errorf("Unexpected commands: %s", got)
+	}
+
+	if removed, err := Remove(ctx, Systemd, "/src/app"); err != nil || !removed {
+		t.Fatalf("Remove() = %t, %v", removed, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the timer unit to be removed, got %v", err)
+	}
+	if removed, err := Remove(ctx, Systemd, "/src/app"); err != nil || removed {
+		t.Errorf("Remove() of a missing job = %t, %v", removed, err)
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const auditFile = "audit.jsonl"
+
+// AuditEntry records one executed deletion attempt, successful or not, for accountability.
+// Unlike the undo journal, the audit log is never read back by git-sweep and is only appended to.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Repo       string    `json:"repo"`   // Absolute path of the repository's top-level directory
+	Branch     string    `json:"branch"` // Short branch name, e.g. "feature/x"
+	Hash       string    `json:"hash,omitempty"`
+	IsRemote   bool      `json:"is_remote"`
+	Remote     string    `json:"remote,omitempty"` // Only set if IsRemote is true
+	Success    bool      `json:"success"`
+	Message    string    `json:"message,omitempty"`     // Success message or error details
+	Cmd        string    `json:"cmd,omitempty"`         // The git command that was run
+	ArchivedAs string    `json:"archived_as,omitempty"` // Ref or tag the branch was archived to
+	Bundle     string    `json:"bundle,omitempty"`      // Bundle file the branch was backed up to
+	Tag        string    `json:"tag,omitempty"`         // Tag created at the branch tip before deletion
+	User       string    `json:"user,omitempty"`        // git user.email of whoever ran git-sweep
+}
+
+// AuditPath returns the location of the audit log: customPath if set, otherwise audit.jsonl
+// in the state directory.
+func AuditPath(customPath string) (string, error) {
+	if customPath != "" {
+		return customPath, nil
+	}
+	return filePath(auditFile)
+}
+
+// AppendAudit appends the given entries to the audit log at path, one JSON object per line.
+// The file is created if needed but never truncated or rewritten.
+func AppendAudit(path string, entries []AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return fmt.Errorf("could not create directory for audit log %q: %w", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
+	if err != nil {
+		return fmt.Errorf("could not open audit log %q: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// The entries are written at once, so those of concurrent runs stay together
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("could not encode audit entry for %q: %w", entry.Branch, err)
+		}
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("could not write audit log %q: %w", path, err)
+	}
+	return nil
+}
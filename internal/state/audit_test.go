@@ -0,0 +1,62 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAudit(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	path, err := AuditPath("")
+	if err != nil {
+		t.Fatalf("AuditPath failed: %v", err)
+	}
+	if filepath.Base(path) != auditFile {
+		t.Errorf("Expected default audit log to be named %s, got %s", auditFile, path)
+	}
+	custom := filepath.Join(t.TempDir(), "team", "sweep-audit.jsonl")
+	if got, _ := AuditPath(custom); got != custom {
+		t.Errorf("Expected custom path %s, got %s", custom, got)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := AppendAudit(custom, nil); err != nil {
+		t.Fatalf("AppendAudit with no entries failed: %v", err)
+	}
+	if _, err := os.Stat(custom); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be created for zero entries, stat error: %v", err)
+	}
+	first := []AuditEntry{{Time: now, Repo: "/repo", Branch: "feature/a", Hash: "h1", Success: true}}
+	second := []AuditEntry{{
+		Time: now, Repo: "/repo", Branch: "feature/a", IsRemote: true, Remote: "origin", Message: "rejected",
+	}}
+	if err := AppendAudit(custom, first); err != nil {
+		t.Fatalf("AppendAudit failed: %v", err)
+	}
+	if err := AppendAudit(custom, second); err != nil {
+		t.Fatalf("AppendAudit failed: %v", err)
+	}
+
+	file, err := os.Open(custom)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	var got []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, entry)
+	}
+	if len(got) != 2 || !got[0].Success || got[1].Success || got[1].Remote != "origin" {
+		t.Errorf("Unexpected audit entries: %+v", got)
+	}
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const bundlesDir = "bundles"
+
+// BundleDir returns the directory the branches of the repository at repoRoot are bundled to:
+// a subdirectory named after the repository in customDir if set, otherwise in the bundles
+// directory of the state directory.
+func BundleDir(customDir, repoRoot string) (string, error) {
+	base := customDir
+	if base == "" {
+		dir, err := Dir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(dir, bundlesDir)
+	}
+	return filepath.Join(base, filepath.Base(repoRoot)), nil
+}
+
+// ExpireBundles deletes the bundle files in dir, including those of branches with slashes in
+// their names, that were written more than maxAge before now. It returns how many it deleted.
+// A missing dir has nothing to expire.
+func ExpireBundles(dir string, maxAge time.Duration, now time.Time) (int, error) {
+	deleted := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".bundle") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if now.Sub(info.ModTime()) <= maxAge {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("could not expire bundles in %q: %w", dir, err)
+	}
+	return deleted, nil
+}
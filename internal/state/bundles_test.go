@@ -0,0 +1,59 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundleDir(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	dir, err := BundleDir("", "/src/project")
+	if err != nil || dir != filepath.Join(stateHome, "git-sweep", "bundles", "project") {
+		t.Errorf("Unexpected default bundle dir %q (err %v)", dir, err)
+	}
+	if dir, _ := BundleDir("/backups", "/src/project"); dir != filepath.Join("/backups", "project") {
+		t.Errorf("Unexpected custom bundle dir %q", dir)
+	}
+}
+
+func TestExpireBundles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := map[string]time.Time{
+		"old.bundle":         now.Add(-48 * time.Hour),
+		"feature/old.bundle": now.Add(-48 * time.Hour),
+		"new.bundle":         now.Add(-time.Hour),
+		"notes.txt":          now.Add(-48 * time.Hour),
+	}
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, filePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := ExpireBundles(dir, 24*time.Hour, now)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 expired bundles, got %d (err %v)", deleted, err)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if kept := err == nil; kept != (name == "new.bundle" || name == "notes.txt") {
+			t.Errorf("%s: unexpected kept=%v", name, kept)
+		}
+	}
+
+	if deleted, err := ExpireBundles(filepath.Join(dir, "missing"), time.Hour, now); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing to expire in a missing dir, got %d (err %v)", deleted, err)
+	}
+}
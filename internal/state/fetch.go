@@ -0,0 +1,72 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
+)
+
+const fetchFileName = "fetched.json"
+
+// fetchFile is the on-disk layout: the time of the last successful fetch keyed by repository
+// root, then remote name.
+type fetchFile map[string]map[string]time.Time
+
+// LastFetch returns when remoteName was last fetched successfully in the repository at
+// repoRoot. The zero time means it was never recorded.
+func LastFetch(repoRoot, remoteName string) (time.Time, error) {
+	all, err := readFetchFile()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return all[repoRoot][remoteName], nil
+}
+
+// RecordFetch remembers that remoteName was fetched successfully in repoRoot at the given time.
+func RecordFetch(repoRoot, remoteName string, at time.Time) error {
+	all, err := readFetchFile()
+	if err != nil {
+		return err
+	}
+	if all[repoRoot] == nil {
+		all[repoRoot] = make(map[string]time.Time)
+	}
+	all[repoRoot][remoteName] = at.UTC()
+
+	path, err := filePath(fetchFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode fetch times: %w", err)
+	}
+	if err := fileutil.Replace(path, data); err != nil {
+		return fmt.Errorf("could not write fetch times %q: %w", path, err)
+	}
+	return nil
+}
+
+// readFetchFile loads all recorded fetch times. A missing file yields an empty list.
+func readFetchFile() (fetchFile, error) {
+	path, err := filePath(fetchFileName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(fetchFile), nil
+		}
+		return nil, fmt.Errorf("could not read fetch times %q: %w", path, err)
+	}
+	all := make(fetchFile)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("could not parse fetch times %q: %w", path, err)
+	}
+	return all, nil
+}
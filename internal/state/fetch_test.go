@@ -0,0 +1,33 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordFetch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	last, err := LastFetch("/repo", "origin")
+	if err != nil || !last.IsZero() {
+		t.Fatalf("Expected no recorded fetch, got %v, %v", last, err)
+	}
+
+	at := time.Now().Truncate(time.Second)
+	if err := RecordFetch("/repo", "origin", at); err != nil {
+		t.Fatalf("RecordFetch failed: %v", err)
+	}
+	if err := RecordFetch("/repo", "upstream", at.Add(-time.Hour)); err != nil {
+		t.Fatalf("RecordFetch failed: %v", err)
+	}
+
+	if last, err = LastFetch("/repo", "origin"); err != nil || !last.Equal(at) {
+		t.Errorf("LastFetch(origin) = %v, %v; want %v", last, err, at)
+	}
+	if last, _ = LastFetch("/repo", "upstream"); !last.Equal(at.Add(-time.Hour)) {
+		t.Errorf("Expected fetch times to be kept per remote, got %v", last)
+	}
+	if last, _ = LastFetch("/other", "origin"); !last.IsZero() {
+		t.Errorf("Expected fetch times to be kept per repository, got %v", last)
+	}
+}
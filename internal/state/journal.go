@@ -0,0 +1,115 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const journalFile = "journal.jsonl"
+
+// JournalEntry records a single branch deletion so it can be undone later.
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo"`   // Absolute path of the repository's top-level directory
+	Branch   string    `json:"branch"` // Short branch name, e.g. "feature/x"
+	Hash     string    `json:"hash"`   // Commit hash the branch pointed at before deletion
+	IsRemote bool      `json:"is_remote"`
+	Remote   string    `json:"remote,omitempty"` // Only set if IsRemote is true
+}
+
+// JournalPath returns the location of the deletion journal.
+func JournalPath() (string, error) {
+	return filePath(journalFile)
+}
+
+// AppendJournal appends the given entries to the deletion journal, one JSON object per line.
+func AppendJournal(entries []JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path, err := JournalPath()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
+	if err != nil {
+		return fmt.Errorf("could not open journal %q: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// One write per call keeps the lines of concurrent runs from interleaving
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("could not encode journal entry for %q: %w", entry.Branch, err)
+		}
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("could not write journal %q: %w", path, err)
+	}
+	return nil
+}
+
+// ReadJournal returns all journal entries in the order they were written.
+// A missing journal is not an error; it simply yields no entries.
+func ReadJournal() ([]JournalEntry, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []JournalEntry{}, nil
+		}
+		return nil, fmt.Errorf("could not open journal %q: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	entries := make([]JournalEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// Skip corrupt lines rather than making the whole journal unusable.
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read journal %q: %w", path, err)
+	}
+	return entries, nil
+}
+
+// FindLatest returns the most recent journal entry for the given repository and branch.
+// Local deletions are preferred over remote ones recorded at the same time, but any
+// entry carries a usable hash. The boolean is false if no entry matches.
+func FindLatest(entries []JournalEntry, repo, branch string) (JournalEntry, bool) {
+	var found JournalEntry
+	ok := false
+	for _, entry := range entries {
+		if entry.Repo != repo || entry.Branch != branch || entry.Hash == "" {
+			continue
+		}
+		newer := entry.Time.After(found.Time)
+		sameRunLocal := entry.Time.Equal(found.Time) && !entry.IsRemote
+		if !ok || newer || sameRunLocal {
+			found = entry
+			ok = true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndReadJournal(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// Reading a journal that doesn't exist yet should yield no entries.
+	entries, err := ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal on missing file failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected no entries, got %d", len(entries))
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	first := []JournalEntry{
+		{Time: now, Repo: "/repo", Branch: "feature/a", Hash: "h1"},
+		{Time: now, Repo: "/repo", Branch: "feature/a", Hash: "h1", IsRemote: true, Remote: "origin"},
+	}
+	second := []JournalEntry{
+		{Time: now.Add(time.Minute), Repo: "/other", Branch: "feature/b", Hash: "h2"},
+	}
+	if err := AppendJournal(first); err != nil {
+		t.Fatalf("AppendJournal failed: %v", err)
+	}
+	if err := AppendJournal(second); err != nil {
+		t.Fatalf("AppendJournal failed: %v", err)
+	}
+
+	entries, err = ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[2].Branch != "feature/b" || entries[2].Hash != "h2" {
+		t.Errorf("Unexpected last entry: %+v", entries[2])
+	}
+}
+
+func TestReadJournal_SkipsCorruptLines(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	dir := filepath.Join(stateHome, stateDirName)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	content := `{"repo":"/repo","branch":"ok","hash":"h1"}
+not json at all
+{"repo":"/repo","branch":"also-ok","hash":"h2"}
+`
+	if err := os.WriteFile(filepath.Join(dir, journalFile), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	entries, err := ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 valid entries, got %d", len(entries))
+	}
+}
+
+func TestFindLatest(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []JournalEntry{
+		{Time: base, Repo: "/repo", Branch: "feature/a", Hash: "old"},
+		{Time: base.Add(time.Hour), Repo: "/repo", Branch: "feature/a", Hash: "new", IsRemote: true, Remote: "origin"},
+		{Time: base.Add(time.Hour), Repo: "/repo", Branch: "feature/a", Hash: "new"},
+		{Time: base.Add(2 * time.Hour), Repo: "/elsewhere", Branch: "feature/a", Hash: "foreign"},
+	}
+
+	entry, ok := FindLatest(entries, "/repo", "feature/a")
+	if !ok {
+		t.Fatal("Expected to find an entry")
+	}
+	if entry.Hash != "new" || entry.IsRemote {
+		t.Errorf("Expected latest local entry with hash 'new', got %+v", entry)
+	}
+
+	if _, ok := FindLatest(entries, "/repo", "missing"); ok {
+		t.Error("Expected no entry for unknown branch")
+	}
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
+)
+
+const lastRunFileName = "last-run.json"
+
+// RunResult is the outcome of one deletion of a run.
+type RunResult struct {
+	Branch     string `json:"branch"`         // Short branch name, e.g. "feature/x"
+	Hash       string `json:"hash,omitempty"` // Commit hash the branch pointed at before deletion
+	IsRemote   bool   `json:"is_remote"`
+	Remote     string `json:"remote,omitempty"` // Only set if IsRemote is true
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`     // Success message or error details
+	ArchivedAs string `json:"archived_as,omitempty"` // Ref or tag the branch was archived to
+	Bundle     string `json:"bundle,omitempty"`      // Bundle file the branch was backed up to
+	Tag        string `json:"tag,omitempty"`         // Tag created at the branch tip before deletion
+}
+
+// LastRun is the most recent run that deleted branches in a repository.
+type LastRun struct {
+	Time    time.Time   `json:"time"`
+	Results []RunResult `json:"results"`
+}
+
+// lastRunFile is the on-disk layout: the last run keyed by repository root.
+type lastRunFile map[string]LastRun
+
+// LastRunOf returns the last run recorded for the repository at repoRoot. The boolean is false
+// if none was recorded.
+func LastRunOf(repoRoot string) (LastRun, bool, error) {
+	all, err := readLastRunFile()
+	if err != nil {
+		return LastRun{}, false, err
+	}
+	run, ok := all[repoRoot]
+	return run, ok, nil
+}
+
+// RecordLastRun replaces the last run recorded for the repository at repoRoot. Runs of other
+// repositories are kept.
+func RecordLastRun(repoRoot string, run LastRun) error {
+	all, err := readLastRunFile()
+	if err != nil {
+		return err
+	}
+	run.Time = run.Time.UTC()
+	all[repoRoot] = run
+
+	path, err := filePath(lastRunFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode last run: %w", err)
+	}
+	if err := fileutil.Replace(path, data); err != nil {
+		return fmt.Errorf("could not write last run %q: %w", path, err)
+	}
+	return nil
+}
+
+// readLastRunFile loads the last runs of all repositories. A missing file yields none.
+func readLastRunFile() (lastRunFile, error) {
+	path, err := filePath(lastRunFileName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(lastRunFile), nil
+		}
+		return nil, fmt.Errorf("could not read last run %q: %w", path, err)
+	}
+	all := make(lastRunFile)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("could not parse last run %q: %w", path, err)
+	}
+	return all, nil
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordLastRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if _, ok, err := LastRunOf("/repo"); err != nil || ok {
+		t.Fatalf("Expected no recorded run, got %v, %v", ok, err)
+	}
+
+	at := time.Now().Truncate(time.Second)
+	first := LastRun{Time: at.Add(-time.Hour), Results: []RunResult{{Branch: "old", Hash: "h0", Success: true}}}
+	second := LastRun{Time: at, Results: []RunResult{
+		{Branch: "feature/a", Hash: "h1", Success: true},
+		{Branch: "feature/a", Hash: "h1", IsRemote: true, Remote: "origin", Message: "rejected"},
+	}}
+	if err := RecordLastRun("/repo", first); err != nil {
+		t.Fatalf("RecordLastRun failed: %v", err)
+	}
+	if err := RecordLastRun("/other", first); err != nil {
+		t.Fatalf("RecordLastRun failed: %v", err)
+	}
+	if err := RecordLastRun("/repo", second); err != nil {
+		t.Fatalf("RecordLastRun failed: %v", err)
+	}
+
+	run, ok, err := LastRunOf("/repo")
+	if err != nil || !ok {
+		t.Fatalf("LastRunOf failed: %v, %v", ok, err)
+	}
+	if !run.Time.Equal(at) || len(run.Results) != 2 || run.Results[1] != second.Results[1] {
+		t.Errorf("LastRunOf() = %+v, want %+v", run, second)
+	}
+	if run, _, _ := LastRunOf("/other"); len(run.Results) != 1 || run.Results[0].Branch != "old" {
+		t.Errorf("Expected runs to be kept per repository, got %+v", run)
+	}
+}
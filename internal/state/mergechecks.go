@@ -0,0 +1,86 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
+)
+
+const mergeCheckFileName = "merge-checks.json"
+
+// mergeChecks are the outcomes of the patch-based merge checks in one repository, all made
+// against the same tip of the branch merges are checked against.
+type mergeChecks struct {
+	MainTip string            `json:"main_tip"`
+	Results map[string]string `json:"results"` // How the branch is merged keyed by its tip; "" if it is not
+}
+
+// mergeCheckFile is the on-disk layout: merge check outcomes keyed by repository root.
+type mergeCheckFile map[string]mergeChecks
+
+// LoadMergeChecks returns the recorded outcomes of the merge checks of branch tips against
+// mainTip in the repository at repoRoot. Outcomes recorded against another tip are not
+// returned, since they may no longer hold. A missing file yields an empty map.
+func LoadMergeChecks(repoRoot, mainTip string) (map[string]string, error) {
+	all, err := readMergeCheckFile()
+	if err != nil {
+		return nil, err
+	}
+	checks := all[repoRoot]
+	if checks.MainTip != mainTip || checks.Results == nil {
+		return make(map[string]string), nil
+	}
+	return checks.Results, nil
+}
+
+// RecordMergeChecks adds results, keyed by branch tip, to the outcomes recorded against
+// mainTip in repoRoot. Outcomes recorded against another tip are dropped.
+func RecordMergeChecks(repoRoot, mainTip string, results map[string]string) error {
+	all, err := readMergeCheckFile()
+	if err != nil {
+		return err
+	}
+	checks := all[repoRoot]
+	if checks.MainTip != mainTip || checks.Results == nil {
+		checks = mergeChecks{MainTip: mainTip, Results: make(map[string]string)}
+	}
+	maps.Copy(checks.Results, results)
+	all[repoRoot] = checks
+
+	path, err := filePath(mergeCheckFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode merge checks: %w", err)
+	}
+	if err := fileutil.Replace(path, data); err != nil {
+		return fmt.Errorf("could not write merge checks %q: %w", path, err)
+	}
+	return nil
+}
+
+// readMergeCheckFile loads all recorded merge checks. A missing file yields an empty list.
+func readMergeCheckFile() (mergeCheckFile, error) {
+	path, err := filePath(mergeCheckFileName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(mergeCheckFile), nil
+		}
+		return nil, fmt.Errorf("could not read merge checks %q: %w", path, err)
+	}
+	all := make(mergeCheckFile)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("could not parse merge checks %q: %w", path, err)
+	}
+	return all, nil
+}
@@ -0,0 +1,40 @@
+package state
+
+import "testing"
+
+func TestRecordMergeChecks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	checks, err := LoadMergeChecks("/repo", "main1")
+	if err != nil || len(checks) != 0 {
+		t.Fatalf("Expected no recorded merge checks, got %v, %v", checks, err)
+	}
+
+	if err := RecordMergeChecks("/repo", "main1", map[string]string{"aaa": "cherry"}); err != nil {
+		t.Fatalf("RecordMergeChecks failed: %v", err)
+	}
+	if err := RecordMergeChecks("/repo", "main1", map[string]string{"bbb": ""}); err != nil {
+		t.Fatalf("RecordMergeChecks failed: %v", err)
+	}
+	checks, err = LoadMergeChecks("/repo", "main1")
+	if err != nil || len(checks) != 2 || checks["aaa"] != "cherry" {
+		t.Errorf("Expected both outcomes against main1, got %v, %v", checks, err)
+	}
+	if result, ok := checks["bbb"]; !ok || result != "" {
+		t.Errorf("Expected the unmerged outcome to be kept, got %q, %v", result, ok)
+	}
+	if checks, _ := LoadMergeChecks("/other", "main1"); len(checks) != 0 {
+		t.Errorf("Expected merge checks to be kept per repository, got %v", checks)
+	}
+
+	// Once the main branch moves, the old outcomes no longer apply and are replaced
+	if checks, _ := LoadMergeChecks("/repo", "main2"); len(checks) != 0 {
+		t.Errorf("Expected no outcomes against another tip, got %v", checks)
+	}
+	if err := RecordMergeChecks("/repo", "main2", map[string]string{"ccc": "no changes"}); err != nil {
+		t.Fatalf("RecordMergeChecks failed: %v", err)
+	}
+	if checks, _ := LoadMergeChecks("/repo", "main2"); len(checks) != 1 || checks["ccc"] != "no changes" {
+		t.Errorf("Expected only the outcome against main2, got %v", checks)
+	}
+}
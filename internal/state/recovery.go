@@ -0,0 +1,25 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteRecoveryScript saves shell commands that recreate deleted branches to a new file in the
+// state directory, named after the time of the deletion run, and returns its path.
+func WriteRecoveryScript(commands []string, now time.Time) (string, error) {
+	path, err := filePath("recovery-" + now.Format("20060102-150405") + ".sh")
+	if err != nil {
+		return "", err
+	}
+	content := "#!/bin/sh\n" +
+		"# Recreates the branches deleted by git-sweep on " + now.Format(time.RFC1123) + ".\n" +
+		"# Run it from the repository they were deleted in.\n" +
+		strings.Join(commands, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), filePerm); err != nil {
+		return "", fmt.Errorf("could not write recovery script %q: %w", path, err)
+	}
+	return path, nil
+}
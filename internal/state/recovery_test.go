@@ -0,0 +1,29 @@
+package state
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteRecoveryScript(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	now := time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC)
+	path, err := WriteRecoveryScript([]string{"git branch feature/a abc123"}, now)
+	if err != nil {
+		t.Fatalf("WriteRecoveryScript failed: %v", err)
+	}
+	if !strings.HasSuffix(path, "recovery-20250301-143000.sh") {
+		t.Errorf("Unexpected recovery script path %q", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read recovery script: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "#!/bin/sh\n") ||
+		!strings.Contains(string(content), "\ngit branch feature/a abc123\n") {
+		t.Errorf("Unexpected recovery script:\n%s", content)
+	}
+}
@@ -0,0 +1,161 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
+)
+
+const snoozeFileName = "snoozed.json"
+
+// Snooze records a branch that should not be suggested for deletion.
+// A zero Until means the branch is ignored indefinitely.
+type Snooze struct {
+	Branch string    `json:"branch"`
+	Until  time.Time `json:"until,omitzero"`
+}
+
+// Active reports whether the snooze is still in effect at now.
+func (s Snooze) Active(now time.Time) bool {
+	return s.Until.IsZero() || now.Before(s.Until)
+}
+
+// snoozeFile is the on-disk layout: snoozes keyed by repository root, then branch name.
+type snoozeFile map[string]map[string]Snooze
+
+// SnoozePath returns the location of the snooze list.
+func SnoozePath() (string, error) {
+	return filePath(snoozeFileName)
+}
+
+// LoadSnoozes returns the snoozes in effect for the repository at repoRoot, keyed by branch name.
+// Expired snoozes are omitted. A missing file yields an empty map.
+func LoadSnoozes(repoRoot string) (map[string]Snooze, error) {
+	all, err := readSnoozeFile()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := make(map[string]Snooze)
+	for name, s := range all[repoRoot] {
+		if s.Active(now) {
+			active[name] = s
+		}
+	}
+	return active, nil
+}
+
+// ListSnoozes returns the snoozes in effect for repoRoot, sorted by branch name.
+func ListSnoozes(repoRoot string) ([]Snooze, error) {
+	active, err := LoadSnoozes(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Snooze, 0, len(active))
+	for _, s := range active {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Branch < list[j].Branch })
+	return list, nil
+}
+
+// SnoozeBranch hides branch in repoRoot from deletion suggestions until the given time
+// (zero means indefinitely), replacing any existing snooze for it.
+func SnoozeBranch(repoRoot, branch string, until time.Time) error {
+	unlock, err := lockSnoozeFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	all, err := readSnoozeFile()
+	if err != nil {
+		return err
+	}
+	if all[repoRoot] == nil {
+		all[repoRoot] = make(map[string]Snooze)
+	}
+	all[repoRoot][branch] = Snooze{Branch: branch, Until: until}
+	return writeSnoozeFile(all)
+}
+
+// UnsnoozeBranch removes the snooze for branch in repoRoot.
+// It reports whether a snooze was in effect.
+func UnsnoozeBranch(repoRoot, branch string) (bool, error) {
+	unlock, err := lockSnoozeFile()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	all, err := readSnoozeFile()
+	if err != nil {
+		return false, err
+	}
+	s, ok := all[repoRoot][branch]
+	if !ok {
+		return false, nil
+	}
+	delete(all[repoRoot], branch)
+	return s.Active(time.Now()), writeSnoozeFile(all)
+}
+
+// lockSnoozeFile locks the snooze list for a load-modify-save, so snoozes added at the same
+// time, e.g. from the TUI and 'git-sweep ignore', are all kept.
+func lockSnoozeFile() (func(), error) {
+	path, err := SnoozePath()
+	if err != nil {
+		return nil, err
+	}
+	return fileutil.Lock(path)
+}
+
+// readSnoozeFile loads the full snooze list. A missing file yields an empty list.
+func readSnoozeFile() (snoozeFile, error) {
+	path, err := SnoozePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(snoozeFile), nil
+		}
+		return nil, fmt.Errorf("could not read snooze list %q: %w", path, err)
+	}
+	all := make(snoozeFile)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("could not parse snooze list %q: %w", path, err)
+	}
+	return all, nil
+}
+
+// writeSnoozeFile saves the snooze list, dropping expired entries and empty repositories.
+func writeSnoozeFile(all snoozeFile) error {
+	path, err := SnoozePath()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for repo, branches := range all {
+		for name, s := range branches {
+			if !s.Active(now) {
+				delete(branches, name)
+			}
+		}
+		if len(branches) == 0 {
+			delete(all, repo)
+		}
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode snooze list: %w", err)
+	}
+	if err := fileutil.Replace(path, data); err != nil {
+		return fmt.Errorf("could not write snooze list %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package state manages git-sweep's persistent runtime state (journals, caches)
+// stored outside the user's configuration file.
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	stateDirName = "git-sweep"
+	dirPerm      = 0o750
+	filePerm     = 0o600
+)
+
+// Dir returns the directory used for git-sweep state files.
+// It honors $XDG_STATE_HOME and falls back to ~/.local/state/git-sweep, or to
+// %LOCALAPPDATA%\git-sweep on Windows.
+func Dir() (string, error) {
+	return dirFor(runtime.GOOS)
+}
+
+// dirFor returns the state directory on the operating system goos.
+func dirFor(goos string) (string, error) {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+		return filepath.Join(xdg, stateDirName), nil
+	}
+	// Windows keeps per-machine application data, unlike the roaming %APPDATA% of the config
+	if localAppData := os.Getenv("LOCALAPPDATA"); goos == "windows" && localAppData != "" {
+		return filepath.Join(localAppData, stateDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", stateDirName), nil
+}
+
+// filePath returns the full path of a named file inside the state directory,
+// creating the directory if it does not exist yet.
+func filePath(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return "", fmt.Errorf("could not create state directory %q: %w", dir, err)
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDirFor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"linux", filepath.Join(home, ".local", "state", "git-sweep")},
+		{"darwin", filepath.Join(home, ".local", "state", "git-sweep")},
+		{"windows", filepath.Join(home, "AppData", "Local", "git-sweep")},
+	}
+	for _, tt := range tests {
+		if got, err := dirFor(tt.goos); err != nil || got != tt.want {
+			t.Errorf("dirFor(%q) = %q, %v, want %q", tt.goos, got, err, tt.want)
+		}
+	}
+
+	// XDG_STATE_HOME takes precedence everywhere
+	xdg := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", xdg)
+	if got, _ := dirFor("windows"); got != filepath.Join(xdg, "git-sweep") {
+		t.Errorf("Expected XDG_STATE_HOME to be used on Windows, got %q", got)
+	}
+}
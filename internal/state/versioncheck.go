@@ -0,0 +1,58 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/fileutil"
+)
+
+const versionCheckFileName = "version-check.json"
+
+// VersionCheck is the outcome of the last check for a newer git-sweep release.
+type VersionCheck struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	LatestVersion string    `json:"latest_version"` // Newest release of the update channel checked
+}
+
+// LastVersionCheck returns the outcome of the last version check. The zero value means no
+// check was recorded yet.
+func LastVersionCheck() (VersionCheck, error) {
+	path, err := filePath(versionCheckFileName)
+	if err != nil {
+		return VersionCheck{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return VersionCheck{}, nil
+		}
+		return VersionCheck{}, fmt.Errorf("could not read version check %q: %w", path, err)
+	}
+	var check VersionCheck
+	if err := json.Unmarshal(data, &check); err != nil {
+		return VersionCheck{}, fmt.Errorf("could not parse version check %q: %w", path, err)
+	}
+	return check, nil
+}
+
+// RecordVersionCheck remembers the outcome of a version check. The file is replaced atomically,
+// so concurrent runs never read a partly written check.
+func RecordVersionCheck(check VersionCheck) error {
+	path, err := filePath(versionCheckFileName)
+	if err != nil {
+		return err
+	}
+	check.CheckedAt = check.CheckedAt.UTC()
+	data, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode version check: %w", err)
+	}
+	if err := fileutil.Replace(path, data); err != nil {
+		return fmt.Errorf("could not write version check %q: %w", path, err)
+	}
+	return nil
+}
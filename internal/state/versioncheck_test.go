@@ -0,0 +1,30 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecordVersionCheck(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	check, err := LastVersionCheck()
+	if err != nil || !check.CheckedAt.IsZero() || check.LatestVersion != "" {
+		t.Fatalf("Expected no recorded version check, got %+v, %v", check, err)
+	}
+
+	at := time.Now().Truncate(time.Second)
+	if err := RecordVersionCheck(VersionCheck{CheckedAt: at, LatestVersion: "v1.3.0"}); err != nil {
+		t.Fatalf("RecordVersionCheck failed: %v", err)
+	}
+	check, err = LastVersionCheck()
+	if err != nil || !check.CheckedAt.Equal(at) || check.LatestVersion != "v1.3.0" {
+		t.Errorf("LastVersionCheck() = %+v, %v; want v1.3.0 checked at %v", check, err, at)
+	}
+
+	dir, _ := Dir()
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the version check file in the state directory, got %d entries", len(entries))
+	}
+}
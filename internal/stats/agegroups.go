@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// AgeGroup holds the branches that fall into one AgeBucket.
+type AgeGroup struct {
+	Label    string
+	Branches []types.AnalyzedBranch // In their original order
+}
+
+// GroupByAge splits branches into AgeBuckets by the age of their last commit, from youngest
+// to oldest. Buckets without branches are left out.
+func GroupByAge(branches []types.AnalyzedBranch, now time.Time) []AgeGroup {
+	groups := make([]AgeGroup, len(AgeBuckets))
+	for i, bucket := range AgeBuckets {
+		groups[i].Label = bucket.Label
+	}
+	for _, branch := range branches {
+		i := BucketFor(int(now.Sub(branch.LastCommitDate).Hours() / 24))
+		groups[i].Branches = append(groups[i].Branches, branch)
+	}
+
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.Branches) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
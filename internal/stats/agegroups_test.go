@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestGroupByAge(t *testing.T) {
+	now := time.Now()
+	branch := func(name string, days int) types.AnalyzedBranch {
+		return types.AnalyzedBranch{BranchInfo: types.BranchInfo{
+			Name: name, LastCommitDate: now.Add(-time.Duration(days) * 24 * time.Hour),
+		}}
+	}
+	branches := []types.AnalyzedBranch{
+		branch("ancient", 800), branch("fresh", 2), branch("old", 400), branch("stale", 120),
+	}
+
+	groups := GroupByAge(branches, now)
+
+	want := []struct {
+		label string
+		names []string
+	}{
+		{"<30d", []string{"fresh"}},
+		{"90-365d", []string{"stale"}},
+		{">1y", []string{"ancient", "old"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("Expected %d non-empty groups, got %+v", len(want), groups)
+	}
+	for i, w := range want {
+		var names []string
+		for _, b := range groups[i].Branches {
+			names = append(names, b.Name)
+		}
+		if groups[i].Label != w.label || !slices.Equal(names, w.names) {
+			t.Errorf("Group %d = %s %v, want %s %v", i, groups[i].Label, names, w.label, w.names)
+		}
+	}
+	if groups := GroupByAge(nil, now); len(groups) != 0 {
+		t.Errorf("Expected no groups without branches, got %+v", groups)
+	}
+}
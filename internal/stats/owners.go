@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Owner lists the candidate branches whose last commit is by one author.
+type Owner struct {
+	Author   string        `json:"author"`         // Email, or "(unknown)"
+	Name     string        `json:"name,omitempty"` // Name of the author, if known
+	Branches []OwnedBranch `json:"branches"`       // Oldest first
+}
+
+// OwnedBranch is one candidate branch in the list of its owner.
+type OwnedBranch struct {
+	Name     string               `json:"name"`
+	Category types.BranchCategory `json:"category"`
+	AgeDays  int                  `json:"age_days"`
+	Remote   string               `json:"remote,omitempty"` // Remote the branch is pushed to, if any
+}
+
+// Owners groups the candidates among branches by the author of their last commit, so each
+// author can be sent their own list. Authors with the most candidates come first.
+func Owners(branches []types.AnalyzedBranch, now time.Time) []Owner {
+	byAuthor := make(map[string]*Owner)
+	for _, branch := range branches {
+		if branch.Category != types.CategoryMergedOld && branch.Category != types.CategoryUnmergedOld {
+			continue
+		}
+		author := cmp.Or(branch.AuthorEmail, "(unknown)")
+		if byAuthor[author] == nil {
+			byAuthor[author] = &Owner{Author: author}
+		}
+		owner := byAuthor[author]
+		owner.Name = cmp.Or(owner.Name, branch.AuthorName)
+		owner.Branches = append(owner.Branches, OwnedBranch{
+			Name:     branch.Name,
+			Category: branch.Category,
+			AgeDays:  int(now.Sub(branch.LastCommitDate).Hours() / 24),
+			Remote:   branch.Remote,
+		})
+	}
+
+	owners := make([]Owner, 0, len(byAuthor))
+	for _, owner := range byAuthor {
+		slices.SortStableFunc(owner.Branches, func(a, b OwnedBranch) int {
+			return cmp.Or(cmp.Compare(b.AgeDays, a.AgeDays), cmp.Compare(a.Name, b.Name))
+		})
+		owners = append(owners, *owner)
+	}
+	slices.SortFunc(owners, func(a, b Owner) int {
+		return cmp.Or(cmp.Compare(len(b.Branches), len(a.Branches)), cmp.Compare(a.Author, b.Author))
+	})
+	return owners
+}
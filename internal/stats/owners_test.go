@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestOwners(t *testing.T) {
+	now := time.Now()
+	branch := func(name string, category types.BranchCategory, days int, email, author string) types.AnalyzedBranch {
+		return types.AnalyzedBranch{
+			BranchInfo: types.BranchInfo{
+				Name: name, LastCommitDate: now.Add(-time.Duration(days) * 24 * time.Hour),
+				AuthorEmail: email, AuthorName: author,
+			},
+			Category: category,
+		}
+	}
+	branches := []types.AnalyzedBranch{
+		branch("main", types.CategoryProtected, 1000, "lead@example.com", "Lead"),
+		branch("merged-a", types.CategoryMergedOld, 10, "ann@example.com", "Ann"),
+		branch("merged-b", types.CategoryMergedOld, 400, "bob@example.com", "Bob"),
+		branch("stale", types.CategoryUnmergedOld, 120, "ann@example.com", "Ann"),
+		branch("wip", types.CategoryActive, 3, "bob@example.com", "Bob"),
+		branch("orphan", types.CategoryUnmergedOld, 200, "", ""),
+	}
+
+	owners := Owners(branches, now)
+
+	if len(owners) != 3 {
+		t.Fatalf("Expected candidates of 3 authors, got %+v", owners)
+	}
+	ann := owners[0]
+	if ann.Author != "ann@example.com" || ann.Name != "Ann" || len(ann.Branches) != 2 {
+		t.Errorf("Expected ann@example.com with the most candidates first, got %+v", ann)
+	}
+	if ann.Branches[0].Name != "stale" || ann.Branches[0].AgeDays != 120 {
+		t.Errorf("Expected the oldest branch of an owner first, got %+v", ann.Branches)
+	}
+	if owners[1].Author != "(unknown)" || owners[2].Author != "bob@example.com" || len(owners[2].Branches) != 1 {
+		t.Errorf("Expected authors with equal counts by email, without active branches, got %+v", owners[1:])
+	}
+}
@@ -0,0 +1,148 @@
+// Package stats computes branch hygiene metrics from analyzed branches.
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// oldestLimit is the number of branches listed in Report.Oldest.
+const oldestLimit = 5
+
+// AgeBucket is a range of branch ages in days; MaxDays < 0 means unbounded.
+type AgeBucket struct {
+	Label   string
+	MaxDays int
+}
+
+// AgeBuckets are the age ranges used for distributions, from youngest to oldest.
+var AgeBuckets = []AgeBucket{
+	{Label: "<30d", MaxDays: 29},
+	{Label: "30-90d", MaxDays: 90},
+	{Label: "90-365d", MaxDays: 365},
+	{Label: ">1y", MaxDays: -1},
+}
+
+// BucketFor returns the index into AgeBuckets for a branch that is days old.
+func BucketFor(days int) int {
+	for i, bucket := range AgeBuckets {
+		if bucket.MaxDays < 0 || days <= bucket.MaxDays {
+			return i
+		}
+	}
+	return len(AgeBuckets) - 1
+}
+
+// categoryOrder is the order categories are reported in.
+var categoryOrder = []types.BranchCategory{
+	types.CategoryMergedOld, types.CategoryUnmergedOld, types.CategoryActive, types.CategoryProtected,
+}
+
+// Report summarizes the branches of a repository.
+type Report struct {
+	Total      int             `json:"total"`
+	Candidates int             `json:"candidates"` // Branches suggested for deletion
+	Categories []CategoryStats `json:"categories"`
+	Oldest     []BranchAge     `json:"oldest"`  // Oldest non-protected branches, oldest first
+	Authors    []AuthorStats   `json:"authors"` // Sorted by branch count, descending
+}
+
+// CategoryStats describes the branches of one category.
+type CategoryStats struct {
+	Category      types.BranchCategory `json:"category"`
+	Count         int                  `json:"count"`
+	MedianAgeDays int                  `json:"median_age_days"`
+	MaxAgeDays    int                  `json:"max_age_days"`
+	AgeBuckets    []BucketCount        `json:"age_buckets"`
+}
+
+// BucketCount is the number of branches in one AgeBucket.
+type BucketCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// BranchAge identifies a branch and how old its last commit is.
+type BranchAge struct {
+	Name     string               `json:"name"`
+	Category types.BranchCategory `json:"category"`
+	AgeDays  int                  `json:"age_days"`
+	Author   string               `json:"author,omitempty"`
+}
+
+// AuthorStats counts the branches whose last commit is by one author.
+type AuthorStats struct {
+	Author     string `json:"author"` // Email, or "(unknown)"
+	Branches   int    `json:"branches"`
+	Candidates int    `json:"candidates"`
+}
+
+// Compute builds the report for the analyzed branches as of now.
+func Compute(branches []types.AnalyzedBranch, now time.Time) Report {
+	report := Report{Total: len(branches)}
+	ages := make(map[types.BranchCategory][]int)
+	authors := make(map[string]*AuthorStats)
+	oldest := make([]BranchAge, 0, len(branches))
+
+	for _, branch := range branches {
+		age := int(now.Sub(branch.LastCommitDate).Hours() / 24)
+		ages[branch.Category] = append(ages[branch.Category], age)
+		candidate := branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld
+		if candidate {
+			report.Candidates++
+		}
+		if branch.Category != types.CategoryProtected {
+			oldest = append(oldest, BranchAge{
+				Name: branch.Name, Category: branch.Category, AgeDays: age, Author: branch.AuthorEmail,
+			})
+		}
+
+		author := cmp.Or(branch.AuthorEmail, "(unknown)")
+		if authors[author] == nil {
+			authors[author] = &AuthorStats{Author: author}
+		}
+		authors[author].Branches++
+		if candidate {
+			authors[author].Candidates++
+		}
+	}
+
+	for _, category := range categoryOrder {
+		report.Categories = append(report.Categories, categoryStats(category, ages[category]))
+	}
+
+	slices.SortStableFunc(oldest, func(a, b BranchAge) int {
+		return cmp.Or(cmp.Compare(b.AgeDays, a.AgeDays), cmp.Compare(a.Name, b.Name))
+	})
+	report.Oldest = oldest[:min(len(oldest), oldestLimit)]
+
+	report.Authors = make([]AuthorStats, 0, len(authors))
+	for _, stats := range authors {
+		report.Authors = append(report.Authors, *stats)
+	}
+	slices.SortFunc(report.Authors, func(a, b AuthorStats) int {
+		return cmp.Or(cmp.Compare(b.Branches, a.Branches), cmp.Compare(a.Author, b.Author))
+	})
+	return report
+}
+
+// categoryStats summarizes the ages (in days) of the branches in one category.
+func categoryStats(category types.BranchCategory, ages []int) CategoryStats {
+	stats := CategoryStats{Category: category, Count: len(ages), AgeBuckets: make([]BucketCount, len(AgeBuckets))}
+	for i, bucket := range AgeBuckets {
+		stats.AgeBuckets[i].Label = bucket.Label
+	}
+	if len(ages) == 0 {
+		return stats
+	}
+	sorted := slices.Sorted(slices.Values(ages))
+	stats.MedianAgeDays = sorted[len(sorted)/2]
+	stats.MaxAgeDays = sorted[len(sorted)-1]
+	for _, age := range ages {
+		stats.AgeBuckets[BucketFor(age)].Count++
+	}
+	return stats
+}
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestBucketFor(t *testing.T) {
+	for days, want := range map[int]string{0: "<30d", 29: "<30d", 30: "30-90d", 90: "30-90d", 91: "90-365d", 366: ">1y"} {
+		if got := AgeBuckets[BucketFor(days)].Label; got != want {
+			t.Errorf("BucketFor(%d) = %s, want %s", days, got, want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	now := time.Now()
+	branch := func(name string, category types.BranchCategory, days int, author string) types.AnalyzedBranch {
+		return types.AnalyzedBranch{
+			BranchInfo: types.BranchInfo{
+				Name: name, LastCommitDate: now.Add(-time.Duration(days) * 24 * time.Hour), AuthorEmail: author,
+			},
+			Category: category,
+		}
+	}
+	branches := []types.AnalyzedBranch{
+		branch("main", types.CategoryProtected, 1000, "lead@example.com"),
+		branch("merged-a", types.CategoryMergedOld, 10, "ann@example.com"),
+		branch("merged-b", types.CategoryMergedOld, 400, "bob@example.com"),
+		branch("stale", types.CategoryUnmergedOld, 120, "ann@example.com"),
+		branch("wip", types.CategoryActive, 3, ""),
+	}
+
+	report := Compute(branches, now)
+
+	if report.Total != 5 || report.Candidates != 3 {
+		t.Errorf("Expected 5 branches and 3 candidates, got %d and %d", report.Total, report.Candidates)
+	}
+	merged := report.Categories[0]
+	if merged.Category != types.CategoryMergedOld || merged.Count != 2 || merged.MaxAgeDays != 400 {
+		t.Errorf("Unexpected merged stats: %+v", merged)
+	}
+	if merged.AgeBuckets[0].Count != 1 || merged.AgeBuckets[3].Count != 1 {
+		t.Errorf("Unexpected merged age buckets: %+v", merged.AgeBuckets)
+	}
+	if len(report.Oldest) != 4 || report.Oldest[0].Name != "merged-b" || report.Oldest[3].Name != "wip" {
+		t.Errorf("Expected oldest non-protected branches first, got %+v", report.Oldest)
+	}
+	if first := report.Authors[0]; first.Author != "ann@example.com" || first.Branches != 2 || first.Candidates != 2 {
+		t.Errorf("Expected ann@example.com to lead the author counts, got %+v", report.Authors)
+	}
+	found := false
+	for _, author := range report.Authors {
+		found = found || (author.Author == "(unknown)" && author.Branches == 1)
+	}
+	if !found {
+		t.Errorf("Expected branches without an author to be counted as (unknown), got %+v", report.Authors)
+	}
+}
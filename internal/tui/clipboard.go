@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+	"unicode/utf16"
+)
+
+// clipTimeout bounds a copy through clip.exe.
+const clipTimeout = 5 * time.Second
+
+// useClipExe reports whether copies must go through clip.exe: in the classic Windows console,
+// which ignores OSC 52. Windows Terminal and SSH clients apply the sequence themselves.
+func useClipExe() bool {
+	return runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == "" && os.Getenv("SSH_CONNECTION") == ""
+}
+
+// clipExe copies text to the Windows clipboard with clip.exe.
+func clipExe(text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), clipTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "clip.exe")
+	cmd.Stdin = bytes.NewReader(utf16LE(text))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("clip.exe failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// utf16LE encodes text as UTF-16LE with a byte order mark, the only encoding clip.exe reads
+// independently of the console code page.
+func utf16LE(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	buf := make([]byte, 2, 2+2*len(units))
+	binary.LittleEndian.PutUint16(buf, 0xfeff)
+	for _, unit := range units {
+		buf = binary.LittleEndian.AppendUint16(buf, unit)
+	}
+	return buf
+}
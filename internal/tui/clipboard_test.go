@@ -0,0 +1,13 @@
+package tui
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUTF16LE(t *testing.T) {
+	want := []byte{0xff, 0xfe, 'f', 0, '/', 0, 0xe9, 0, 0x3d, 0xd8, 0x80, 0xde}
+	if got := utf16LE("f/é\U0001F680"); !bytes.Equal(got, want) {
+		t.Errorf("utf16LE() = % x, want % x", got, want)
+	}
+}
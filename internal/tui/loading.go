@@ -0,0 +1,79 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// LoadEvent reports the progress of an analysis that runs while the TUI is already shown.
+// The analysis closes the channel once it has sent all branches.
+type LoadEvent struct {
+	Status   string                 // What the analysis is doing, shown next to the spinner
+	Branches []types.AnalyzedBranch // Branches analyzed since the previous event
+	Warning  string                 // A problem the analysis continues after, shown as the status message
+	Err      error                  // The analysis failed; the TUI exits and leaves reporting it to the caller
+}
+
+// loadMsg carries the next LoadEvent, or the end of the analysis when done is set.
+type loadMsg struct {
+	event LoadEvent
+	done  bool
+}
+
+// LoadFrom makes the model show a loading indicator and add the branches streamed over events
+// as they are analyzed, instead of waiting for the whole analysis before the UI appears.
+func (m *Model) LoadFrom(events <-chan LoadEvent) {
+	m.Load = events
+	m.Loading = true
+}
+
+// waitForLoadCmd waits for the next event of the background analysis. It returns nil when
+// there is no channel.
+func waitForLoadCmd(ch <-chan LoadEvent) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		event, ok := <-ch
+		return loadMsg{event: event, done: !ok}
+	}
+}
+
+// applyLoad adds streamed branches to the list and tracks the state of the analysis. An
+// analysis error ends the program; LoadErr tells the caller what went wrong.
+func (m Model) applyLoad(msg loadMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.done:
+		m.Loading = false
+		m.LoadStatus = ""
+		m.layoutViewports() // The loading line no longer takes up room
+		return m, nil
+	case msg.event.Err != nil:
+		m.Loading = false
+		m.LoadErr = msg.event.Err
+		return m, tea.Quit
+	}
+
+	if msg.event.Warning != "" {
+		m.StatusMessage = "Warning: " + msg.event.Warning
+	}
+	if msg.event.Status != "" {
+		m.LoadStatus = msg.event.Status
+	}
+	if len(msg.event.Branches) > 0 {
+		m.addBranches(msg.event.Branches)
+	}
+	return m, tea.Batch(waitForLoadCmd(m.Load), m.previewCmd())
+}
+
+// addBranches appends newly analyzed branches, preselecting them like the branches already
+// shown, and keeps the cursor on the branch it was on.
+func (m *Model) addBranches(branches []types.AnalyzedBranch) {
+	from := len(m.AllAnalyzedBranches)
+	m.AllAnalyzedBranches = append(m.AllAnalyzedBranches, branches...)
+	if m.preselection != nil {
+		m.preselect(from)
+	}
+	m.rebuildListKeepingCursor()
+}
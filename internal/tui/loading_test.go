@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestLoadFrom(t *testing.T) {
+	events := make(chan LoadEvent, 1)
+	model := createTestModel(nil)
+	model.LoadFrom(events)
+	model.Preselect(func(b types.AnalyzedBranch) bool { return b.IsMerged })
+
+	var m tea.Model = model
+	m, _ = m.Update(loadMsg{event: LoadEvent{Status: "Fetching origin..."}})
+	if view := m.View(); !strings.Contains(view, "Fetching origin...") ||
+		strings.Contains(view, "No branches found") {
+		t.Errorf("Expected the loading status, got:\n%s", view)
+	}
+
+	branches := createSampleBranches()[1:] // Protected branches are never streamed
+	m, cmd := m.Update(loadMsg{event: LoadEvent{Branches: branches[:2]}})
+	if cmd == nil {
+		t.Error("Expected to keep waiting for the analysis")
+	}
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // feat/unmerged-old
+	m, _ = m.Update(loadMsg{event: LoadEvent{Branches: branches[2:]}})
+
+	model, _ = m.(Model)
+	if len(model.AllAnalyzedBranches) != len(branches) {
+		t.Fatalf("Expected %d branches, got %d", len(branches), len(model.AllAnalyzedBranches))
+	}
+	if branch, _ := model.cursorBranch(); branch.Name != "feat/unmerged-old" {
+		t.Errorf("Expected the cursor to stay on feat/unmerged-old, got %s", branch.Name)
+	}
+	// feat/merged arrived first and feat/merged-no-remote later; both are preselected
+	if len(model.SelectedLocal) != 2 {
+		t.Errorf("Expected both merged candidates to be preselected, got %v", model.SelectedLocal)
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	if model, _ = m.(Model); model.ViewState != StateSelecting {
+		t.Error("Expected confirming to wait for the analysis")
+	}
+
+	m, _ = m.Update(loadMsg{done: true})
+	if model, _ = m.(Model); model.Loading {
+		t.Error("Expected loading to finish")
+	}
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	if model, _ = m.(Model); model.ViewState != StateConfirming {
+		t.Errorf("Expected to confirm after loading, got state %v", model.ViewState)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	model := createTestModel(nil)
+	model.LoadFrom(make(chan LoadEvent))
+
+	loadErr := errors.New("not inside a Git repository")
+	m, cmd := model.Update(loadMsg{event: LoadEvent{Err: loadErr}})
+	if checkCmdType(cmd) != cmdTypeQuit {
+		t.Error("Expected the TUI to quit when the analysis fails")
+	}
+	if model, _ := m.(Model); !errors.Is(model.LoadErr, loadErr) {
+		t.Errorf("Expected LoadErr to be set, got %v", model.LoadErr)
+	}
+}
@@ -2,55 +2,56 @@
 package tui
 
 import (
+	"cmp"
 	"context" // Added for deletion context
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
 	"strings" // Added for View
 	"time"    // Added for age calculation
 
+	"github.com/aymanbagabas/go-osc52/v2"
 	"github.com/charmbracelet/bubbles/spinner" // Added spinner
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss" // Added lipgloss
+	"github.com/charmbracelet/x/ansi"
 
 	"github.com/bral/git-sweep-go/internal/gitcmd" // Added for BranchToDelete
+	"github.com/bral/git-sweep-go/internal/state"
 	"github.com/bral/git-sweep-go/internal/types"
 )
 
 // --- Styles ---
+// Styles without color are fixed; the others are set by SetTheme.
 var (
-	docStyle           = lipgloss.NewStyle().Margin(1, 2)
-	selectedStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
-	cursorStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
-	helpStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	headingStyle       = lipgloss.NewStyle().Bold(true).Underline(true).MarginBottom(1)
-	confirmPromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
-	warningStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("202")) // Orange/Red for warnings
-	successStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("78"))  // Green for success
-	errorStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("196")) // Red for errors
-	spinnerStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("205")) // Spinner color
-	forceDeleteStyle   = errorStyle.Bold(true).Reverse(true)                   // Style for force delete warnings
-	protectedStyle     = lipgloss.NewStyle().Faint(true)                       // Style for protected branches ONLY
-	// Style for active branches (faint, unselectable)
-	activeStyle    = helpStyle.Faint(true)
-	separatorStyle = helpStyle.Faint(true) // Style for separator line
-	// New styles for remote selection states
-	remoteDimmedStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("240")).
-				Faint(true) // Dimmed style for unavailable remotes
-	remoteNoneStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			Faint(true).
-			Italic(true) // Style for non-existent remotes
+	docStyle     = lipgloss.NewStyle().Margin(1, 2)
+	headingStyle = lipgloss.NewStyle().Bold(true).Underline(true).MarginBottom(1)
+
+	selectedStyle      lipgloss.Style
+	cursorStyle        lipgloss.Style
+	helpStyle          lipgloss.Style
+	confirmPromptStyle lipgloss.Style
+	warningStyle       lipgloss.Style // Orange/Red for warnings
+	successStyle       lipgloss.Style // Green for success
+	errorStyle         lipgloss.Style // Red for errors
+	spinnerStyle       lipgloss.Style // Spinner color
+	forceDeleteStyle   lipgloss.Style // Style for force delete warnings
+	protectedStyle     lipgloss.Style // Style for protected branches ONLY
+	activeStyle        lipgloss.Style // Style for active branches (faint, unselectable)
+	separatorStyle     lipgloss.Style // Style for separator line
+	remoteDimmedStyle  lipgloss.Style // Dimmed style for unavailable remotes
+	remoteNoneStyle    lipgloss.Style // Style for non-existent remotes
 
 	// Progress indicator styles
-	progressStyle       = helpStyle
-	progressMarkerStyle = selectedStyle
-	progressInfoStyle   = helpStyle
-	categoryStyleMap    = map[types.BranchCategory]lipgloss.Style{
-		// Protected category is handled separately (keyBranches)
-		types.CategoryActive:      activeStyle,  // Style for the label text only
-		types.CategoryMergedOld:   successStyle, // Removed .Copy()
-		types.CategoryUnmergedOld: warningStyle, // Removed .Copy()
-	}
+	progressStyle       lipgloss.Style
+	progressMarkerStyle lipgloss.Style
+	progressInfoStyle   lipgloss.Style
+	// Commit log preview pane
+	logPaneStyle     lipgloss.Style
+	categoryStyleMap map[types.BranchCategory]lipgloss.Style
+	riskStyleMap     map[types.Risk]lipgloss.Style
 )
 
 // ViewState represents the different views the TUI can be in.
@@ -68,16 +69,87 @@ const (
 
 	// Constants for UI elements (kept internal)
 	checkboxUnselectable = "[-]"
+	forceConfirmWord     = "force" // Must be typed to confirm force deletions
 	checkboxUnchecked    = "[ ]"
 	remoteNone           = "(none)"
+
+	// Number of commits shown in the log preview pane
+	logPreviewCount = 10
+
+	// Terminal size assumed until the first WindowSizeMsg arrives
+	defaultHeight = 24
+	defaultWidth  = 80
+
+	// Narrowest detail column worth showing next to a suggested branch
+	minDetailWidth = 12
 )
 
 // --- Messages ---
 
-// resultsMsg carries the deletion results back to the TUI after execution.
+// deleteResultMsg carries the outcome of a single branch deletion back to the TUI.
 // Kept internal as it's only used within the TUI update loop.
-type resultsMsg struct {
-	results []types.DeleteResult
+type deleteResultMsg struct {
+	result types.DeleteResult
+}
+
+// deletionDoneMsg signals that the background deletion run has finished.
+type deletionDoneMsg struct{}
+
+// logMsg carries the recent commits of a branch back to the TUI for the preview pane.
+type logMsg struct {
+	branch string
+	lines  []string
+	err    error
+}
+
+// logRecordMsg carries one formatted log record emitted while the TUI owns the terminal.
+type logRecordMsg string
+
+// snoozeMsg reports whether snoozing a branch was persisted.
+type snoozeMsg struct {
+	branch string
+	err    error
+}
+
+// switchMsg reports the outcome of switching to a branch.
+type switchMsg struct {
+	branch string
+	err    error
+}
+
+// renameMsg reports the outcome of renaming a branch, and of renaming it on remote if asked.
+type renameMsg struct {
+	oldName   string
+	newName   string
+	remote    string // Remote the branch was renamed on as well, if any
+	err       error  // Local rename failed; nothing was changed
+	remoteErr error  // Remote rename failed after the local rename succeeded
+}
+
+// copyMsg reports whether text was sent to the clipboard.
+type copyMsg struct {
+	text string
+	err  error
+}
+
+// recoveryScriptMsg reports where the recovery commands were written.
+type recoveryScriptMsg struct {
+	path string
+	err  error
+}
+
+// deletionRun tracks a background deletion run, so it can be cancelled and its results
+// collected even if the UI exits before it has received all of them.
+type deletionRun struct {
+	cancel  context.CancelFunc
+	done    chan struct{}        // Closed when the run has finished
+	results []types.DeleteResult // All results in completion order; valid once done is closed
+}
+
+// logPreview is a cached result of a commit log lookup.
+type logPreview struct {
+	lines []string
+	err   error
 }
 
 // --- Section Types ---
@@ -105,25 +177,79 @@ type ViewportState struct {
 
 // Model represents the state of the TUI application.
 type Model struct { // Renamed from model
-	Ctx                 context.Context        `json:"-"` // Context for git commands (ignore in JSON if ever needed)
-	DryRun              bool                   `json:"dryRun"`
-	AllAnalyzedBranches []types.AnalyzedBranch `json:"-"` // Full list (ignore in JSON)
-	KeyBranches         []types.AnalyzedBranch `json:"-"` // Protected (ignore in JSON)
-	SuggestedBranches   []types.AnalyzedBranch `json:"-"` // Candidates (ignore in JSON)
-	OtherActiveBranches []types.AnalyzedBranch `json:"-"` // Active (ignore in JSON)
-	ListOrder           []int                  `json:"-"` // Maps display index to original index (ignore in JSON)
-	Cursor              int                    `json:"cursor"`
-	SelectedLocal       map[int]bool           `json:"selectedLocal"`  // Map using original index
-	SelectedRemote      map[int]bool           `json:"selectedRemote"` // Map using original index
-	ViewState           ViewState              `json:"viewState"`      // Renamed from viewState
-	Results             []types.DeleteResult   `json:"results"`
-	Spinner             spinner.Model          `json:"-"` // Spinner model (ignore in JSON)
-	Width               int                    `json:"width"`
-	Height              int                    `json:"height"`
+	Ctx                 context.Context         `json:"-"` // Context for git commands (ignore in JSON if ever needed)
+	DryRun              bool                    `json:"dryRun"`
+	AllAnalyzedBranches []types.AnalyzedBranch  `json:"-"` // Full list (ignore in JSON)
+	KeyBranches         []types.AnalyzedBranch  `json:"-"` // Protected (ignore in JSON)
+	SuggestedBranches   []types.AnalyzedBranch  `json:"-"` // Candidates (ignore in JSON)
+	OtherActiveBranches []types.AnalyzedBranch  `json:"-"` // Active (ignore in JSON)
+	ListOrder           []int                   `json:"-"` // Maps display index to original index (ignore in JSON)
+	Cursor              int                     `json:"cursor"`
+	SelectedLocal       map[int]bool            `json:"selectedLocal"`  // Map using original index
+	SelectedRemote      map[int]bool            `json:"selectedRemote"` // Map using original index
+	ViewState           ViewState               `json:"viewState"`      // Renamed from viewState
+	Results             []types.DeleteResult    `json:"results"`
+	PendingDeletions    []gitcmd.BranchToDelete `json:"-"`             // Deletions not yet completed (ignore in JSON)
+	DeleteOptions       gitcmd.DeleteOptions    `json:"-"`             // Concurrency settings for remote deletions
+	Archive             bool                    `json:"archive"`       // Archive local branches before deleting them
+	ArchiveMode         string                  `json:"-"`             // "ref" (default) or "tag"
+	BundleDir           string                  `json:"-"`             // Bundle local branches here first ("" = off)
+	TagPrefix           string                  `json:"-"`             // Tag local branches as <prefix><name> first
+	SnoozeFor           time.Duration           `json:"-"`             // Duration of a snooze (0 means indefinitely)
+	MaxDelete           int                     `json:"-"`             // Branch limit per run (0 = unlimited)
+	UpdateNotice        string                  `json:"-"`             // Shown below the footer when an update exists
+	Offline             bool                    `json:"offline"`       // No network: remote branches cannot be selected
+	OfflineNotice       string                  `json:"-"`             // Shown below the title in offline mode
+	HeadNotice          string                  `json:"-"`             // Shown below the title when HEAD is detached
+	StatusMessage       string                  `json:"statusMessage"` // Feedback for the last background action
+	Interrupted         bool                    `json:"interrupted"`   // Deletions were cancelled before finishing
+	RecoveryStatus      string                  `json:"-"`             // Outcome of writing the recovery commands
+	Clipboard           io.Writer               `json:"-"`             // Terminal receiving OSC 52 copies (nil = stderr)
+	SwitchedTo          string                  `json:"switchedTo"`    // Branch checked out with c before exiting
+	Spinner             spinner.Model           `json:"-"`             // Spinner model (ignore in JSON)
+	Width               int                     `json:"width"`
+	Height              int                     `json:"height"`
 
 	// Viewport management
 	Viewports      map[Section]ViewportState `json:"-"` // Viewport state for each section
 	CurrentSection Section                   `json:"-"` // Currently active section
+
+	// Filtering and grouping
+	Filtering     bool   `json:"filtering"`     // True while the filter input has focus
+	FilterQuery   string `json:"filterQuery"`   // Fuzzy query restricting the displayed branches
+	GroupByAuthor bool   `json:"groupByAuthor"` // Candidates are listed by the author of their last commit
+
+	// Background analysis, when the UI is shown before all branches are known
+	Load         <-chan LoadEvent                `json:"-"`          // Streams the analyzed branches
+	Loading      bool                            `json:"loading"`    // True until the analysis has finished
+	LoadStatus   string                          `json:"loadStatus"` // Current step of the analysis
+	LoadErr      error                           `json:"-"`          // Why the analysis failed, if it did
+	preselection func(types.AnalyzedBranch) bool // Set by Preselect, applied to loaded branches too
+
+	// Renaming the branch under the cursor
+	Renaming     bool   `json:"renaming"`     // True while the new name input has focus
+	RenameInput  string `json:"renameInput"`  // New name being typed
+	RenameRemote bool   `json:"renameRemote"` // Also rename the branch on its remote
+
+	// Typed confirmation, required when branches would be force deleted
+	ConfirmInput string `json:"confirmInput"`
+
+	// Confirming each selected branch separately instead of the whole selection at once
+	ConfirmEach  bool         `json:"confirmEach"`
+	stepQueue    []int        // Selected branches (original indices) in list order; nil when not stepping
+	stepIndex    int          // Position in stepQueue of the branch being confirmed
+	stepDeclined map[int]bool // Branches answered with n or q, deselected once stepping ends
+
+	// Commit log preview
+	ShowLog     bool                  `json:"showLog"` // True when the log preview pane is visible
+	logPreviews map[string]logPreview // Cached log lookups keyed by branch name
+
+	// Logging: records are collected instead of being written over the UI
+	LogRecords <-chan string `json:"-"` // Formatted log records emitted while the TUI runs
+	Logs       []string      `json:"-"` // Records received from LogRecords, printed after the TUI exits
+
+	deletionResults chan types.DeleteResult // Streams results from the background deletion run
+	deletion        *deletionRun            // The background deletion run, once started
 }
 
 // Helper function to render the compact progress indicator
@@ -172,6 +298,19 @@ func renderCompactIndicator(start, viewportSize, total int, width int) string {
 		progressInfoStyle.Render(helpText)
 }
 
+// authorLabel names the author of the last commit of a branch for grouping by author.
+func authorLabel(branch types.AnalyzedBranch) string {
+	return cmp.Or(branch.AuthorName, branch.AuthorEmail, "(unknown)")
+}
+
+// pullRequestLabel returns a " | PR #N (state)" suffix for branches with a known pull request.
+func pullRequestLabel(branch types.AnalyzedBranch) string {
+	if branch.PullRequest == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | PR #%d (%s)", branch.PullRequest.Number, branch.PullRequest.State)
+}
+
 // Helper function to get the section for a branch
 func (m Model) getBranchSection(originalIndex int) Section {
 	if originalIndex < 0 || originalIndex >= len(m.AllAnalyzedBranches) {
@@ -202,81 +341,485 @@ func InitialModel(
 	s.Style = spinnerStyle
 	s.Spinner = spinner.Dot
 
+	m := Model{
+		Ctx:                 ctx,
+		DryRun:              dryRun,
+		AllAnalyzedBranches: analyzedBranches,   // Keep original full list
+		SelectedLocal:       make(map[int]bool), // Key is original index
+		SelectedRemote:      make(map[int]bool), // Key is original index
+		Cursor:              0,
+		ViewState:           StateSelecting, // Renamed from stateSelecting
+		Spinner:             s,
+		CurrentSection:      SectionSuggested, // Default to suggested section
+		logPreviews:         make(map[string]logPreview),
+	}
+	m.rebuildList()
+	return m
+}
+
+// rebuildList regroups AllAnalyzedBranches into the three display sections, keeping only
+// branches that match FilterQuery, and resets the display order and viewports accordingly.
+// Selections are keyed by original index, so they survive any rebuild.
+func (m *Model) rebuildList() {
 	key := make([]types.AnalyzedBranch, 0)
 	suggested := make([]types.AnalyzedBranch, 0)
 	active := make([]types.AnalyzedBranch, 0)
-	order := make([]int, 0, len(analyzedBranches))
+	order := make([]int, 0, len(m.AllAnalyzedBranches))
+
+	// Collect original indices of matching branches once
+	visible := make([]int, 0, len(m.AllAnalyzedBranches))
+	for i, branch := range m.AllAnalyzedBranches {
+		if fuzzyMatch(m.FilterQuery, branch.Name) {
+			visible = append(visible, i)
+		}
+	}
 
 	// Populate key branches first and build order map
-	for i, branch := range analyzedBranches {
-		if branch.Category == types.CategoryProtected {
-			key = append(key, branch)
+	for _, i := range visible {
+		if m.AllAnalyzedBranches[i].Category == types.CategoryProtected {
+			key = append(key, m.AllAnalyzedBranches[i])
 			order = append(order, i) // Store original index
 		}
 	}
-	// Populate suggested branches second and build order map
-	for i, branch := range analyzedBranches {
-		if branch.Category == types.CategoryMergedOld || branch.Category == types.CategoryUnmergedOld {
-			suggested = append(suggested, branch)
-			order = append(order, i) // Store original index
+	// Populate suggested branches second, the safest first, and build order map
+	suggestedIndices := make([]int, 0, len(visible))
+	for _, i := range visible {
+		category := m.AllAnalyzedBranches[i].Category
+		if category == types.CategoryMergedOld || category == types.CategoryUnmergedOld {
+			suggestedIndices = append(suggestedIndices, i)
 		}
 	}
+	sort.SliceStable(suggestedIndices, func(a, b int) bool {
+		branchA, branchB := m.AllAnalyzedBranches[suggestedIndices[a]], m.AllAnalyzedBranches[suggestedIndices[b]]
+		if authorA, authorB := authorLabel(branchA), authorLabel(branchB); m.GroupByAuthor && authorA != authorB {
+			return strings.ToLower(authorA) < strings.ToLower(authorB)
+		}
+		return branchA.RiskScore < branchB.RiskScore
+	})
+	for _, i := range suggestedIndices {
+		suggested = append(suggested, m.AllAnalyzedBranches[i])
+		order = append(order, i) // Store original index
+	}
 	// Populate active branches third and build order map
-	for i, branch := range analyzedBranches {
-		if branch.Category == types.CategoryActive {
-			active = append(active, branch)
+	for _, i := range visible {
+		if m.AllAnalyzedBranches[i].Category == types.CategoryActive {
+			active = append(active, m.AllAnalyzedBranches[i])
 			order = append(order, i) // Store original index
 		}
 	}
 
-	// Initialize viewports for each section
-	viewports := map[Section]ViewportState{
-		SectionKey: {
-			Start: 0,
-			Size:  len(key),
-			Total: len(key),
-		},
-		SectionSuggested: {
-			Start: 0,
-			Size:  min(5, len(suggested)), // Force a smaller viewport for testing
-			Total: len(suggested),
-		},
-		SectionOther: {
-			Start: 0,
-			Size:  len(active),
-			Total: len(active),
-		},
+	m.KeyBranches = key
+	m.SuggestedBranches = suggested
+	m.OtherActiveBranches = active
+	m.ListOrder = order // Store the display order mapping
+	if m.Cursor >= len(order) {
+		m.Cursor = max(0, len(order)-1)
 	}
 
-	return Model{
-		Ctx:                 ctx,
-		DryRun:              dryRun,
-		AllAnalyzedBranches: analyzedBranches, // Keep original full list
-		KeyBranches:         key,
-		SuggestedBranches:   suggested,
-		OtherActiveBranches: active,
-		ListOrder:           order,              // Store the display order mapping
-		SelectedLocal:       make(map[int]bool), // Key is original index
-		SelectedRemote:      make(map[int]bool), // Key is original index
-		Cursor:              0,
-		ViewState:           StateSelecting, // Renamed from stateSelecting
-		Spinner:             s,
-		Viewports:           viewports,
-		CurrentSection:      SectionSuggested, // Default to suggested section
+	// Start each section at the top, sized to the terminal
+	m.Viewports = map[Section]ViewportState{
+		SectionKey:       {Total: len(key)},
+		SectionSuggested: {Total: len(suggested)},
+		SectionOther:     {Total: len(active)},
+	}
+	m.layoutViewports()
+}
+
+// rebuildListKeepingCursor rebuilds the list like rebuildList, moving the cursor to wherever
+// the branch it was on ends up.
+func (m *Model) rebuildListKeepingCursor() {
+	cursorIndex := -1
+	if m.Cursor >= 0 && m.Cursor < len(m.ListOrder) {
+		cursorIndex = m.ListOrder[m.Cursor]
+	}
+	m.rebuildList()
+
+	for i, originalIndex := range m.ListOrder {
+		if originalIndex == cursorIndex {
+			m.Cursor = i
+			m.ensureCursorVisible()
+			break
+		}
 	}
 }
 
+// sectionPriority orders sections by who gets rows left over when the list does not fit.
+var sectionPriority = []Section{SectionSuggested, SectionOther, SectionKey}
+
+// layoutViewports sizes the section viewports to fill the terminal height left over by the
+// title, headings, separators, status and footer lines, and keeps the cursor visible.
+func (m *Model) layoutViewports() {
+	height := m.Height
+	if height <= 0 {
+		height = defaultHeight
+	}
+	lengths := map[Section]int{
+		SectionKey:       len(m.KeyBranches),
+		SectionSuggested: len(m.SuggestedBranches),
+		SectionOther:     len(m.OtherActiveBranches),
+	}
+
+	// Sections that do not fit need extra lines for their scroll indicators, which in turn
+	// leaves fewer rows for branches, so lay out again until no further section overflows
+	chrome := m.chromeHeight()
+	sizes := distributeRows(height-chrome, lengths)
+	scrolling := make(map[Section]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, section := range sectionPriority {
+			if sizes[section] < lengths[section] && !scrolling[section] {
+				scrolling[section] = true
+				chrome += scrollIndicatorLines(section)
+				changed = true
+			}
+		}
+		if changed {
+			sizes = distributeRows(height-chrome, lengths)
+		}
+	}
+
+	for section, size := range sizes {
+		viewport := m.Viewports[section]
+		viewport.Size = size
+		viewport.Total = lengths[section]
+		viewport.Start = min(viewport.Start, max(0, viewport.Total-viewport.Size))
+		m.Viewports[section] = viewport
+	}
+	m.ensureCursorVisible()
+}
+
+// chromeHeight returns the number of lines the selecting view uses besides branch rows and
+// scroll indicators: margins, title, filter, section headings and separators, status and footer.
+func (m Model) chromeHeight() int {
+	lines := 2 + 2 + 2 + 3 // Margins, title, status message, footer
+	if m.UpdateNotice != "" {
+		lines++
+	}
+	if m.OfflineNotice != "" {
+		lines++
+	}
+	if m.HeadNotice != "" {
+		lines++
+	}
+	if m.Filtering || m.FilterQuery != "" {
+		lines += 2
+	}
+	if m.Renaming {
+		lines += 2
+	}
+	if m.Loading {
+		lines += 2
+	}
+	hasKeys, hasSuggestions, hasActive := len(m.KeyBranches) > 0, len(m.SuggestedBranches) > 0,
+		len(m.OtherActiveBranches) > 0
+	if hasKeys && (hasSuggestions || hasActive) {
+		lines++ // Separator
+	}
+	if hasSuggestions {
+		lines += 4 // Heading and the lines reserved for "more above/below"
+	}
+	if hasSuggestions && hasActive {
+		lines++ // Separator
+	}
+	if hasActive {
+		lines += 2 // Heading
+	}
+	return lines
+}
+
+// scrollIndicatorLines returns the extra lines a section shows while it does not fit.
+func scrollIndicatorLines(section Section) int {
+	if section == SectionSuggested {
+		return 1 // Position indicator; the "more" lines are always reserved
+	}
+	return 3 // "More above", "more below" and position indicator
+}
+
+// distributeRows splits rows among sections in proportion to their lengths. Every non-empty
+// section gets at least one row and no section more rows than it has branches; rows left over
+// by rounding go to sections in sectionPriority order.
+func distributeRows(rows int, lengths map[Section]int) map[Section]int {
+	total := 0
+	for _, length := range lengths {
+		total += length
+	}
+	sizes := make(map[Section]int, len(lengths))
+	if total <= rows {
+		for section, length := range lengths {
+			sizes[section] = length
+		}
+		return sizes
+	}
+
+	used := 0
+	for section, length := range lengths {
+		if length > 0 {
+			sizes[section] = max(1, max(0, rows)*length/total)
+			used += sizes[section]
+		}
+	}
+	// Rows given to small sections to reach one row are taken back from the largest one
+	for used > rows {
+		largest := sectionPriority[0]
+		for _, section := range sectionPriority {
+			if sizes[section] > sizes[largest] {
+				largest = section
+			}
+		}
+		if sizes[largest] <= 1 {
+			break // Too small a terminal to show every section; overflow rather than hide one
+		}
+		sizes[largest]--
+		used--
+	}
+	for _, section := range sectionPriority {
+		extra := min(rows-used, lengths[section]-sizes[section])
+		if extra > 0 {
+			sizes[section] += extra
+			used += extra
+		}
+	}
+	return sizes
+}
+
+// fuzzyMatch reports whether all characters of query appear in name in order (case-insensitive).
+// An empty query matches everything.
+func fuzzyMatch(query, name string) bool {
+	if query == "" {
+		return true
+	}
+	nameRunes := []rune(strings.ToLower(name))
+	pos := 0
+	for _, qr := range strings.ToLower(query) {
+		found := false
+		for pos < len(nameRunes) {
+			pos++
+			if nameRunes[pos-1] == qr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // Init is the first command that runs when the Bubble Tea program starts.
 func (m Model) Init() tea.Cmd {
-	return m.Spinner.Tick // Start the spinner ticking
+	// Start the spinner ticking and begin collecting log records and analyzed branches
+	return tea.Batch(m.Spinner.Tick, waitForLogRecordCmd(m.LogRecords), waitForLoadCmd(m.Load))
+}
+
+// waitForLogRecordCmd waits for the next log record. It returns nil when there is no channel,
+// and stops listening once the channel is closed.
+func waitForLogRecordCmd(ch <-chan string) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		record, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return logRecordMsg(record)
+	}
 }
 
-// performDeletionCmd is a tea.Cmd that executes the branch deletions.
+// performDeletionCmd is a tea.Cmd that starts executing the branch deletions in the background
+// and waits for the first result. Each result is streamed back as a deleteResultMsg so the
+// TUI can show progress as deletions complete. Once the run is cancelled, results are no
+// longer streamed; they are collected from run when it is done.
 // Kept internal as it's only used within the TUI update loop.
-func performDeletionCmd(ctx context.Context, branchesToDelete []gitcmd.BranchToDelete, dryRun bool) tea.Cmd {
+func performDeletionCmd(
+	ctx context.Context, branchesToDelete []gitcmd.BranchToDelete, dryRun bool,
+	opts gitcmd.DeleteOptions, ch chan types.DeleteResult, run *deletionRun,
+) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			defer close(ch)
+			run.results = gitcmd.DeleteBranchesConcurrently(ctx, branchesToDelete, dryRun, opts,
+				func(res types.DeleteResult) {
+					select {
+					case ch <- res:
+					case <-ctx.Done():
+					}
+				})
+			close(run.done)
+		}()
+		return waitForDeletionCmd(ch)()
+	}
+}
+
+// waitForDeletionCmd waits for the next streamed deletion result.
+func waitForDeletionCmd(ch <-chan types.DeleteResult) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		res, ok := <-ch
+		if !ok {
+			return deletionDoneMsg{}
+		}
+		return deleteResultMsg{result: res}
+	}
+}
+
+// logDeleteResult records the outcome of a deletion: failures as errors, successes as info.
+func logDeleteResult(res types.DeleteResult) {
+	attrs := []any{"branch", res.BranchName, "remote", res.RemoteName, "cmd", res.Cmd}
+	if !res.Success {
+		slog.Error("Branch deletion failed", append(attrs, "message", res.Message)...)
+		return
+	}
+	slog.Info("Deleted branch", append(attrs, "hash", res.DeletedHash, "archived_as", res.ArchivedAs)...)
+}
+
+// loadLogCmd is a tea.Cmd that fetches the recent commits of a branch for the preview pane.
+func loadLogCmd(ctx context.Context, branchName string) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := gitcmd.GetRecentCommits(ctx, branchName, logPreviewCount)
+		return logMsg{branch: branchName, lines: lines, err: err}
+	}
+}
+
+// snoozeCmd persists a snooze for branchName in the current repository.
+func snoozeCmd(ctx context.Context, branchName string, until time.Time) tea.Cmd {
+	return func() tea.Msg {
+		repoRoot, err := gitcmd.GetRepoRoot(ctx)
+		if err == nil {
+			err = state.SnoozeBranch(repoRoot, branchName, until)
+		}
+		return snoozeMsg{branch: branchName, err: err}
+	}
+}
+
+// switchCmd checks out branchName, refusing if the working tree has uncommitted changes.
+func switchCmd(ctx context.Context, branchName string) tea.Cmd {
+	return func() tea.Msg {
+		return switchMsg{branch: branchName, err: gitcmd.SwitchBranch(ctx, branchName)}
+	}
+}
+
+// renameCmd renames a local branch and, if remoteName is set, its upstream oldRemoteBranch
+// on that remote.
+func renameCmd(ctx context.Context, oldName, newName, remoteName, oldRemoteBranch string) tea.Cmd {
+	return func() tea.Msg {
+		msg := renameMsg{oldName: oldName, newName: newName}
+		if msg.err = gitcmd.RenameBranch(ctx, oldName, newName); msg.err != nil || remoteName == "" {
+			return msg
+		}
+		msg.remote = remoteName
+		msg.remoteErr = gitcmd.RenameRemoteBranch(ctx, remoteName, oldRemoteBranch, newName)
+		return msg
+	}
+}
+
+// clipboardOutput returns the writer OSC 52 sequences are sent to.
+func (m Model) clipboardOutput() io.Writer {
+	if m.Clipboard != nil {
+		return m.Clipboard
+	}
+	return os.Stderr
+}
+
+// copyCmd copies text to the system clipboard with an OSC 52 escape sequence, which the
+// terminal applies even over SSH. Inside tmux or screen the sequence is wrapped so they pass
+// it on to the outer terminal. The classic Windows console ignores the sequence, so clip.exe
+// is used there instead.
+func copyCmd(w io.Writer, text string) tea.Cmd {
 	return func() tea.Msg {
-		results := gitcmd.DeleteBranches(ctx, branchesToDelete, dryRun)
-		return resultsMsg{results: results}
+		if useClipExe() {
+			return copyMsg{text: text, err: clipExe(text)}
+		}
+		seq := osc52.New(text)
+		if os.Getenv("TMUX") != "" {
+			seq = seq.Tmux()
+		} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(w)
+		return copyMsg{text: text, err: err}
+	}
+}
+
+// writeRecoveryCmd saves the recovery commands to a script in the state directory.
+func writeRecoveryCmd(commands []string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := state.WriteRecoveryScript(commands, time.Now())
+		return recoveryScriptMsg{path: path, err: err}
+	}
+}
+
+// snoozeCursorBranch hides the branch under the cursor from the suggestions and
+// returns a command that records the snooze. Protected and already snoozed branches are ignored.
+func (m *Model) snoozeCursorBranch() tea.Cmd {
+	if m.Cursor < 0 || m.Cursor >= len(m.ListOrder) {
+		return nil
+	}
+	originalIndex := m.ListOrder[m.Cursor]
+	branch := &m.AllAnalyzedBranches[originalIndex]
+	if branch.Category == types.CategoryProtected || branch.IsSnoozed {
+		return nil
+	}
+
+	var until time.Time
+	if m.SnoozeFor > 0 {
+		until = time.Now().Add(m.SnoozeFor).UTC()
+	}
+	branch.IsSnoozed = true
+	branch.Snoozed = true
+	branch.SnoozedUntil = until
+	branch.Category = types.CategoryActive
+	delete(m.SelectedLocal, originalIndex)
+	delete(m.SelectedRemote, originalIndex)
+	m.rebuildList()
+	return snoozeCmd(m.Ctx, branch.Name, until)
+}
+
+// cursorBranch returns the branch under the cursor, if any.
+func (m Model) cursorBranch() (types.AnalyzedBranch, bool) {
+	if m.Cursor < 0 || m.Cursor >= len(m.ListOrder) {
+		return types.AnalyzedBranch{}, false
+	}
+	return m.AllAnalyzedBranches[m.ListOrder[m.Cursor]], true
+}
+
+// previewCmd returns a command loading the log for the branch under the cursor
+// when the preview pane is visible and the log isn't cached yet.
+func (m Model) previewCmd() tea.Cmd {
+	if !m.ShowLog {
+		return nil
+	}
+	branch, ok := m.cursorBranch()
+	if !ok {
+		return nil
+	}
+	if _, cached := m.logPreviews[branch.Name]; cached {
+		return nil
+	}
+	return loadLogCmd(m.Ctx, branch.Name)
+}
+
+// Preselect selects every candidate for which match returns true, together with its remote
+// branch where that may be deleted, as if it had been toggled with space. Branches loaded
+// later are preselected the same way.
+func (m *Model) Preselect(match func(types.AnalyzedBranch) bool) {
+	m.preselection = match
+	m.preselect(0)
+}
+
+// preselect applies the preselection to the branches from the given original index on.
+func (m *Model) preselect(from int) {
+	for i := from; i < len(m.AllAnalyzedBranches); i++ {
+		if !m.isSelectable(i) || !m.preselection(m.AllAnalyzedBranches[i]) {
+			continue
+		}
+		m.SelectedLocal[i] = true
+		if m.isRemoteSelectable(i) {
+			m.SelectedRemote[i] = true
+		}
 	}
 }
 
@@ -291,6 +834,17 @@ func (m Model) isSelectable(originalIndex int) bool {
 	return category == types.CategoryMergedOld || category == types.CategoryUnmergedOld
 }
 
+// isRemoteSelectable checks if the remote branch of the item at the given original index can be
+// selected for deletion: the branch has a remote, is not protected by protected_remote_patterns,
+// and offline mode is off.
+func (m Model) isRemoteSelectable(originalIndex int) bool {
+	if originalIndex < 0 || originalIndex >= len(m.AllAnalyzedBranches) {
+		return false
+	}
+	branch := m.AllAnalyzedBranches[originalIndex]
+	return branch.Remote != "" && !branch.IsRemoteProtected && !m.Offline
+}
+
 // --- Update Logic ---
 
 // Update handles messages and updates the model accordingly.
@@ -301,59 +855,106 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
+		m.layoutViewports()
+		return m, nil
 
-		// Calculate available space after accounting for headers, footers, etc.
-		availableHeight := max(3, m.Height-15) // 15 is an estimate for UI elements
+	case logMsg: // Internal message type
+		if m.logPreviews == nil {
+			m.logPreviews = make(map[string]logPreview)
+		}
+		m.logPreviews[msg.branch] = logPreview{lines: msg.lines, err: msg.err}
+		return m, nil
 
-		// Allocate space to sections based on priority
-		keyHeight := min(len(m.KeyBranches), 3)
-		otherHeight := min(len(m.OtherActiveBranches), 3)
+	case loadMsg: // Internal message type
+		return m.applyLoad(msg)
 
-		// Note: We're not using this variable anymore since we're forcing a smaller viewport
-		_ = max(1, availableHeight-keyHeight-otherHeight) // Avoid unused variable error
+	case logRecordMsg: // Internal message type
+		m.Logs = append(m.Logs, string(msg))
+		return m, waitForLogRecordCmd(m.LogRecords)
 
-		// Update viewport sizes
-		if m.Viewports == nil {
-			m.Viewports = make(map[Section]ViewportState)
+	case snoozeMsg: // Internal message type
+		if msg.err != nil {
+			slog.Warn("Could not save snooze", "branch", msg.branch, "error", msg.err)
+			m.StatusMessage = fmt.Sprintf("Could not save snooze for '%s': %v", msg.branch, msg.err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Snoozed '%s'", msg.branch)
 		}
+		return m, nil
 
-		keyViewport := m.Viewports[SectionKey]
-		keyViewport.Size = keyHeight
-		m.Viewports[SectionKey] = keyViewport
+	case switchMsg: // Internal message type
+		if msg.err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not switch to '%s': %v", msg.branch, msg.err)
+			return m, nil
+		}
+		m.SwitchedTo = msg.branch
+		return m, tea.Quit
 
-		suggestedViewport := m.Viewports[SectionSuggested]
-		// Force a smaller viewport size for testing pagination
-		suggestedViewport.Size = min(5, len(m.SuggestedBranches))
-		m.Viewports[SectionSuggested] = suggestedViewport
+	case renameMsg: // Internal message type
+		return m.applyRename(msg), nil
 
-		otherViewport := m.Viewports[SectionOther]
-		otherViewport.Size = otherHeight
-		m.Viewports[SectionOther] = otherViewport
+	case copyMsg: // Internal message type
+		if msg.err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not copy '%s': %v", msg.text, msg.err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Copied '%s' to the clipboard", msg.text)
+		}
+		return m, nil
 
+	case recoveryScriptMsg: // Internal message type
+		if msg.err != nil {
+			m.RecoveryStatus = fmt.Sprintf("Could not write recovery commands: %v", msg.err)
+		} else {
+			m.RecoveryStatus = "Recovery commands written to " + msg.path
+		}
 		return m, nil
 
-	case resultsMsg: // Internal message type
-		m.Results = msg.results
+	case deleteResultMsg: // Internal message type
+		logDeleteResult(msg.result)
+		m.Results = append(m.Results, msg.result)
+		m.PendingDeletions = removePending(m.PendingDeletions, msg.result)
+		if len(m.PendingDeletions) == 0 {
+			m.ViewState = StateResults
+			return m, nil
+		}
+		return m, waitForDeletionCmd(m.deletionResults)
+
+	case deletionDoneMsg: // Internal message type
+		if m.deletion != nil {
+			m.Results = m.deletion.results
+		}
+		m.PendingDeletions = nil
 		m.ViewState = StateResults
 		return m, nil
 
 	case spinner.TickMsg:
-		// Only update spinner if in deleting state
-		if m.ViewState == StateDeleting {
+		// Only update spinner while deleting or loading
+		if m.ViewState == StateDeleting || m.Loading {
 			m.Spinner, cmd = m.Spinner.Update(msg)
 			return m, cmd
 		}
 		return m, nil // Ignore spinner ticks in other states
 
 	case tea.KeyMsg:
-		// Global Quit
+		// Global Quit; while deleting, the first Ctrl+C stops the run and shows what was done
 		if msg.String() == "ctrl+c" {
+			if m.ViewState == StateDeleting && m.deletion != nil && !m.Interrupted {
+				m.Interrupted = true
+				m.deletion.cancel()
+				m.StatusMessage = "Interrupted; stopping after the deletions in progress..."
+				return m, nil
+			}
 			return m, tea.Quit
 		}
 
 		// Delegate key handling based on state
 		switch m.ViewState {
 		case StateSelecting:
+			if m.Filtering {
+				return m.updateFiltering(msg)
+			}
+			if m.Renaming {
+				return m.updateRenaming(msg)
+			}
 			return m.updateSelecting(msg)
 		case StateConfirming:
 			return m.updateConfirming(msg)
@@ -367,131 +968,130 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// updateSelecting handles key presses when in the selecting state.
-func (m Model) updateSelecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	totalItems := len(m.ListOrder)
-	if totalItems == 0 {
-		if msg.String() == "q" {
-			return m, tea.Quit
+// updateFiltering handles key presses while the filter input has focus.
+func (m Model) updateFiltering(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		// Keep the filter applied and return to list navigation
+		m.Filtering = false
+		return m, nil
+	case tea.KeyEsc:
+		// Discard the filter entirely
+		m.Filtering = false
+		m.FilterQuery = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.FilterQuery); len(runes) > 0 {
+			m.FilterQuery = string(runes[:len(runes)-1])
 		}
-		return m, nil // Ignore other keys if list is empty
-	}
-
-	// Get the current section for the cursor
-	var cursorSection Section
-	if m.Cursor < len(m.ListOrder) {
-		originalIndex := m.ListOrder[m.Cursor]
-		cursorSection = m.getBranchSection(originalIndex)
-	} else {
-		cursorSection = SectionSuggested // Default
+	case tea.KeyRunes:
+		m.FilterQuery += string(msg.Runes)
+	default:
+		return m, nil // Ignore navigation and other special keys while typing
 	}
 
-	// Update current section based on cursor position
-	m.CurrentSection = cursorSection
-
-	switch msg.String() {
-	case "q":
-		return m, tea.Quit
+	m.Cursor = 0
+	m.rebuildList()
+	return m, nil
+}
 
-	case "up", "k":
-		if m.Cursor > 0 {
-			m.Cursor--
-
-			// Auto-scroll viewport when cursor moves out of view
-			if cursorSection == SectionSuggested {
-				// Calculate the index within the suggested branches section
-				// The cursor is in the suggested section if it's after the key branches
-				// So the index in the suggested section is (cursor - len(keyBranches))
-				sectionIndex := m.Cursor - len(m.KeyBranches)
-
-				// If cursor is now in the suggested section and out of viewport
-				if sectionIndex >= 0 { // Make sure we're in the suggested section
-					viewport := m.Viewports[SectionSuggested]
-					if sectionIndex < viewport.Start {
-						viewport.Start = max(0, sectionIndex)
-						m.Viewports[SectionSuggested] = viewport
-					}
-				}
-			}
+// updateRenaming handles key presses while the new name input has focus. Tab toggles
+// renaming the branch on its remote as well.
+func (m Model) updateRenaming(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	branch, ok := m.cursorBranch()
+	if !ok {
+		m.Renaming = false
+		return m, nil
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.Renaming = false
+		m.layoutViewports()
+	case tea.KeyEnter:
+		m.Renaming = false
+		m.layoutViewports()
+		newName := strings.TrimSpace(m.RenameInput)
+		if newName == "" || newName == branch.Name {
+			return m, nil
 		}
-
-	case "down", "j":
-		if m.Cursor < totalItems-1 {
-			m.Cursor++
-
-			// Auto-scroll viewport when cursor moves out of view
-			if cursorSection == SectionSuggested {
-				// Calculate the index within the suggested branches section
-				// The cursor is in the suggested section if it's after the key branches
-				// So the index in the suggested section is (cursor - len(keyBranches))
-				sectionIndex := m.Cursor - len(m.KeyBranches)
-
-				// If cursor is now in the suggested section and out of viewport
-				if sectionIndex >= 0 { // Make sure we're in the suggested section
-					viewport := m.Viewports[SectionSuggested]
-					if sectionIndex >= viewport.Start+viewport.Size {
-						viewport.Start = max(0, sectionIndex-viewport.Size+1)
-						m.Viewports[SectionSuggested] = viewport
-					}
-				}
-			}
+		remote := ""
+		if m.RenameRemote {
+			remote = branch.Remote
 		}
-
-	case "pgup":
-		// Only scroll in the suggested section
-		if cursorSection == SectionSuggested {
-			viewport := m.Viewports[SectionSuggested]
-			// Move viewport up by page size
-			viewport.Start = max(0, viewport.Start-viewport.Size)
-			m.Viewports[SectionSuggested] = viewport
-
-			// Move cursor to top of viewport if it's now outside
-			newCursorPos := len(m.KeyBranches) + viewport.Start
-			if m.Cursor < newCursorPos || m.Cursor >= newCursorPos+viewport.Size {
-				m.Cursor = newCursorPos
-			}
+		m.StatusMessage = fmt.Sprintf("Renaming '%s' to '%s'...", branch.Name, newName)
+		return m, renameCmd(m.Ctx, branch.Name, newName, remote, branch.RemoteBranchName())
+	case tea.KeyTab:
+		m.RenameRemote = !m.RenameRemote && m.isRemoteSelectable(m.ListOrder[m.Cursor])
+	case tea.KeyBackspace:
+		if runes := []rune(m.RenameInput); len(runes) > 0 {
+			m.RenameInput = string(runes[:len(runes)-1])
 		}
+	case tea.KeyRunes:
+		m.RenameInput += string(msg.Runes)
+	}
+	return m, nil
+}
 
-	case "pgdown":
-		// Only scroll in the suggested section
-		if cursorSection == SectionSuggested {
-			viewport := m.Viewports[SectionSuggested]
-			// Move viewport down by page size
-			maxStart := max(0, viewport.Total-viewport.Size)
-			viewport.Start = min(maxStart, viewport.Start+viewport.Size)
-			m.Viewports[SectionSuggested] = viewport
-
-			// Move cursor to top of viewport
-			newCursorPos := len(m.KeyBranches) + viewport.Start
-			if m.Cursor < newCursorPos || m.Cursor >= newCursorPos+viewport.Size {
-				m.Cursor = newCursorPos
-			}
+// applyRename updates the renamed branch in the list and reports the outcome.
+func (m Model) applyRename(msg renameMsg) Model {
+	if msg.err != nil {
+		m.StatusMessage = fmt.Sprintf("Could not rename '%s': %v", msg.oldName, msg.err)
+		return m
+	}
+	for i := range m.AllAnalyzedBranches {
+		branch := &m.AllAnalyzedBranches[i]
+		if branch.Name != msg.oldName {
+			continue
 		}
+		branch.Name = msg.newName
+		if msg.remote != "" && msg.remoteErr == nil {
+			branch.Upstream = msg.remote + "/" + msg.newName
+			branch.RemoteBranch = msg.newName
+		}
+	}
+	m.rebuildList()
+
+	switch {
+	case msg.remoteErr != nil:
+		m.StatusMessage = fmt.Sprintf("Renamed '%s' to '%s', but not on %s: %v",
+			msg.oldName, msg.newName, msg.remote, msg.remoteErr)
+	case msg.remote != "":
+		m.StatusMessage = fmt.Sprintf("Renamed '%s' to '%s' locally and on %s", msg.oldName, msg.newName, msg.remote)
+	default:
+		m.StatusMessage = fmt.Sprintf("Renamed '%s' to '%s'", msg.oldName, msg.newName)
+	}
+	return m
+}
 
-	case "home":
-		// Jump to first item in current section
-		if cursorSection == SectionSuggested {
-			viewport := m.Viewports[SectionSuggested]
-			viewport.Start = 0
-			m.Viewports[SectionSuggested] = viewport
+// updateSelecting handles key presses when in the selecting state.
+func (m Model) updateSelecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "/":
+		m.Filtering = true
+		m.layoutViewports() // The filter line takes up room
+		return m, nil
+	case "esc":
+		if m.FilterQuery != "" {
+			m.FilterQuery = ""
+			m.Cursor = 0
+			m.rebuildList()
+		}
+		return m, nil
+	}
 
-			// Move cursor to first item in section
-			m.Cursor = len(m.KeyBranches)
+	totalItems := len(m.ListOrder)
+	if totalItems == 0 {
+		if msg.String() == "q" {
+			return m, tea.Quit
 		}
+		return m, nil // Ignore other keys if list is empty
+	}
 
-	case "end":
-		// Jump to last item in current section
-		if cursorSection == SectionSuggested {
-			viewport := m.Viewports[SectionSuggested]
-			maxStart := max(0, viewport.Total-viewport.Size)
-			viewport.Start = maxStart
-			m.Viewports[SectionSuggested] = viewport
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
 
-			// Move cursor to last visible item
-			lastVisible := min(len(m.KeyBranches)+viewport.Start+viewport.Size-1,
-				len(m.KeyBranches)+len(m.SuggestedBranches)-1)
-			m.Cursor = lastVisible
-		}
+	case "up", "k", "down", "j", "pgup", "pgdown", "home", "end":
+		m.navigate(msg.String())
 
 	case " ": // Toggle local selection
 		if m.Cursor >= len(m.ListOrder) {
@@ -506,9 +1106,8 @@ func (m Model) updateSelecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			} else {
 				m.SelectedLocal[originalIndex] = true
 
-				// Auto-select remote if it exists
-				branch := m.AllAnalyzedBranches[originalIndex]
-				if branch.Remote != "" {
+				// Auto-select remote if it exists and may be deleted
+				if m.isRemoteSelectable(originalIndex) {
 					m.SelectedRemote[originalIndex] = true
 				}
 			}
@@ -521,8 +1120,7 @@ func (m Model) updateSelecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		originalIndex := m.ListOrder[m.Cursor]
 		if m.isSelectable(originalIndex) {
 			if _, localSelected := m.SelectedLocal[originalIndex]; localSelected {
-				branch := m.AllAnalyzedBranches[originalIndex]
-				if branch.Remote != "" {
+				if m.isRemoteSelectable(originalIndex) {
 					_, remoteSelected := m.SelectedRemote[originalIndex]
 					if remoteSelected {
 						delete(m.SelectedRemote, originalIndex)
@@ -533,51 +1131,249 @@ func (m Model) updateSelecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case "l": // Toggle commit log preview pane
+		m.ShowLog = !m.ShowLog
+
+	case "a": // Toggle archive-before-delete
+		m.Archive = !m.Archive
+
+	case "o": // Toggle grouping the candidates by author
+		m.GroupByAuthor = !m.GroupByAuthor
+		m.rebuildListKeepingCursor()
+		return m, m.previewCmd()
+
+	case "c": // Switch to the branch under the cursor and exit
+		if branch, ok := m.cursorBranch(); ok && m.cursorSection() != SectionKey {
+			m.StatusMessage = fmt.Sprintf("Switching to '%s'...", branch.Name)
+			return m, switchCmd(m.Ctx, branch.Name)
+		}
+
+	case "R": // Rename the branch under the cursor
+		if branch, ok := m.cursorBranch(); ok && m.cursorSection() != SectionKey {
+			m.Renaming = true
+			m.RenameInput = branch.Name
+			m.RenameRemote = false
+			m.layoutViewports() // The rename line takes up room
+		}
+		return m, nil
+
+	case "y": // Copy the name of the branch under the cursor
+		if branch, ok := m.cursorBranch(); ok {
+			return m, copyCmd(m.clipboardOutput(), branch.Name)
+		}
+
+	case "z": // Snooze the branch under the cursor
+		if cmd := m.snoozeCursorBranch(); cmd != nil {
+			return m, tea.Batch(cmd, m.previewCmd())
+		}
+
 	case "enter":
+		if m.Loading {
+			m.StatusMessage = "Still analyzing branches; confirm once the analysis has finished"
+			return m, nil
+		}
+		// Remote selection requires local selection, so SelectedLocal counts the branches
+		if m.MaxDelete > 0 && len(m.SelectedLocal) > m.MaxDelete {
+			m.StatusMessage = fmt.Sprintf("%d branches selected, more than max_delete (%d); "+
+				"deselect some or restart with --force", len(m.SelectedLocal), m.MaxDelete)
+			return m, nil
+		}
 		if len(m.SelectedLocal) > 0 || len(m.SelectedRemote) > 0 {
 			m.ViewState = StateConfirming
+			m.ConfirmInput = ""
+			if queue := m.selectionInListOrder(); m.ConfirmEach && len(queue) > 0 {
+				m.stepQueue, m.stepIndex, m.stepDeclined = queue, 0, make(map[int]bool)
+			}
 		}
 		return m, nil // No command needed here
 	}
 
-	return m, nil
+	// Load the log for the branch now under the cursor if the preview is open
+	return m, m.previewCmd()
 }
 
-// updateConfirming handles key presses when in the confirming state.
+// updateConfirming handles key presses when in the confirming state. Selections with force
+// deletions must be confirmed by typing forceConfirmWord instead of pressing y.
 func (m Model) updateConfirming(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.stepQueue != nil {
+		return m.updateStepConfirmation(msg)
+	}
+	if m.hasForceDeletes() {
+		return m.updateTypedConfirmation(msg)
+	}
+	switch msg.String() {
+	case "q", "n", "N", "esc", "e":
+		m.ViewState = StateSelecting
+		return m, nil
+	case "y", "Y":
+		return m.startDeletion()
+	}
+	return m, nil
+}
+
+// updateTypedConfirmation collects the confirmation word for force deletions. Esc, or e before
+// anything was typed, returns to editing the selection.
+func (m Model) updateTypedConfirmation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.ViewState = StateSelecting
+	case tea.KeyEnter:
+		if strings.EqualFold(strings.TrimSpace(m.ConfirmInput), forceConfirmWord) {
+			return m.startDeletion()
+		}
+		m.ConfirmInput = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.ConfirmInput); len(runes) > 0 {
+			m.ConfirmInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes:
+		if m.ConfirmInput == "" && msg.String() == "e" {
+			m.ViewState = StateSelecting
+			return m, nil
+		}
+		m.ConfirmInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// selectionInListOrder returns the selected branches in the order they are listed, followed by
+// selected branches the filter hides, by original index.
+func (m Model) selectionInListOrder() []int {
+	order := make([]int, 0, len(m.SelectedLocal))
+	listed := make(map[int]bool, len(m.ListOrder))
+	for _, originalIndex := range m.ListOrder {
+		listed[originalIndex] = true
+		if m.SelectedLocal[originalIndex] && m.isSelectable(originalIndex) {
+			order = append(order, originalIndex)
+		}
+	}
+	hidden := make([]int, 0)
+	for originalIndex := range m.SelectedLocal {
+		if !listed[originalIndex] && m.isSelectable(originalIndex) {
+			hidden = append(hidden, originalIndex)
+		}
+	}
+	sort.Ints(hidden)
+	return append(order, hidden...)
+}
+
+// updateStepConfirmation handles key presses while each selected branch is confirmed in turn,
+// like 'git add -p': y deletes the branch, n keeps it, a deletes it and all remaining ones, q
+// keeps it and all remaining ones. Esc, or e, returns to the selection unchanged.
+func (m Model) updateStepConfirmation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "q", "n", "N", "esc":
+	case "esc", "e":
+		m.stepQueue, m.stepDeclined = nil, nil
 		m.ViewState = StateSelecting
 		return m, nil
 	case "y", "Y":
-		m.ViewState = StateDeleting
-		branchesToDelete := m.GetBranchesToDelete()
-		return m, tea.Batch(
-			performDeletionCmd(m.Ctx, branchesToDelete, m.DryRun),
-			m.Spinner.Tick, // Ensure spinner keeps ticking
-		)
+		m.stepIndex++
+	case "n", "N":
+		m.stepDeclined[m.stepQueue[m.stepIndex]] = true
+		m.stepIndex++
+	case "a", "A":
+		return m.finishStepping(m.stepQueue[m.stepIndex+1:])
+	case "q", "Q":
+		for _, originalIndex := range m.stepQueue[m.stepIndex:] {
+			m.stepDeclined[originalIndex] = true
+		}
+		return m.finishStepping(nil)
+	default:
+		return m, nil
+	}
+	if m.stepIndex >= len(m.stepQueue) {
+		return m.finishStepping(nil)
 	}
 	return m, nil
 }
 
+// finishStepping deselects the declined branches and deletes the rest. Unmerged branches among
+// unseen, which were approved with a without being shown, still need the typed confirmation.
+func (m Model) finishStepping(unseen []int) (tea.Model, tea.Cmd) {
+	for originalIndex := range m.stepDeclined {
+		delete(m.SelectedLocal, originalIndex)
+		delete(m.SelectedRemote, originalIndex)
+	}
+	m.stepQueue, m.stepDeclined = nil, nil
+	if len(m.SelectedLocal) == 0 {
+		m.ViewState = StateSelecting
+		m.StatusMessage = "No branch was confirmed; nothing was deleted"
+		return m, nil
+	}
+	for _, originalIndex := range unseen {
+		if m.AllAnalyzedBranches[originalIndex].ForceDelete() {
+			return m, nil // Stay on the confirmation, which now asks to type the confirmation word
+		}
+	}
+	return m.startDeletion()
+}
+
+// startDeletion leaves the confirmation and deletes the selected branches in the background.
+func (m Model) startDeletion() (tea.Model, tea.Cmd) {
+	m.ViewState = StateDeleting
+	m.ConfirmInput = ""
+	m.PendingDeletions = m.GetBranchesToDelete()
+	m.Results = make([]types.DeleteResult, 0, len(m.PendingDeletions))
+	if len(m.PendingDeletions) == 0 {
+		m.ViewState = StateResults
+		return m, nil
+	}
+	m.deletionResults = make(chan types.DeleteResult)
+	ctx, cancel := context.WithCancel(m.Ctx)
+	m.deletion = &deletionRun{cancel: cancel, done: make(chan struct{})}
+	return m, tea.Batch(
+		performDeletionCmd(ctx, m.PendingDeletions, m.DryRun, m.DeleteOptions, m.deletionResults, m.deletion),
+		m.Spinner.Tick, // Ensure spinner keeps ticking
+	)
+}
+
+// hasForceDeletes reports whether the selection force deletes any local branch.
+func (m Model) hasForceDeletes() bool {
+	for _, bd := range m.GetBranchesToDelete() {
+		if !bd.IsRemote && bd.ForceDelete() {
+			return true
+		}
+	}
+	return false
+}
+
 // updateDeleting handles key presses when in the deleting state (currently ignores them).
 func (m Model) updateDeleting(_ tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Ignore key presses while deleting
 	return m, nil
 }
 
-// updateResults handles key presses when in the results state (any key quits).
-func (m Model) updateResults(_ tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Any key press quits
+// updateResults handles key presses when in the results state: w writes the recovery
+// commands to a file, any other key quits.
+func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if commands := m.recoveryCommands(); msg.String() == "w" && len(commands) > 0 {
+		return m, writeRecoveryCmd(commands)
+	}
 	return m, tea.Quit
 }
 
+// recoveryCommands returns the shell commands recreating each successfully deleted branch.
+func (m Model) recoveryCommands() []string {
+	var commands []string
+	for _, res := range m.Results {
+		if args := gitcmd.RecoveryArgs(res); args != nil {
+			commands = append(commands, gitcmd.ShellCommand(args))
+		}
+	}
+	return commands
+}
+
 // --- View Helper Functions ---
 
 // renderKeyBranches renders the non-selectable key branches (Protected, Current).
 // Kept internal as it's only called by View.
 func (m Model) renderKeyBranches(b *strings.Builder, itemIndex *int) {
-	for _, branch := range m.KeyBranches {
+	viewport := m.Viewports[SectionKey]
+	visibleEnd := min(viewport.Start+viewport.Size, len(m.KeyBranches))
+	m.renderMoreAbove(b, viewport)
+	for i := viewport.Start; i < visibleEnd; i++ {
+		branch := m.KeyBranches[i]
+		*itemIndex = m.sectionOffset(SectionKey) + i
 		cursor := " "
 		if m.Cursor == *itemIndex {
 			cursor = cursorStyle.Render(">")
@@ -589,12 +1385,14 @@ func (m Model) renderKeyBranches(b *strings.Builder, itemIndex *int) {
 
 		remoteInfo := remoteNone
 		if branch.Remote != "" {
-			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.Name)
+			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.RemoteBranchName())
 		}
 
 		status := "Protected"
 		if branch.IsCurrent {
 			status = "Current"
+		} else if branch.WorktreePath != "" {
+			status = fmt.Sprintf("Worktree (%s)", branch.WorktreePath)
 		}
 		categoryText := protectedStyle.Render(fmt.Sprintf("Status: %s", status))
 
@@ -604,6 +1402,60 @@ func (m Model) renderKeyBranches(b *strings.Builder, itemIndex *int) {
 		b.WriteString(cursor + " " + lineStyle.Render(line) + "\n")
 		*itemIndex++ // Increment the shared index
 	}
+	m.renderMoreBelow(b, viewport)
+}
+
+// branchDetail returns the first line of the branch description, or else "subject — author"
+// for its last commit, truncated to the terminal width left after a line of lineWidth cells.
+// It is empty if too little space is left, and while the log pane, which shows both in full,
+// takes up the width.
+func (m Model) branchDetail(branch types.AnalyzedBranch, lineWidth int) string {
+	if m.ShowLog || (branch.Description == "" && branch.Subject == "" && branch.AuthorName == "") {
+		return ""
+	}
+	width := m.Width
+	if width == 0 {
+		width = defaultWidth
+	}
+	// Leave room for the margins, the cursor column and the " | " separator
+	available := width - docStyle.GetHorizontalFrameSize() - 2 - lineWidth - 3
+	if available < minDetailWidth {
+		return ""
+	}
+	detail := branch.Subject
+	if branch.AuthorName != "" {
+		detail = strings.TrimPrefix(detail+" — "+branch.AuthorName, " — ")
+	}
+	if branch.Description != "" {
+		detail = "Note: " + strings.SplitN(branch.Description, "\n", 2)[0]
+	}
+	return ansi.Truncate(detail, available, "…")
+}
+
+// renderMoreAbove writes the "more above" line of a scrolled Key or Other section.
+// Sections that fit entirely get no indicator lines.
+func (m Model) renderMoreAbove(b *strings.Builder, viewport ViewportState) {
+	if viewport.Total <= viewport.Size {
+		return
+	}
+	if viewport.Start > 0 {
+		b.WriteString(helpStyle.Render("   ↑ More branches above ↑") + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+}
+
+// renderMoreBelow writes the "more below" line and the position of a scrolled Key or Other section.
+func (m Model) renderMoreBelow(b *strings.Builder, viewport ViewportState) {
+	if viewport.Total <= viewport.Size {
+		return
+	}
+	if viewport.Start+viewport.Size < viewport.Total {
+		b.WriteString(helpStyle.Render("   ↓ More branches below ↓") + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString(renderCompactIndicator(viewport.Start, viewport.Size, viewport.Total, m.Width) + "\n")
 }
 
 // renderSuggestedBranches renders the selectable suggested branches (MergedOld, UnmergedOld).
@@ -663,9 +1515,12 @@ func (m Model) renderSuggestedBranches(b *strings.Builder, itemIndex *int) {
 
 		remoteCheckbox := checkboxUnselectable
 		remoteInfo := remoteNone
-		if branch.Remote != "" {
+		switch {
+		case branch.IsRemoteProtected || (m.Offline && branch.Remote != ""):
+			remoteInfo = remoteDimmedStyle.Render(fmt.Sprintf("(%s/%s)", branch.Remote, branch.RemoteBranchName()))
+		case branch.Remote != "":
 			remoteCheckbox = checkboxUnchecked
-			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.Name)
+			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.RemoteBranchName())
 			if _, ok := m.SelectedRemote[originalIndex]; ok {
 				remoteCheckbox = selectedStyle.Render("[x]")
 			}
@@ -680,18 +1535,49 @@ func (m Model) renderSuggestedBranches(b *strings.Builder, itemIndex *int) {
 		switch branch.Category {
 		case types.CategoryMergedOld:
 			statusText = fmt.Sprintf("Status: Merged (%d days)", daysOld)
+			if branch.MergedInto != "" {
+				statusText = fmt.Sprintf("Status: Merged into %s (%d days)", branch.MergedInto, daysOld)
+			}
 		case types.CategoryUnmergedOld:
 			statusText = fmt.Sprintf("Status: Old (%d days)", daysOld)
+			if !branch.IsOldByAge {
+				statusText = fmt.Sprintf("Status: Unmerged (%d days)", daysOld)
+			}
 		case types.CategoryProtected:
 			statusText = "Status: Protected"
 		case types.CategoryActive:
 			statusText = fmt.Sprintf("Status: Active (%d days)", daysOld)
 		}
 
-		categoryText := categoryStyle.Render(statusText)
+		if label := branch.DuplicateLabel(); label != "" {
+			statusText += " · " + label
+		}
+		if label := branch.StackLabel(); label != "" {
+			statusText += " · " + label
+		}
+		if branch.CategoryRule != "" {
+			statusText += " · rule " + branch.CategoryRule
+		}
+		if branch.IsRemoteProtected {
+			statusText += " · " + remoteProtectionLabel(branch)
+		}
+		categoryText := categoryStyle.Render(statusText + pullRequestLabel(branch))
+		if branch.Risk != "" {
+			categoryText += " " + riskStyleMap[branch.Risk].Render("["+string(branch.Risk)+"]")
+		}
+		if branch.UnpushedCommits > 0 {
+			categoryText += " " + forceDeleteStyle.Render(unpushedLabel(branch.UnpushedCommits))
+		}
 
 		line := fmt.Sprintf("Local: %s %s | Remote: %s %s | %s",
 			localCheckbox, branch.Name, remoteCheckbox, remoteInfo, categoryText)
+		if m.GroupByAuthor {
+			line = fmt.Sprintf("Local: %s %s | Remote: %s %s | Author: %s | %s",
+				localCheckbox, branch.Name, remoteCheckbox, remoteInfo, authorLabel(branch), categoryText)
+		}
+		if detail := m.branchDetail(branch, lipgloss.Width(line)); detail != "" {
+			line += " | " + helpStyle.Render(detail)
+		}
 
 		// Apply styling based on cursor and category
 		if m.Cursor == displayIndex {
@@ -735,7 +1621,12 @@ func (m Model) renderSuggestedBranches(b *strings.Builder, itemIndex *int) {
 // renderOtherActiveBranches renders the non-selectable active branches.
 // Kept internal as it's only called by View.
 func (m Model) renderOtherActiveBranches(b *strings.Builder, itemIndex *int) {
-	for _, branch := range m.OtherActiveBranches {
+	viewport := m.Viewports[SectionOther]
+	visibleEnd := min(viewport.Start+viewport.Size, len(m.OtherActiveBranches))
+	m.renderMoreAbove(b, viewport)
+	for i := viewport.Start; i < visibleEnd; i++ {
+		branch := m.OtherActiveBranches[i]
+		*itemIndex = m.sectionOffset(SectionOther) + i
 		cursor := " "
 		if m.Cursor == *itemIndex {
 			cursor = cursorStyle.Render(">")
@@ -748,11 +1639,31 @@ func (m Model) renderOtherActiveBranches(b *strings.Builder, itemIndex *int) {
 
 		remoteInfo := remoteNone
 		if branch.Remote != "" {
-			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.Name)
+			remoteInfo = fmt.Sprintf("(%s/%s)", branch.Remote, branch.RemoteBranchName())
 		}
 
 		daysOld := int(time.Since(branch.LastCommitDate).Hours() / 24)
-		categoryText := activeStyle.Render(fmt.Sprintf("Status: Active (%d days)", daysOld))
+		statusText := fmt.Sprintf("Status: Active (%d days)", daysOld)
+		if branch.InMergeGrace {
+			statusText = fmt.Sprintf("Status: Recently merged (%d days)", daysOld)
+		}
+		if branch.IsRemoteActive {
+			statusText = fmt.Sprintf("Status: Remote active (%d days)",
+				int(time.Since(branch.RemoteCommitDate).Hours()/24))
+		}
+		if branch.IsTooRecent {
+			statusText = fmt.Sprintf("Status: Too recent (%d days)", daysOld)
+		}
+		if branch.IsOtherAuthor {
+			statusText = fmt.Sprintf("Status: Other author (%s)", branch.AuthorEmail)
+		}
+		if branch.IsSnoozed {
+			statusText = "Status: Snoozed"
+			if !branch.SnoozedUntil.IsZero() {
+				statusText += " until " + branch.SnoozedUntil.Local().Format("2006-01-02")
+			}
+		}
+		categoryText := activeStyle.Render(statusText + pullRequestLabel(branch))
 
 		line := fmt.Sprintf("Local: %s %s | Remote: %s %s | %s",
 			localCheckbox, branch.Name, remoteCheckbox, remoteInfo, categoryText)
@@ -760,6 +1671,7 @@ func (m Model) renderOtherActiveBranches(b *strings.Builder, itemIndex *int) {
 		b.WriteString(cursor + " " + lineStyle.Render(line) + "\n")
 		*itemIndex++ // Increment the shared index
 	}
+	m.renderMoreBelow(b, viewport)
 }
 
 // renderSelectingState renders the branch selection view
@@ -769,7 +1681,51 @@ func (m Model) renderSelectingState(b *strings.Builder) {
 		title = warningStyle.Render("[Dry Run] ") + title
 	}
 	title += helpStyle.Render(" (Remote requires local)")
-	b.WriteString(title + "\n\n")
+	if m.Archive {
+		title += successStyle.Render(" [Archive: " + gitcmd.ArchiveName(m.archiveMode(), "<name>") + "]")
+	}
+	if m.TagPrefix != "" {
+		title += successStyle.Render(" [Tag: " + m.TagPrefix + "<name>]")
+	}
+	if m.BundleDir != "" {
+		title += successStyle.Render(" [Bundle]")
+	}
+	b.WriteString(title + "\n")
+	if m.OfflineNotice != "" {
+		b.WriteString(warningStyle.Render(m.OfflineNotice) + "\n")
+	}
+	if m.HeadNotice != "" {
+		b.WriteString(warningStyle.Render(m.HeadNotice) + "\n")
+	}
+	b.WriteString("\n")
+
+	// --- Loading line ---
+	if m.Loading {
+		b.WriteString(m.Spinner.View() + " " + helpStyle.Render(m.LoadStatus) + "\n\n")
+	}
+
+	// --- Filter line ---
+	if m.Filtering || m.FilterQuery != "" {
+		filterLine := "Filter: " + m.FilterQuery
+		if m.Filtering {
+			filterLine += cursorStyle.Render("█")
+		}
+		b.WriteString(filterLine + helpStyle.Render(fmt.Sprintf("  (%d of %d branches)",
+			len(m.ListOrder), len(m.AllAnalyzedBranches))) + "\n\n")
+	}
+
+	// --- Rename line ---
+	if branch, ok := m.cursorBranch(); ok && m.Renaming {
+		renameLine := fmt.Sprintf("Rename '%s' to: %s", branch.Name, m.RenameInput) + cursorStyle.Render("█")
+		if m.isRemoteSelectable(m.ListOrder[m.Cursor]) {
+			checkbox := checkboxUnchecked
+			if m.RenameRemote {
+				checkbox = "[x]"
+			}
+			renameLine += helpStyle.Render(fmt.Sprintf("  %s also on %s", checkbox, branch.Remote))
+		}
+		b.WriteString(renameLine + "\n\n")
+	}
 
 	itemIndex := 0 // Tracks the overall item index for cursor comparison
 
@@ -786,7 +1742,11 @@ func (m Model) renderSelectingState(b *strings.Builder) {
 		b.WriteString(separatorStyle.Render("---") + "\n")
 	}
 	if hasSuggestions {
-		b.WriteString(headingStyle.Render("Suggested Branches (Candidates):") + "\n")
+		heading := "Suggested Branches (Candidates):"
+		if m.GroupByAuthor {
+			heading = "Suggested Branches (Candidates, by author):"
+		}
+		b.WriteString(headingStyle.Render(heading) + "\n")
 		m.renderSuggestedBranches(b, &itemIndex)
 	}
 
@@ -800,90 +1760,363 @@ func (m Model) renderSelectingState(b *strings.Builder) {
 		m.renderOtherActiveBranches(b, &itemIndex)
 	}
 
-	if itemIndex == 0 { // If no branches were rendered at all
-		b.WriteString(helpStyle.Render("No branches found to display.") + "\n")
+	if itemIndex == 0 && !m.Loading { // If no branches were rendered at all (yet)
+		if m.FilterQuery != "" {
+			b.WriteString(helpStyle.Render("No branches match the filter.") + "\n")
+		} else {
+			b.WriteString(helpStyle.Render("No branches found to display.") + "\n")
+		}
+	}
+
+	if m.StatusMessage != "" {
+		b.WriteString("\n" + helpStyle.Render(m.StatusMessage) + "\n")
 	}
 
 	// Add selection summary to footer
-	footer := fmt.Sprintf("\nSelected: %d local, %d remote | Enter: Confirm | q/Ctrl+C: Quit\n",
+	footer := fmt.Sprintf(
+		"\nSelected: %d local, %d remote | /: Filter | o: By author | l: Log | a: Archive | z: Snooze | y: Copy name | "+
+			"c: Switch | R: Rename | Enter: Confirm | q/Ctrl+C: Quit\n",
 		len(m.SelectedLocal), len(m.SelectedRemote))
+	if m.Filtering {
+		footer = "\nType to filter | Enter: Apply | Esc: Clear filter | Ctrl+C: Quit\n"
+	}
+	if m.Renaming {
+		footer = "\nType the new name | Tab: Also rename on the remote | Enter: Rename | Esc: Cancel\n"
+	}
 	b.WriteString(helpStyle.Render(footer))
+	if m.UpdateNotice != "" {
+		b.WriteString(helpStyle.Render(m.UpdateNotice) + "\n")
+	}
+}
+
+// renderLogPane renders the commit log preview for the branch under the cursor.
+func (m Model) renderLogPane() string {
+	branch, ok := m.cursorBranch()
+	if !ok {
+		return logPaneStyle.Render(helpStyle.Render("No branch selected"))
+	}
+
+	var b strings.Builder
+	if branch.Description != "" {
+		b.WriteString(headingStyle.Render("Description: "+branch.Name) + "\n")
+		b.WriteString(branch.Description + "\n\n")
+	}
+	b.WriteString(headingStyle.Render("Recent commits: "+branch.Name) + "\n")
+	preview, cached := m.logPreviews[branch.Name]
+	switch {
+	case !cached:
+		b.WriteString(helpStyle.Render("Loading..."))
+	case preview.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Failed to load log: %v", preview.err)))
+	case len(preview.lines) == 0:
+		b.WriteString(helpStyle.Render("(no commits)"))
+	default:
+		b.WriteString(strings.Join(preview.lines, "\n"))
+	}
+
+	paneWidth := 50
+	if m.Width > 0 {
+		paneWidth = max(30, m.Width/3)
+	}
+	return logPaneStyle.Width(paneWidth).Render(b.String())
 }
 
 // renderConfirmingState renders the confirmation view
 func (m Model) renderConfirmingState(b *strings.Builder) {
+	if m.stepQueue != nil {
+		m.renderStepConfirmation(b)
+		return
+	}
 	title := "Confirm Actions:"
 	if m.DryRun {
 		title = warningStyle.Render("[Dry Run] ") + title
 	}
 	b.WriteString(title + "\n\n")
 	branchesToDelete := m.GetBranchesToDelete()
-	hasForceDeletes := false
+	unpushed := make(map[string]int)
+	for originalIndex := range m.SelectedLocal {
+		if originalIndex >= 0 && originalIndex < len(m.AllAnalyzedBranches) {
+			branch := m.AllAnalyzedBranches[originalIndex]
+			unpushed[branch.Name] = branch.UnpushedCommits
+		}
+	}
+
+	var safe, force, remote []string
+	unpushedBranches := 0
+	for _, bd := range branchesToDelete {
+		switch {
+		case bd.IsRemote:
+			remote = append(remote, fmt.Sprintf("  ✓ Delete remote '%s/%s'", bd.Remote, bd.Name))
+		case !bd.ForceDelete():
+			safe = append(safe, archivedText(fmt.Sprintf("  ✓ Delete '%s'", bd.Name), bd))
+		default:
+			text := archivedText(fmt.Sprintf("  ⚠️ Delete '%s'", bd.Name), bd)
+			if bd.IsMerged {
+				text += fmt.Sprintf(" (merged by %s only)", bd.MergedBy)
+			}
+			if count := unpushed[bd.Name]; count > 0 {
+				text += " [" + unpushedLabel(count) + "]"
+				unpushedBranches++
+			}
+			force = append(force, text)
+		}
+	}
 
 	if len(branchesToDelete) == 0 {
 		b.WriteString("No actions selected.\n")
 	} else {
-		b.WriteString("Local Deletions:\n")
-		hasLocal := false
-		for _, bd := range branchesToDelete {
-			if !bd.IsRemote {
-				var indicator, label string
-				var style lipgloss.Style
-
-				if bd.IsMerged {
-					indicator = "✓" // Checkmark for safe
-					label = "SAFE"
-					style = successStyle // Green color defined at the top
-				} else {
-					indicator = "⚠️" // Warning symbol for force
-					label = "FORCE"
-					style = errorStyle.Bold(true) // Bold red defined at the top
-					hasForceDeletes = true
-				}
-
-				// Format string with consistent alignment
-				formattedText := fmt.Sprintf("  %s Delete '%s' [%s]", indicator, bd.Name, label)
+		renderConfirmGroup(b, "Safe (merged, -d):", safe, successStyle)
+		renderConfirmGroup(b, "\nForce (-D, unmerged):", force, errorStyle.Bold(true))
+		renderConfirmGroup(b, "\nRemote Deletions:", remote, successStyle)
+		m.renderSkippedRemoteDeletions(b)
+		m.renderStackWarnings(b)
+		m.renderDuplicateWarnings(b)
+	}
 
-				// Render with style and add newline separately to prevent potential rendering issues
-				b.WriteString(style.Render(formattedText) + "\n")
-				hasLocal = true
-			}
-		}
-		if !hasLocal {
-			b.WriteString(helpStyle.Render("  (None)\n"))
+	if len(force) > 0 && m.Archive {
+		b.WriteString("\n" + helpStyle.Render(
+			"Force deleted branches are archived first, so their commits remain reachable.") + "\n")
+	} else if len(force) > 0 && m.TagPrefix != "" {
+		b.WriteString("\n" + helpStyle.Render(
+			"Force deleted branches are tagged as "+m.TagPrefix+"<name> first, so their commits remain reachable.") + "\n")
+	} else if len(force) > 0 && m.BundleDir != "" {
+		b.WriteString("\n" + helpStyle.Render(
+			"Force deleted branches are bundled to "+m.BundleDir+" first, so they can be restored from there.") + "\n")
+	} else if len(force) > 0 {
+		b.WriteString("\n" + warningStyle.Render(
+			"WARNING: Force deleted branches may contain unmerged work, which will be permanently lost!") + "\n")
+		if unpushedBranches > 0 {
+			b.WriteString(forceDeleteStyle.Render(fmt.Sprintf(
+				"%d of them have commits that were never pushed and exist nowhere else.", unpushedBranches)) + "\n")
 		}
+	}
 
-		b.WriteString("\nRemote Deletions:\n")
-		hasRemote := false
-		for _, bd := range branchesToDelete {
-			if bd.IsRemote {
-				// Format string for remote deletions with consistent indicator style
-				formattedText := fmt.Sprintf("  ✓ Delete remote '%s/%s'", bd.Remote, bd.Name)
-				// Apply styling and add newline separately
-				b.WriteString(successStyle.Render(formattedText) + "\n")
-				hasRemote = true
-			}
+	if len(force) > 0 {
+		b.WriteString("\n" + confirmPromptStyle.Render(fmt.Sprintf(
+			"Type %q and press Enter to force delete %d branches: ", forceConfirmWord, len(force))))
+		b.WriteString(m.ConfirmInput + cursorStyle.Render("█") + "\n")
+		b.WriteString(helpStyle.Render("e: edit selection • esc: cancel"))
+		return
+	}
+	b.WriteString("\n" + confirmPromptStyle.Render("Proceed? (y/N) "))
+	b.WriteString("\n" + helpStyle.Render("e: edit selection"))
+}
+
+// renderStepConfirmation renders the question for the branch being confirmed when each
+// selected branch is confirmed separately.
+func (m Model) renderStepConfirmation(b *strings.Builder) {
+	title := fmt.Sprintf("Confirm Each Branch (%d/%d):", m.stepIndex+1, len(m.stepQueue))
+	if m.DryRun {
+		title = warningStyle.Render("[Dry Run] ") + title
+	}
+	b.WriteString(title + "\n\n")
+
+	originalIndex := m.stepQueue[m.stepIndex]
+	branch := m.AllAnalyzedBranches[originalIndex]
+	local := gitcmd.BranchToDelete{
+		Name: branch.Name, IsMerged: branch.IsMerged, MergedBy: branch.MergedBy, Archive: m.archiveMode(),
+		BundleDir: m.BundleDir, TagPrefix: m.TagPrefix,
+	}
+	switch {
+	case !local.ForceDelete():
+		b.WriteString(successStyle.Render(archivedText(fmt.Sprintf("  ✓ Delete '%s' (merged, -d)", branch.Name), local)))
+	default:
+		status := "unmerged"
+		if branch.IsMerged {
+			status = fmt.Sprintf("merged by %s only", branch.MergedBy)
 		}
-		if !hasRemote {
-			b.WriteString(helpStyle.Render("  (None)\n"))
+		text := archivedText(fmt.Sprintf("  ⚠️ Force delete '%s' (%s, -D)", branch.Name, status), local)
+		if branch.UnpushedCommits > 0 {
+			text += " [" + unpushedLabel(branch.UnpushedCommits) + "]"
 		}
+		b.WriteString(errorStyle.Bold(true).Render(text))
+	}
+	b.WriteString("\n")
+	if m.SelectedRemote[originalIndex] && m.isRemoteSelectable(originalIndex) {
+		b.WriteString(successStyle.Render(fmt.Sprintf("  ✓ Delete remote '%s/%s'",
+			branch.Remote, branch.RemoteBranchName())) + "\n")
+	}
+	if label := branch.StackLabel(); label != "" {
+		b.WriteString(warningStyle.Render(fmt.Sprintf("  ⚠️ This is the %s", label)) + "\n")
+	}
+	if branch.Subject != "" {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  Last commit: %s — %s, %s", branch.Subject,
+			authorLabel(branch), branch.LastCommitDate.Format("2006-01-02"))) + "\n")
+	}
+	if declined := len(m.stepDeclined); declined > 0 {
+		b.WriteString("\n" + helpStyle.Render(fmt.Sprintf("%d of %d kept so far.", declined, m.stepIndex)) + "\n")
 	}
 
-	if hasForceDeletes {
-		b.WriteString("\n" + warningStyle.Render(
-			"WARNING: Branches marked with [FORCE] contain unmerged work and will be permanently lost!") + "\n")
+	b.WriteString("\n" + confirmPromptStyle.Render("Delete this branch? [y,n,a,q] "))
+	b.WriteString("\n" + helpStyle.Render(
+		"y: delete • n: keep • a: delete this and all remaining • q: keep this and all remaining • esc: cancel"))
+}
+
+// unpushedLabel flags a branch whose commits exist only in the local repository.
+func unpushedLabel(count int) string {
+	if count == 1 {
+		return "UNPUSHED: 1 commit"
 	}
+	return fmt.Sprintf("UNPUSHED: %d commits", count)
+}
 
-	b.WriteString("\n" + confirmPromptStyle.Render("Proceed? (y/N) "))
+// renderConfirmGroup writes a heading and the lines of one group of confirmed actions.
+func renderConfirmGroup(b *strings.Builder, heading string, lines []string, style lipgloss.Style) {
+	b.WriteString(heading + "\n")
+	if len(lines) == 0 {
+		b.WriteString(helpStyle.Render("  (None)\n"))
+	}
+	for _, line := range lines {
+		// Render with style and add newline separately to prevent potential rendering issues
+		b.WriteString(style.Render(line) + "\n")
+	}
+}
+
+// archivedText appends the archive target, tag and bundle of a local deletion to its description.
+func archivedText(text string, bd gitcmd.BranchToDelete) string {
+	if bd.Archive != "" {
+		text += " (archived as " + gitcmd.ArchiveName(bd.Archive, bd.Name) + ")"
+	}
+	if bd.TagPrefix != "" {
+		text += " (tagged as " + bd.TagPrefix + bd.Name + ")"
+	}
+	if bd.BundleDir != "" {
+		text += " (bundled)"
+	}
+	return text
 }
 
-// renderDeletingState renders the deletion in progress view
+// renderDeletingState renders the deletion in progress view as a live checklist
 func (m Model) renderDeletingState(b *strings.Builder) {
+	total := len(m.Results) + len(m.PendingDeletions)
 	b.WriteString(m.Spinner.View())
-	b.WriteString(" Processing deletions...")
+	b.WriteString(fmt.Sprintf(" Processing deletions... (%d/%d)", len(m.Results), total))
 	if m.DryRun {
 		b.WriteString(warningStyle.Render(" (Dry Run)"))
 	}
+	b.WriteString("\n\n")
+	if m.Interrupted {
+		b.WriteString(warningStyle.Render(m.StatusMessage) + "\n\n")
+	}
+
+	// Completed deletions
+	for _, res := range m.Results {
+		if res.Success {
+			b.WriteString(successStyle.Render("  ✓ "+deletionLabel(res.BranchName, res.IsRemote, res.RemoteName)) + "\n")
+		} else {
+			b.WriteString(errorStyle.Render("  ✗ "+deletionLabel(res.BranchName, res.IsRemote, res.RemoteName)) + "\n")
+		}
+	}
+	// In-flight and queued deletions
+	for _, bd := range m.PendingDeletions {
+		b.WriteString(helpStyle.Render("  · "+deletionLabel(bd.Name, bd.IsRemote, bd.Remote)) + "\n")
+	}
+}
+
+// removePending drops the pending deletion matching a completed result.
+// Results may arrive out of order when remote deletions run concurrently.
+func removePending(pending []gitcmd.BranchToDelete, res types.DeleteResult) []gitcmd.BranchToDelete {
+	for i, bd := range pending {
+		if bd.Name == res.BranchName && bd.IsRemote == res.IsRemote && (!bd.IsRemote || bd.Remote == res.RemoteName) {
+			return append(pending[:i:i], pending[i+1:]...)
+		}
+	}
+	return pending
+}
+
+// remoteProtectionLabel explains why the remote branch of a branch cannot be deleted.
+func remoteProtectionLabel(branch types.AnalyzedBranch) string {
+	if branch.ServerProtected {
+		return "protected on server"
+	}
+	return "remote protected"
+}
+
+// renderSkippedRemoteDeletions lists the remote branches of the selected local branches that
+// will be kept because they are protected, so the user knows why no remote deletion is queued.
+func (m Model) renderSkippedRemoteDeletions(b *strings.Builder) {
+	var skipped []string
+	for originalIndex := range m.SelectedLocal {
+		if !m.isSelectable(originalIndex) {
+			continue
+		}
+		branch := m.AllAnalyzedBranches[originalIndex]
+		if branch.Remote != "" && branch.IsRemoteProtected {
+			skipped = append(skipped, fmt.Sprintf("  - Keep remote '%s/%s' (%s)",
+				branch.Remote, branch.RemoteBranchName(), remoteProtectionLabel(branch)))
+		}
+	}
+	if len(skipped) == 0 {
+		return
+	}
+	sort.Strings(skipped)
+	b.WriteString("\nRemote Branches Kept:\n")
+	for _, line := range skipped {
+		b.WriteString(helpStyle.Render(line) + "\n")
+	}
+}
+
+// renderStackWarnings warns about the selected branches that other branches are stacked on,
+// since deleting the base of a stack confuses tools that track it.
+func (m Model) renderStackWarnings(b *strings.Builder) {
+	var bases []string
+	for originalIndex := range m.SelectedLocal {
+		if !m.isSelectable(originalIndex) {
+			continue
+		}
+		branch := m.AllAnalyzedBranches[originalIndex]
+		if label := branch.StackLabel(); label != "" {
+			bases = append(bases, fmt.Sprintf("  ⚠️ '%s' is the %s", branch.Name, label))
+		}
+	}
+	if len(bases) == 0 {
+		return
+	}
+	sort.Strings(bases)
+	b.WriteString("\nStacked Branches:\n")
+	for _, line := range bases {
+		b.WriteString(warningStyle.Render(line) + "\n")
+	}
+	b.WriteString(helpStyle.Render("Tools that track these stacks may lose their base; rebase the branches first.") + "\n")
+}
+
+// renderDuplicateWarnings warns about selected duplicates whose kept branch is selected as well,
+// since deleting both loses the commits they share.
+func (m Model) renderDuplicateWarnings(b *strings.Builder) {
+	selected := make(map[string]bool)
+	for originalIndex := range m.SelectedLocal {
+		if m.isSelectable(originalIndex) {
+			selected[m.AllAnalyzedBranches[originalIndex].Name] = true
+		}
+	}
+	var pairs []string
+	for originalIndex := range m.SelectedLocal {
+		if !m.isSelectable(originalIndex) {
+			continue
+		}
+		branch := m.AllAnalyzedBranches[originalIndex]
+		if !branch.DuplicatesMain && selected[branch.DuplicateOf] {
+			pairs = append(pairs, fmt.Sprintf("  ⚠️ '%s' is a duplicate of '%s', which is deleted too",
+				branch.Name, branch.DuplicateOf))
+		}
+	}
+	if len(pairs) == 0 {
+		return
+	}
+	sort.Strings(pairs)
+	b.WriteString("\nDuplicates:\n")
+	for _, line := range pairs {
+		b.WriteString(warningStyle.Render(line) + "\n")
+	}
+	b.WriteString(helpStyle.Render("Deleting both loses the commits they share; deselect one to keep them.") + "\n")
+}
+
+// deletionLabel describes a local or remote branch deletion for progress output.
+func deletionLabel(name string, isRemote bool, remote string) string {
+	if isRemote {
+		return fmt.Sprintf("Remote %s/%s", remote, name)
+	}
+	return "Local " + name
 }
 
 // renderResultsState renders the results view
@@ -893,6 +2126,9 @@ func (m Model) renderResultsState(b *strings.Builder) {
 		title = warningStyle.Render("[Dry Run] ") + title
 	}
 	b.WriteString(title + "\n\n")
+	if m.Interrupted {
+		b.WriteString(warningStyle.Render("Interrupted: deletions that had not started were skipped.") + "\n\n")
+	}
 	if len(m.Results) > 0 {
 		for _, res := range m.Results {
 			style := successStyle
@@ -909,12 +2145,44 @@ func (m Model) renderResultsState(b *strings.Builder) {
 			if res.Success && res.DeletedHash != "" {
 				hashInfo = fmt.Sprintf(" (was %s)", res.DeletedHash)
 			}
-			line := fmt.Sprintf("%s: %s %s%s - %s", status, branchType, res.BranchName, hashInfo, res.Message)
+			archiveInfo := ""
+			if res.ArchivedAs != "" {
+				archiveInfo = " | Archived: " + res.ArchivedAs
+			}
+			if res.Tag != "" {
+				archiveInfo += " | Tag: " + res.Tag
+			}
+			if res.BundlePath != "" {
+				archiveInfo += " | Bundle: " + res.BundlePath
+			}
+			message := res.Message
+			if gitcmd.IsAuthFailure(res) {
+				message = gitcmd.AuthFailureMessage // Explained once below instead of per branch
+			}
+			line := fmt.Sprintf("%s: %s %s%s - %s%s", status, branchType, res.BranchName, hashInfo, message, archiveInfo)
 			b.WriteString(style.Render(line) + "\n")
 		}
+		if hint := gitcmd.AuthHint(m.Results); hint != "" {
+			b.WriteString("\n" + warningStyle.Render(hint) + "\n")
+		}
 	} else {
 		b.WriteString(helpStyle.Render("(No deletion actions were performed or results available)\n"))
 	}
+
+	commands := m.recoveryCommands()
+	if len(commands) > 0 {
+		b.WriteString("\nTo recover the deleted branches, run:\n")
+		for _, command := range commands {
+			b.WriteString("  " + command + "\n")
+		}
+	}
+	if m.RecoveryStatus != "" {
+		b.WriteString("\n" + helpStyle.Render(m.RecoveryStatus) + "\n")
+	}
+	if len(commands) > 0 {
+		b.WriteString(helpStyle.Render("\nPress w to write the recovery commands to a file, any other key to exit."))
+		return
+	}
 	b.WriteString(helpStyle.Render("\nPress any key to exit."))
 }
 
@@ -925,6 +2193,9 @@ func (m Model) View() string {
 	switch m.ViewState {
 	case StateSelecting:
 		m.renderSelectingState(&b)
+		if m.ShowLog {
+			return docStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, b.String(), m.renderLogPane()))
+		}
 	case StateConfirming:
 		m.renderConfirmingState(&b)
 	case StateDeleting:
@@ -949,7 +2220,8 @@ func (m Model) GetBranchesToDelete() []gitcmd.BranchToDelete {
 		// Check if it's selectable before adding
 		if m.isSelectable(originalIndex) {
 			branches = append(branches, gitcmd.BranchToDelete{
-				Name: branchInfo.Name, IsRemote: false, Remote: "", IsMerged: branchInfo.IsMerged, Hash: branchInfo.CommitHash,
+				Name: branchInfo.Name, IsRemote: false, Remote: "", IsMerged: branchInfo.IsMerged, MergedBy: branchInfo.MergedBy,
+				Hash: branchInfo.CommitHash, Archive: m.archiveMode(), BundleDir: m.BundleDir, TagPrefix: m.TagPrefix,
 			})
 		}
 	}
@@ -958,22 +2230,28 @@ func (m Model) GetBranchesToDelete() []gitcmd.BranchToDelete {
 			continue
 		}
 		branchInfo := m.AllAnalyzedBranches[originalIndex]
-		// Check if it's selectable and has a remote before adding
-		if m.isSelectable(originalIndex) && branchInfo.Remote != "" {
+		// Check if it's selectable and its remote may be deleted before adding
+		if m.isSelectable(originalIndex) && m.isRemoteSelectable(originalIndex) {
 			branches = append(branches, gitcmd.BranchToDelete{
-				Name:     branchInfo.Name,
+				Name:     branchInfo.RemoteBranchName(),
 				IsRemote: true,
 				Remote:   branchInfo.Remote,
 				IsMerged: branchInfo.IsMerged,
+				MergedBy: branchInfo.MergedBy,
 				Hash:     branchInfo.CommitHash,
 			})
 		}
 	}
-	// Remove duplicates (e.g., if local+remote selected, only one entry per type needed by DeleteBranches)
+	// Remove duplicates (e.g., if local+remote selected, only one entry per type needed by DeleteBranches).
+	// Branches of the same name on different remotes are different branches.
+	type deletionKey struct {
+		remote, name string
+		isRemote     bool
+	}
 	finalBranches := make([]gitcmd.BranchToDelete, 0, len(branches))
-	seen := make(map[string]bool)
+	seen := make(map[deletionKey]bool)
 	for _, btd := range branches {
-		key := fmt.Sprintf("%s-%t", btd.Name, btd.IsRemote)
+		key := deletionKey{remote: btd.Remote, name: btd.Name, isRemote: btd.IsRemote}
 		if !seen[key] {
 			finalBranches = append(finalBranches, btd)
 			seen[key] = true
@@ -981,3 +2259,32 @@ func (m Model) GetBranchesToDelete() []gitcmd.BranchToDelete {
 	}
 	return finalBranches
 }
+
+// WaitForDeletions stops a deletion run that is still in progress, waits for it to finish, and
+// records all of its results. Call it after the program exits, so deletions the UI did not
+// see complete are still reported.
+func (m *Model) WaitForDeletions() {
+	if m.deletion == nil {
+		return
+	}
+	select {
+	case <-m.deletion.done:
+	default:
+		m.Interrupted = true
+		m.deletion.cancel()
+		<-m.deletion.done
+	}
+	m.Results = m.deletion.results
+	m.PendingDeletions = nil
+}
+
+// archiveMode returns the archive mode for local deletions, or "" when archiving is off.
+func (m Model) archiveMode() string {
+	if !m.Archive {
+		return ""
+	}
+	if m.ArchiveMode == "" {
+		return gitcmd.ArchiveModeRef
+	}
+	return m.ArchiveMode
+}
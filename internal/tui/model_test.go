@@ -1,15 +1,21 @@
 package tui
 
 import (
-	"context" // Added import
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors" // Added import
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/bral/git-sweep-go/internal/gitcmd"
 	"github.com/bral/git-sweep-go/internal/types"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // Helper to create a basic model for testing
@@ -403,6 +409,122 @@ func TestPagination(t *testing.T) {
 	}
 }
 
+func TestOtherAndKeySectionScrolling(t *testing.T) {
+	const keyCount, activeCount = 8, 10
+	now := time.Now()
+	var branches []types.AnalyzedBranch
+	for i := range keyCount {
+		branches = append(branches, types.AnalyzedBranch{
+			BranchInfo: types.BranchInfo{Name: fmt.Sprintf("protected-%d", i), LastCommitDate: now},
+			Category:   types.CategoryProtected, IsProtected: true,
+		})
+	}
+	branches = append(branches, types.AnalyzedBranch{
+		BranchInfo: types.BranchInfo{Name: "old", LastCommitDate: now.AddDate(0, 0, -200)},
+		Category:   types.CategoryUnmergedOld,
+	})
+	for i := range activeCount {
+		branches = append(branches, types.AnalyzedBranch{
+			BranchInfo: types.BranchInfo{Name: fmt.Sprintf("active-%d", i), LastCommitDate: now},
+			Category:   types.CategoryActive,
+		})
+	}
+	var m tea.Model = createTestModel(branches)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model, _ := m.(Model)
+	keySize, otherSize := model.Viewports[SectionKey].Size, model.Viewports[SectionOther].Size
+	if keySize >= keyCount || otherSize >= activeCount {
+		t.Fatalf("Expected both sections to scroll, got sizes %d and %d", keySize, otherSize)
+	}
+
+	view := m.View()
+	if strings.Contains(view, fmt.Sprintf("protected-%d", keySize)) ||
+		strings.Contains(view, fmt.Sprintf("active-%d", otherSize)) {
+		t.Errorf("Expected the Key and Other sections to be limited to their viewports, got:\n%s", view)
+	}
+	if strings.Count(view, "More branches below") != 2 {
+		t.Errorf("Expected a 'more below' indicator for both sections, got:\n%s", view)
+	}
+
+	// Moving down through the Key section scrolls it
+	for range keyCount - 1 {
+		m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	}
+	model, _ = m.(Model)
+	if model.Cursor != keyCount-1 || model.Viewports[SectionKey].Start != keyCount-keySize {
+		t.Errorf("Expected cursor %d with the Key section scrolled to %d, got %d and %d",
+			keyCount-1, keyCount-keySize, model.Cursor, model.Viewports[SectionKey].Start)
+	}
+	if view := model.View(); !strings.Contains(view, fmt.Sprintf("protected-%d", keyCount-1)) ||
+		!strings.Contains(view, "More branches above") {
+		t.Errorf("Expected the scrolled Key section in view, got:\n%s", view)
+	}
+
+	// Page down and End work within the Other section
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // old
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // active-0
+	m, _ = simulateSpecialKeyPress(m, tea.KeyPgDown)
+	model, _ = m.(Model)
+	wantStart := min(otherSize, activeCount-otherSize)
+	if model.Viewports[SectionOther].Start != wantStart || model.Cursor != keyCount+1+wantStart {
+		t.Errorf("Expected the Other section to page to %d with cursor %d, got %d and %d",
+			wantStart, keyCount+1+wantStart, model.Viewports[SectionOther].Start, model.Cursor)
+	}
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnd)
+	model, _ = m.(Model)
+	if model.Cursor != len(branches)-1 || !strings.Contains(model.View(), fmt.Sprintf("active-%d", activeCount-1)) {
+		t.Errorf("Expected End to select the last active branch, got cursor %d", model.Cursor)
+	}
+
+	// Moving up from the Other section back into Suggested keeps the cursor visible
+	m, _ = simulateSpecialKeyPress(m, tea.KeyHome)
+	m, _ = simulateSpecialKeyPress(m, tea.KeyUp)
+	model, _ = m.(Model)
+	if model.ListOrder[model.Cursor] != keyCount {
+		t.Errorf("Expected the cursor on 'old', got display index %d", model.Cursor)
+	}
+}
+
+func TestViewportSizing(t *testing.T) {
+	var m tea.Model = createTestModel(createManyBranches(40))
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model, _ := m.(Model)
+	suggested := model.Viewports[SectionSuggested]
+	if suggested.Size < 10 || suggested.Size >= suggested.Total {
+		t.Fatalf("Expected a tall terminal to show many but not all suggested branches, got %d of %d",
+			suggested.Size, suggested.Total)
+	}
+	if lines := strings.Count(model.View(), "\n") + 1; lines > 30 {
+		t.Errorf("Expected the view to fit in 30 lines, got %d", lines)
+	}
+
+	// Shrinking the terminal shrinks the viewport and keeps the cursor visible
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnd)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 22})
+	model, _ = m.(Model)
+	suggested = model.Viewports[SectionSuggested]
+	if suggested.Size >= 10 || suggested.Start+suggested.Size != suggested.Total {
+		t.Errorf("Expected a smaller viewport scrolled to the end, got start %d size %d of %d",
+			suggested.Start, suggested.Size, suggested.Total)
+	}
+}
+
+func TestDistributeRows(t *testing.T) {
+	lengths := map[Section]int{SectionKey: 2, SectionSuggested: 30, SectionOther: 10}
+	sizes := distributeRows(20, lengths)
+	if sizes[SectionKey]+sizes[SectionSuggested]+sizes[SectionOther] != 20 {
+		t.Errorf("Expected all 20 rows to be used, got %v", sizes)
+	}
+	if sizes[SectionKey] < 1 || sizes[SectionSuggested] <= sizes[SectionOther] {
+		t.Errorf("Expected rows in proportion to section lengths, got %v", sizes)
+	}
+
+	if sizes := distributeRows(50, lengths); !reflect.DeepEqual(sizes, lengths) {
+		t.Errorf("Expected every section to fit, got %v", sizes)
+	}
+}
+
 // --- TestTuiStateTransitions (Refactored) ---
 
 // Define command types for easier assertion
@@ -492,9 +614,12 @@ func TestTuiStateTransitions(t *testing.T) {
 			expectedCmd:   cmdTypeBatch, // Expecting the batch with deletion + tick
 		},
 		{
-			name:          "Deleting: resultsMsg -> Results",
-			initialState:  StateDeleting,
-			inputMsg:      resultsMsg{results: results}, // Use internal resultsMsg
+			name:         "Deleting: last deleteResultMsg -> Results",
+			initialState: StateDeleting,
+			setupModel: func(m *Model) {
+				m.PendingDeletions = []gitcmd.BranchToDelete{{Name: "test"}}
+			},
+			inputMsg:      deleteResultMsg{result: results[0]}, // Use internal deleteResultMsg
 			expectedState: StateResults,
 			expectedCmd:   cmdTypeNil,
 		},
@@ -558,11 +683,11 @@ func TestTuiStateTransitions(t *testing.T) {
 				t.Errorf("Expected command type %v, got %v", tc.expectedCmd, actualCmdType)
 			}
 
-			// Specific check for resultsMsg case
-			if _, ok := tc.inputMsg.(resultsMsg); ok {
+			// Specific check for deleteResultMsg case
+			if _, ok := tc.inputMsg.(deleteResultMsg); ok {
 				if len(mAsserted.Results) != len(results) ||
 					(len(results) > 0 && mAsserted.Results[0].BranchName != results[0].BranchName) {
-					t.Errorf("Results not stored correctly in model after resultsMsg. Got: %+v", mAsserted.Results)
+					t.Errorf("Results not stored correctly in model after deleteResultMsg. Got: %+v", mAsserted.Results)
 				}
 			}
 		})
@@ -711,6 +836,457 @@ func TestRemoteAutoSelection(t *testing.T) {
 	}
 }
 
+func TestRemoteProtectedBranch(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].IsRemoteProtected = true // feat/merged
+	m := createTestModel(branches)
+
+	// Move to feat/merged and select it; the remote must not be auto-selected
+	mUpdated, _ := simulateSpecialKeyPress(m, tea.KeyDown)
+	m = mUpdated.(Model)
+	mUpdated, _ = simulateKeyPress(m, " ")
+	m = mUpdated.(Model)
+	originalIndex := m.ListOrder[1]
+	if !m.SelectedLocal[originalIndex] || m.SelectedRemote[originalIndex] {
+		t.Fatalf("Expected only the local branch to be selected, got local=%t remote=%t",
+			m.SelectedLocal[originalIndex], m.SelectedRemote[originalIndex])
+	}
+
+	// Tab must not select it either
+	mUpdated, _ = simulateKeyPress(m, "tab")
+	m = mUpdated.(Model)
+	if m.SelectedRemote[originalIndex] {
+		t.Error("Expected the protected remote branch not to be selectable")
+	}
+
+	for _, btd := range m.GetBranchesToDelete() {
+		if btd.IsRemote {
+			t.Errorf("Expected no remote deletion, got %+v", btd)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "remote protected") {
+		t.Errorf("Expected the view to explain the remote protection, got:\n%s", view)
+	}
+}
+
+func TestOfflineRemoteSelection(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.Offline = true
+	m.OfflineNotice = "Offline: remote branches may be stale."
+
+	// Selecting feat/merged must not select its remote branch, and neither may Tab
+	mUpdated, _ := simulateSpecialKeyPress(m, tea.KeyDown)
+	mUpdated, _ = simulateKeyPress(mUpdated, " ")
+	mUpdated, _ = simulateKeyPress(mUpdated, "tab")
+	m = mUpdated.(Model)
+	originalIndex := m.ListOrder[1]
+	if !m.SelectedLocal[originalIndex] || len(m.SelectedRemote) != 0 {
+		t.Fatalf("Expected only the local branch to be selected offline, got local=%t remote=%v",
+			m.SelectedLocal[originalIndex], m.SelectedRemote)
+	}
+	if view := m.View(); !strings.Contains(view, m.OfflineNotice) {
+		t.Errorf("Expected the offline notice in the view, got:\n%s", view)
+	}
+}
+
+func TestHeadNotice(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.HeadNotice = "HEAD is detached at abc1234 (fix)."
+	if view := m.View(); !strings.Contains(view, m.HeadNotice) {
+		t.Errorf("Expected the detached HEAD notice below the title, got:\n%s", view)
+	}
+}
+
+func TestServerProtectedBranchConfirmation(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].ServerProtected = true // feat/merged
+	branches[1].IsRemoteProtected = true
+	m := createTestModel(branches)
+
+	mUpdated, _ := simulateSpecialKeyPress(m, tea.KeyDown)
+	m = mUpdated.(Model)
+	mUpdated, _ = simulateKeyPress(m, " ")
+	m = mUpdated.(Model)
+	mUpdated, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	m = mUpdated.(Model)
+	if m.ViewState != StateConfirming {
+		t.Fatalf("Expected confirmation state, got %v", m.ViewState)
+	}
+	if view := m.View(); !strings.Contains(view, "Keep remote 'origin/feat/merged' (protected on server)") {
+		t.Errorf("Expected the confirmation to explain the kept remote branch, got:\n%s", view)
+	}
+}
+
+func TestPullRequestMergeNeedsForceConfirmation(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].MergedBy = types.MergedByPullRequest // feat/merged
+	var m tea.Model = createTestModel(branches)
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, " ")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	view := m.View()
+	for _, want := range []string{"Force (-D, unmerged):", "Delete 'feat/merged' (merged by pull request only)",
+		`Type "force"`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q on the confirmation screen, got:\n%s", want, view)
+		}
+	}
+	for _, deletion := range m.(Model).GetBranchesToDelete() {
+		if args := gitcmd.DeleteArgs(deletion); !deletion.IsRemote && args[1] != "-D" {
+			t.Errorf("Expected the local branch to be force deleted, got %q", args)
+		}
+	}
+}
+
+func TestForceDeleteConfirmation(t *testing.T) {
+	var m tea.Model = createTestModel(createSampleBranches())
+
+	// Select feat/merged and feat/unmerged-old
+	for range 2 {
+		m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+		m, _ = simulateKeyPress(m, " ")
+	}
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	view := m.View()
+	for _, want := range []string{"Safe (merged, -d):", "Delete 'feat/merged'", "Force (-D, unmerged):",
+		"Delete 'feat/unmerged-old'", "Remote Deletions:", `Type "force"`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q on the confirmation screen, got:\n%s", want, view)
+		}
+	}
+
+	// y is not enough, and a wrong word is rejected
+	m, _ = simulateKeyPress(m, "y")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	if model, _ := m.(Model); model.ViewState != StateConfirming || model.ConfirmInput != "" {
+		t.Fatalf("Expected the confirmation to stay open, got state %v and input %q", model.ViewState, model.ConfirmInput)
+	}
+
+	// e returns to editing with the selection intact
+	m, _ = simulateKeyPress(m, "e")
+	if model, _ := m.(Model); model.ViewState != StateSelecting || len(model.SelectedLocal) != 2 {
+		t.Fatalf("Expected e to return to the selection, got state %v with %d selected",
+			model.ViewState, len(model.SelectedLocal))
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	m, _ = simulateKeyPress(m, "forcx")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyBackspace)
+	m, _ = simulateKeyPress(m, "e")
+	m, cmd := simulateSpecialKeyPress(m, tea.KeyEnter)
+	if model, _ := m.(Model); model.ViewState != StateDeleting || cmd == nil {
+		t.Errorf("Expected typing %q to start the deletions, got state %v", forceConfirmWord, model.ViewState)
+	}
+}
+
+func TestConfirmEach(t *testing.T) {
+	model := createTestModel(createSampleBranches())
+	model.ConfirmEach = true
+	var m tea.Model = model
+
+	// Select the three suggested branches
+	for range 3 {
+		m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+		m, _ = simulateKeyPress(m, " ")
+	}
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	model = m.(Model)
+	first := model.AllAnalyzedBranches[model.ListOrder[1]].Name
+	if view := m.View(); !strings.Contains(view, "Confirm Each Branch (1/3)") ||
+		!strings.Contains(view, "'"+first+"'") || !strings.Contains(view, "[y,n,a,q]") {
+		t.Fatalf("Expected the first branch to be confirmed on its own, got:\n%s", view)
+	}
+
+	// Esc returns to the selection unchanged
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEsc)
+	if model, _ := m.(Model); model.ViewState != StateSelecting || len(model.SelectedLocal) != 3 {
+		t.Fatalf("Expected esc to return to the selection, got state %v with %d selected",
+			model.ViewState, len(model.SelectedLocal))
+	}
+
+	// Keep the first branch, delete the others
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	m, _ = simulateKeyPress(m, "n")
+	if view := m.View(); !strings.Contains(view, "Confirm Each Branch (2/3)") || !strings.Contains(view, "1 of 1 kept") {
+		t.Fatalf("Expected the second branch after n, got:\n%s", view)
+	}
+	m, _ = simulateKeyPress(m, "y")
+	m, cmd := simulateKeyPress(m, "y")
+	model = m.(Model)
+	if model.ViewState != StateDeleting || cmd == nil || len(model.PendingDeletions) == 0 {
+		t.Fatalf("Expected the deletions to start after the last answer, got state %v", model.ViewState)
+	}
+	for _, bd := range model.PendingDeletions {
+		if bd.Name == first {
+			t.Errorf("Expected %s to be kept, got deletions %+v", first, model.PendingDeletions)
+		}
+	}
+}
+
+func TestConfirmEachAllAndQuit(t *testing.T) {
+	selectAll := func() tea.Model {
+		model := createTestModel(createSampleBranches())
+		model.ConfirmEach = true
+		var m tea.Model = model
+		for range 3 {
+			m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+			m, _ = simulateKeyPress(m, " ")
+		}
+		m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+		return m
+	}
+
+	// a approves the rest, but unmerged branches it skipped over still need the typed word
+	m, _ := simulateKeyPress(selectAll(), "a")
+	if model, _ := m.(Model); model.ViewState != StateConfirming || len(model.SelectedLocal) != 3 ||
+		!strings.Contains(m.View(), `Type "force"`) {
+		t.Errorf("Expected the typed confirmation after a, got state %v:\n%s", model.ViewState, m.View())
+	}
+
+	// Once the unmerged branch was shown, a deletes right away
+	m, _ = simulateKeyPress(selectAll(), "y")
+	m, cmd := simulateKeyPress(m, "a")
+	if model, _ := m.(Model); model.ViewState != StateDeleting || cmd == nil || len(model.SelectedLocal) != 3 {
+		t.Errorf("Expected a to delete the shown and remaining branches, got state %v", model.ViewState)
+	}
+
+	// q keeps this and the remaining branches; with none approved nothing is deleted
+	m, _ = simulateKeyPress(selectAll(), "q")
+	if model, _ := m.(Model); model.ViewState != StateSelecting || len(model.SelectedLocal) != 0 ||
+		model.StatusMessage == "" {
+		t.Errorf("Expected q to keep every branch, got state %v with %d selected",
+			model.ViewState, len(model.SelectedLocal))
+	}
+}
+
+func TestUnpushedWarning(t *testing.T) {
+	branches := createSampleBranches()
+	branches[2].UnpushedCommits = 2 // feat/unmerged-old
+	var m tea.Model = createTestModel(branches)
+	if view := m.View(); !strings.Contains(view, "UNPUSHED: 2 commits") {
+		t.Errorf("Expected the unpushed branch to be flagged in the list, got:\n%s", view)
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, " ")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	view := m.View()
+	if !strings.Contains(view, "Delete 'feat/unmerged-old' [UNPUSHED: 2 commits]") ||
+		!strings.Contains(view, "1 of them have commits that were never pushed") {
+		t.Errorf("Expected the confirmation to flag the unpushed branch, got:\n%s", view)
+	}
+}
+
+func TestStackedWarning(t *testing.T) {
+	branches := createSampleBranches()
+	branches[2].StackedBranches = []string{"feat/active"} // feat/unmerged-old
+	var m tea.Model = createTestModel(branches)
+	if view := m.View(); !strings.Contains(view, "· base of feat/active") {
+		t.Errorf("Expected the stacked branch to be named in the list, got:\n%s", view)
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, " ")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	if view := m.View(); !strings.Contains(view, "'feat/unmerged-old' is the base of feat/active") {
+		t.Errorf("Expected the confirmation to warn about the stack, got:\n%s", view)
+	}
+}
+
+func TestCommitDetailColumn(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].Subject = "feat: add the merged feature with a rather long subject line" // feat/merged
+	branches[1].AuthorName = "Jane Doe"
+	var m tea.Model = createTestModel(branches)
+
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	if view := m.View(); !strings.Contains(view, branches[1].Subject+" — Jane Doe") {
+		t.Errorf("Expected the full commit detail on a wide terminal, got:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 110, Height: 40})
+	view := m.View()
+	if !strings.Contains(view, "feat: add") || !strings.Contains(view, "…") || strings.Contains(view, "Jane Doe") {
+		t.Errorf("Expected a truncated commit detail, got:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if width := lipgloss.Width(strings.TrimRight(line, " ")); strings.Contains(line, "feat/merged |") && width > 110 {
+			t.Errorf("Expected the branch line to fit the terminal, got %d cells: %q", width, line)
+		}
+	}
+
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 60, Height: 40})
+	if view := m.View(); strings.Contains(view, "feat: add") {
+		t.Errorf("Expected no commit detail on a narrow terminal, got:\n%s", view)
+	}
+}
+
+func TestBranchDescription(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].Subject = "feat: merged" // feat/merged
+	branches[1].Description = "Spike for the export job\nKeep until Q3"
+	var m tea.Model = createTestModel(branches)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+
+	view := m.View()
+	if !strings.Contains(view, "Note: Spike for the export job") || strings.Contains(view, "feat: merged") {
+		t.Errorf("Expected the description instead of the commit subject, got:\n%s", view)
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, "l")
+	if view := m.View(); !strings.Contains(view, "Keep until Q3") {
+		t.Errorf("Expected the full description in the log pane, got:\n%s", view)
+	}
+}
+
+func TestCopyBranchName(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	var clipboard bytes.Buffer
+	m := createTestModel(createSampleBranches())
+	m.Clipboard = &clipboard
+
+	tm, _ := simulateSpecialKeyPress(m, tea.KeyDown) // feat/merged
+	tm, cmd := simulateKeyPress(tm, "y")
+	if cmd == nil {
+		t.Fatal("Expected y to copy the branch name")
+	}
+	tm, _ = tm.Update(cmd())
+	if want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("feat/merged")) + "\a"; clipboard.String() != want {
+		t.Errorf("Expected the OSC 52 sequence %q, got %q", want, clipboard.String())
+	}
+	if model, _ := tm.(Model); model.StatusMessage != "Copied 'feat/merged' to the clipboard" {
+		t.Errorf("Unexpected status message %q", model.StatusMessage)
+	}
+}
+
+func TestSwitchToBranch(t *testing.T) {
+	var m tea.Model = createTestModel(createSampleBranches())
+
+	// The current branch in the Key section cannot be switched to
+	if _, cmd := simulateKeyPress(m, "c"); cmd != nil {
+		t.Error("Expected c to be ignored on the current branch")
+	}
+
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // feat/merged
+	if _, cmd := simulateKeyPress(m, "c"); cmd == nil {
+		t.Fatal("Expected c to switch to the branch")
+	}
+
+	m, cmd := m.Update(switchMsg{branch: "feat/merged", err: gitcmd.ErrDirtyWorkingTree})
+	model, _ := m.(Model)
+	if cmd != nil || model.SwitchedTo != "" || !strings.Contains(model.StatusMessage, "uncommitted changes") {
+		t.Errorf("Expected the refusal to be reported, got status %q", model.StatusMessage)
+	}
+
+	m, cmd = m.Update(switchMsg{branch: "feat/merged"})
+	model, _ = m.(Model)
+	if checkCmdType(cmd) != cmdTypeQuit || model.SwitchedTo != "feat/merged" {
+		t.Errorf("Expected to quit after switching, got %q", model.SwitchedTo)
+	}
+}
+
+func TestRenameBranch(t *testing.T) {
+	var m tea.Model = createTestModel(createSampleBranches())
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // feat/merged, on origin
+	m, _ = simulateKeyPress(m, "R")
+	model, _ := m.(Model)
+	if !model.Renaming || model.RenameInput != "feat/merged" {
+		t.Fatalf("Expected R to start renaming with the current name, got %q", model.RenameInput)
+	}
+
+	for range len("merged") {
+		m, _ = simulateSpecialKeyPress(m, tea.KeyBackspace)
+	}
+	m, _ = simulateKeyPress(m, "done")
+	m, _ = simulateSpecialKeyPress(m, tea.KeyTab)
+	if view := m.View(); !strings.Contains(view, "Rename 'feat/merged' to: feat/done") ||
+		!strings.Contains(view, "[x] also on origin") {
+		t.Errorf("Expected the rename prompt, got:\n%s", view)
+	}
+	m, cmd := simulateSpecialKeyPress(m, tea.KeyEnter)
+	if cmd == nil {
+		t.Fatal("Expected Enter to rename the branch")
+	}
+	if model, _ = m.(Model); model.Renaming {
+		t.Error("Expected the rename prompt to close")
+	}
+
+	m, _ = m.Update(renameMsg{oldName: "feat/merged", newName: "feat/done", remote: "origin"})
+	model, _ = m.(Model)
+	branch, _ := model.cursorBranch()
+	if branch.Name != "feat/done" || branch.Upstream != "origin/feat/done" {
+		t.Errorf("Expected the branch to be renamed, got %q tracking %q", branch.Name, branch.Upstream)
+	}
+	if !strings.Contains(model.StatusMessage, "locally and on origin") {
+		t.Errorf("Unexpected status %q", model.StatusMessage)
+	}
+
+	m, _ = m.Update(renameMsg{oldName: "feat/done", newName: "main", err: errors.New("already exists")})
+	if model, _ = m.(Model); !strings.Contains(model.StatusMessage, "Could not rename 'feat/done'") {
+		t.Errorf("Expected the failure to be reported, got %q", model.StatusMessage)
+	}
+}
+
+func TestSafeDeleteConfirmation(t *testing.T) {
+	var m tea.Model = createTestModel(createSampleBranches())
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, " ") // feat/merged
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	if view := m.View(); !strings.Contains(view, "Proceed? (y/N)") || strings.Contains(view, `Type "force"`) {
+		t.Errorf("Expected a y/N prompt without force deletions, got:\n%s", view)
+	}
+	m, _ = simulateKeyPress(m, "e")
+	if model, _ := m.(Model); model.ViewState != StateSelecting {
+		t.Errorf("Expected e to return to the selection, got state %v", model.ViewState)
+	}
+}
+
+func TestPreselect(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	// Protected and active branches are never preselected, even if they match
+	m.Preselect(func(b types.AnalyzedBranch) bool { return b.IsMerged || b.Name == "feat/active" })
+
+	wantLocal := map[int]bool{1: true, 4: true} // feat/merged, feat/merged-no-remote
+	if !reflect.DeepEqual(m.SelectedLocal, wantLocal) {
+		t.Errorf("SelectedLocal = %v, want %v", m.SelectedLocal, wantLocal)
+	}
+	if wantRemote := map[int]bool{1: true}; !reflect.DeepEqual(m.SelectedRemote, wantRemote) {
+		t.Errorf("SelectedRemote = %v, want %v", m.SelectedRemote, wantRemote)
+	}
+}
+
+func TestMaxDeleteBlocksConfirmation(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.MaxDelete = 1
+
+	// Select feat/merged and feat/unmerged-old
+	for range 2 {
+		mUpdated, _ := simulateSpecialKeyPress(m, tea.KeyDown)
+		m = mUpdated.(Model)
+		mUpdated, _ = simulateKeyPress(m, " ")
+		m = mUpdated.(Model)
+	}
+	mUpdated, _ := simulateSpecialKeyPress(m, tea.KeyEnter)
+	m = mUpdated.(Model)
+	if m.ViewState != StateSelecting || !strings.Contains(m.StatusMessage, "max_delete (1)") {
+		t.Fatalf("Expected confirmation to be refused, got state %v and status %q", m.ViewState, m.StatusMessage)
+	}
+
+	// Within the limit the confirmation opens
+	mUpdated, _ = simulateKeyPress(m, " ")
+	m = mUpdated.(Model)
+	mUpdated, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	m = mUpdated.(Model)
+	if m.ViewState != StateConfirming {
+		t.Errorf("Expected confirmation with one selected branch, got state %v", m.ViewState)
+	}
+}
+
 // TestRemoteStyleRendering tests that the rendering logic applies the appropriate styles
 // This is a more complex test that checks the actual rendered output
 func TestRemoteStyleRendering(t *testing.T) {
@@ -763,3 +1339,554 @@ func TestRemoteStyleRendering(t *testing.T) {
 		t.Errorf("Expected cursor view and selected view to be different due to styling changes")
 	}
 }
+
+func TestPullRequestRendering(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].PullRequest = &types.PullRequest{Number: 42, State: types.PullRequestMerged}
+	m := createTestModel(branches)
+
+	view := m.View()
+	if !strings.Contains(view, "PR #42 (merged)") {
+		t.Errorf("Expected view to contain pull request label, got:\n%s", view)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	testCases := []struct {
+		query, name string
+		want        bool
+	}{
+		{"", "anything", true},
+		{"merged", "feat/merged", true},
+		{"fmrg", "feat/merged", true},
+		{"FEAT", "feat/merged", true},
+		{"gemr", "feat/merged", false},
+		{"xyz", "feat/merged", false},
+	}
+	for _, tc := range testCases {
+		if got := fuzzyMatch(tc.query, tc.name); got != tc.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %t, want %t", tc.query, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilterSelectionPersistence(t *testing.T) {
+	branches := createSampleBranches()
+	var m tea.Model = createTestModel(branches)
+
+	// Select feat/merged (original index 1) before filtering
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, _ = simulateKeyPress(m, " ")
+
+	// Open the filter and type a query matching only the unmerged branch
+	m, _ = simulateKeyPress(m, "/")
+	for _, r := range "unmerged" {
+		m, _ = simulateKeyPress(m, string(r))
+	}
+	model, ok := m.(Model)
+	if !ok {
+		t.Fatalf("Type assertion failed for m.(Model)")
+	}
+	if !model.Filtering {
+		t.Fatal("Expected filter input to be active")
+	}
+	if len(model.ListOrder) != 1 || model.ListOrder[0] != 2 {
+		t.Fatalf("Expected only original index 2 to be visible, got %v", model.ListOrder)
+	}
+	if !strings.Contains(model.View(), "Filter: unmerged") {
+		t.Error("Expected view to show the filter query")
+	}
+
+	// Apply the filter and select the visible branch
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEnter)
+	m, _ = simulateKeyPress(m, " ")
+	model, _ = m.(Model)
+	if model.Filtering {
+		t.Error("Expected Enter to leave filter input mode")
+	}
+	if !model.SelectedLocal[1] || !model.SelectedLocal[2] {
+		t.Errorf("Expected both selections to be kept, got %v", model.SelectedLocal)
+	}
+
+	// Clearing the filter restores the full list with selections intact
+	m, _ = simulateSpecialKeyPress(m, tea.KeyEsc)
+	model, _ = m.(Model)
+	if model.FilterQuery != "" || len(model.ListOrder) != len(branches) {
+		t.Errorf("Expected filter to be cleared, got query %q and %d items", model.FilterQuery, len(model.ListOrder))
+	}
+	if len(model.SelectedLocal) != 2 {
+		t.Errorf("Expected 2 local selections after clearing filter, got %d", len(model.SelectedLocal))
+	}
+}
+
+func TestLogPreviewPane(t *testing.T) {
+	branches := createSampleBranches()
+	var m tea.Model = createTestModel(branches)
+
+	// Move to feat/merged and open the preview pane
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown)
+	m, cmd := simulateKeyPress(m, "l")
+	model, ok := m.(Model)
+	if !ok {
+		t.Fatalf("Type assertion failed for m.(Model)")
+	}
+	if !model.ShowLog {
+		t.Fatal("Expected log preview to be visible after pressing 'l'")
+	}
+	if cmd == nil {
+		t.Fatal("Expected a command to load the commit log")
+	}
+	if !strings.Contains(model.View(), "Loading...") {
+		t.Error("Expected preview pane to show a loading message before the log arrives")
+	}
+
+	// Deliver the log and verify it is rendered and cached
+	m, _ = m.Update(logMsg{branch: "feat/merged", lines: []string{"abc1234 Add feature"}})
+	model, _ = m.(Model)
+	view := model.View()
+	if !strings.Contains(view, "Recent commits: feat/merged") || !strings.Contains(view, "abc1234 Add feature") {
+		t.Errorf("Expected preview pane to show the commit log, got:\n%s", view)
+	}
+	if cmd := model.previewCmd(); cmd != nil {
+		t.Error("Expected no reload for a cached branch")
+	}
+
+	// Toggling again hides the pane
+	m, _ = simulateKeyPress(m, "l")
+	model, _ = m.(Model)
+	if model.ShowLog || strings.Contains(model.View(), "Recent commits:") {
+		t.Error("Expected log preview to be hidden after pressing 'l' again")
+	}
+}
+
+func TestStreamingDeletionProgress(t *testing.T) {
+	branches := createSampleBranches()
+	m := createTestModel(branches)
+	m.ViewState = StateDeleting
+	m.PendingDeletions = []gitcmd.BranchToDelete{
+		{Name: "feat/merged", IsMerged: true},
+		{Name: "feat/merged", IsRemote: true, Remote: "origin"},
+	}
+	m.deletionResults = make(chan types.DeleteResult)
+
+	// First result arrives: still deleting, next deletion queued
+	mUpdated, cmd := m.Update(deleteResultMsg{result: types.DeleteResult{BranchName: "feat/merged", Success: true}})
+	model, ok := mUpdated.(Model)
+	if !ok {
+		t.Fatalf("Update did not return a Model")
+	}
+	if model.ViewState != StateDeleting {
+		t.Fatalf("Expected to remain in StateDeleting, got %v", model.ViewState)
+	}
+	if cmd == nil {
+		t.Fatal("Expected a command for the next deletion")
+	}
+	view := model.View()
+	if !strings.Contains(view, "(1/2)") || !strings.Contains(view, "✓ Local feat/merged") {
+		t.Errorf("Expected live checklist in view, got:\n%s", view)
+	}
+	if !strings.Contains(view, "Remote origin/feat/merged") {
+		t.Errorf("Expected pending remote deletion in view, got:\n%s", view)
+	}
+
+	// Second result completes the run
+	mUpdated, _ = model.Update(deleteResultMsg{
+		result: types.DeleteResult{BranchName: "feat/merged", IsRemote: true, RemoteName: "origin"},
+	})
+	model, _ = mUpdated.(Model)
+	if model.ViewState != StateResults || len(model.Results) != 2 {
+		t.Errorf("Expected StateResults with 2 results, got %v with %d", model.ViewState, len(model.Results))
+	}
+}
+
+func TestRemovePendingOutOfOrder(t *testing.T) {
+	pending := []gitcmd.BranchToDelete{
+		{Name: "a", IsRemote: true, Remote: "origin"},
+		{Name: "b", IsRemote: true, Remote: "origin"},
+		{Name: "b"},
+	}
+	pending = removePending(pending, types.DeleteResult{BranchName: "b", IsRemote: true, RemoteName: "origin"})
+	if len(pending) != 2 || pending[0].Name != "a" || pending[1].Name != "b" || pending[1].IsRemote {
+		t.Errorf("Unexpected pending list after removal: %+v", pending)
+	}
+
+	// Closing the result stream finishes the run even if entries are left over
+	m := createTestModel(createSampleBranches())
+	m.ViewState = StateDeleting
+	m.PendingDeletions = pending
+	mUpdated, _ := m.Update(deletionDoneMsg{})
+	model, _ := mUpdated.(Model)
+	if model.ViewState != StateResults {
+		t.Errorf("Expected StateResults after deletionDoneMsg, got %v", model.ViewState)
+	}
+}
+
+func TestInterruptDeletion(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.ViewState = StateDeleting
+	m.PendingDeletions = []gitcmd.BranchToDelete{{Name: "feat/merged"}, {Name: "feat/unmerged-old"}}
+	cancelled := false
+	m.deletion = &deletionRun{cancel: func() { cancelled = true }, done: make(chan struct{})}
+
+	// The first Ctrl+C stops the run but keeps the UI open to show the outcome
+	mUpdated, cmd := simulateSpecialKeyPress(m, tea.KeyCtrlC)
+	model, _ := mUpdated.(Model)
+	if !cancelled || !model.Interrupted || model.ViewState != StateDeleting || cmd != nil {
+		t.Fatalf("Expected the run to be cancelled without quitting, got state %v, cmd %v", model.ViewState, cmd)
+	}
+
+	// The run winds down; its results replace the streamed ones
+	model.deletion.results = []types.DeleteResult{
+		{BranchName: "feat/merged", Success: true, Message: "Successfully deleted"},
+		{BranchName: "feat/unmerged-old", Message: "Skipped: context canceled"},
+	}
+	close(model.deletion.done)
+	mUpdated, _ = model.Update(deletionDoneMsg{})
+	model, _ = mUpdated.(Model)
+	if model.ViewState != StateResults || len(model.Results) != 2 {
+		t.Fatalf("Expected StateResults with 2 results, got %v with %d", model.ViewState, len(model.Results))
+	}
+	if view := model.View(); !strings.Contains(view, "Interrupted") || !strings.Contains(view, "Skipped") {
+		t.Errorf("Expected the interruption in the results view, got:\n%s", view)
+	}
+}
+
+func TestWaitForDeletions(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.ViewState = StateDeleting
+	run := &deletionRun{done: make(chan struct{})}
+	run.cancel = func() {
+		run.results = []types.DeleteResult{{BranchName: "feat/merged", Message: "Skipped: context canceled"}}
+		close(run.done)
+	}
+	m.deletion = run
+
+	m.WaitForDeletions()
+	if !m.Interrupted || len(m.Results) != 1 {
+		t.Errorf("Expected the unfinished run to be interrupted and its result recorded, got %+v", m.Results)
+	}
+}
+
+func TestSnoozeKey(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.SnoozeFor = 30 * 24 * time.Hour
+	m.Cursor = 1 // feat/merged
+
+	var tm tea.Model = m
+	tm, _ = simulateKeyPress(tm, " ")
+	tm, cmd := simulateKeyPress(tm, "z")
+	if cmd == nil {
+		t.Fatal("Expected a command persisting the snooze")
+	}
+	model, _ := tm.(Model)
+
+	branch := model.AllAnalyzedBranches[1]
+	if !branch.IsSnoozed || branch.Category != types.CategoryActive || branch.SnoozedUntil.IsZero() {
+		t.Errorf("Expected feat/merged to be snoozed and moved out of the suggestions, got %+v", branch)
+	}
+	if model.SelectedLocal[1] || model.SelectedRemote[1] {
+		t.Error("Expected selections of the snoozed branch to be cleared")
+	}
+	if len(model.SuggestedBranches) != 2 || len(model.OtherActiveBranches) != 2 {
+		t.Errorf("Expected 2 suggested and 2 other branches, got %d and %d",
+			len(model.SuggestedBranches), len(model.OtherActiveBranches))
+	}
+	if view := model.View(); !strings.Contains(view, "Status: Snoozed until") {
+		t.Errorf("Expected snoozed status in view, got:\n%s", view)
+	}
+
+	// Protected branches cannot be snoozed
+	model.Cursor = 0
+	if _, cmd := simulateKeyPress(model, "z"); cmd != nil {
+		t.Error("Expected no command when snoozing a protected branch")
+	}
+
+	tm, _ = model.Update(snoozeMsg{branch: "feat/merged", err: errors.New("disk full")})
+	model, _ = tm.(Model)
+	if !strings.Contains(model.View(), "Could not save snooze for 'feat/merged': disk full") {
+		t.Error("Expected the snooze failure to be shown")
+	}
+}
+
+func TestArchiveToggle(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.Cursor = 1 // feat/merged
+
+	var tm tea.Model = m
+	tm, _ = simulateKeyPress(tm, " ")
+	tm, _ = simulateKeyPress(tm, "a")
+	model, _ := tm.(Model)
+	if !model.Archive {
+		t.Fatal("Expected 'a' to enable archiving")
+	}
+
+	for _, bd := range model.GetBranchesToDelete() {
+		wantMode := gitcmd.ArchiveModeRef
+		if bd.IsRemote {
+			wantMode = "" // Only local deletions are archived
+		}
+		if bd.Archive != wantMode {
+			t.Errorf("Unexpected archive mode for %+v, want %q", bd, wantMode)
+		}
+	}
+
+	model.ViewState = StateConfirming
+	if view := model.View(); !strings.Contains(view, "archived as refs/archive/feat/merged") {
+		t.Errorf("Expected archive target on confirmation screen, got:\n%s", view)
+	}
+
+	model.ViewState = StateResults
+	model.Results = []types.DeleteResult{
+		{BranchName: "feat/merged", Success: true, Message: "Successfully deleted", ArchivedAs: "refs/archive/feat/merged"},
+	}
+	if view := model.View(); !strings.Contains(view, "Archived: refs/archive/feat/merged") {
+		t.Errorf("Expected archive column in results, got:\n%s", view)
+	}
+}
+
+func TestRecoveryCommands(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := createTestModel(createSampleBranches())
+	m.ViewState = StateResults
+	m.Results = []types.DeleteResult{
+		{BranchName: "feat/merged", Success: true, DeletedHash: "abc123"},
+		{BranchName: "feat/merged", IsRemote: true, RemoteName: "origin", Success: true, DeletedHash: "abc123"},
+		{BranchName: "feat/unmerged-old", Message: "Failed: boom"},
+	}
+
+	view := m.View()
+	for _, want := range []string{"git branch feat/merged abc123", "git push origin abc123:refs/heads/feat/merged"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected recovery command %q in the results, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "feat/unmerged-old abc") {
+		t.Errorf("Expected no recovery command for the failed deletion, got:\n%s", view)
+	}
+
+	tm, cmd := simulateKeyPress(m, "w")
+	if cmd == nil {
+		t.Fatal("Expected w to write the recovery commands")
+	}
+	tm, _ = tm.Update(cmd())
+	model, _ := tm.(Model)
+	path := strings.TrimPrefix(model.RecoveryStatus, "Recovery commands written to ")
+	content, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(content), "git branch feat/merged abc123") {
+		t.Errorf("Expected the recovery script at %q, got %q (%v)", path, content, err)
+	}
+	if _, cmd := simulateKeyPress(model, "x"); cmd == nil {
+		t.Error("Expected any other key to quit")
+	}
+}
+
+func TestAuthFailureHint(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.ViewState = StateResults
+	denied := "Failed: git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository."
+	m.Results = []types.DeleteResult{
+		{BranchName: "feat/merged", IsRemote: true, RemoteName: "origin", Message: denied},
+		{BranchName: "feat/unmerged-old", IsRemote: true, RemoteName: "origin", Message: denied},
+	}
+
+	view := m.View()
+	if strings.Contains(view, "publickey") {
+		t.Errorf("Expected the raw error to be replaced, got:\n%s", view)
+	}
+	if strings.Count(view, "could not authenticate") != 3 || !strings.Contains(view, "2 remote deletions on origin") {
+		t.Errorf("Expected one hint for both failures, got:\n%s", view)
+	}
+}
+
+func TestBundleShown(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.BundleDir = "/tmp/bundles/repo"
+	if view := m.View(); !strings.Contains(view, "[Bundle]") {
+		t.Errorf("Expected the title to show that bundles are written, got:\n%s", view)
+	}
+	m.SelectedLocal[1] = true // feat/merged
+	for _, bd := range m.GetBranchesToDelete() {
+		if !bd.IsRemote && bd.BundleDir != m.BundleDir {
+			t.Errorf("Expected %s to be bundled to %s, got %q", bd.Name, m.BundleDir, bd.BundleDir)
+		}
+	}
+
+	m.ViewState = StateResults
+	m.Results = []types.DeleteResult{{
+		BranchName: "feat/merged", Success: true, Message: "Deleted", BundlePath: "/tmp/bundles/repo/feat/merged.bundle",
+	}}
+	if view := m.View(); !strings.Contains(view, "Bundle: /tmp/bundles/repo/feat/merged.bundle") {
+		t.Errorf("Expected the bundle path in the results, got:\n%s", view)
+	}
+}
+
+func TestTagShown(t *testing.T) {
+	m := createTestModel(createSampleBranches())
+	m.TagPrefix = "sweep/"
+	m.SelectedLocal[2] = true // feat/unmerged-old
+	if view := m.View(); !strings.Contains(view, "[Tag: sweep/<name>]") {
+		t.Errorf("Expected the title to show the tag prefix, got:\n%s", view)
+	}
+
+	tm, _ := simulateSpecialKeyPress(m, tea.KeyEnter)
+	if view := tm.View(); !strings.Contains(view, "(tagged as sweep/feat/unmerged-old)") ||
+		strings.Contains(view, "permanently lost") {
+		t.Errorf("Expected the confirmation to mention the tag, got:\n%s", view)
+	}
+
+	m.ViewState = StateResults
+	m.Results = []types.DeleteResult{{BranchName: "feat/unmerged-old", Success: true, Tag: "sweep/feat/unmerged-old"}}
+	if view := m.View(); !strings.Contains(view, "Tag: sweep/feat/unmerged-old") {
+		t.Errorf("Expected the tag in the results, got:\n%s", view)
+	}
+}
+
+func TestDuplicateLabel(t *testing.T) {
+	branches := createSampleBranches()
+	branches[4].DuplicateOf = "feat/merged" // feat/merged-no-remote
+	branches[4].Category = types.CategoryUnmergedOld
+	m := createTestModel(branches)
+
+	if view := m.View(); !strings.Contains(view, "Status: Unmerged (91 days) · duplicate of feat/merged") {
+		t.Errorf("Expected the duplicate to be labeled, got:\n%s", view)
+	}
+}
+
+func TestDuplicateWarning(t *testing.T) {
+	branches := createSampleBranches()
+	branches[4].DuplicateOf = "feat/unmerged-old" // feat/merged-no-remote
+	branches[4].Category = types.CategoryUnmergedOld
+	m := createTestModel(branches)
+	m.ViewState = StateConfirming
+
+	m.SelectedLocal = map[int]bool{4: true}
+	if view := m.View(); strings.Contains(view, "Duplicates:") {
+		t.Errorf("Expected no warning while the kept branch is not selected, got:\n%s", view)
+	}
+	m.SelectedLocal = map[int]bool{2: true, 4: true}
+	want := "'feat/merged-no-remote' is a duplicate of 'feat/unmerged-old', which is deleted too"
+	if view := m.View(); !strings.Contains(view, want) {
+		t.Errorf("Expected a warning when both duplicates are selected, got:\n%s", view)
+	}
+}
+
+func TestCategoryRuleLabel(t *testing.T) {
+	branches := createSampleBranches()
+	branches[3].Category, branches[3].CategoryRule = types.CategoryUnmergedOld, "feat/*" // feat/active
+	m := createTestModel(branches)
+
+	if view := m.View(); !strings.Contains(view, "Status: Unmerged (60 days) · rule feat/*") {
+		t.Errorf("Expected the rule to be named, got:\n%s", view)
+	}
+}
+
+func TestRiskOrderAndBadge(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].RiskScore, branches[1].Risk = 0, types.RiskSafe      // feat/merged
+	branches[2].RiskScore, branches[2].Risk = 46, types.RiskModerate // feat/unmerged-old
+	branches[4].RiskScore, branches[4].Risk = 10, types.RiskSafe     // feat/merged-no-remote
+	m := createTestModel(branches)
+
+	var names []string
+	for _, branch := range m.SuggestedBranches {
+		names = append(names, branch.Name)
+	}
+	if want := []string{"feat/merged", "feat/merged-no-remote", "feat/unmerged-old"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected the safest candidates first, got %v, want %v", names, want)
+	}
+	if view := m.View(); !strings.Contains(view, "Status: Unmerged (91 days) [Moderate]") {
+		t.Errorf("Expected the risk badge, got:\n%s", view)
+	}
+}
+
+func TestGroupByAuthor(t *testing.T) {
+	branches := createSampleBranches()
+	branches[1].AuthorName = "Zoe" // feat/merged
+	branches[2].AuthorName = "Ann" // feat/unmerged-old
+	branches[4].AuthorName = "Zoe" // feat/merged-no-remote
+	branches[2].RiskScore = 50     // Listed last until grouped
+	var m tea.Model = createTestModel(branches)
+	m, _ = simulateSpecialKeyPress(m, tea.KeyDown) // feat/merged
+
+	m, _ = simulateKeyPress(m, "o")
+	model, _ := m.(Model)
+	var names []string
+	for _, branch := range model.SuggestedBranches {
+		names = append(names, branch.Name)
+	}
+	if want := []string{"feat/unmerged-old", "feat/merged", "feat/merged-no-remote"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected candidates grouped by author, got %v, want %v", names, want)
+	}
+	if branch, _ := model.cursorBranch(); branch.Name != "feat/merged" {
+		t.Errorf("Expected the cursor to stay on feat/merged, got %s", branch.Name)
+	}
+	view := model.View()
+	if !strings.Contains(view, "Candidates, by author") || !strings.Contains(view, "| Author: Ann | ") {
+		t.Errorf("Expected the authors to be shown, got:\n%s", view)
+	}
+
+	m, _ = simulateKeyPress(m, "o")
+	if model, _ = m.(Model); model.GroupByAuthor || model.SuggestedBranches[0].Name != "feat/merged" {
+		t.Error("Expected o to toggle the grouping off again")
+	}
+}
+
+func TestLogRecordsCollected(t *testing.T) {
+	records := make(chan string, 2)
+	m := createTestModel(createSampleBranches())
+	m.LogRecords = records
+
+	records <- "level=WARN msg=first"
+	close(records)
+
+	tm, cmd := m.Update(logRecordMsg(<-records))
+	if cmd == nil {
+		t.Fatal("Expected a command waiting for the next log record")
+	}
+	if msg := cmd(); msg != nil {
+		t.Errorf("Expected no message once the channel is closed, got %#v", msg)
+	}
+	model, _ := tm.(Model)
+	if len(model.Logs) != 1 || model.Logs[0] != "level=WARN msg=first" {
+		t.Errorf("Expected the record to be collected, got %v", model.Logs)
+	}
+}
+
+func TestUpdateNoticeFooter(t *testing.T) {
+	model := createTestModel(createManyBranches(40))
+	model.UpdateNotice = "git-sweep v9.9.9 is available"
+	var m tea.Model = model
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	view := m.View()
+	if !strings.Contains(view, model.UpdateNotice) {
+		t.Errorf("Expected the update notice below the footer, got:\n%s", view)
+	}
+	if lines := strings.Count(view, "\n") + 1; lines > 30 {
+		t.Errorf("Expected the view with the notice to fit in 30 lines, got %d", lines)
+	}
+}
+
+func TestGetBranchesToDeleteKeepsSameNameOnDifferentRemotes(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -91)
+	m := createTestModel([]types.AnalyzedBranch{
+		{
+			BranchInfo: types.BranchInfo{Name: "fix", LastCommitDate: old, Remote: "origin", RemoteBranch: "fix"},
+			Category:   types.CategoryMergedOld, IsMerged: true, MergedBy: types.MergedByAncestry,
+		},
+		{
+			BranchInfo: types.BranchInfo{Name: "fork-fix", LastCommitDate: old, Remote: "fork", RemoteBranch: "fix"},
+			Category:   types.CategoryMergedOld, IsMerged: true, MergedBy: types.MergedByAncestry,
+		},
+	})
+	m.SelectedRemote = map[int]bool{0: true, 1: true}
+
+	remotes := make(map[string]bool)
+	for _, btd := range m.GetBranchesToDelete() {
+		if btd.IsRemote && btd.Name == "fix" {
+			remotes[btd.Remote] = true
+		}
+	}
+	if !remotes["origin"] || !remotes["fork"] {
+		t.Errorf("Expected 'fix' to be deleted on both origin and fork, got %v", remotes)
+	}
+}
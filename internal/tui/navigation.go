@@ -0,0 +1,83 @@
+package tui
+
+// The branch list is displayed as three sections (Key, Suggested, Other), each scrolled through
+// its own viewport. The cursor is a display index into ListOrder, which holds the sections in
+// that order. All cursor movement goes through navigate, and every change of the cursor or of
+// the viewport sizes ends with ensureCursorVisible, so the sections behave the same way.
+
+// sectionOffset returns the display index of the first branch of the section.
+func (m Model) sectionOffset(section Section) int {
+	switch section {
+	case SectionKey:
+		return 0
+	case SectionSuggested:
+		return len(m.KeyBranches)
+	default:
+		return len(m.KeyBranches) + len(m.SuggestedBranches)
+	}
+}
+
+// cursorSection returns the section holding the cursor.
+func (m Model) cursorSection() Section {
+	if m.Cursor < 0 || m.Cursor >= len(m.ListOrder) {
+		return SectionSuggested
+	}
+	return m.getBranchSection(m.ListOrder[m.Cursor])
+}
+
+// navigate moves the cursor for a navigation key and reports whether key is one. Up and down
+// step across section boundaries; PgUp/PgDn scroll the section under the cursor by a page and
+// move the cursor along, and Home/End jump to the first or last branch of that section.
+func (m *Model) navigate(key string) bool {
+	total := len(m.ListOrder)
+	if total == 0 {
+		return false
+	}
+	section := m.cursorSection()
+	first := m.sectionOffset(section)
+	viewport := m.Viewports[section]
+	last := first + max(0, viewport.Total-1)
+	page := max(1, viewport.Size)
+
+	switch key {
+	case "up", "k":
+		m.Cursor = max(0, m.Cursor-1)
+	case "down", "j":
+		m.Cursor = min(total-1, m.Cursor+1)
+	case "pgup":
+		viewport.Start = max(0, viewport.Start-page)
+		m.Viewports[section] = viewport
+		m.Cursor = max(first, m.Cursor-page)
+	case "pgdown":
+		viewport.Start = min(max(0, viewport.Total-viewport.Size), viewport.Start+page)
+		m.Viewports[section] = viewport
+		m.Cursor = min(last, m.Cursor+page)
+	case "home":
+		m.Cursor = first
+	case "end":
+		m.Cursor = last
+	default:
+		return false
+	}
+	m.ensureCursorVisible()
+	return true
+}
+
+// ensureCursorVisible scrolls the viewport of the section holding the cursor just far enough to
+// show the cursor, e.g. after it moved across a section boundary or the terminal was resized,
+// and makes that section the current one.
+func (m *Model) ensureCursorVisible() {
+	if m.Cursor < 0 || m.Cursor >= len(m.ListOrder) {
+		return
+	}
+	section := m.cursorSection()
+	m.CurrentSection = section
+	sectionIndex := m.Cursor - m.sectionOffset(section)
+	viewport := m.Viewports[section]
+	if sectionIndex < viewport.Start {
+		viewport.Start = sectionIndex
+	} else if sectionIndex >= viewport.Start+viewport.Size {
+		viewport.Start = max(0, sectionIndex-viewport.Size+1)
+	}
+	m.Viewports[section] = viewport
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNavigateStaysWithinSection(t *testing.T) {
+	var m tea.Model = createTestModel(createManyBranches(20))
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	model, _ := m.(Model)
+	first := model.sectionOffset(SectionSuggested)
+	last := first + model.Viewports[SectionSuggested].Total - 1
+
+	model.Cursor = first
+	model.ensureCursorVisible()
+	for _, key := range []string{"pgup", "home"} {
+		if !model.navigate(key) || model.Cursor != first || model.Viewports[SectionSuggested].Start != 0 {
+			t.Errorf("%s at the top of the section: cursor %d, start %d", key, model.Cursor,
+				model.Viewports[SectionSuggested].Start)
+		}
+	}
+
+	for range model.Viewports[SectionSuggested].Total {
+		model.navigate("pgdown")
+	}
+	viewport := model.Viewports[SectionSuggested]
+	if model.Cursor != last || viewport.Start != viewport.Total-viewport.Size {
+		t.Errorf("Expected repeated PgDn to stop at cursor %d, start %d; got %d, %d",
+			last, viewport.Total-viewport.Size, model.Cursor, viewport.Start)
+	}
+
+	model.navigate("home")
+	model.navigate("end")
+	if model.Cursor != last || model.CurrentSection != SectionSuggested {
+		t.Errorf("Expected End to select the last suggested branch %d, got %d", last, model.Cursor)
+	}
+
+	if model.navigate("x") {
+		t.Error("Expected navigate to ignore non-navigation keys")
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Theme is the color palette of the interface.
+type Theme struct {
+	Accent  lipgloss.TerminalColor // Cursor and selected branches
+	Muted   lipgloss.TerminalColor // Help text, active branches, separators and the log pane border
+	Prompt  lipgloss.TerminalColor // Confirmation prompts and the spinner
+	Warning lipgloss.TerminalColor // Unmerged branches and warnings
+	Success lipgloss.TerminalColor // Merged branches and successful deletions
+	Error   lipgloss.TerminalColor // Force deletions and errors
+	Dimmed  lipgloss.TerminalColor // Remotes that cannot be selected
+	Faint   bool                   // Render de-emphasized text faint
+}
+
+var (
+	// DarkTheme is the default palette, made for terminals with a dark background.
+	DarkTheme = Theme{
+		Accent: lipgloss.Color("212"), Muted: lipgloss.Color("241"), Prompt: lipgloss.Color("205"),
+		Warning: lipgloss.Color("202"), Success: lipgloss.Color("78"), Error: lipgloss.Color("196"),
+		Dimmed: lipgloss.Color("240"), Faint: true,
+	}
+	// LightTheme uses darker colors that stay readable on a light background.
+	LightTheme = Theme{
+		Accent: lipgloss.Color("127"), Muted: lipgloss.Color("242"), Prompt: lipgloss.Color("125"),
+		Warning: lipgloss.Color("166"), Success: lipgloss.Color("28"), Error: lipgloss.Color("160"),
+		Dimmed: lipgloss.Color("247"), Faint: true,
+	}
+	// HighContrastTheme uses the bright basic colors, which every terminal scheme keeps apart from
+	// its background, and never renders text faint.
+	HighContrastTheme = Theme{
+		Accent: lipgloss.Color("14"), Muted: lipgloss.Color("7"), Prompt: lipgloss.Color("13"),
+		Warning: lipgloss.Color("11"), Success: lipgloss.Color("10"), Error: lipgloss.Color("9"),
+		Dimmed: lipgloss.Color("8"), Faint: false,
+	}
+)
+
+func init() {
+	SetTheme(DarkTheme)
+}
+
+// ResolveTheme returns the theme selected by the configuration. With noColor set, as requested
+// by the NO_COLOR environment variable, all colors are dropped and only text attributes such as
+// bold and underline remain.
+func ResolveTheme(cfg config.Config, noColor bool) Theme {
+	var theme Theme
+	switch cfg.Theme {
+	case config.ThemeLight:
+		theme = LightTheme
+	case config.ThemeHighContrast:
+		theme = HighContrastTheme
+	case config.ThemeCustom:
+		theme = DarkTheme
+		override := func(color *lipgloss.TerminalColor, value string) {
+			if value != "" {
+				*color = lipgloss.Color(value)
+			}
+		}
+		override(&theme.Accent, cfg.ThemeColors.Accent)
+		override(&theme.Muted, cfg.ThemeColors.Muted)
+		override(&theme.Prompt, cfg.ThemeColors.Prompt)
+		override(&theme.Warning, cfg.ThemeColors.Warning)
+		override(&theme.Success, cfg.ThemeColors.Success)
+		override(&theme.Error, cfg.ThemeColors.Error)
+		override(&theme.Dimmed, cfg.ThemeColors.Dimmed)
+	default:
+		theme = DarkTheme
+	}
+	if noColor {
+		none := lipgloss.NoColor{}
+		theme.Accent, theme.Muted, theme.Prompt, theme.Dimmed = none, none, none, none
+		theme.Warning, theme.Success, theme.Error = none, none, none
+	}
+	return theme
+}
+
+// SetTheme restyles the interface with the theme. It must be called before the program starts.
+func SetTheme(theme Theme) {
+	selectedStyle = lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	cursorStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	helpStyle = lipgloss.NewStyle().Foreground(theme.Muted)
+	confirmPromptStyle = lipgloss.NewStyle().Foreground(theme.Prompt).Bold(true)
+	warningStyle = lipgloss.NewStyle().Foreground(theme.Warning)
+	successStyle = lipgloss.NewStyle().Foreground(theme.Success)
+	errorStyle = lipgloss.NewStyle().Foreground(theme.Error)
+	spinnerStyle = lipgloss.NewStyle().Foreground(theme.Prompt)
+	forceDeleteStyle = errorStyle.Bold(true).Reverse(true)
+	protectedStyle = lipgloss.NewStyle().Faint(theme.Faint)
+	activeStyle = helpStyle.Faint(theme.Faint)
+	separatorStyle = helpStyle.Faint(theme.Faint)
+	remoteDimmedStyle = lipgloss.NewStyle().Foreground(theme.Dimmed).Faint(theme.Faint)
+	remoteNoneStyle = remoteDimmedStyle.Italic(true)
+
+	progressStyle = helpStyle
+	progressMarkerStyle = selectedStyle
+	progressInfoStyle = helpStyle
+	logPaneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Muted).
+		Padding(0, 1).
+		MarginLeft(2)
+	riskStyleMap = map[types.Risk]lipgloss.Style{
+		types.RiskSafe:     successStyle,
+		types.RiskModerate: warningStyle,
+		types.RiskRisky:    errorStyle.Bold(true),
+	}
+	categoryStyleMap = map[types.BranchCategory]lipgloss.Style{
+		// Protected category is handled separately (keyBranches)
+		types.CategoryActive:      activeStyle, // Style for the label text only
+		types.CategoryMergedOld:   successStyle,
+		types.CategoryUnmergedOld: warningStyle,
+	}
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestResolveTheme(t *testing.T) {
+	if theme := ResolveTheme(config.Config{}, false); theme != DarkTheme {
+		t.Errorf("Expected the dark theme by default, got %+v", theme)
+	}
+	if theme := ResolveTheme(config.Config{Theme: config.ThemeLight}, false); theme != LightTheme {
+		t.Errorf("Expected the light theme, got %+v", theme)
+	}
+	if theme := ResolveTheme(config.Config{Theme: config.ThemeHighContrast}, false); theme.Faint {
+		t.Error("Expected the high-contrast theme to avoid faint text")
+	}
+
+	custom := ResolveTheme(config.Config{
+		Theme: config.ThemeCustom, ThemeColors: config.ThemeColors{Accent: "#ff87d7"},
+	}, false)
+	if custom.Accent != lipgloss.Color("#ff87d7") || custom.Muted != DarkTheme.Muted {
+		t.Errorf("Expected the custom accent over the dark theme, got %+v", custom)
+	}
+
+	noColor := ResolveTheme(config.Config{Theme: config.ThemeLight}, true)
+	for _, color := range []lipgloss.TerminalColor{
+		noColor.Accent, noColor.Muted, noColor.Prompt, noColor.Warning, noColor.Success, noColor.Error, noColor.Dimmed,
+	} {
+		if color != (lipgloss.NoColor{}) {
+			t.Errorf("Expected NO_COLOR to drop all colors, got %+v", noColor)
+			break
+		}
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	defer SetTheme(DarkTheme)
+
+	SetTheme(LightTheme)
+	if selectedStyle.GetForeground() != LightTheme.Accent {
+		t.Errorf("Expected the light accent on selected branches, got %v", selectedStyle.GetForeground())
+	}
+	if color := categoryStyleMap[types.CategoryMergedOld].GetForeground(); color != LightTheme.Success {
+		t.Errorf("Expected the light success color on merged branches, got %v", color)
+	}
+	SetTheme(HighContrastTheme)
+	if activeStyle.GetFaint() || protectedStyle.GetFaint() {
+		t.Error("Expected no faint styles in the high-contrast theme")
+	}
+}
@@ -1,15 +1,88 @@
 // Package types defines shared data structures used across the git-sweep application.
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // BranchInfo holds raw Git data for a local branch.
 type BranchInfo struct {
-	Name           string
-	Upstream       string // e.g., "origin/feature/x"
-	Remote         string // e.g., "origin"
-	LastCommitDate time.Time
-	CommitHash     string
+	Name            string
+	Upstream        string // e.g., "origin/feature/x"
+	Remote          string // e.g., "origin"
+	RemoteBranch    string // Name of the upstream branch on Remote, e.g. "feature/x"
+	LastCommitDate  time.Time
+	CommitHash      string
+	AuthorEmail     string       // Author email of the last commit, without angle brackets
+	AuthorName      string       // Author name of the last commit
+	Subject         string       // Subject line of the last commit
+	Description     string       // Notes from 'git config branch.<name>.description', if any
+	WorktreePath    string       // Path of the worktree that has this branch checked out, if any
+	PullRequest     *PullRequest // Associated pull request from the hosting provider, if known
+	ServerProtected bool         // Branch protection on the hosting provider rejects deleting it there
+	Snoozed         bool         // Branch was snoozed by the user and should not be suggested
+	SnoozedUntil    time.Time    // When the snooze expires (zero means indefinitely)
+	DuplicateOf     string       // Branch pointing at the same commit that is kept instead of this one
+	DuplicatesMain  bool         // DuplicateOf is the primary main branch
+	StackedBranches []string     // Local branches stacked on this one: their history contains its unmerged tip
+	MergedInto      string       // Protected branch other than the primary main branch this one is merged into
+	// Outcome of the git cherry and git diff merge checks recorded by an earlier run for the same
+	// branch and main tips; CachedMergedBy is empty if the branch was found not to be merged
+	HasCachedMergeCheck bool
+	CachedMergedBy      MergeMethod
+	// Committer date of the tip of the remote-tracking branch Upstream, which a teammate may have
+	// pushed to since the local branch was last updated; zero if there is none
+	RemoteCommitDate time.Time
+}
+
+// RemoteBranchName returns the name of the branch on its remote, which may differ from the
+// local name, e.g. for a local "fix" tracking "upstream/bugfix/123".
+func (b BranchInfo) RemoteBranchName() string {
+	if b.RemoteBranch != "" {
+		return b.RemoteBranch
+	}
+	return b.Name
+}
+
+// DuplicateLabel describes which branch points at the same commit as this one, e.g.
+// "identical to main" or "duplicate of feature/x", or returns "" if no other branch does.
+func (b BranchInfo) DuplicateLabel() string {
+	switch {
+	case b.DuplicateOf == "":
+		return ""
+	case b.DuplicatesMain:
+		return "identical to " + b.DuplicateOf
+	default:
+		return "duplicate of " + b.DuplicateOf
+	}
+}
+
+// StackLabel names the branches stacked on this one, e.g. "base of feature/b, feature/c", or
+// returns "" if there are none.
+func (b BranchInfo) StackLabel() string {
+	if len(b.StackedBranches) == 0 {
+		return ""
+	}
+	return "base of " + strings.Join(b.StackedBranches, ", ")
+}
+
+// PullRequestState is the state of a pull request as reported by the hosting provider.
+type PullRequestState string
+
+// Pull request state constants.
+const (
+	PullRequestOpen   PullRequestState = "open"
+	PullRequestClosed PullRequestState = "closed" // Closed without being merged
+	PullRequestMerged PullRequestState = "merged"
+)
+
+// PullRequest summarizes the pull request associated with a branch.
+type PullRequest struct {
+	Number  int
+	State   PullRequestState
+	URL     string
+	HeadSHA string // Commit the pull request's head branch pointed at last
 }
 
 // BranchCategory classifies a branch after analysis.
@@ -17,9 +90,12 @@ type BranchCategory string
 
 // Branch category constants.
 const (
-	// CategoryProtected indicates a branch protected by config, the primary main branch, or the current branch.
+	// CategoryProtected indicates a branch protected by config, the primary main branch, the current branch,
+	// or a branch checked out in a worktree.
 	CategoryProtected BranchCategory = "Protected"
-	// CategoryActive indicates a branch that is not protected, not merged, and not old.
+	// CategoryActive indicates a branch that is not protected, not merged, and not old,
+	// or a candidate the user has snoozed, that belongs to another author, that was merged too recently,
+	// or whose remote branch changed recently.
 	CategoryActive BranchCategory = "Active"
 	// CategoryMergedOld indicates a branch that is merged into the primary main branch.
 	CategoryMergedOld BranchCategory = "MergedOld"
@@ -27,14 +103,54 @@ const (
 	CategoryUnmergedOld BranchCategory = "UnmergedOld"
 )
 
+// MergeMethod tells how a branch was found to be merged.
+type MergeMethod string
+
+// Merge method constants, from the most to the least conclusive.
+const (
+	MergedByAncestry    MergeMethod = "ancestry"     // The tip is reachable from the main or a protected branch
+	MergedByPullRequest MergeMethod = "pull request" // The hosting provider reports its pull request as merged
+	MergedByCherry      MergeMethod = "cherry"       // Every commit has an equivalent patch in the main branch
+	MergedByNoChanges   MergeMethod = "no changes"   // The branch changes nothing since it diverged
+)
+
+// Risk rates how much could be lost by deleting a candidate branch.
+type Risk string
+
+// Risk level constants.
+const (
+	RiskSafe     Risk = "Safe"
+	RiskModerate Risk = "Moderate"
+	RiskRisky    Risk = "Risky"
+)
+
 // AnalyzedBranch contains processed branch info for UI and decisions.
 type AnalyzedBranch struct {
-	BranchInfo  // Embedded raw info
-	IsMerged    bool
-	IsOldByAge  bool
-	IsProtected bool
-	IsCurrent   bool // Added flag for current branch
-	Category    BranchCategory
+	BranchInfo        // Embedded raw info
+	IsMerged          bool
+	MergedBy          MergeMethod // How IsMerged was detected; empty if the branch is not merged
+	IsOldByAge        bool
+	IsProtected       bool
+	IsRemoteProtected bool // May be deleted locally but never on its remote (protected_remote_patterns)
+	IsCurrent         bool // Added flag for current branch
+	IsSnoozed         bool // Hidden from suggestions by a snooze still in effect
+	IsOtherAuthor     bool // Last commit is not by one of the configured only_authors
+	InMergeGrace      bool // Merged, but still within the merged_age_days grace period
+	IsTooRecent       bool // Last commit is younger than min_age_days, so the branch is never suggested
+	IsRemoteActive    bool // The remote-tracking branch got newer commits within the age threshold
+	UnpushedCommits   int  // Commits on no remote-tracking branch; not counted for ancestry-merged candidates
+	AheadCommits      int  // Commits the primary main branch lacks; only counted for unmerged candidates
+	RiskScore         int  // 0 to 100, higher means deleting the candidate is more likely to lose work
+	Risk              Risk // Level of RiskScore; empty for branches that are not candidates
+	Category          BranchCategory
+	CategoryRule      string // Match pattern of the rules entry that changed Category, if any
+	RanMergeCheck     bool   // The git cherry and git diff merge checks ran, rather than coming from the cache
+}
+
+// ForceDelete reports whether deleting the branch locally takes 'git branch -D': it is unmerged,
+// or merged by something other than ancestry, which 'git branch -d' does not recognize.
+func (b AnalyzedBranch) ForceDelete() bool {
+	return !b.IsMerged || (b.MergedBy != "" && b.MergedBy != MergedByAncestry)
 }
 
 // DeleteResult holds outcome of one delete attempt.
@@ -46,4 +162,7 @@ type DeleteResult struct {
 	Message     string // Success message or error details
 	Cmd         string // The command attempted
 	DeletedHash string // Commit hash of the branch before deletion (if successful)
+	ArchivedAs  string // Ref or tag the branch was archived to before deletion, if any
+	BundlePath  string // Bundle file the branch was backed up to before deletion, if any
+	Tag         string // Tag created at the branch tip before deletion, if any
 }
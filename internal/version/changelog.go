@@ -0,0 +1,59 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// changelogLines is the number of changes shown before the rest is summarized
+const changelogLines = 8
+
+var (
+	// commitPrefix matches the abbreviated commit hash goreleaser starts changelog entries with.
+	commitPrefix = regexp.MustCompile(`^[0-9a-f]{7,40}\s+`)
+	// markdownLink matches a Markdown link, whose text is kept.
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// Changelog condenses release notes to at most maxLines changes: the bullet points of the
+// notes without commit hashes and Markdown markup, or their first lines if they have no
+// bullet points. If there are more, the last line says how many were left out.
+func Changelog(notes string, maxLines int) []string {
+	var bullets, text []string
+	for _, line := range strings.Split(strings.ReplaceAll(notes, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "**Full Changelog**") {
+			continue
+		}
+		if item, ok := cutBullet(line); ok {
+			bullets = append(bullets, plainText(commitPrefix.ReplaceAllString(item, "")))
+		} else {
+			text = append(text, plainText(line))
+		}
+	}
+	changes := bullets
+	if len(changes) == 0 {
+		changes = text
+	}
+	if len(changes) <= maxLines {
+		return changes
+	}
+	return append(changes[:maxLines-1:maxLines-1], fmt.Sprintf("... and %d more changes", len(changes)-maxLines+1))
+}
+
+// cutBullet returns the text of a Markdown list item.
+func cutBullet(line string) (string, bool) {
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if item, ok := strings.CutPrefix(line, marker); ok {
+			return strings.TrimSpace(item), true
+		}
+	}
+	return "", false
+}
+
+// plainText removes the Markdown links, emphasis and code markup of a line.
+func plainText(line string) string {
+	line = markdownLink.ReplaceAllString(line, "$1")
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+}
@@ -0,0 +1,54 @@
+package version
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChangelog(t *testing.T) {
+	goreleaser := "## Changelog\n" +
+		"* 1a2b3c4 add(cli): **print** timings with `--profile`\n" +
+		"* 5d6e7f8901 fix: see [issue 12](https://github.com/bral/git-sweep-go/issues/12)\r\n" +
+		"\n" +
+		"**Full Changelog**: https://github.com/bral/git-sweep-go/compare/v1.0.0...v1.1.0\n"
+	tests := []struct {
+		name     string
+		notes    string
+		maxLines int
+		want     []string
+	}{
+		{"Goreleaser notes", goreleaser, 8, []string{"add(cli): print timings with --profile", "fix: see issue 12"}},
+		{"Prose notes", "# v1.1.0\nFaster analysis.\n\nNew update command.\n", 8,
+			[]string{"Faster analysis.", "New update command."}},
+		{"Too many changes", "- a\n- b\n- c\n- d\n", 3, []string{"a", "b", "... and 2 more changes"}},
+		{"Empty notes", "", 8, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changelog(tt.notes, tt.maxLines); !slices.Equal(got, tt.want) {
+				t.Errorf("Changelog() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintChangelog(t *testing.T) {
+	var buf bytes.Buffer
+	if PrintChangelog(&buf, "") || buf.Len() != 0 {
+		t.Errorf("Expected nothing for empty notes, got %q", buf.String())
+	}
+	printed := PrintChangelog(&buf, "- Faster analysis\n")
+	if !printed || !strings.Contains(buf.String(), "What's new:\n• Faster analysis\n") {
+		t.Errorf("Unexpected changelog: %q", buf.String())
+	}
+}
+
+func TestUpdateNotice(t *testing.T) {
+	notice := UpdateNotice("v1.2.0", "v1.3.0")
+	if strings.Contains(notice, "\n") || !strings.Contains(notice, "v1.3.0") ||
+		!strings.Contains(notice, "v1.2.0") || !strings.Contains(notice, "git-sweep update") {
+		t.Errorf("UpdateNotice() = %q, want a single line naming both versions and the update command", notice)
+	}
+}
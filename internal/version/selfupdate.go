@@ -0,0 +1,414 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	// checksumsAsset is the release asset listing the SHA-256 checksum of every archive.
+	checksumsAsset = "checksums.txt"
+	// signatureAsset is the release asset holding the base64 Ed25519 signature of checksumsAsset.
+	signatureAsset = checksumsAsset + ".sig"
+	// maxAssetSize bounds the download of a release asset.
+	maxAssetSize = 100 << 20
+	// DownloadTimeout bounds the whole download of a release asset.
+	DownloadTimeout = 5 * time.Minute
+)
+
+// signingKey is the base64 Ed25519 public key release checksums are signed with, set at build
+// time with -ldflags "-X github.com/bral/git-sweep-go/internal/version.signingKey=...". Builds
+// without it cannot verify releases and refuse to update themselves.
+var signingKey string
+
+// ReleaseAsset is a file attached to a GitHub release.
+type ReleaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Updater replaces the running binary with the one published in the latest GitHub release.
+type Updater struct {
+	Client      *http.Client // HTTP client for the API and the downloads; a default client if nil
+	ReleaseURL  string       // API URL of the latest release; GitHubReleaseURL if empty
+	ReleasesURL string       // API URL listing the releases; GitHubReleasesURL if empty
+	Channel     string       // "prerelease" also installs prereleases; stable releases only otherwise
+	Executable  string       // Binary to replace; the running executable if empty
+	GOOS        string       // Operating system of the asset to install; runtime.GOOS if empty
+	GOARCH      string       // Architecture of the asset to install; runtime.GOARCH if empty
+
+	// PublicKey verifies the signature of the release checksums; the key built into the
+	// binary if nil. Without any key, no update is installed.
+	PublicKey ed25519.PublicKey
+}
+
+// UpdateResult describes the outcome of Updater.Update.
+type UpdateResult struct {
+	Previous string // Version that was running
+	Latest   string // Tag of the latest release
+	Updated  bool   // Whether the binary was replaced; false if it was up to date
+}
+
+// Latest returns the newest release of the updater's channel and whether Update would
+// install it instead of currentVersion.
+func (u Updater) Latest(ctx context.Context, currentVersion string) (GitHubRelease, bool, error) {
+	currentVersion = GetVersionFromBuildInfo(currentVersion)
+	release, err := fetchRelease(ctx, u.client(), cmp.Or(u.ReleaseURL, GitHubReleaseURL),
+		cmp.Or(u.ReleasesURL, GitHubReleasesURL), u.Channel, currentVersion)
+	if err != nil {
+		return GitHubRelease{}, false, err
+	}
+	return release, currentVersion == "dev" || isNewer(release.TagName, currentVersion), nil
+}
+
+// Update downloads the archive of the latest release built for the target OS and
+// architecture, verifies it against the release checksums and their signature, and
+// atomically replaces the executable with the binary it contains. Nothing is replaced if
+// currentVersion is already the latest one, except for development builds, which are always
+// replaced.
+func (u Updater) Update(ctx context.Context, currentVersion string) (UpdateResult, error) {
+	currentVersion = GetVersionFromBuildInfo(currentVersion)
+	result := UpdateResult{Previous: currentVersion}
+
+	release, newer, err := u.Latest(ctx, currentVersion)
+	if err != nil {
+		return result, err
+	}
+	result.Latest = release.TagName
+	if !newer {
+		return result, nil
+	}
+
+	name := AssetName(u.goos(), u.goarch(), goarm())
+	archiveAsset, ok := release.asset(name)
+	if !ok {
+		return result, fmt.Errorf("release %s has no binary for %s/%s (expected asset %s)",
+			release.TagName, u.goos(), u.goarch(), name)
+	}
+	sumsAsset, ok := release.asset(checksumsAsset)
+	if !ok {
+		return result, fmt.Errorf("release %s has no %s to verify the download", release.TagName, checksumsAsset)
+	}
+
+	sums, err := u.download(ctx, sumsAsset.DownloadURL)
+	if err != nil {
+		return result, err
+	}
+	if err := u.verifySignature(ctx, release, sums); err != nil {
+		return result, err
+	}
+	want, err := findChecksum(sums, name)
+	if err != nil {
+		return result, err
+	}
+	archive, err := u.download(ctx, archiveAsset.DownloadURL)
+	if err != nil {
+		return result, err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return result, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+
+	binary, err := extractBinary(archive, name, u.goos())
+	if err != nil {
+		return result, err
+	}
+	exe, err := u.executable()
+	if err != nil {
+		return result, err
+	}
+	if err := replaceExecutable(exe, binary, u.goos()); err != nil {
+		return result, err
+	}
+	result.Updated = true
+	return result, nil
+}
+
+// AssetName returns the name of the release archive built for goos and goarch, following the
+// name template of the release configuration, e.g. git-sweep_Linux_x86_64.tar.gz. goarm is
+// the ARM version of 32-bit ARM builds.
+func AssetName(goos, goarch, goarm string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	case "arm":
+		arch += "v" + goarm
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return "git-sweep_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + arch + ext
+}
+
+// goarm returns the ARM version the running binary was built for.
+func goarm() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "GOARM" && setting.Value != "" {
+				return setting.Value
+			}
+		}
+	}
+	return "6"
+}
+
+// asset returns the asset of the release named name.
+func (r GitHubRelease) asset(name string) (ReleaseAsset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+// download fetches a release asset into memory.
+func (u Updater) download(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, DownloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", path.Base(url), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", path.Base(url), maxAssetSize>>20)
+	}
+	return data, nil
+}
+
+// verifySignature checks the signature of the checksums file sums published with release.
+// The release must carry a valid signature; unsigned or tampered checksums are refused, as
+// is any release when there is no key to verify it with.
+func (u Updater) verifySignature(ctx context.Context, release GitHubRelease, sums []byte) error {
+	key := u.PublicKey
+	if key == nil && signingKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(signingKey)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			return errors.New("the built-in release signing key is malformed")
+		}
+		key = decoded
+	}
+	if key == nil {
+		return errors.New("this build of git-sweep has no release signing key; " +
+			"refusing to install a binary it cannot verify (reinstall from a release instead)")
+	}
+	sigAsset, ok := release.asset(signatureAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s; refusing to install an unsigned binary", release.TagName, signatureAsset)
+	}
+	encoded, err := u.download(ctx, sigAsset.DownloadURL)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%s of release %s is malformed", signatureAsset, release.TagName)
+	}
+	if !ed25519.Verify(key, sums, sig) {
+		return fmt.Errorf("signature verification of %s failed; refusing to install release %s",
+			checksumsAsset, release.TagName)
+	}
+	return nil
+}
+
+// findChecksum returns the SHA-256 checksum of name in a checksums file of the
+// "<sha256>  <name>" lines written by sha256sum.
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", checksumsAsset, name)
+}
+
+// extractBinary returns the git-sweep binary inside a release archive.
+func extractBinary(archive []byte, name, goos string) ([]byte, error) {
+	binaryName := "git-sweep"
+	if goos == "windows" {
+		binaryName += ".exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		for _, file := range zr.File {
+			if path.Base(file.Name) != binaryName || file.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract %s: %w", binaryName, err)
+			}
+			defer func() { _ = rc.Close() }()
+			return readBinary(rc, binaryName)
+		}
+		return nil, fmt.Errorf("%s does not contain %s", name, binaryName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s does not contain %s", name, binaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return readBinary(tr, binaryName)
+		}
+	}
+}
+
+// readBinary reads an extracted binary, refusing one larger than maxAssetSize.
+func readBinary(r io.Reader, binaryName string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", binaryName, err)
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", binaryName, maxAssetSize>>20)
+	}
+	return data, nil
+}
+
+// replaceExecutable writes binary next to exe and renames it over exe, so exe is never left
+// half-written. Windows cannot replace a running executable, so it is moved aside first and
+// the copy is removed on the next update.
+func replaceExecutable(exe string, binary []byte, goos string) error {
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".git-sweep-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (reinstall with the permissions it was installed with): %w", dir, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil { //nolint:gosec // The binary must be executable
+		return fmt.Errorf("failed to make the new binary executable: %w", err)
+	}
+
+	old := ""
+	if goos == "windows" {
+		old = asideName(exe)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move the running binary aside (close other git-sweep windows and retry): %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		if old != "" {
+			_ = os.Rename(old, exe)
+		}
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// asideName removes the binaries earlier updates moved aside from exe and returns the name to
+// move the running exe to. A moved-aside binary that is still running is locked on Windows;
+// it is left for a later update and the new one gets a name of its own.
+func asideName(exe string) string {
+	old := exe + ".old"
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, filepath.Base(exe)+".") && strings.HasSuffix(name, ".old") {
+			_ = os.Remove(filepath.Join(filepath.Dir(exe), name))
+		}
+	}
+	if _, err := os.Lstat(old); err == nil {
+		return fmt.Sprintf("%s.%d.old", exe, os.Getpid())
+	}
+	return old
+}
+
+// client returns the HTTP client of the updater.
+func (u Updater) client() *http.Client {
+	if u.Client != nil {
+		return u.Client
+	}
+	return &http.Client{Timeout: DownloadTimeout}
+}
+
+// goos returns the operating system of the asset to install.
+func (u Updater) goos() string {
+	if u.GOOS != "" {
+		return u.GOOS
+	}
+	return runtime.GOOS
+}
+
+// goarch returns the architecture of the asset to install.
+func (u Updater) goarch() string {
+	if u.GOARCH != "" {
+		return u.GOARCH
+	}
+	return runtime.GOARCH
+}
+
+// executable returns the binary to replace, with symlinks resolved so a symlinked install
+// keeps pointing at the updated binary.
+func (u Updater) executable() (string, error) {
+	exe := u.Executable
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return "", fmt.Errorf("cannot locate the running binary: %w", err)
+		}
+	}
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the running binary: %w", err)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,364 @@
+package version
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssetName(t *testing.T) {
+	tests := []struct {
+		goos, goarch, goarm string
+		want                string
+	}{
+		{"linux", "amd64", "", "git-sweep_Linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "", "git-sweep_Darwin_arm64.tar.gz"},
+		{"windows", "386", "", "git-sweep_Windows_i386.zip"},
+		{"linux", "arm", "7", "git-sweep_Linux_armv7.tar.gz"},
+	}
+	for _, tt := range tests {
+		if got := AssetName(tt.goos, tt.goarch, tt.goarm); got != tt.want {
+			t.Errorf("AssetName(%q, %q, %q) = %q, want %q", tt.goos, tt.goarch, tt.goarm, got, tt.want)
+		}
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.2.0.1", "v1.2.0", true},
+		{"v1.3.0-beta.1", "v1.2.0", true},
+		{"v1.3.0", "v1.3.0-beta.1", true},
+		{"v1.3.0-beta.1", "v1.3.0", false},
+		{"v1.3.0-beta.10", "v1.3.0-beta.9", true},
+		{"v1.3.0-rc.1", "v1.3.0-beta.2", true},
+	}
+	for _, tt := range tests {
+		if got := isNewer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+// release describes the latest release served by releaseServer.
+type release struct {
+	tag    string
+	binary []byte // Content of git-sweep in the Linux amd64 archive
+	sum    string // Checksum listed for the archive; its real checksum if empty
+
+	signer       ed25519.PrivateKey // Signs checksums.txt; testSigner if nil
+	unsigned     bool               // Publish no signature
+	badSignature bool               // Publish the signature of other content
+}
+
+// testSigner signs the checksums of test releases; testPublicKey verifies them.
+var (
+	testSigner    = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	testPublicKey = testSigner.Public().(ed25519.PublicKey)
+)
+
+// releaseServer serves r as the latest release at /latest, with its Linux amd64 archive,
+// checksums.txt and optionally checksums.txt.sig. /releases lists r between a draft and an
+// older release.
+func releaseServer(t *testing.T, r release) *httptest.Server {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string][]byte{"README.md": []byte("readme"), "git-sweep": r.binary} {
+		header := &tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+	sum := r.sum
+	if sum == "" {
+		digest := sha256.Sum256(archive)
+		sum = hex.EncodeToString(digest[:])
+	}
+	sums := []byte(strings.Repeat("0", 64) + "  git-sweep_Darwin_arm64.tar.gz\n" +
+		sum + "  git-sweep_Linux_x86_64.tar.gz\n")
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	assets := []ReleaseAsset{
+		{Name: "git-sweep_Linux_x86_64.tar.gz", DownloadURL: server.URL + "/archive"},
+		{Name: "checksums.txt", DownloadURL: server.URL + "/checksums"},
+	}
+	if !r.unsigned {
+		signer := r.signer
+		if signer == nil {
+			signer = testSigner
+		}
+		signed := sums
+		if r.badSignature {
+			signed = []byte("other content")
+		}
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(signer, signed))
+		assets = append(assets, ReleaseAsset{Name: "checksums.txt.sig", DownloadURL: server.URL + "/sig"})
+		mux.HandleFunc("/sig", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(sig + "\n")) })
+	}
+	latest := GitHubRelease{TagName: r.tag, Prerelease: strings.Contains(r.tag, "-"), Assets: assets}
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(latest)
+	})
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]GitHubRelease{{TagName: "v9.0.0", Draft: true}, latest, {TagName: "v1.0.0"}})
+	})
+	mux.HandleFunc("/archive", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(archive) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(sums) })
+	return server
+}
+
+// installedBinary writes a fake installed binary and returns its path.
+func installedBinary(t *testing.T) string {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "git-sweep")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil { //nolint:gosec // Test binary
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func TestUpdate(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.3.0", binary: []byte("new binary")})
+	exe := installedBinary(t)
+	updater := Updater{
+		ReleaseURL: server.URL + "/latest", Executable: exe, GOOS: "linux", GOARCH: "amd64", PublicKey: testPublicKey,
+	}
+
+	result, err := updater.Update(context.Background(), "v1.2.0")
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if !result.Updated || result.Previous != "v1.2.0" || result.Latest != "v1.3.0" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	content, err := os.ReadFile(exe)
+	if err != nil || string(content) != "new binary" {
+		t.Errorf("Binary = %q, %v; want the new binary", content, err)
+	}
+	if info, err := os.Stat(exe); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("The new binary is not executable: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("Expected no leftover files next to the binary, got %d entries", len(entries))
+	}
+}
+
+func TestReplaceExecutableWindows(t *testing.T) {
+	exe := installedBinary(t)
+	dir := filepath.Dir(exe)
+	// An earlier update left a copy that can be removed and one that is still running
+	if err := os.WriteFile(exe+".old", []byte("older binary"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	running := exe + ".123.old"
+	if err := os.MkdirAll(filepath.Join(running, "locked"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceExecutable(exe, []byte("new binary"), "windows"); err != nil {
+		t.Fatalf("replaceExecutable() failed: %v", err)
+	}
+	if content, err := os.ReadFile(exe); err != nil || string(content) != "new binary" {
+		t.Errorf("Binary = %q, %v; want the new binary", content, err)
+	}
+	if content, err := os.ReadFile(exe + ".old"); err != nil || string(content) != "old binary" {
+		t.Errorf("Moved-aside binary = %q, %v; want the replaced binary", content, err)
+	}
+	if _, err := os.Stat(running); err != nil {
+		t.Errorf("Expected the running copy to be left alone: %v", err)
+	}
+
+	// The next update finds the last copy locked, as it is still running
+	if err := os.Remove(exe + ".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(exe+".old", "locked"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(exe, []byte("newer binary"), "windows"); err != nil {
+		t.Fatalf("replaceExecutable() with a locked copy failed: %v", err)
+	}
+	aside := fmt.Sprintf("%s.%d.old", exe, os.Getpid())
+	if content, err := os.ReadFile(aside); err != nil || string(content) != "new binary" {
+		t.Errorf("Moved-aside binary = %q, %v; want it under its own name", content, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("Expected the binary and three moved-aside copies, got %d entries", len(entries))
+	}
+}
+
+func TestUpdateUpToDate(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.2.0", binary: []byte("new binary")})
+	exe := installedBinary(t)
+	updater := Updater{
+		ReleaseURL: server.URL + "/latest", Executable: exe, GOOS: "linux", GOARCH: "amd64", PublicKey: testPublicKey,
+	}
+
+	result, err := updater.Update(context.Background(), "v1.2.0")
+	if err != nil || result.Updated {
+		t.Errorf("Update() = %+v, %v; want no update", result, err)
+	}
+	if content, _ := os.ReadFile(exe); string(content) != "old binary" {
+		t.Errorf("Binary was replaced: %q", content)
+	}
+}
+
+func TestUpdateChecksumMismatch(t *testing.T) {
+	server := releaseServer(t, release{
+		tag: "v1.3.0", binary: []byte("tampered binary"), sum: strings.Repeat("ab", 32),
+	})
+	exe := installedBinary(t)
+	updater := Updater{
+		ReleaseURL: server.URL + "/latest", Executable: exe, GOOS: "linux", GOARCH: "amd64", PublicKey: testPublicKey,
+	}
+
+	_, err := updater.Update(context.Background(), "v1.2.0")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Update() error = %v, want a checksum mismatch", err)
+	}
+	if content, _ := os.ReadFile(exe); string(content) != "old binary" {
+		t.Errorf("Binary was replaced despite the mismatch: %q", content)
+	}
+}
+
+func TestUpdateMissingAsset(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.3.0", binary: []byte("new binary")})
+	updater := Updater{
+		ReleaseURL: server.URL + "/latest", Executable: installedBinary(t), GOOS: "freebsd", GOARCH: "amd64",
+		PublicKey: testPublicKey,
+	}
+
+	_, err := updater.Update(context.Background(), "v1.2.0")
+	if err == nil || !strings.Contains(err.Error(), "git-sweep_Freebsd_x86_64.tar.gz") {
+		t.Errorf("Update() error = %v, want the missing asset named", err)
+	}
+}
+
+func TestUpdateSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		release release
+		wantErr string
+	}{
+		{"Valid signature", release{signer: private}, ""},
+		{"Unsigned release", release{unsigned: true}, "refusing to install an unsigned binary"},
+		{"Tampered checksums", release{signer: private, badSignature: true}, "signature verification"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.release.tag, tt.release.binary = "v1.3.0", []byte("new binary")
+			server := releaseServer(t, tt.release)
+			exe := installedBinary(t)
+			updater := Updater{
+				ReleaseURL: server.URL + "/latest", Executable: exe, GOOS: "linux", GOARCH: "amd64", PublicKey: public,
+			}
+
+			_, err := updater.Update(context.Background(), "v1.2.0")
+			content, _ := os.ReadFile(exe)
+			if tt.wantErr == "" {
+				if err != nil || string(content) != "new binary" {
+					t.Errorf("Update() = %v, binary %q; want the new binary", err, content)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Update() error = %v, want %q", err, tt.wantErr)
+			}
+			if string(content) != "old binary" {
+				t.Errorf("Binary was replaced: %q", content)
+			}
+		})
+	}
+}
+
+func TestUpdateWithoutSigningKey(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.3.0", binary: []byte("new binary")})
+	exe := installedBinary(t)
+	// Test builds carry no signing key, like a build without the release ldflags
+	updater := Updater{ReleaseURL: server.URL + "/latest", Executable: exe, GOOS: "linux", GOARCH: "amd64"}
+
+	_, err := updater.Update(context.Background(), "v1.2.0")
+	if err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Errorf("Update() error = %v, want a refusal without a signing key", err)
+	}
+	if content, _ := os.ReadFile(exe); string(content) != "old binary" {
+		t.Errorf("Binary was replaced without a signature check: %q", content)
+	}
+}
+
+func TestUpdatePrereleaseChannel(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.4.0-beta.1", binary: []byte("beta binary")})
+	exe := installedBinary(t)
+	updater := Updater{
+		ReleasesURL: server.URL + "/releases", Executable: exe, GOOS: "linux", GOARCH: "amd64",
+		Channel: "prerelease", PublicKey: testPublicKey,
+	}
+
+	result, err := updater.Update(context.Background(), "v1.3.0")
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if !result.Updated || result.Latest != "v1.4.0-beta.1" {
+		t.Errorf("Expected an update to the prerelease, skipping the draft, got %+v", result)
+	}
+	if content, _ := os.ReadFile(exe); string(content) != "beta binary" {
+		t.Errorf("Binary = %q, want the prerelease binary", content)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	server := releaseServer(t, release{tag: "v1.3.0", binary: []byte("new binary")})
+	updater := Updater{ReleaseURL: server.URL + "/latest"}
+
+	tests := []struct {
+		current   string
+		wantNewer bool
+	}{
+		{"v1.2.0", true},
+		{"v1.3.0", false},
+		{"dev", true},
+	}
+	for _, tt := range tests {
+		latest, newer, err := updater.Latest(context.Background(), tt.current)
+		if err != nil || latest.TagName != "v1.3.0" || newer != tt.wantNewer {
+			t.Errorf("Latest(%q) = %q, %v, %v; want v1.3.0, %v", tt.current, latest.TagName, newer, err, tt.wantNewer)
+		}
+	}
+}
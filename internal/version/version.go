@@ -4,142 +4,179 @@ package version
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/exec"
-	"regexp"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/httpclient"
+	"github.com/bral/git-sweep-go/internal/state"
 )
 
 const (
 	// GitHubReleaseURL is the URL for checking the latest release
 	GitHubReleaseURL = "https://api.github.com/repos/bral/git-sweep-go/releases/latest"
+	// GitHubReleasesURL lists the releases, prereleases included, for the prerelease channel
+	GitHubReleasesURL = "https://api.github.com/repos/bral/git-sweep-go/releases?per_page=30"
 	// DayInSeconds is the number of seconds in a day (for version check interval)
 	DayInSeconds = 86400
+	// CheckTimeout bounds the version check, which must never hold up a run for long
+	CheckTimeout = 5 * time.Second
 )
 
 // GitHubRelease represents the GitHub API response for releases
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+	TagName    string         `json:"tag_name"`
+	HTMLURL    string         `json:"html_url"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Body       string         `json:"body"` // Release notes in Markdown
+	Assets     []ReleaseAsset `json:"assets"`
 }
 
 // Check checks if a new version is available and returns information about the update
 // It follows these steps:
-// 1. Checks if 24 hours have passed since last check
-// 2. If so, queries GitHub API for latest version
+// 1. Checks if 24 hours have passed since the last check recorded in the state directory
+// 2. If so, queries GitHub API for latest version of the update channel
 // 3. Compares with current version
-// 4. Updates config with check time and latest version
+// 4. Records the check time and latest version, leaving the configuration file untouched
 // 5. Returns information about available updates
-func Check(ctx context.Context, currentVersion string, cfg *config.Config) (bool, string, string, error) {
+// The GitHub API is queried with client, or a default client with CheckTimeout if it is nil.
+func Check(ctx context.Context, client *http.Client, currentVersion, channel string) (bool, string, string, error) {
 	// Try to get the correct version from build info if it's "dev"
 	currentVersion = GetVersionFromBuildInfo(currentVersion)
-	now := time.Now().Unix()
+	now := time.Now()
 	hasUpdate := false
 	latestVersion := ""
 	releaseURL := ""
 
-	// Check if it's been at least a day since last check
-	if now-cfg.LastVersionCheck < DayInSeconds {
-		// If we already know about an update, return that info
-		if cfg.LatestKnownVersion != "" && cfg.LatestKnownVersion != currentVersion {
-			cleanCurrent := strings.TrimPrefix(currentVersion, "v")
-			cleanLatest := strings.TrimPrefix(cfg.LatestKnownVersion, "v")
-
-			// Use proper semantic versioning comparison
-			hasUpdate = false
-			latestVersionParts := strings.Split(cleanLatest, ".")
-			currentVersionParts := strings.Split(cleanCurrent, ".")
-
-			// Compare each part numerically
-			for i := 0; i < len(latestVersionParts) && i < len(currentVersionParts); i++ {
-				latestPart, latestErr := strconv.Atoi(latestVersionParts[i])
-				currentPart, currentErr := strconv.Atoi(currentVersionParts[i])
-
-				// If conversion fails, fall back to string comparison for this part
-				if latestErr != nil || currentErr != nil {
-					if latestVersionParts[i] > currentVersionParts[i] {
-						hasUpdate = true
-						break
-					} else if latestVersionParts[i] < currentVersionParts[i] {
-						break
-					}
-					continue
-				}
-
-				if latestPart > currentPart {
-					hasUpdate = true
-					break
-				} else if latestPart < currentPart {
-					break
-				}
-			}
-
-			// If all compared parts are equal but latest has more parts, it's newer
-			if !hasUpdate && len(latestVersionParts) > len(currentVersionParts) {
-				hasUpdate = true
-			}
+	last, err := state.LastVersionCheck()
+	if err != nil {
+		// An unreadable state file only means checking again
+		slog.Debug("Could not read the last version check", "error", err)
+	}
 
+	// Check if it's been at least a day since last check
+	if now.Sub(last.CheckedAt) < DayInSeconds*time.Second {
+		// If we already know about an update, return that info; a prerelease found before
+		// switching back to the stable channel is not offered
+		knownPrerelease := strings.Contains(last.LatestVersion, "-")
+		if last.LatestVersion != "" && last.LatestVersion != currentVersion &&
+			(channel == config.UpdateChannelPrerelease || !knownPrerelease) {
+			hasUpdate = isNewer(last.LatestVersion, currentVersion)
 			if hasUpdate {
-				return true, cfg.LatestKnownVersion, GitHubReleaseURL, nil
+				return true, last.LatestVersion, GitHubReleaseURL, nil
 			}
 		}
 		return false, "", "", nil
 	}
 
 	// Get latest version from GitHub
-	client := &http.Client{
-		Timeout: 5 * time.Second, // Set a short timeout
+	if client == nil {
+		client = &http.Client{Timeout: CheckTimeout}
+	}
+	release, err := fetchRelease(ctx, client, GitHubReleaseURL, GitHubReleasesURL, channel, currentVersion)
+	if err != nil {
+		// Silently fail on network and GitHub API errors
+		return false, "", "", nil
+	}
+
+	// Record the check time and version
+	if err := state.RecordVersionCheck(state.VersionCheck{CheckedAt: now, LatestVersion: release.TagName}); err != nil {
+		// Just log the error, don't fail the check
+		fmt.Fprintf(os.Stderr, "Warning: Failed to save version check info: %v\n", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", GitHubReleaseURL, nil)
+	// Compare versions (using proper semantic versioning comparison)
+	hasUpdate = isNewer(release.TagName, currentVersion)
+
+	if hasUpdate {
+		latestVersion = release.TagName
+		releaseURL = release.HTMLURL
+	}
+
+	return hasUpdate, latestVersion, releaseURL, nil
+}
+
+// fetchRelease queries the GitHub API for the newest release of channel: the latest stable
+// release at latestURL, or with the prerelease channel the newest release listed at listURL,
+// prereleases included.
+func fetchRelease(
+	ctx context.Context, client *http.Client, latestURL, listURL, channel, currentVersion string,
+) (GitHubRelease, error) {
+	url := latestURL
+	if channel == config.UpdateChannelPrerelease {
+		url = listURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return false, "", "", fmt.Errorf("error creating request: %w", err)
+		return GitHubRelease{}, fmt.Errorf("error creating request: %w", err)
 	}
 	req.Header.Set("User-Agent", "git-sweep-go/"+currentVersion)
-
 	resp, err := client.Do(req)
 	if err != nil {
-		// Silently fail on network errors
-		return false, "", "", nil
+		return GitHubRelease{}, fmt.Errorf("failed to query the latest release: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
-
+	if httpclient.RateLimited(resp) {
+		return GitHubRelease{}, fmt.Errorf("failed to query the latest release: %w", httpclient.ErrRateLimited)
+	}
 	if resp.StatusCode != http.StatusOK {
-		// GitHub API error, silently fail
-		return false, "", "", nil
+		return GitHubRelease{}, fmt.Errorf("failed to query the latest release: %s", resp.Status)
 	}
 
 	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return false, "", "", nil
+	if channel == config.UpdateChannelPrerelease {
+		var releases []GitHubRelease
+		if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+			return GitHubRelease{}, fmt.Errorf("failed to decode the releases: %w", err)
+		}
+		for _, candidate := range releases {
+			if !candidate.Draft && (release.TagName == "" || isNewer(candidate.TagName, release.TagName)) {
+				release = candidate
+			}
+		}
+	} else if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return GitHubRelease{}, fmt.Errorf("failed to decode the latest release: %w", err)
 	}
-
-	// Update config with latest check time and version
-	cfg.LastVersionCheck = now
-	cfg.LatestKnownVersion = release.TagName
-
-	// Save the updated config
-	_, err = config.SaveConfig(*cfg, "")
-	if err != nil {
-		// Just log the error, don't fail the check
-		fmt.Fprintf(os.Stderr, "Warning: Failed to save version check info: %v\n", err)
+	if release.TagName == "" {
+		return GitHubRelease{}, errors.New("no release found")
 	}
+	return release, nil
+}
 
-	// Compare versions (using proper semantic versioning comparison)
-	cleanCurrent := strings.TrimPrefix(currentVersion, "v")
-	cleanLatest := strings.TrimPrefix(release.TagName, "v")
+// isNewer reports whether version latest is newer than current. The dot-separated parts of
+// the versions are compared numerically where both are numbers; for equal versions, a
+// release is newer than its prereleases, e.g. v1.2.0 is newer than v1.2.0-beta.1.
+func isNewer(latest, current string) bool {
+	latestCore, latestPre, _ := strings.Cut(strings.TrimPrefix(latest, "v"), "-")
+	currentCore, currentPre, _ := strings.Cut(strings.TrimPrefix(current, "v"), "-")
+	if c := compareParts(latestCore, currentCore); c != 0 {
+		return c > 0
+	}
+	switch {
+	case latestPre == currentPre:
+		return false
+	case latestPre == "":
+		return true
+	case currentPre == "":
+		return false
+	}
+	return compareParts(latestPre, currentPre) > 0
+}
 
-	// Use proper semantic versioning comparison
-	latestVersionParts := strings.Split(cleanLatest, ".")
-	currentVersionParts := strings.Split(cleanCurrent, ".")
+// compareParts compares two dot-separated versions part by part, numerically where both parts
+// are numbers, and returns -1, 0 or 1.
+func compareParts(latest, current string) int {
+	latestVersionParts := strings.Split(latest, ".")
+	currentVersionParts := strings.Split(current, ".")
 
 	// Compare each part numerically
 	for i := 0; i < len(latestVersionParts) && i < len(currentVersionParts); i++ {
@@ -148,116 +185,49 @@ func Check(ctx context.Context, currentVersion string, cfg *config.Config) (bool
 
 		// If conversion fails, fall back to string comparison for this part
 		if latestErr != nil || currentErr != nil {
-			if latestVersionParts[i] > currentVersionParts[i] {
-				hasUpdate = true
-				break
-			} else if latestVersionParts[i] < currentVersionParts[i] {
-				break
+			if c := strings.Compare(latestVersionParts[i], currentVersionParts[i]); c != 0 {
+				return c
 			}
 			continue
 		}
 
-		if latestPart > currentPart {
-			hasUpdate = true
-			break
-		} else if latestPart < currentPart {
-			break
+		if latestPart != currentPart {
+			if latestPart > currentPart {
+				return 1
+			}
+			return -1
 		}
 	}
 
-	// If all compared parts are equal but latest has more parts, it's newer
-	if !hasUpdate && len(latestVersionParts) > len(currentVersionParts) {
-		hasUpdate = true
-	}
-
-	if hasUpdate {
-		latestVersion = release.TagName
-		releaseURL = release.HTMLURL
+	// If all compared parts are equal, the version with more parts is newer
+	switch {
+	case len(latestVersionParts) > len(currentVersionParts):
+		return 1
+	case len(latestVersionParts) < len(currentVersionParts):
+		return -1
 	}
-
-	return hasUpdate, latestVersion, releaseURL, nil
+	return 0
 }
 
-// ShowUpdateNotification displays a notification about an available update
-func ShowUpdateNotification(currentVersion, latestVersion, releaseURL string) {
-	// Use os.Stdout to comply with linting rules
-	out := os.Stdout
-
-	// Try to get the correct version from build info if it's "dev"
-	currentVersion = GetVersionFromBuildInfo(currentVersion)
-
-	_, _ = fmt.Fprintln(out, "")
-	_, _ = fmt.Fprintln(out, "----------------------------------------")
-	_, _ = fmt.Fprintln(out, "🚀 New Version Available! 🚀")
-	_, _ = fmt.Fprintln(out, "----------------------------------------")
-	_, _ = fmt.Fprintf(out, "Current version: %s\n", currentVersion)
-	_, _ = fmt.Fprintf(out, "Latest version:  %s\n", latestVersion)
-	_, _ = fmt.Fprintln(out, "")
-	_, _ = fmt.Fprintln(out, "To update:")
-	_, _ = fmt.Fprintln(out, "• Go users: go install github.com/bral/git-sweep-go/cmd/git-sweep@latest")
-	_, _ = fmt.Fprintln(out, "• Binary: Download from GitHub releases page")
-	_, _ = fmt.Fprintln(out, "")
-	_, _ = fmt.Fprintf(out, "Release details: %s\n", releaseURL)
-	_, _ = fmt.Fprintln(out, "----------------------------------------")
-	_, _ = fmt.Fprintln(out, "")
-
-	// Ask if user wants to update now
-	_, _ = fmt.Fprint(out, "Would you like to update now? (y/n): ")
-	var response string
-	_, _ = fmt.Scanln(&response)
-
-	if strings.ToLower(response) == "y" || strings.ToLower(response) == "yes" {
-		performUpdate(latestVersion)
-	}
+// UpdateNotice returns the one-line notice shown when latestVersion is newer than
+// currentVersion. It never blocks on input, so it is safe before the TUI and in scripts.
+func UpdateNotice(currentVersion, latestVersion string) string {
+	return fmt.Sprintf("git-sweep %s is available (you have %s); run 'git-sweep update' to install it.",
+		latestVersion, GetVersionFromBuildInfo(currentVersion))
 }
 
-// performUpdate attempts to update the application based on how it was installed
-func performUpdate(latestVersion string) {
-	// Use os.Stdout to comply with linting rules
-	out := os.Stdout
-
-	// Validate that latestVersion has a valid format (v1.2.3 or similar)
-	if !strings.HasPrefix(latestVersion, "v") || !isValidSemVer(latestVersion[1:]) {
-		_, _ = fmt.Fprintf(out, "❌ Invalid version format: %s\n", latestVersion)
-		_, _ = fmt.Fprintln(out, "Please update manually:")
-		printManualInstructions(out)
-		return
+// PrintChangelog writes the condensed changelog of release notes under a "What's new" heading
+// and reports whether there was anything to write.
+func PrintChangelog(out io.Writer, notes string) bool {
+	changes := Changelog(notes, changelogLines)
+	if len(changes) == 0 {
+		return false
 	}
-
-	// Try different update mechanisms
-
-	// 1. Try go install
-	_, _ = fmt.Fprintln(out, "Attempting update via go install...")
-	packagePath := "github.com/bral/git-sweep-go/cmd/git-sweep@" + latestVersion
-	_, _ = fmt.Fprintf(out, "Running: go install %s\n", packagePath)
-	cmd := exec.Command("go", "install", packagePath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err == nil {
-		_, _ = fmt.Fprintln(out, "✅ Update successful! You're now using the latest version.")
-		return
+	_, _ = fmt.Fprintln(out, "What's new:")
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(out, "• %s\n", change)
 	}
-
-	// If auto-update failed, provide manual instructions
-	_, _ = fmt.Fprintln(out, "\nAutomatic update failed. Please update manually:")
-	printManualInstructions(out)
-}
-
-// isValidSemVer returns true if the version string follows valid semver format
-func isValidSemVer(version string) bool {
-	// Simple regex check for semver format (x.y.z with optional pre-release/build metadata)
-	matched, err := regexp.MatchString(`^(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z-]+)?(\+[0-9A-Za-z-]+)?$`, version)
-	return err == nil && matched
-}
-
-// printManualInstructions prints instructions for manual updates
-func printManualInstructions(out io.Writer) {
-	_, _ = fmt.Fprintln(out, "- For Go users: Run the following command:")
-	_, _ = fmt.Fprintln(out, "  go install github.com/bral/git-sweep-go/cmd/git-sweep@latest")
-	_, _ = fmt.Fprintln(out, "- Or download the latest binary from GitHub:")
-	_, _ = fmt.Fprintf(out, "  %s\n", "https://github.com/bral/git-sweep-go/releases/latest")
-	_, _ = fmt.Fprintln(out, "- Or use your package manager to update")
+	return true
 }
 
 // GetVersionFromBuildInfo returns the version extracted from build info if available
@@ -0,0 +1,89 @@
+package sweep
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Deletion is one local or remote branch deletion of a Plan.
+type Deletion = gitcmd.BranchToDelete
+
+// DeleteResult is the outcome of one Deletion.
+type DeleteResult = types.DeleteResult
+
+// Plan lists the deletions Delete performs: the local branches first, then the remote ones.
+type Plan struct {
+	Deletions []Deletion
+}
+
+// PlanOptions control NewPlan.
+type PlanOptions struct {
+	Remote    bool   // Also delete the remote branches that are not protected on the remote
+	Archive   string // Archive mode applied before each local deletion, e.g. "ref"; empty disables archiving
+	BundleDir string // Directory each local branch is bundled to before it is deleted; empty disables bundles
+	TagPrefix string // Prefix of the tag created at each local branch's tip before it is deleted; empty disables tags
+}
+
+// NewPlan returns the plan that deletes branches, typically picked from the result of
+// Analyze. Branches merged by ancestry are deleted with 'git branch -d' and the others are
+// force deleted.
+func NewPlan(branches []Branch, opts PlanOptions) Plan {
+	deletions := make([]Deletion, 0, len(branches))
+	for _, branch := range branches {
+		deletions = append(deletions, Deletion{
+			Name: branch.Name, IsMerged: branch.IsMerged, MergedBy: branch.MergedBy, Hash: branch.CommitHash,
+			Archive: opts.Archive, BundleDir: opts.BundleDir, TagPrefix: opts.TagPrefix,
+		})
+	}
+	for _, branch := range branches {
+		if opts.Remote && branch.Remote != "" && !branch.IsRemoteProtected {
+			deletions = append(deletions, Deletion{
+				Name: branch.RemoteBranchName(), IsRemote: true, Remote: branch.Remote, IsMerged: branch.IsMerged,
+				MergedBy: branch.MergedBy, Hash: branch.CommitHash,
+			})
+		}
+	}
+	return Plan{Deletions: deletions}
+}
+
+// RemoteCount returns the number of remote deletions in the plan.
+func (p Plan) RemoteCount() int {
+	count := 0
+	for _, deletion := range p.Deletions {
+		if deletion.IsRemote {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteOptions control Delete.
+type DeleteOptions struct {
+	DryRun          bool    // Report the deletions without performing them
+	RemoteWorkers   int     // Maximum concurrent remote deletions; values below 1 mean sequential
+	RemoteRateLimit float64 // Maximum remote deletions started per second, per remote; 0 means unlimited
+
+	// OnResult, if set, is called as each deletion completes, never concurrently
+	OnResult func(DeleteResult)
+}
+
+// Delete performs the deletions of plan in the repository in the directory repo, or in the
+// current directory if repo is empty. Once ctx is cancelled, in-flight git commands are
+// stopped and the remaining deletions are reported as skipped. The results are in completion
+// order.
+func Delete(ctx context.Context, repo string, plan Plan, opts DeleteOptions) []DeleteResult {
+	return gitcmd.DeleteBranchesConcurrently(gitcmd.WithDir(ctx, repo), plan.Deletions, opts.DryRun,
+		gitcmd.DeleteOptions{RemoteWorkers: opts.RemoteWorkers, RemoteRateLimit: opts.RemoteRateLimit}, opts.OnResult)
+}
+
+// ArchiveMode returns the archive mode NewPlan should apply with cfg, or "" if cfg does not
+// archive deleted branches.
+func ArchiveMode(cfg Config) string {
+	if !cfg.Archive {
+		return ""
+	}
+	return cmp.Or(cfg.ArchiveMode, gitcmd.ArchiveModeRef)
+}
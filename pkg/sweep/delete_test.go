@@ -0,0 +1,44 @@
+package sweep
+
+import (
+	"testing"
+
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+func TestNewPlan(t *testing.T) {
+	branches := []Branch{
+		{BranchInfo: types.BranchInfo{Name: "merged", CommitHash: "aaa", Remote: "origin"}, IsMerged: true},
+		{BranchInfo: types.BranchInfo{Name: "local", CommitHash: "bbb"}},
+		{BranchInfo: types.BranchInfo{Name: "guarded", CommitHash: "ccc", Remote: "origin"}, IsRemoteProtected: true},
+	}
+
+	plan := NewPlan(branches, PlanOptions{Archive: "ref"})
+	if len(plan.Deletions) != 3 || plan.RemoteCount() != 0 {
+		t.Fatalf("Expected 3 local deletions, got %+v", plan.Deletions)
+	}
+	if !plan.Deletions[0].IsMerged || plan.Deletions[1].IsMerged || plan.Deletions[0].Archive != "ref" {
+		t.Errorf("Unexpected local deletions: %+v", plan.Deletions)
+	}
+
+	plan = NewPlan(branches, PlanOptions{Remote: true})
+	if len(plan.Deletions) != 4 || plan.RemoteCount() != 1 {
+		t.Fatalf("Expected 3 local and 1 remote deletion, got %+v", plan.Deletions)
+	}
+	if remote := plan.Deletions[3]; !remote.IsRemote || remote.Name != "merged" || remote.Remote != "origin" {
+		t.Errorf("Unexpected remote deletion: %+v", remote)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	branches := []Branch{
+		{BranchInfo: types.BranchInfo{Name: "main"}, Category: CategoryProtected},
+		{BranchInfo: types.BranchInfo{Name: "merged"}, Category: CategoryMergedOld},
+		{BranchInfo: types.BranchInfo{Name: "active"}, Category: CategoryActive},
+		{BranchInfo: types.BranchInfo{Name: "old"}, Category: CategoryUnmergedOld},
+	}
+	candidates := Candidates(branches)
+	if len(candidates) != 2 || candidates[0].Name != "merged" || candidates[1].Name != "old" {
+		t.Errorf("Candidates() = %+v, want merged and old", candidates)
+	}
+}
@@ -0,0 +1,122 @@
+package sweep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/state"
+)
+
+// FetchRemote fetches and prunes remote, giving up after fetch_timeout_seconds so an
+// unreachable remote cannot stall the caller. The fetch is skipped if remote was fetched
+// successfully within the last fetch_cache_minutes.
+func FetchRemote(ctx context.Context, cfg Config, remote string) error {
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		slog.Debug("Could not determine the repository root; not caching the fetch", "error", err)
+	} else if fetchedRecently(cfg, repoRoot, remote) {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.FetchTimeoutSeconds) * time.Second
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err = gitcmd.FetchAndPrune(ctx, remote)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("fetching '%s' timed out after %s", remote, timeout)
+	}
+	if err == nil && repoRoot != "" {
+		if err := state.RecordFetch(repoRoot, remote, time.Now()); err != nil {
+			slog.Debug("Could not record the fetch time", "error", err)
+		}
+	}
+	return err
+}
+
+// fetchedRecently reports whether remote was fetched in repoRoot within fetch_cache_minutes.
+func fetchedRecently(cfg Config, repoRoot, remote string) bool {
+	if cfg.FetchCacheMinutes <= 0 {
+		return false
+	}
+	last, err := state.LastFetch(repoRoot, remote)
+	if err != nil {
+		slog.Debug("Could not read the last fetch time", "error", err)
+		return false
+	}
+	age := time.Since(last)
+	if age < 0 || age >= time.Duration(cfg.FetchCacheMinutes)*time.Minute {
+		return false
+	}
+	slog.Debug("Skipping fetch; the remote was fetched recently", "remote", remote,
+		"age", age.Round(time.Second))
+	return true
+}
+
+// RemotesToFetch returns remote followed by the other remotes that local branches track,
+// so that the tracking information of every branch is current. With prune_all_remotes, every
+// configured remote is included, so stale remote-tracking branches of remotes no branch
+// tracks are pruned as well. The remotes should be fetched one after the other since
+// concurrent fetches contend for the same ref locks.
+func RemotesToFetch(ctx context.Context, cfg Config, remote string) []string {
+	remotes := []string{remote}
+	listRemotes := gitcmd.TrackedRemotes
+	if cfg.PruneAllRemotes {
+		listRemotes = gitcmd.ListRemotes
+	}
+	others, err := listRemotes(ctx)
+	if err != nil {
+		slog.Debug("Could not list remotes; fetching only the selected one", "remote", remote, "error", err)
+		return remotes
+	}
+	for _, other := range others {
+		if other != remote {
+			remotes = append(remotes, other)
+		}
+	}
+	return remotes
+}
+
+// FastForwardMain fast-forwards the primary main branch to its upstream after fetching, so
+// branches merged upstream are detected without pulling first. It returns why the branch was
+// left behind its upstream, or nil if it is up to date now.
+func FastForwardMain(ctx context.Context, cfg Config) error {
+	branch := cfg.PrimaryMainBranch
+	moved, err := gitcmd.FastForwardBranch(ctx, branch)
+	if err != nil {
+		slog.Debug("Could not fast-forward the primary main branch", "branch", branch, "error", err)
+		return err
+	}
+	if moved {
+		slog.Debug("Fast-forwarded the primary main branch to its upstream", "branch", branch)
+	}
+	return nil
+}
+
+// Unshallow fetches the full history of a shallow clone from remote, so branches merged
+// before its oldest commit are recognized as merged. A complete repository is left alone.
+func Unshallow(ctx context.Context, remote string) error {
+	shallow, err := gitcmd.IsShallowRepo(ctx)
+	if err != nil || !shallow {
+		return err
+	}
+	slog.Debug("Fetching the full history of the shallow clone", "remote", remote)
+	return gitcmd.Unshallow(ctx, remote)
+}
+
+// ErrorSummary shortens an error to one line, preferring the message git printed.
+func ErrorSummary(err error) string {
+	msg := err.Error()
+	if _, stderr, ok := strings.Cut(msg, "\nstderr: "); ok && stderr != "" {
+		msg = stderr
+	}
+	line, _, _ := strings.Cut(msg, "\n")
+	return line
+}
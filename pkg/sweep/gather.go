@@ -0,0 +1,533 @@
+package sweep
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bral/git-sweep-go/internal/analyze"
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/provider"
+	"github.com/bral/git-sweep-go/internal/state"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// defaultRemote is the remote used when Options.Remote is empty.
+const defaultRemote = "origin"
+
+// shallowWarning explains why merge detection is unreliable in a shallow clone.
+const shallowWarning = "This is a shallow clone, so merge detection may be wrong: branches merged before its " +
+	"oldest commit can be reported as unmerged. Run with --unshallow or 'git fetch --unshallow' first."
+
+// fallbackMainBranch is used when the primary main branch is "auto" but cannot be detected.
+const fallbackMainBranch = "main"
+
+// Repository is the input gathered for the analysis of one repository.
+type Repository struct {
+	Branches      []types.BranchInfo // Annotated local branches
+	Merged        map[string]bool    // Branches merged into the primary main branch
+	CurrentBranch string             // Checked-out branch; empty if it could not be determined
+	MainHash      string             // Commit the branches are checked against
+
+	dir  string
+	opts Options
+}
+
+// Config returns the settings the repository is analyzed with, with the primary main branch
+// resolved if it was "auto".
+func (r *Repository) Config() Config {
+	return r.opts.Config
+}
+
+// Gather checks that repo, or the current directory if repo is empty, is inside a Git
+// repository, fetches its remotes if opts.Fetch is set, and collects the annotated local
+// branches, their merge status and the current branch. Branches is empty if the repository
+// has no branches; in a repository without commits yet, nothing is fetched either.
+func Gather(ctx context.Context, repo string, opts Options) (*Repository, error) {
+	ctx = gitcmd.WithDir(ctx, repo)
+	opts.Remote = cmp.Or(opts.Remote, defaultRemote)
+
+	slog.Debug("Checking environment")
+	inGitRepo, err := gitcmd.ActiveBackend.IsInGitRepo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check Git repository status: %w", err)
+	}
+	// The branches of a bare repository can be analyzed as well, though it has no working tree
+	if bare, _ := gitcmd.IsBareRepo(ctx); !inGitRepo && !bare {
+		return nil, ErrNotInGitRepo
+	}
+	slog.Debug("Environment check passed")
+	hasCommits, err := gitcmd.HasCommits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for commits: %w", err)
+	}
+	opts.Config = prepareConfig(ctx, opts)
+	cfg := opts.Config
+	r := &Repository{dir: repo, opts: opts}
+	if !hasCommits {
+		slog.Debug("Repository has no commits yet")
+		return r, nil
+	}
+
+	if opts.Fetch && !cfg.Offline {
+		stopFetch := opts.track("fetch")
+		if cfg.Unshallow {
+			if err := Unshallow(ctx, opts.Remote); err != nil {
+				opts.warn(fmt.Sprintf("Could not fetch the full history from '%s': %s", opts.Remote, ErrorSummary(err)))
+			}
+		}
+		for _, remote := range RemotesToFetch(ctx, cfg, opts.Remote) {
+			slog.Debug("Fetching remote state", "remote", remote)
+			if err := FetchRemote(ctx, cfg, remote); err != nil {
+				opts.warn(fmt.Sprintf("Failed to fetch remote state for '%s': %v", remote, err))
+			} else {
+				slog.Debug("Remote fetch complete", "remote", remote)
+			}
+		}
+		if cfg.FastForwardMain {
+			if err := FastForwardMain(ctx, cfg); err != nil {
+				opts.warn(fmt.Sprintf("Could not fast-forward '%s': %s", cfg.PrimaryMainBranch, ErrorSummary(err)))
+			}
+		}
+		stopFetch()
+	}
+	// Merges older than the history of a shallow clone cannot be seen
+	if shallow, err := gitcmd.IsShallowRepo(ctx); err == nil && shallow {
+		opts.warn(shallowWarning)
+	}
+
+	slog.Debug("Gathering branch data")
+	stopBranches := opts.track("for-each-ref")
+	allBranches, err := gitcmd.ActiveBackend.LocalBranches(ctx)
+	stopBranches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather local branch info: %w", err)
+	}
+	if len(allBranches) == 0 {
+		return r, nil
+	}
+	if err := checkBranchesExist(allBranches, opts.Branches); err != nil {
+		return nil, err
+	}
+	stopAnnotate := opts.track("annotate")
+	allBranches = annotateWorktrees(ctx, allBranches)
+	annotateSnoozes(ctx, allBranches, opts)
+	annotateRemoteDates(ctx, allBranches)
+	if !opts.SkipDetails {
+		annotateDescriptions(ctx, allBranches)
+		annotateFromProvider(ctx, allBranches, opts)
+	}
+	stopAnnotate()
+
+	stopMerged := opts.track("merged")
+	defer stopMerged()
+	mainHash, err := gitcmd.ActiveBackend.ResolveRef(ctx, cfg.CompareTarget())
+	if err != nil && cfg.CompareRef != "" {
+		return nil, fmt.Errorf("failed to get hash for compare ref '%s': %w\n"+
+			"Please ensure the 'compare_ref' in your config or --against exists", cfg.CompareRef, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get hash for primary main branch '%s': %w\n"+
+			"Please ensure the 'primary_main_branch' in your config or flag exists", cfg.PrimaryMainBranch, err)
+	}
+
+	mergedBranchesMap, err := gitcmd.ActiveBackend.MergedBranches(ctx, mainHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine merged branches against hash %s: %w", mainHash, err)
+	}
+	slog.Debug("Gathered branch data", "branches", len(allBranches),
+		"main_branch", cfg.CompareTarget(), "main_hash", mainHash, "merged", len(mergedBranchesMap))
+
+	currentBranch, err := gitcmd.ActiveBackend.CurrentBranch(ctx)
+	if err != nil {
+		opts.warn(fmt.Sprintf("Could not determine current branch: %v", err))
+		currentBranch = ""
+	} else if currentBranch != "" {
+		slog.Debug("Current branch detected; it will be protected", "branch", currentBranch)
+	}
+	annotateProtectedMerges(ctx, allBranches, mergedBranchesMap, cfg)
+	analyze.MarkDuplicates(allBranches, mainHash, cfg, currentBranch)
+	if !opts.SkipDetails {
+		annotateStacks(ctx, allBranches, mainHash, cfg, currentBranch)
+	}
+	annotateMergeChecks(ctx, allBranches, mainHash)
+
+	r.Branches, r.Merged, r.CurrentBranch, r.MainHash = allBranches, mergedBranchesMap, currentBranch, mainHash
+	if len(opts.Branches) > 0 {
+		r.Branches = slices.DeleteFunc(allBranches, func(b types.BranchInfo) bool {
+			return !slices.Contains(opts.Branches, b.Name)
+		})
+	}
+	return r, nil
+}
+
+// checkBranchesExist returns an error naming the branches in names that are not among branches.
+func checkBranchesExist(branches []types.BranchInfo, names []string) error {
+	existing := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		existing[b.Name] = true
+	}
+	var missing []string
+	for _, name := range names {
+		if !existing[name] {
+			missing = append(missing, strconv.Quote(name))
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("no local branch named %s", missing[0])
+	default:
+		return fmt.Errorf("no local branches named %s", strings.Join(missing, ", "))
+	}
+}
+
+// Analyze categorizes branches, a subset of r.Branches or all of them, and scores the risk of
+// deleting each candidate. Large repositories can be analyzed in batches to report progress.
+func (r *Repository) Analyze(ctx context.Context, branches []types.BranchInfo) ([]Branch, error) {
+	ctx = gitcmd.WithDir(ctx, r.dir)
+	slog.Debug("Analyzing branches")
+	stopChecks := r.opts.track("merge checks")
+	analyzed, err := analyze.Branches(ctx, branches, r.Merged, r.opts.Config, r.CurrentBranch)
+	stopChecks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze branches: %w", err)
+	}
+	stopRisk := r.opts.track("risk")
+	annotateRisk(ctx, analyzed, r.opts.Config)
+	stopRisk()
+	return analyzed, nil
+}
+
+// Explain analyzes the local branch named name like Analyze and returns the decision trail
+// that led to its category.
+func (r *Repository) Explain(ctx context.Context, name string) (Branch, []Step, error) {
+	for _, branch := range r.Branches {
+		if branch.Name == name {
+			return analyze.Explain(gitcmd.WithDir(ctx, r.dir), branch, r.Merged, r.opts.Config, r.CurrentBranch)
+		}
+	}
+	return Branch{}, nil, fmt.Errorf("no local branch named %q", name)
+}
+
+// PrimaryMainBranch returns the configured primary main branch, detecting it from the HEAD
+// of remoteName when it is set to "auto". If detection fails, warn is told and "main" is
+// used. Outside a repository, and in one without commits, detection is skipped silently.
+func PrimaryMainBranch(ctx context.Context, configured, remoteName string, warn func(string)) string {
+	if configured != config.PrimaryMainAuto {
+		return configured
+	}
+	inGitRepo, _ := gitcmd.IsInGitRepo(ctx)
+	if bare, _ := gitcmd.IsBareRepo(ctx); !inGitRepo && !bare {
+		return fallbackMainBranch
+	}
+	if hasCommits, err := gitcmd.HasCommits(ctx); err == nil && !hasCommits {
+		return fallbackMainBranch
+	}
+	detected, err := gitcmd.DetectDefaultBranch(ctx, remoteName)
+	if err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("%v; using '%s'. Set primary_main_branch to override.", err, fallbackMainBranch))
+		}
+		return fallbackMainBranch
+	}
+	slog.Debug("Detected primary main branch", "branch", detected)
+	return detected
+}
+
+// prepareConfig completes a configuration that did not come from LoadConfig: it resolves an
+// "auto" primary main branch and indexes the protected branches.
+func prepareConfig(ctx context.Context, opts Options) Config {
+	cfg := opts.Config
+	cfg.PrimaryMainBranch = PrimaryMainBranch(ctx, cmp.Or(cfg.PrimaryMainBranch, config.PrimaryMainAuto),
+		opts.Remote, opts.Warn)
+	if cfg.ProtectedBranchMap == nil {
+		cfg.ProtectedBranchMap = make(map[string]bool)
+		for _, branch := range cfg.ProtectedBranches {
+			cfg.ProtectedBranchMap[branch] = true
+		}
+	}
+	return cfg
+}
+
+// annotateWorktrees marks branches that are checked out in any worktree so analysis can protect them.
+// Failure to list worktrees is non-fatal; the branches are returned unchanged.
+func annotateWorktrees(ctx context.Context, branches []types.BranchInfo) []types.BranchInfo {
+	worktrees, err := gitcmd.GetWorktrees(ctx)
+	if err != nil {
+		slog.Debug("Could not list worktrees", "error", err)
+		return branches
+	}
+	checkedOut := gitcmd.WorktreeBranches(worktrees)
+	for i := range branches {
+		if path, ok := checkedOut[branches[i].Name]; ok {
+			branches[i].WorktreePath = path
+		}
+	}
+	return branches
+}
+
+// annotateSnoozes marks branches the user has snoozed for this repository so analysis
+// keeps them out of the suggestions. Problems reading the snooze list are reported as warnings.
+func annotateSnoozes(ctx context.Context, branches []types.BranchInfo, opts Options) {
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		slog.Debug("Could not determine repository root for snoozes", "error", err)
+		return
+	}
+	snoozes, err := state.LoadSnoozes(repoRoot)
+	if err != nil {
+		opts.warn(fmt.Sprintf("Could not read snoozed branches: %v", err))
+		return
+	}
+	for i := range branches {
+		if s, ok := snoozes[branches[i].Name]; ok {
+			branches[i].Snoozed = true
+			branches[i].SnoozedUntil = s.Until
+		}
+	}
+}
+
+// annotateRemoteDates attaches the commit date of each branch's remote-tracking branch, so
+// analysis sees a teammate's recent pushes to a shared branch whose local copy looks stale.
+func annotateRemoteDates(ctx context.Context, branches []types.BranchInfo) {
+	dates, err := gitcmd.GetRemoteCommitDates(ctx)
+	if err != nil {
+		slog.Debug("Could not read remote-tracking branch dates", "error", err)
+		return
+	}
+	for i := range branches {
+		if branches[i].Upstream != "" {
+			branches[i].RemoteCommitDate = dates[branches[i].Upstream]
+		}
+	}
+}
+
+// annotateDescriptions attaches the branch descriptions set with 'git branch --edit-description',
+// so the notes teams leave on their branches are visible when deciding what to delete.
+func annotateDescriptions(ctx context.Context, branches []types.BranchInfo) {
+	descriptions, err := gitcmd.GetBranchDescriptions(ctx)
+	if err != nil {
+		slog.Debug("Could not read branch descriptions", "error", err)
+		return
+	}
+	for i := range branches {
+		branches[i].Description = descriptions[branches[i].Name]
+	}
+}
+
+// annotateProtectedMerges marks the branches merged into a protected branch other than the
+// primary main branch, e.g. hotfixes merged into develop with GitFlow, when merged_into_protected
+// is set. Branches already in merged, the branches merged into the primary main branch, are
+// left alone.
+func annotateProtectedMerges(ctx context.Context, branches []types.BranchInfo, merged map[string]bool, cfg Config) {
+	if !cfg.MergedIntoProtected {
+		return
+	}
+	for _, target := range branches {
+		if target.Name == cfg.PrimaryMainBranch || !cfg.IsProtectedName(target.Name) {
+			continue
+		}
+		mergedIntoTarget, err := gitcmd.ActiveBackend.MergedBranches(ctx, target.CommitHash)
+		if err != nil {
+			slog.Debug("Could not determine branches merged into protected branch", "branch", target.Name, "error", err)
+			continue
+		}
+		for i := range branches {
+			name := branches[i].Name
+			if mergedIntoTarget[name] && name != target.Name && !merged[name] && branches[i].MergedInto == "" {
+				branches[i].MergedInto = target.Name
+			}
+		}
+	}
+}
+
+// annotateFromProvider queries the configured hosting provider for each branch's pull request
+// and for server-side branch protection, which rules out deleting the branch on the remote.
+// Provider problems are reported as warnings and never abort the sweep. Nothing is looked up
+// in offline mode.
+func annotateFromProvider(ctx context.Context, branches []types.BranchInfo, opts Options) {
+	cfg := opts.Config
+	if cfg.Provider == "" || cfg.Offline {
+		return
+	}
+	remoteURL, err := gitcmd.GetRemoteURL(ctx, opts.Remote)
+	if err != nil {
+		opts.warn(fmt.Sprintf("Skipping pull request lookup: %v", err))
+		return
+	}
+	prov, err := provider.New(cfg, remoteURL)
+	if err != nil {
+		opts.warn(fmt.Sprintf("Skipping pull request lookup: %v", err))
+		return
+	}
+
+	slog.Debug("Looking up pull requests", "provider", cfg.Provider)
+	skip := map[string]bool{cfg.PrimaryMainBranch: true}
+	for _, b := range branches {
+		// Only the selected branches are analyzed, so the others need no lookups, and the
+		// provider only knows the repository behind the remote it was created for
+		if (len(opts.Branches) > 0 && !slices.Contains(opts.Branches, b.Name)) ||
+			(b.Remote != "" && b.Remote != opts.Remote) {
+			skip[b.Name] = true
+		}
+	}
+	if err := provider.AnnotatePullRequests(ctx, prov, branches, skip); err != nil {
+		opts.warn(fmt.Sprintf("Some pull request lookups failed: %v", err))
+	}
+	verifyPullRequestHeads(ctx, branches)
+	if err := provider.AnnotateProtection(ctx, prov, branches, skip); err != nil {
+		opts.warn(fmt.Sprintf("Some branch protection lookups failed: %v", err))
+	}
+}
+
+// verifyPullRequestHeads drops merged and closed pull requests that do not contain the
+// branch tip, e.g. an old pull request from an earlier branch of the same name, since the
+// pull request then says nothing about the commits on the branch. A tip contained in the
+// head the pull request last pointed at was merged or abandoned along with it.
+func verifyPullRequestHeads(ctx context.Context, branches []types.BranchInfo) {
+	for i := range branches {
+		b := &branches[i]
+		pr := b.PullRequest
+		if pr == nil || pr.State == types.PullRequestOpen || pr.HeadSHA == b.CommitHash {
+			continue
+		}
+		contained := false
+		if pr.HeadSHA != "" && b.CommitHash != "" {
+			var err error
+			// The head is unknown locally when it was never fetched, which counts as unrelated
+			if contained, err = gitcmd.IsAncestor(ctx, b.CommitHash, pr.HeadSHA); err != nil {
+				slog.Debug("Could not compare pull request head", "branch", b.Name, "error", err)
+			}
+		}
+		if !contained {
+			slog.Debug("Ignoring pull request whose head does not contain the branch tip",
+				"branch", b.Name, "pr", pr.Number, "head", pr.HeadSHA)
+			b.PullRequest = nil
+		}
+	}
+}
+
+// annotateRisk counts the commits of candidates that were never pushed to any remote, since
+// force deleting those branches loses work that exists nowhere else, and the commits the
+// primary main branch lacks of unmerged candidates, then scores the risk of deleting each
+// candidate. Only ancestry proves that every commit of a merged branch is on the branch it
+// was merged into; a pull request, git cherry or an empty diff says nothing about commits
+// added afterwards, so those are counted too. The commits of a duplicate remain on the
+// branch it duplicates, so they are not counted.
+func annotateRisk(ctx context.Context, branches []types.AnalyzedBranch, cfg Config) {
+	unpushedTips := make(map[string]string)
+	aheadTips := make(map[string]string)
+	for _, branch := range branches {
+		if branch.DuplicateOf != "" || branch.CommitHash == "" {
+			continue
+		}
+		switch {
+		case branch.Category == types.CategoryUnmergedOld:
+			unpushedTips[branch.Name] = branch.CommitHash
+			aheadTips[branch.Name] = branch.CommitHash
+		case branch.Category == types.CategoryMergedOld && branch.MergedBy != types.MergedByAncestry:
+			unpushedTips[branch.Name] = branch.CommitHash
+		}
+	}
+	// Counts that could not be determined stay zero
+	if len(unpushedTips) > 0 {
+		unpushed, err := gitcmd.CountUnpushedCommits(ctx, unpushedTips)
+		if err != nil {
+			slog.Debug("Could not count unpushed commits", "error", err)
+		}
+		for i := range branches {
+			if _, ok := unpushedTips[branches[i].Name]; ok {
+				branches[i].UnpushedCommits = unpushed[branches[i].Name]
+			}
+		}
+	}
+	if len(aheadTips) > 0 {
+		ahead, err := gitcmd.CountCommitsAhead(ctx, cfg.CompareTarget(), aheadTips)
+		if err != nil {
+			slog.Debug("Could not count commits ahead", "error", err)
+		}
+		for i := range branches {
+			if _, ok := aheadTips[branches[i].Name]; ok {
+				branches[i].AheadCommits = ahead[branches[i].Name]
+			}
+		}
+	}
+	analyze.ScoreRisk(branches)
+}
+
+// annotateStacks lists the branches stacked on each branch, e.g. for stacked pull requests,
+// since deleting the base of a stack confuses the tools that track it. Only unmerged bases
+// count: once a base is in mainHash, the branches on it are based on the main branch as well.
+// Protected branches and the current branch, often a long-lived one like main, collect merged
+// work rather than stack on it, so they are not listed.
+func annotateStacks(
+	ctx context.Context, branches []types.BranchInfo, mainHash string, cfg Config, currentBranch string,
+) {
+	tips := make(map[string]string, len(branches))
+	for _, branch := range branches {
+		if branch.CommitHash != "" {
+			tips[branch.Name] = branch.CommitHash
+		}
+	}
+	stacked, err := gitcmd.GetStackedBranches(ctx, mainHash, tips)
+	if err != nil {
+		slog.Debug("Could not find stacked branches", "error", err)
+		return
+	}
+	for i := range branches {
+		branches[i].StackedBranches = slices.DeleteFunc(stacked[branches[i].Name], func(name string) bool {
+			return cfg.IsProtectedName(name) || name == cfg.PrimaryMainBranch || name == currentBranch
+		})
+	}
+}
+
+// annotateMergeChecks sets the outcome of the git cherry and git diff merge checks that an
+// earlier run recorded for the same branch tips against mainHash, so they are not run again.
+// An unreadable cache only costs time, so it is not reported.
+func annotateMergeChecks(ctx context.Context, branches []types.BranchInfo, mainHash string) {
+	repoRoot, err := gitcmd.GetRepoRoot(ctx)
+	if err != nil {
+		slog.Debug("Could not determine repository root for merge checks", "error", err)
+		return
+	}
+	checks, err := state.LoadMergeChecks(repoRoot, mainHash)
+	if err != nil {
+		slog.Debug("Could not read cached merge checks", "error", err)
+		return
+	}
+	for i := range branches {
+		if mergedBy, ok := checks[branches[i].CommitHash]; ok && branches[i].CommitHash != "" {
+			branches[i].HasCachedMergeCheck = true
+			branches[i].CachedMergedBy = types.MergeMethod(mergedBy)
+		}
+	}
+}
+
+// RecordMergeChecks caches the outcome of the git cherry and git diff merge checks that ran
+// during the analysis of branches, keyed by branch tip, so later analyses of the same commits
+// skip them.
+func (r *Repository) RecordMergeChecks(ctx context.Context, branches []Branch) {
+	results := make(map[string]string)
+	for _, branch := range branches {
+		if branch.RanMergeCheck && branch.CommitHash != "" {
+			results[branch.CommitHash] = string(branch.MergedBy)
+		}
+	}
+	if len(results) == 0 || ctx.Err() != nil {
+		return
+	}
+	repoRoot, err := gitcmd.GetRepoRoot(gitcmd.WithDir(ctx, r.dir))
+	if err != nil {
+		slog.Debug("Could not determine repository root for merge checks", "error", err)
+		return
+	}
+	if err := state.RecordMergeChecks(repoRoot, r.MainHash, results); err != nil {
+		slog.Debug("Could not cache merge checks", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package sweep is the library behind git-sweep: it finds the local branches of a repository
+// that can be deleted and deletes them, so other tools can embed the same logic without
+// running the git-sweep binary.
+//
+// Analyze runs the whole analysis in one call. Gather and Repository.Analyze split it into
+// steps for callers that want to show progress, as the git-sweep TUI does. NewPlan and Delete
+// remove the branches the user picked from the result.
+//
+// Repositories are read and changed with the git binary, which must be installed.
+package sweep
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bral/git-sweep-go/internal/analyze"
+	"github.com/bral/git-sweep-go/internal/config"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// Config holds the settings of the analysis, as read from the git-sweep configuration file.
+type Config = config.Config
+
+// Branch is an analyzed local branch. Its Category tells whether it is suggested for deletion.
+type Branch = types.AnalyzedBranch
+
+// Step is one check of the decision trail returned by Repository.Explain.
+type Step = analyze.Step
+
+// Category classifies an analyzed branch.
+type Category = types.BranchCategory
+
+// Branch categories; MergedOld and UnmergedOld branches are suggested for deletion.
+const (
+	CategoryProtected   = types.CategoryProtected
+	CategoryActive      = types.CategoryActive
+	CategoryMergedOld   = types.CategoryMergedOld
+	CategoryUnmergedOld = types.CategoryUnmergedOld
+)
+
+// ErrNotInGitRepo is returned when the directory to analyze is not inside a Git repository.
+var ErrNotInGitRepo = errors.New("not inside a Git repository")
+
+// DefaultConfig returns the settings git-sweep uses without a configuration file.
+func DefaultConfig() Config {
+	return config.DefaultConfig()
+}
+
+// LoadConfig reads the configuration file at path, or the user's git-sweep configuration file
+// if path is empty, and fills in defaults for the settings it leaves out. Without a
+// configuration file it returns the defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg, err := config.LoadConfig(path)
+	if errors.Is(err, config.ErrConfigNotFound) {
+		return cfg, nil
+	}
+	return cfg, err
+}
+
+// Options control Analyze and Gather.
+type Options struct {
+	Config Config // Settings of the analysis; see DefaultConfig and LoadConfig
+	Remote string // Remote fetched first and used to look up pull requests; "origin" if empty
+	Fetch  bool   // Fetch and prune the remotes before analyzing, unless Config.Offline is set
+
+	// Branches, if set, limits the analysis to these local branches instead of all of them.
+	// Every one of them must exist. Duplicates are still detected among all branches
+	Branches []string
+
+	// SkipDetails leaves out branch descriptions and hosting provider lookups, which only add
+	// information for the user and are slow on large repositories
+	SkipDetails bool
+
+	// Warn, if set, receives the problems that do not stop the analysis, such as a failed fetch
+	Warn func(message string)
+	// Track, if set, is called as each phase of the analysis starts, e.g. "fetch" or
+	// "merge checks", and the function it returns as the phase ends
+	Track func(phase string) func()
+}
+
+// warn reports a problem that does not stop the analysis.
+func (o Options) warn(message string) {
+	if o.Warn != nil {
+		o.Warn(message)
+	}
+}
+
+// track starts timing a phase of the analysis and returns the function that ends it.
+func (o Options) track(phase string) func() {
+	if o.Track == nil {
+		return func() {}
+	}
+	return o.Track(phase)
+}
+
+// Candidates returns the branches among branches that are suggested for deletion.
+func Candidates(branches []Branch) []Branch {
+	candidates := make([]Branch, 0, len(branches))
+	for _, branch := range branches {
+		if branch.Category == CategoryMergedOld || branch.Category == CategoryUnmergedOld {
+			candidates = append(candidates, branch)
+		}
+	}
+	return candidates
+}
+
+// Analyze analyzes the local branches of the repository in the directory repo, or in the
+// current directory if repo is empty. It returns an empty slice if the repository has no
+// branches.
+func Analyze(ctx context.Context, repo string, opts Options) ([]Branch, error) {
+	r, err := Gather(ctx, repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Branches) == 0 {
+		return []Branch{}, nil
+	}
+	branches, err := r.Analyze(ctx, r.Branches)
+	if err != nil {
+		return nil, err
+	}
+	r.RecordMergeChecks(ctx, branches)
+	return branches, nil
+}
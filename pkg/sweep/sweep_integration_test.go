@@ -0,0 +1,203 @@
+//go:build integration
+// +build integration
+
+// Integration tests require the 'integration' build tag to run:
+// go test -tags=integration ./pkg/sweep/...
+
+package sweep
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bral/git-sweep-go/internal/gitcmd"
+	"github.com/bral/git-sweep-go/internal/types"
+)
+
+// commitFile commits a file named name in dir, so the commit changes something.
+func commitFile(t *testing.T, dir, name string, date time.Time) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	git(t, dir, date, "add", name)
+	git(t, dir, date, "commit", "-q", "-m", "Add "+name)
+}
+
+// git runs a git command in dir with a fixed identity and commit date.
+func git(t *testing.T, dir string, date time.Time, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stamp := date.Format(time.RFC3339)
+	cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func TestAnalyzeAndDeleteInAnotherDirectory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repo := t.TempDir()
+	old := time.Now().AddDate(0, 0, -200)
+	git(t, repo, old, "init", "-q", "-b", "main")
+	git(t, repo, old, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git(t, repo, old, "branch", "merged")
+	git(t, repo, old, "checkout", "-q", "-b", "unmerged")
+	commitFile(t, repo, "unmerged.txt", old)
+	git(t, repo, time.Now(), "checkout", "-q", "-b", "active")
+	commitFile(t, repo, "active.txt", time.Now())
+	git(t, repo, time.Now(), "checkout", "-q", "main")
+
+	// The test runs in the package directory, so only the repo argument points at the repository
+	cfg := DefaultConfig()
+	cfg.PrimaryMainBranch = "main"
+	branches, err := Analyze(context.Background(), repo, Options{Config: cfg})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	categories := make(map[string]Category)
+	for _, branch := range branches {
+		categories[branch.Name] = branch.Category
+	}
+	want := map[string]Category{
+		"main": CategoryProtected, "merged": CategoryMergedOld, "unmerged": CategoryUnmergedOld, "active": CategoryActive,
+	}
+	for name, category := range want {
+		if categories[name] != category {
+			t.Errorf("Branch %s: category = %q, want %q", name, categories[name], category)
+		}
+	}
+
+	candidates := Candidates(branches)
+	if len(candidates) != 2 {
+		t.Fatalf("Candidates() returned %d branches, want 2", len(candidates))
+	}
+	results := Delete(context.Background(), repo, NewPlan(candidates, PlanOptions{}), DeleteOptions{})
+	for _, res := range results {
+		if !res.Success {
+			t.Errorf("Deleting %s failed: %s", res.BranchName, res.Message)
+		}
+	}
+
+	remaining, err := Analyze(context.Background(), repo, Options{Config: cfg})
+	if err != nil {
+		t.Fatalf("Analyze() after deletion failed: %v", err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("Expected main and active to remain, got %d branches", len(remaining))
+	}
+}
+
+func TestAnalyzeOutsideRepository(t *testing.T) {
+	_, err := Analyze(context.Background(), t.TempDir(), Options{Config: DefaultConfig()})
+	if err != ErrNotInGitRepo {
+		t.Errorf("Analyze() error = %v, want ErrNotInGitRepo", err)
+	}
+}
+
+func TestAnalyzeSelectedBranches(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repo := t.TempDir()
+	old := time.Now().AddDate(0, 0, -200)
+	git(t, repo, old, "init", "-q", "-b", "main")
+	git(t, repo, old, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git(t, repo, old, "branch", "merged")
+	git(t, repo, old, "branch", "copy")
+	git(t, repo, old, "checkout", "-q", "-b", "unmerged")
+	commitFile(t, repo, "unmerged.txt", old)
+	git(t, repo, old, "checkout", "-q", "main")
+
+	cfg := DefaultConfig()
+	cfg.PrimaryMainBranch = "main"
+	branches, err := Analyze(context.Background(), repo, Options{Config: cfg, Branches: []string{"unmerged", "merged"}})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	if len(branches) != 2 || branches[0].Name != "merged" || branches[1].Name != "unmerged" {
+		t.Errorf("Expected only the selected branches, got %+v", branches)
+	}
+
+	_, err = Analyze(context.Background(), repo, Options{Config: cfg, Branches: []string{"merged", "gone", "missing"}})
+	if err == nil || err.Error() != `no local branches named "gone", "missing"` {
+		t.Errorf("Expected the missing branches to be reported, got %v", err)
+	}
+}
+
+func TestVerifyPullRequestHeads(t *testing.T) {
+	repo := t.TempDir()
+	old := time.Now().AddDate(0, 0, -200)
+	git(t, repo, old, "init", "-q", "-b", "main")
+	git(t, repo, old, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git(t, repo, old, "checkout", "-q", "-b", "feature")
+	commitFile(t, repo, "first.txt", old)
+	commitFile(t, repo, "second.txt", old)
+	ctx := gitcmd.WithDir(context.Background(), repo)
+	head, err := gitcmd.RunGitCommand(ctx, "rev-parse", "feature")
+	if err != nil {
+		t.Fatalf("rev-parse failed: %v", err)
+	}
+	parent, err := gitcmd.RunGitCommand(ctx, "rev-parse", "feature~1")
+	if err != nil {
+		t.Fatalf("rev-parse failed: %v", err)
+	}
+
+	merged := func(headSHA string) *types.PullRequest {
+		return &types.PullRequest{Number: 1, State: types.PullRequestMerged, HeadSHA: headSHA}
+	}
+	branches := []types.BranchInfo{
+		{Name: "same", CommitHash: head, PullRequest: merged(head)},
+		{Name: "contained", CommitHash: parent, PullRequest: merged(head)},
+		// Commits added after the pull request was merged
+		{Name: "newer", CommitHash: head, PullRequest: merged(parent)},
+		{Name: "unknown", CommitHash: head, PullRequest: merged("0123456789abcdef0123456789abcdef01234567")},
+		{Name: "open", CommitHash: head, PullRequest: &types.PullRequest{Number: 2, State: types.PullRequestOpen}},
+	}
+	verifyPullRequestHeads(ctx, branches)
+
+	for i, want := range []bool{true, true, false, false, true} {
+		if got := branches[i].PullRequest != nil; got != want {
+			t.Errorf("%s: pull request kept = %t, want %t", branches[i].Name, got, want)
+		}
+	}
+}
+
+func TestAnalyzeCountsUnpushedCommitsOfSquashMergedBranches(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repo := t.TempDir()
+	old := time.Now().AddDate(0, 0, -200)
+	git(t, repo, old, "init", "-q", "-b", "main")
+	git(t, repo, old, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git(t, repo, old, "checkout", "-q", "-b", "squashed")
+	commitFile(t, repo, "squashed.txt", old)
+	git(t, repo, old, "checkout", "-q", "-b", "merged", "main")
+	git(t, repo, old, "checkout", "-q", "main")
+	git(t, repo, old, "merge", "-q", "--squash", "squashed")
+	git(t, repo, old, "commit", "-q", "-m", "Squashed")
+
+	cfg := DefaultConfig()
+	cfg.PrimaryMainBranch = "main"
+	branches, err := Analyze(context.Background(), repo, Options{Config: cfg})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	// The repository has no remote, so neither the squashed branch's commit nor main's exist anywhere else
+	for _, branch := range branches {
+		switch branch.Name {
+		case "squashed":
+			if branch.MergedBy == types.MergedByAncestry || branch.UnpushedCommits != 2 {
+				t.Errorf("Expected the squash-merged branch to have 2 unpushed commits, got %+v", branch)
+			}
+		case "merged":
+			if branch.MergedBy != types.MergedByAncestry || branch.UnpushedCommits != 0 {
+				t.Errorf("Expected the ancestry-merged branch to have no unpushed commits counted, got %+v", branch)
+			}
+		}
+	}
+}
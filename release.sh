@@ -24,6 +24,19 @@ if ! command -v jq &> /dev/null; then
     exit 1
 fi
 
+# Check for the release signing keys; binaries without the public key refuse to update
+# themselves, and releases without checksums.txt.sig cannot be installed with 'git-sweep update'
+if [ -z "$GIT_SWEEP_SIGNING_PUBKEY" ] || [ -z "$GIT_SWEEP_SIGNING_KEY_FILE" ]; then
+    echo "❌ GIT_SWEEP_SIGNING_PUBKEY and GIT_SWEEP_SIGNING_KEY_FILE must be set to sign the release."
+    echo "   export GIT_SWEEP_SIGNING_KEY_FILE=/path/to/signing.pem"
+    echo "   export GIT_SWEEP_SIGNING_PUBKEY=\"\$(openssl pkey -in \"\$GIT_SWEEP_SIGNING_KEY_FILE\" -pubout -outform DER | tail -c 32 | base64)\""
+    exit 1
+fi
+if [ ! -r "$GIT_SWEEP_SIGNING_KEY_FILE" ]; then
+    echo "❌ GIT_SWEEP_SIGNING_KEY_FILE ($GIT_SWEEP_SIGNING_KEY_FILE) is not readable."
+    exit 1
+fi
+
 # Check for OpenAI API key
 if [ -z "$OPENAI_API_KEY" ]; then
     echo "⚠️ OPENAI_API_KEY environment variable not set."
@@ -107,6 +120,10 @@ if ! go test ./...; then
     echo "❌ Tests failed! Aborting release."
     exit 1
 fi
+if ! go test -tags gogit ./...; then
+    echo "❌ Tests with the go-git backend failed! Aborting release."
+    exit 1
+fi
 
 # Build to verify compilation
 echo "Building project to verify it compiles..."
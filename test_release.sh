@@ -19,6 +19,16 @@ if ! command -v jq &> /dev/null; then
     exit 1
 fi
 
+# Check for the release signing keys release.sh requires
+if [ -z "$GIT_SWEEP_SIGNING_PUBKEY" ] || [ -z "$GIT_SWEEP_SIGNING_KEY_FILE" ]; then
+    echo "⚠️ GIT_SWEEP_SIGNING_PUBKEY and GIT_SWEEP_SIGNING_KEY_FILE are not both set."
+    echo "   release.sh will refuse to run until the release can be signed."
+elif [ ! -r "$GIT_SWEEP_SIGNING_KEY_FILE" ]; then
+    echo "⚠️ GIT_SWEEP_SIGNING_KEY_FILE ($GIT_SWEEP_SIGNING_KEY_FILE) is not readable; release.sh will refuse to run."
+else
+    echo "✅ Release signing keys found."
+fi
+
 # Check for OpenAI API key
 if [ -z "$OPENAI_API_KEY" ]; then
     echo "⚠️ OPENAI_API_KEY environment variable not set."
@@ -106,6 +116,14 @@ if ! go test ./...; then
         exit 1
     fi
 fi
+if ! go test -tags gogit ./...; then
+    echo "❌ Tests with the go-git backend failed! In actual release, this would abort."
+    echo "   Continue anyway for testing? (y/n): "
+    read CONTINUE
+    if [[ "$CONTINUE" != "y" && "$CONTINUE" != "Y" ]]; then
+        exit 1
+    fi
+fi
 
 # Build to verify compilation
 echo "Building project to verify it compiles..."